@@ -0,0 +1,432 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ============================================================================
+// Build-system workspace detection
+//
+// Detectors for monorepos that are organised by a build tool rather than a
+// package manager: Gradle multi-project builds, Maven aggregators, Bazel
+// modules, .NET solutions and Mix umbrella projects. Like the JS, Cargo, Go
+// and uv detectors in main.go they are stdlib-only, tolerant of files they
+// can't parse, and fill in the same envSignals / HasDev fields: HasDev marks
+// a runnable service (an application plugin, a web SDK, a *_binary target,
+// Phoenix), and RuntimeConfig marks a workspace whose build or boot reads
+// env (Spring Boot, Flyway/Liquibase, dotenv plugins, user secrets,
+// config/runtime.exs) so env seeding covers it.
+// ============================================================================
+
+// parseGradleWorkspaces reads include statements from settings.gradle(.kts).
+// ":services:api" maps to services/api unless a projectDir assignment moves
+// it. Only directories that exist are kept.
+func parseGradleWorkspaces(root string) ([]workspaceInfo, bool) {
+	var data []byte
+	for _, name := range []string{"settings.gradle.kts", "settings.gradle"} {
+		if b, err := os.ReadFile(filepath.Join(root, name)); err == nil {
+			data = b
+			break
+		}
+	}
+	if data == nil {
+		return nil, false
+	}
+	text := stripLineComments(string(data), "//")
+
+	dirs := map[string]string{}
+	for _, m := range gradleProjectDirRe.FindAllStringSubmatch(text, -1) {
+		dirs[strings.TrimPrefix(m[1], ":")] = m[2]
+	}
+
+	// Argument lists may span lines: include(\n ":app",\n ":core"\n) in the
+	// Kotlin DSL, or a trailing comma continuing a Groovy include.
+	var projects []string
+	for _, m := range gradleIncludeRe.FindAllStringSubmatch(text, -1) {
+		args := m[1]
+		if args == "" {
+			args = m[2]
+		}
+		for _, q := range quotedStringRe.FindAllStringSubmatch(args, -1) {
+			projects = append(projects, strings.TrimPrefix(q[1], ":"))
+		}
+	}
+
+	var out []workspaceInfo
+	for _, p := range uniqueStrings(projects) {
+		if p == "" {
+			continue
+		}
+		rel := strings.ReplaceAll(p, ":", "/")
+		if d, ok := dirs[p]; ok {
+			rel = d
+		}
+		rel = filepath.Clean(rel)
+		if !dirExists(filepath.Join(root, rel)) {
+			continue
+		}
+		ws := workspaceInfo{ID: p, Path: rel, Manifest: firstExisting(filepath.Join(root, rel), "build.gradle.kts", "build.gradle")}
+		ws.Signals, ws.HasDev = gradleManifestSignals(ws.Manifest)
+		out = append(out, ws)
+	}
+	return out, len(out) > 0
+}
+
+var (
+	gradleIncludeRe    = regexp.MustCompile(`\binclude(?:\s*\(([^)]*)\)|[ \t]+((?:["'][^"']+["']\s*,\s*)*["'][^"']+["']))`)
+	gradleProjectDirRe = regexp.MustCompile(`project\(\s*["']([^"']+)["']\s*\)\.projectDir\s*=\s*(?:file|new\s+File)\(\s*(?:settingsDir\s*,\s*|rootDir\s*,\s*)?["']([^"']+)["']`)
+	quotedStringRe     = regexp.MustCompile(`["']([^"']+)["']`)
+)
+
+// gradleManifestSignals inspects a build.gradle(.kts). A runnable plugin
+// (application, Spring Boot, Ktor, Quarkus, Micronaut) marks a dev server.
+func gradleManifestSignals(path string) (envSignals, bool) {
+	var sig envSignals
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return sig, false
+	}
+	text := string(data)
+	hasDev := containsAny(text, `id("application")`, `id 'application'`, `id "application"`, `plugin: 'application'`, `plugin: "application"`, "\napplication {", "\napplication{",
+		"org.springframework.boot", "io.ktor.plugin", "io.quarkus", "io.micronaut.application")
+	sig.RuntimeConfig = containsAny(text, "org.springframework.boot", "flyway", "liquibase", "dotenv")
+	return sig, hasDev
+}
+
+// parseMavenWorkspaces reads <modules> from the root pom.xml, following
+// nested aggregators a few levels down.
+func parseMavenWorkspaces(root string) ([]workspaceInfo, bool) {
+	if !fileExists(filepath.Join(root, "pom.xml")) {
+		return nil, false
+	}
+	var out []workspaceInfo
+	var walk func(rel string, depth int)
+	walk = func(rel string, depth int) {
+		data, err := os.ReadFile(filepath.Join(root, rel, "pom.xml"))
+		if err != nil || depth > 4 {
+			return
+		}
+		for _, m := range mavenModuleRe.FindAllStringSubmatch(stripXMLComments(string(data)), -1) {
+			child := filepath.Clean(filepath.Join(rel, strings.TrimSpace(m[1])))
+			if strings.HasSuffix(child, ".xml") {
+				child = filepath.Dir(child) // <module>sub/pom-alt.xml</module>
+			}
+			manifest := filepath.Join(root, child, "pom.xml")
+			if !fileExists(manifest) {
+				continue
+			}
+			ws := workspaceInfo{ID: mavenArtifactID(manifest), Path: child, Manifest: manifest}
+			if ws.ID == "" {
+				ws.ID = filepath.Base(child)
+			}
+			ws.Signals, ws.HasDev = mavenManifestSignals(manifest)
+			out = append(out, ws)
+			walk(child, depth+1)
+		}
+	}
+	walk(".", 0)
+	return out, len(out) > 0
+}
+
+var (
+	mavenModuleRe   = regexp.MustCompile(`<module>\s*([^<]+?)\s*</module>`)
+	mavenParentRe   = regexp.MustCompile(`(?s)<parent>.*?</parent>`)
+	mavenArtifactRe = regexp.MustCompile(`<artifactId>\s*([^<]+?)\s*</artifactId>`)
+	xmlCommentRe    = regexp.MustCompile(`(?s)<!--.*?-->`)
+)
+
+func stripXMLComments(s string) string { return xmlCommentRe.ReplaceAllString(s, "") }
+
+// mavenArtifactID returns the module's own artifactId (not its parent's).
+func mavenArtifactID(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	text := mavenParentRe.ReplaceAllString(stripXMLComments(string(data)), "")
+	if m := mavenArtifactRe.FindStringSubmatch(text); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// mavenManifestSignals inspects a pom.xml. A run plugin (Spring Boot,
+// Quarkus, exec, Jetty) marks a dev server.
+func mavenManifestSignals(path string) (envSignals, bool) {
+	var sig envSignals
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return sig, false
+	}
+	text := stripXMLComments(string(data))
+	hasDev := containsAny(text, "spring-boot-maven-plugin", "quarkus-maven-plugin", "exec-maven-plugin", "jetty-maven-plugin", "micronaut-maven-plugin")
+	sig.RuntimeConfig = containsAny(text, "spring-boot", "flyway", "liquibase", "dotenv")
+	return sig, hasDev
+}
+
+// parseBazelWorkspaces treats each outermost Bazel package (a directory with
+// a BUILD or BUILD.bazel file, up to three levels deep) under a
+// MODULE.bazel / WORKSPACE root as a workspace. Packages nested inside
+// another are part of it.
+func parseBazelWorkspaces(root string) ([]workspaceInfo, bool) {
+	if firstExisting(root, "MODULE.bazel", "WORKSPACE.bazel", "WORKSPACE") == "" {
+		return nil, false
+	}
+	var out []workspaceInfo
+	var walk func(rel string, depth int)
+	walk = func(rel string, depth int) {
+		entries, err := os.ReadDir(filepath.Join(root, rel))
+		if err != nil {
+			return
+		}
+		for _, e := range entries {
+			name := e.Name()
+			if !e.IsDir() || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "bazel-") || name == "node_modules" || name == "third_party" {
+				continue
+			}
+			child := filepath.Join(rel, name)
+			if manifest := firstExisting(filepath.Join(root, child), "BUILD.bazel", "BUILD"); manifest != "" {
+				ws := workspaceInfo{ID: filepath.ToSlash(child), Path: child, Manifest: manifest}
+				ws.Signals, ws.HasDev = bazelManifestSignals(manifest)
+				out = append(out, ws)
+				continue
+			}
+			if depth < 3 {
+				walk(child, depth+1)
+			}
+		}
+	}
+	walk(".", 1)
+	return out, len(out) > 0
+}
+
+var bazelBinaryRe = regexp.MustCompile(`(?m)^\s*\w*_binary\(`)
+
+// bazelManifestSignals: a *_binary target is runnable; an env attribute or
+// dotenv reference means the target reads env.
+func bazelManifestSignals(path string) (envSignals, bool) {
+	var sig envSignals
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return sig, false
+	}
+	text := string(data)
+	sig.RuntimeConfig = containsAny(text, "env = {", "env={", "dotenv")
+	return sig, bazelBinaryRe.MatchString(text)
+}
+
+// parseDotnetWorkspaces reads the projects of the root solution (.sln or
+// .slnx, first by name). Without a solution, a Directory.Build.props at the
+// root marks every project file up to three levels down as a workspace.
+func parseDotnetWorkspaces(root string) ([]workspaceInfo, bool) {
+	var projects []string // repo-relative project file paths
+	if sln := firstMatch(root, "*.sln", "*.slnx"); sln != "" {
+		data, err := os.ReadFile(sln)
+		if err != nil {
+			return nil, false
+		}
+		re := dotnetSlnProjectRe
+		if strings.HasSuffix(sln, ".slnx") {
+			re = dotnetSlnxProjectRe
+		}
+		for _, m := range re.FindAllStringSubmatch(string(data), -1) {
+			p := filepath.FromSlash(strings.ReplaceAll(m[1], `\`, "/"))
+			if isDotnetProjectFile(p) {
+				projects = append(projects, filepath.Clean(p))
+			}
+		}
+	} else if fileExists(filepath.Join(root, "Directory.Build.props")) {
+		filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			rel, _ := filepath.Rel(root, path)
+			if d.IsDir() {
+				if rel != "." && (strings.HasPrefix(d.Name(), ".") || d.Name() == "bin" || d.Name() == "obj" || d.Name() == "node_modules" || strings.Count(rel, string(filepath.Separator)) >= 3) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if isDotnetProjectFile(rel) && filepath.Dir(rel) != "." {
+				projects = append(projects, rel)
+			}
+			return nil
+		})
+	}
+
+	var out []workspaceInfo
+	for _, p := range projects {
+		manifest := filepath.Join(root, p)
+		if !fileExists(manifest) {
+			continue
+		}
+		ws := workspaceInfo{
+			ID:       strings.TrimSuffix(filepath.Base(p), filepath.Ext(p)),
+			Path:     filepath.Dir(p),
+			Manifest: manifest,
+		}
+		ws.Signals, ws.HasDev = dotnetManifestSignals(manifest)
+		out = append(out, ws)
+	}
+	return out, len(out) > 0
+}
+
+var (
+	dotnetSlnProjectRe  = regexp.MustCompile(`(?m)^Project\("\{[^}]+\}"\)\s*=\s*"[^"]*",\s*"([^"]+)"`)
+	dotnetSlnxProjectRe = regexp.MustCompile(`<Project\s+Path="([^"]+)"`)
+)
+
+func isDotnetProjectFile(p string) bool {
+	switch strings.ToLower(filepath.Ext(p)) {
+	case ".csproj", ".fsproj", ".vbproj":
+		return true
+	}
+	return false
+}
+
+// dotnetManifestSignals: a Web/Worker SDK, an Exe output type or a
+// launchSettings.json marks a runnable project; user secrets, launch
+// profile environment variables or DotNetEnv mean it reads env.
+func dotnetManifestSignals(path string) (envSignals, bool) {
+	var sig envSignals
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return sig, false
+	}
+	text := string(data)
+	launch := filepath.Join(filepath.Dir(path), "Properties", "launchSettings.json")
+	hasDev := containsAny(text, "Microsoft.NET.Sdk.Web", "Microsoft.NET.Sdk.Worker", "<OutputType>Exe</OutputType>") || fileExists(launch)
+	sig.RuntimeConfig = containsAny(text, "<UserSecretsId>", "DotNetEnv")
+	if l, err := os.ReadFile(launch); err == nil && strings.Contains(string(l), "environmentVariables") {
+		sig.RuntimeConfig = true
+	}
+	return sig, hasDev
+}
+
+// parseMixUmbrella reads an umbrella mix.exs (apps_path: "apps") and lists
+// each app with its own mix.exs.
+func parseMixUmbrella(root string) ([]workspaceInfo, bool) {
+	data, err := os.ReadFile(filepath.Join(root, "mix.exs"))
+	if err != nil {
+		return nil, false
+	}
+	m := mixAppsPathRe.FindStringSubmatch(string(data))
+	if m == nil {
+		return nil, false
+	}
+	rootRuntime := fileExists(filepath.Join(root, "config", "runtime.exs"))
+	var out []workspaceInfo
+	for _, rel := range walkForManifests(root, m[1], "mix.exs", 0) {
+		manifest := filepath.Join(root, rel, "mix.exs")
+		ws := workspaceInfo{ID: mixAppName(manifest), Path: rel, Manifest: manifest}
+		if ws.ID == "" {
+			ws.ID = filepath.Base(rel)
+		}
+		ws.Signals, ws.HasDev = mixManifestSignals(manifest)
+		if rootRuntime {
+			ws.Signals.RuntimeConfig = true
+		}
+		out = append(out, ws)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Path < out[j].Path })
+	return out, len(out) > 0
+}
+
+var (
+	mixAppsPathRe = regexp.MustCompile(`apps_path:\s*"([^"]+)"`)
+	mixAppRe      = regexp.MustCompile(`app:\s*:(\w+)`)
+)
+
+func mixAppName(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	if m := mixAppRe.FindStringSubmatch(string(data)); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// mixManifestSignals: Phoenix or a Plug/Bandit server is runnable; Ecto,
+// dotenv libraries or an app-level config/runtime.exs read env.
+func mixManifestSignals(path string) (envSignals, bool) {
+	var sig envSignals
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return sig, false
+	}
+	text := string(data)
+	hasDev := containsAny(text, "{:phoenix,", "{:plug_cowboy,", "{:bandit,")
+	sig.RuntimeConfig = containsAny(text, "{:ecto_sql,", "{:dotenvy,", "{:dotenv,") ||
+		fileExists(filepath.Join(filepath.Dir(path), "config", "runtime.exs"))
+	return sig, hasDev
+}
+
+// buildManifestSignals dispatches to the build-system signal readers by
+// manifest name, for workspaces declared in worktree.json.
+func buildManifestSignals(manifest string) (envSignals, bool) {
+	switch base := filepath.Base(manifest); {
+	case base == "build.gradle" || base == "build.gradle.kts":
+		return gradleManifestSignals(manifest)
+	case base == "pom.xml":
+		return mavenManifestSignals(manifest)
+	case base == "BUILD" || base == "BUILD.bazel":
+		return bazelManifestSignals(manifest)
+	case base == "mix.exs":
+		return mixManifestSignals(manifest)
+	case isDotnetProjectFile(base):
+		return dotnetManifestSignals(manifest)
+	}
+	return envSignals{}, false
+}
+
+// firstExisting returns the first of names that exists in dir, as a full
+// path, or "".
+func firstExisting(dir string, names ...string) string {
+	for _, n := range names {
+		if p := filepath.Join(dir, n); fileExists(p) {
+			return p
+		}
+	}
+	return ""
+}
+
+// firstMatch returns the first file in dir matching any pattern, by name.
+func firstMatch(dir string, patterns ...string) string {
+	var all []string
+	for _, p := range patterns {
+		m, _ := filepath.Glob(filepath.Join(dir, p))
+		all = append(all, m...)
+	}
+	sort.Strings(all)
+	if len(all) == 0 {
+		return ""
+	}
+	return all[0]
+}
+
+func containsAny(s string, subs ...string) bool {
+	for _, sub := range subs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// stripLineComments drops everything after marker on each line. Good
+// enough for build scripts, where the marker rarely appears in strings.
+func stripLineComments(s, marker string) string {
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		if j := strings.Index(l, marker); j >= 0 {
+			lines[i] = l[:j]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// findWorkspace returns the workspace with the given ID, failing if absent.
+func findWorkspace(t *testing.T, ws []workspaceInfo, id string) workspaceInfo {
+	t.Helper()
+	for _, w := range ws {
+		if w.ID == id {
+			return w
+		}
+	}
+	t.Fatalf("workspace %q not found in %v", id, workspaceIDs(ws))
+	return workspaceInfo{}
+}
+
+func TestDetectWorkspaces_GradleSettings(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "settings.gradle.kts", `rootProject.name = "shop"
+include(":services:api", ":libs:core")
+include("web") // the storefront
+// include(":legacy")
+includeBuild("build-logic")
+project(":web").projectDir = file("apps/storefront")
+`)
+	writeFile(t, dir, "services/api/build.gradle.kts", "plugins {\n  id(\"org.springframework.boot\") version \"3.2.0\"\n}\n")
+	writeFile(t, dir, "libs/core/build.gradle.kts", "plugins { `java-library` }\n")
+	writeFile(t, dir, "apps/storefront/build.gradle", "plugins {\n  id 'application'\n}\n")
+	writeFile(t, dir, "legacy/build.gradle", "")
+
+	ws, mType := detectWorkspaces(dir)
+	if mType != monorepoGradle {
+		t.Errorf("type = %q, want %q", mType, monorepoGradle)
+	}
+	if got := strings.Join(workspaceIDs(ws), ","); got != "libs:core,services:api,web" {
+		t.Fatalf("workspaces = %s", got)
+	}
+	api := findWorkspace(t, ws, "services:api")
+	if api.Path != filepath.Join("services", "api") || !api.HasDev || !api.Signals.consumesEnv() {
+		t.Errorf("api = %+v", api)
+	}
+	if core := findWorkspace(t, ws, "libs:core"); core.HasDev || core.Signals.consumesEnv() {
+		t.Errorf("core should be a plain library: %+v", core)
+	}
+	if web := findWorkspace(t, ws, "web"); web.Path != filepath.Join("apps", "storefront") || !web.HasDev {
+		t.Errorf("web = %+v", web)
+	}
+}
+
+func TestDetectWorkspaces_GradleMultiLineInclude(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "settings.gradle.kts", `rootProject.name = "shop"
+include(
+    ":app",
+    // ":legacy",
+    ":core"
+)
+includeBuild("build-logic")
+`)
+	writeFile(t, dir, "app/build.gradle.kts", "plugins { application }\n")
+	writeFile(t, dir, "core/build.gradle.kts", "plugins { `java-library` }\n")
+	writeFile(t, dir, "legacy/build.gradle", "")
+	writeFile(t, dir, "build-logic/build.gradle.kts", "")
+
+	ws, _ := detectWorkspaces(dir)
+	if got := strings.Join(workspaceIDs(ws), ","); got != "app,core" {
+		t.Fatalf("workspaces = %s", got)
+	}
+
+	groovy := t.TempDir()
+	writeFile(t, groovy, "settings.gradle", "include ':app',\n        ':core'\n")
+	writeFile(t, groovy, "app/build.gradle", "")
+	writeFile(t, groovy, "core/build.gradle", "")
+	if ws, ok := parseGradleWorkspaces(groovy); !ok || strings.Join(workspaceIDs(ws), ",") != "app,core" {
+		t.Errorf("groovy workspaces = %v", workspaceIDs(ws))
+	}
+}
+
+func TestDetectWorkspaces_MavenNestedModules(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "pom.xml", `<project><artifactId>shop-parent</artifactId>
+<modules>
+  <module>core</module>
+  <module>services</module>
+  <!-- <module>legacy</module> -->
+</modules></project>`)
+	writeFile(t, dir, "core/pom.xml", `<project><parent><artifactId>shop-parent</artifactId></parent><artifactId>shop-core</artifactId></project>`)
+	writeFile(t, dir, "services/pom.xml", `<project><artifactId>shop-services</artifactId><modules><module>api</module></modules></project>`)
+	writeFile(t, dir, "services/api/pom.xml", `<project><parent><artifactId>shop-services</artifactId></parent><artifactId>shop-api</artifactId>
+<build><plugins><plugin><artifactId>spring-boot-maven-plugin</artifactId></plugin></plugins></build></project>`)
+	writeFile(t, dir, "legacy/pom.xml", `<project><artifactId>legacy</artifactId></project>`)
+
+	ws, mType := detectWorkspaces(dir)
+	if mType != monorepoMaven {
+		t.Errorf("type = %q, want %q", mType, monorepoMaven)
+	}
+	if got := strings.Join(workspaceIDs(ws), ","); got != "shop-api,shop-core,shop-services" {
+		t.Fatalf("workspaces = %s", got)
+	}
+	if api := findWorkspace(t, ws, "shop-api"); !api.HasDev || !api.Signals.RuntimeConfig || api.Path != filepath.Join("services", "api") {
+		t.Errorf("api = %+v", api)
+	}
+}
+
+func TestDetectWorkspaces_BazelOutermostPackages(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "MODULE.bazel", `module(name = "shop")`)
+	writeFile(t, dir, "BUILD.bazel", "")
+	writeFile(t, dir, "services/api/BUILD.bazel", "go_binary(\n    name = \"api\",\n)\n")
+	writeFile(t, dir, "services/api/internal/BUILD.bazel", "go_library(name = \"internal\")\n")
+	writeFile(t, dir, "libs/core/BUILD", "java_library(name = \"core\")\n")
+	writeFile(t, dir, "bazel-out/x/BUILD", "")
+
+	ws, mType := detectWorkspaces(dir)
+	if mType != monorepoBazel {
+		t.Errorf("type = %q, want %q", mType, monorepoBazel)
+	}
+	if got := strings.Join(workspaceIDs(ws), ","); got != "libs/core,services/api" {
+		t.Fatalf("workspaces = %s", got)
+	}
+	if !findWorkspace(t, ws, "services/api").HasDev || findWorkspace(t, ws, "libs/core").HasDev {
+		t.Errorf("only the *_binary package should have a dev target")
+	}
+}
+
+func TestDetectWorkspaces_DotnetSolution(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "Shop.sln", `Microsoft Visual Studio Solution File, Format Version 12.00
+Project("{FAE04EC0-301F-11D3-BF4B-00C04F79EFBC}") = "Shop.Api", "src\Shop.Api\Shop.Api.csproj", "{11111111-1111-1111-1111-111111111111}"
+EndProject
+Project("{2150E333-8FDC-42A3-9474-1A3956D46DE8}") = "src", "src", "{22222222-2222-2222-2222-222222222222}"
+EndProject
+Project("{F2A71F9B-5D33-465A-A702-920D77279786}") = "Shop.Core", "src\Shop.Core\Shop.Core.fsproj", "{33333333-3333-3333-3333-333333333333}"
+EndProject
+`)
+	writeFile(t, dir, "src/Shop.Api/Shop.Api.csproj", `<Project Sdk="Microsoft.NET.Sdk.Web"><PropertyGroup><UserSecretsId>abc</UserSecretsId></PropertyGroup></Project>`)
+	writeFile(t, dir, "src/Shop.Core/Shop.Core.fsproj", `<Project Sdk="Microsoft.NET.Sdk"></Project>`)
+
+	ws, mType := detectWorkspaces(dir)
+	if mType != monorepoDotnet {
+		t.Errorf("type = %q, want %q", mType, monorepoDotnet)
+	}
+	if got := strings.Join(workspaceIDs(ws), ","); got != "Shop.Api,Shop.Core" {
+		t.Fatalf("workspaces = %s", got)
+	}
+	api := findWorkspace(t, ws, "Shop.Api")
+	if api.Path != filepath.Join("src", "Shop.Api") || !api.HasDev || !api.Signals.RuntimeConfig {
+		t.Errorf("api = %+v", api)
+	}
+	if findWorkspace(t, ws, "Shop.Core").HasDev {
+		t.Error("class library should not have a dev target")
+	}
+}
+
+func TestDetectWorkspaces_DotnetDirectoryBuildPropsFallback(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "Directory.Build.props", `<Project></Project>`)
+	writeFile(t, dir, "src/Worker/Worker.csproj", `<Project Sdk="Microsoft.NET.Sdk"><PropertyGroup><OutputType>Exe</OutputType></PropertyGroup></Project>`)
+	writeFile(t, dir, "src/Worker/obj/Generated.csproj", "")
+	writeFile(t, dir, "tests/Worker.Tests/Worker.Tests.csproj", `<Project Sdk="Microsoft.NET.Sdk"></Project>`)
+
+	ws, mType := detectWorkspaces(dir)
+	if mType != monorepoDotnet || strings.Join(workspaceIDs(ws), ",") != "Worker,Worker.Tests" {
+		t.Fatalf("type=%q workspaces=%v", mType, workspaceIDs(ws))
+	}
+	if !findWorkspace(t, ws, "Worker").HasDev {
+		t.Error("Exe project should have a dev target")
+	}
+}
+
+func TestDetectWorkspaces_MixUmbrella(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "mix.exs", "defmodule Shop.Umbrella.MixProject do\n  def project do\n    [apps_path: \"apps\", version: \"0.1.0\"]\n  end\nend\n")
+	writeFile(t, dir, "apps/shop_web/mix.exs", "def project, do: [app: :shop_web]\ndefp deps, do: [{:phoenix, \"~> 1.7\"}]\n")
+	writeFile(t, dir, "apps/shop/mix.exs", "def project, do: [app: :shop]\ndefp deps, do: [{:ecto_sql, \"~> 3.10\"}]\n")
+
+	ws, mType := detectWorkspaces(dir)
+	if mType != monorepoMix {
+		t.Errorf("type = %q, want %q", mType, monorepoMix)
+	}
+	if got := strings.Join(workspaceIDs(ws), ","); got != "shop,shop_web" {
+		t.Fatalf("workspaces = %s", got)
+	}
+	if !findWorkspace(t, ws, "shop_web").HasDev || !findWorkspace(t, ws, "shop").Signals.consumesEnv() {
+		t.Errorf("signals = %+v", ws)
+	}
+	if pickPrimary(ws, "") != "shop_web" {
+		t.Errorf("primary = %q, want the Phoenix app", pickPrimary(ws, ""))
+	}
+}
+
+func TestDetectWorkspaces_MixProjectWithoutUmbrellaIsSinglePackage(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "mix.exs", "def project, do: [app: :shop]\n")
+	if ws, mType := detectWorkspaces(dir); mType != monorepoNone || len(ws) != 0 {
+		t.Errorf("type=%q workspaces=%v", mType, workspaceIDs(ws))
+	}
+}
+
+func TestResolveWorkspaces_ExplicitBuildSystemWorkspace(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "services/api/pom.xml", `<project><artifactId>api</artifactId><build><plugins><plugin><artifactId>quarkus-maven-plugin</artifactId></plugin></plugins></build></project>`)
+	writeFile(t, dir, "src/Web/Web.csproj", `<Project Sdk="Microsoft.NET.Sdk.Web"></Project>`)
+	hooks := &worktreeHooks{Workspaces: map[string]workspaceOverride{
+		"api": {Path: "services/api"},
+		"web": {Path: "src/Web"},
+	}}
+	ws, _, _ := resolveWorkspaces(dir, hooks)
+	for _, w := range ws {
+		if w.Manifest == "" || !w.HasDev {
+			t.Errorf("%s: manifest=%q hasDev=%v", w.ID, w.Manifest, w.HasDev)
+		}
+	}
+}
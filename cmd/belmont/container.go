@@ -0,0 +1,242 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ============================================================================
+// Container-isolated worktrees
+//
+// Worktrees isolate files and ports, but agents still run on the host with
+// their approval prompts bypassed, and parallel agents share its databases,
+// global caches and system services. With a container block in
+// worktree.json, each worktree's agent, triage pass and setup/teardown hooks
+// run inside a throwaway container of the project's image instead. The
+// worktree (and the main repo's .git, which its .git file points into) is
+// bind-mounted at the same absolute path, so prompts, paths and git work
+// unchanged; the worktree's port is published on 127.0.0.1; CPU, memory and
+// process limits apply per call. Everything else — worktree creation,
+// merges, reconciliation, the merge gate — stays on the host.
+// ============================================================================
+
+// worktreeContainerConfig is the container block of worktree.json.
+type worktreeContainerConfig struct {
+	Image      string   `json:"image,omitempty"`      // image to run; required unless dockerfile is set
+	Dockerfile string   `json:"dockerfile,omitempty"` // build the image from this Dockerfile (relative to the project root)
+	Runtime    string   `json:"runtime,omitempty"`    // "docker" (default) or "podman"
+	CPUs       string   `json:"cpus,omitempty"`       // --cpus
+	Memory     string   `json:"memory,omitempty"`     // --memory
+	PidsLimit  int      `json:"pids_limit,omitempty"` // --pids-limit
+	Network    string   `json:"network,omitempty"`    // --network; "host" skips port publishing
+	User       string   `json:"user,omitempty"`       // --user; default is the host uid:gid with HOME=/tmp
+	Mounts     []string `json:"mounts,omitempty"`     // extra -v specs; a leading ~ expands to the host home
+	PassEnv    []string `json:"pass_env,omitempty"`   // host env vars forwarded on top of the agent credentials
+	Args       []string `json:"args,omitempty"`       // extra arguments to `<runtime> run`
+}
+
+// containerPassEnv are the host variables every container gets so agent
+// CLIs can authenticate. Only names are passed; values never reach argv.
+var containerPassEnv = []string{
+	"ANTHROPIC_API_KEY", "CLAUDE_CODE_OAUTH_TOKEN",
+	"OPENAI_API_KEY", "CODEX_API_KEY",
+	"GEMINI_API_KEY", "GOOGLE_API_KEY",
+	"CURSOR_API_KEY",
+	"GH_TOKEN", "GITHUB_TOKEN",
+}
+
+// containerLabel marks containers with the worktree they belong to so
+// teardown can remove any a killed agent left behind.
+const containerLabel = "belmont.worktree"
+
+// worktreeContainer is a container block resolved for one worktree.
+// Methods are no-ops on a nil receiver, which means "run on the host".
+type worktreeContainer struct {
+	cfg     worktreeContainerConfig
+	runtime string
+	image   string
+	wtPath  string
+	mounts  []string // host paths bind-mounted at the same path
+	ports   []int    // named ports published next to the primary one
+}
+
+// newWorktreeContainer resolves the container block for wtPath, or returns
+// nil when the project has none.
+func newWorktreeContainer(root, wtPath string, hooks *worktreeHooks) *worktreeContainer {
+	if hooks == nil || hooks.Container == nil {
+		return nil
+	}
+	c := &worktreeContainer{cfg: *hooks.Container, runtime: hooks.Container.Runtime, image: hooks.Container.Image, wtPath: wtPath}
+	if c.runtime == "" {
+		c.runtime = "docker"
+	}
+	if c.image == "" && c.cfg.Dockerfile != "" {
+		c.image = "belmont-" + strings.ToLower(unsafeHistoryChars.ReplaceAllString(filepath.Base(root), "-"))
+	}
+	c.mounts = []string{wtPath}
+	if common := gitCommonDir(wtPath); common != "" && !strings.HasPrefix(common, wtPath+string(filepath.Separator)) {
+		c.mounts = append(c.mounts, common)
+	}
+	if cache := filepath.Join(worktreePoolDir(root), "cache"); dirExists(cache) {
+		c.mounts = append(c.mounts, cache)
+	}
+	return c
+}
+
+// gitCommonDir returns the absolute git common dir of the checkout at dir.
+func gitCommonDir(dir string) string {
+	out, err := gitOutputIn(dir, "rev-parse", "--git-common-dir")
+	if err != nil {
+		return ""
+	}
+	common := strings.TrimSpace(out)
+	if common != "" && !filepath.IsAbs(common) {
+		common = filepath.Join(dir, common)
+	}
+	return common
+}
+
+// containerImagesBuilt records images built from a Dockerfile this run, so
+// parallel worktrees build once.
+var (
+	containerImagesMu    sync.Mutex
+	containerImagesBuilt = map[string]bool{}
+)
+
+// prepare checks the runtime is installed and builds the image when the
+// project defines it by Dockerfile. Called once per worktree before hooks.
+func (c *worktreeContainer) prepare(root string) error {
+	if c == nil {
+		return nil
+	}
+	if c.image == "" {
+		return fmt.Errorf("container: worktree.json container block needs an image or dockerfile")
+	}
+	if _, err := exec.LookPath(c.runtime); err != nil {
+		return fmt.Errorf("container: %s not found on PATH", c.runtime)
+	}
+	if c.cfg.Dockerfile == "" {
+		return nil
+	}
+	containerImagesMu.Lock()
+	defer containerImagesMu.Unlock()
+	if containerImagesBuilt[c.image] {
+		return nil
+	}
+	fmt.Fprintf(os.Stderr, "  Building container image %s from %s...\n", c.image, c.cfg.Dockerfile)
+	cmd := exec.Command(c.runtime, "build", "-t", c.image, "-f", filepath.Join(root, c.cfg.Dockerfile), root)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("container: build %s: %w\n%s", c.image, err, tailOutput(string(out), 2000))
+	}
+	containerImagesBuilt[c.image] = true
+	return nil
+}
+
+// runArgs is the `<runtime> run` argv that runs argv in dir. env holds the
+// worktree's KEY=VALUE additions; only their names are passed, and the
+// runtime reads the values from its own environment.
+func (c *worktreeContainer) runArgs(dir string, port int, env []string, argv []string) []string {
+	args := []string{"run", "--rm", "-i", "--init",
+		"--label", containerLabel + "=" + c.wtPath,
+		"-w", dir,
+	}
+	for _, m := range c.mounts {
+		args = append(args, "-v", m+":"+m)
+	}
+	home, _ := os.UserHomeDir()
+	for _, m := range c.cfg.Mounts {
+		if home != "" && (m == "~" || strings.HasPrefix(m, "~/")) {
+			m = home + m[1:]
+		}
+		args = append(args, "-v", m)
+	}
+	if c.cfg.Network != "" {
+		args = append(args, "--network", c.cfg.Network)
+	}
+	if c.cfg.Network != "host" {
+		for _, p := range append([]int{port}, c.ports...) {
+			if p != 0 {
+				args = append(args, "-p", fmt.Sprintf("127.0.0.1:%d:%d", p, p))
+			}
+		}
+	}
+	if c.cfg.CPUs != "" {
+		args = append(args, "--cpus", c.cfg.CPUs)
+	}
+	if c.cfg.Memory != "" {
+		args = append(args, "--memory", c.cfg.Memory)
+	}
+	if c.cfg.PidsLimit > 0 {
+		args = append(args, "--pids-limit", fmt.Sprint(c.cfg.PidsLimit))
+	}
+	if c.cfg.User != "" {
+		args = append(args, "--user", c.cfg.User)
+	} else if uid := os.Getuid(); uid >= 0 {
+		// Files the agent writes stay owned by the operator. That uid has no
+		// home in most images, so give it a writable one.
+		args = append(args, "--user", fmt.Sprintf("%d:%d", uid, os.Getgid()), "-e", "HOME=/tmp")
+	}
+	args = append(args, "-e", "BELMONT_CONTAINER=1")
+	var names []string
+	for _, kv := range env {
+		if k, _, ok := strings.Cut(kv, "="); ok {
+			names = append(names, k)
+		}
+	}
+	names = append(names, containerPassEnv...)
+	names = append(names, c.cfg.PassEnv...)
+	for _, k := range uniqueStrings(names) {
+		args = append(args, "-e", k)
+	}
+	args = append(args, c.cfg.Args...)
+	args = append(args, c.image)
+	return append(args, argv...)
+}
+
+// wrap rewrites cmd, already set up to run on the host in the worktree, to
+// run the same argv inside the container. cmd.Env must carry env's values.
+func (c *worktreeContainer) wrap(cmd *exec.Cmd, port int, env []string) {
+	if c == nil {
+		return
+	}
+	dir := cmd.Dir
+	if dir == "" {
+		dir = c.wtPath
+	}
+	cmd.Args = append([]string{c.runtime}, c.runArgs(dir, port, env, cmd.Args)...)
+	cmd.Path = c.runtime
+	cmd.Err = nil
+	if p, err := exec.LookPath(c.runtime); err == nil {
+		cmd.Path = p
+	}
+}
+
+// publish adds the worktree's named ports to those the container exposes.
+func (c *worktreeContainer) publish(ports map[string]int) {
+	if c == nil {
+		return
+	}
+	for _, p := range ports {
+		c.ports = append(c.ports, p)
+	}
+	sort.Ints(c.ports)
+}
+
+// stop removes any container still running for the worktree. Best-effort.
+func (c *worktreeContainer) stop() {
+	if c == nil {
+		return
+	}
+	out, err := exec.Command(c.runtime, "ps", "-q", "--filter", "label="+containerLabel+"="+c.wtPath).Output()
+	if err != nil {
+		return
+	}
+	if ids := strings.Fields(string(out)); len(ids) > 0 {
+		exec.Command(c.runtime, append([]string{"rm", "-f"}, ids...)...).Run()
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWorktreeContainer_NilRunsOnHost(t *testing.T) {
+	root := setupPoolRepo(t, `{}`)
+	if c := newWorktreeContainer(root, root, loadWorktreeHooks(root)); c != nil {
+		t.Fatalf("no container block should mean no container, got %+v", c)
+	}
+	cmd := exec.Command("sh", "-c", "true")
+	var c *worktreeContainer
+	c.wrap(cmd, 4000, nil)
+	if strings.Join(cmd.Args, " ") != "sh -c true" {
+		t.Errorf("nil container rewrote the command: %v", cmd.Args)
+	}
+	if err := c.prepare(root); err != nil {
+		t.Errorf("nil prepare: %v", err)
+	}
+}
+
+func TestWorktreeContainer_WrapMountsWorktreeAndForwardsPort(t *testing.T) {
+	root := setupPoolRepo(t, `{"container": {
+		"image": "acme/dev:1", "runtime": "podman", "cpus": "2", "memory": "4g", "pids_limit": 512,
+		"mounts": ["~/.claude:/tmp/.claude"], "pass_env": ["NPM_TOKEN"], "args": ["--cap-drop=ALL"]
+	}}`)
+	hooks := loadWorktreeHooks(root)
+	wtPath := filepath.Join(worktreeBasePath(root), "m1")
+	if err := createUnitWorktree(root, "belmont/m1", wtPath); err != nil {
+		t.Fatal(err)
+	}
+	c := newWorktreeContainer(root, wtPath, hooks)
+
+	cmd := exec.Command("claude", "-p", "do the thing")
+	cmd.Dir = wtPath
+	c.wrap(cmd, 4123, []string{"PORT=4123", "SECRET=hunter2"})
+
+	args := strings.Join(cmd.Args, " ")
+	common := filepath.Join(root, ".git")
+	for _, want := range []string{
+		"podman run --rm -i --init",
+		"--label belmont.worktree=" + wtPath,
+		"-w " + wtPath,
+		"-v " + wtPath + ":" + wtPath,
+		"-v " + common + ":" + common,
+		"-p 127.0.0.1:4123:4123",
+		"--cpus 2", "--memory 4g", "--pids-limit 512",
+		"-e PORT", "-e SECRET", "-e ANTHROPIC_API_KEY", "-e NPM_TOKEN",
+		"--cap-drop=ALL acme/dev:1 claude -p do the thing",
+	} {
+		if !strings.Contains(args, want) {
+			t.Errorf("argv missing %q:\n%s", want, args)
+		}
+	}
+	if strings.Contains(args, "hunter2") {
+		t.Errorf("env values must not reach argv: %s", args)
+	}
+	if strings.Contains(args, "~/.claude") {
+		t.Errorf("~ in mounts should expand to the host home: %s", args)
+	}
+}
+
+func TestWorktreeContainer_HostNetworkSkipsPortPublishing(t *testing.T) {
+	c := &worktreeContainer{cfg: worktreeContainerConfig{Network: "host"}, runtime: "docker", image: "img", wtPath: "/wt", mounts: []string{"/wt"}}
+	args := strings.Join(c.runArgs("/wt", 4000, nil, []string{"sh", "-c", "make"}), " ")
+	if strings.Contains(args, "-p ") {
+		t.Errorf("host network should not publish ports: %s", args)
+	}
+	if !strings.Contains(args, "--network host") || !strings.HasSuffix(args, "img sh -c make") {
+		t.Errorf("unexpected argv: %s", args)
+	}
+}
+
+func TestWorktreeContainer_PrepareRequiresImage(t *testing.T) {
+	root := setupPoolRepo(t, `{"container": {"runtime": "sh"}}`)
+	c := newWorktreeContainer(root, root, loadWorktreeHooks(root))
+	if err := c.prepare(root); err == nil || !strings.Contains(err.Error(), "image or dockerfile") {
+		t.Errorf("prepare without image = %v", err)
+	}
+}
+
+func TestWorktreeContainer_PublishesNamedPorts(t *testing.T) {
+	c := &worktreeContainer{runtime: "docker", image: "img", wtPath: "/wt", mounts: []string{"/wt"}}
+	c.publish(map[string]int{"storybook": 6107, "api": 6105})
+	args := strings.Join(c.runArgs("/wt", 6100, nil, []string{"true"}), " ")
+	if !strings.Contains(args, "-p 127.0.0.1:6100:6100 -p 127.0.0.1:6105:6105 -p 127.0.0.1:6107:6107") {
+		t.Errorf("argv = %s", args)
+	}
+}
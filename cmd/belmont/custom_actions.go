@@ -0,0 +1,414 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// ============================================================================
+// Project-defined loop actions
+//
+// .belmont/actions.json registers extra phases for the auto loop — a
+// SECURITY_REVIEW after VERIFY on backend milestones, a DOCS_UPDATE before
+// COMPLETE — without forking Belmont:
+//
+//	{"actions": [{
+//	  "name": "SECURITY_REVIEW", "after": "VERIFY",
+//	  "when": {"work_types": ["backend", "mixed"]},
+//	  "skill": "security-review", "tier": "high", "scope_guard": "milestone"
+//	}]}
+//
+// Each action hooks onto a built-in one: "after" runs it once each time that
+// action succeeds, "before" runs it once before that action executes (for
+// the work done since the last implement/fix). The deterministic rules never
+// see custom entries — they decide on the built-in history, then
+// scheduleCustomAction substitutes a custom action when one is due — so the
+// existing rule set keeps working unchanged. The AI decider is told about
+// the custom actions and may pick them too. A custom action runs a skill
+// (/belmont:<skill>, e.g. one added through .belmont/overrides/skills) or a
+// prompt template, at its own model tier, under its own scope-guard mode.
+// Findings it writes as FWLUP tasks flow into TRIAGE like verify's do.
+// ============================================================================
+
+const customActionsFile = "actions.json"
+
+// Scope-guard modes for custom actions.
+const (
+	customGuardMilestone = "milestone" // default: PROGRESS.md changes confined to the action's milestone
+	customGuardReadOnly  = "read_only" // PROGRESS.md restored exactly; the action only reports
+	customGuardOff       = "off"       // no guard
+)
+
+// loopActionsFile is .belmont/actions.json.
+type loopActionsFile struct {
+	Actions []customLoopAction `json:"actions"`
+	Review  loopReviewConfig   `json:"review"`
+}
+
+// customLoopAction is one entry of .belmont/actions.json.
+type customLoopAction struct {
+	Name        string           `json:"name"`                  // UPPER_SNAKE action name
+	Description string           `json:"description,omitempty"` // shown to the AI decider
+	After       string           `json:"after,omitempty"`       // built-in action this follows
+	Before      string           `json:"before,omitempty"`      // built-in action this precedes
+	When        customActionWhen `json:"when,omitempty"`
+	Skill       string           `json:"skill,omitempty"`       // runs /belmont:<skill>
+	Prompt      string           `json:"prompt,omitempty"`      // inline prompt template
+	PromptFile  string           `json:"prompt_file,omitempty"` // prompt template, relative to the project root
+	Tier        string           `json:"tier,omitempty"`        // low|medium|high; empty = tool default
+	ScopeGuard  string           `json:"scope_guard,omitempty"` // milestone|read_only|off
+	Optional    bool             `json:"optional,omitempty"`    // a failure doesn't block the loop
+}
+
+// customActionWhen narrows which milestones an action runs for. Empty
+// fields match everything.
+type customActionWhen struct {
+	WorkTypes  []string `json:"work_types,omitempty"` // frontend|backend|config|docs|mixed|minimal
+	Milestones []string `json:"milestones,omitempty"` // milestone IDs
+}
+
+var customActionNameRe = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+
+// customHookableActions are the built-in actions a custom action may hook.
+var customHookableActions = map[loopActionType]bool{
+	actionImplementMilestone: true,
+	actionImplementNext:      true,
+	actionVerify:             true,
+	actionTriage:             true,
+	actionFixAll:             true,
+	actionDebug:              true,
+	actionReplan:             true,
+	actionComplete:           true,
+	actionReview:             true,
+}
+
+// builtinLoopActions are every action name Belmont itself defines.
+var builtinLoopActions = map[loopActionType]bool{
+	actionImplementMilestone: true, actionImplementNext: true, actionVerify: true,
+	actionPause: true, actionComplete: true, actionError: true, actionReplan: true,
+	actionSkipMilestone: true, actionDebug: true, actionTriage: true, actionFixAll: true,
+	actionReview: true,
+}
+
+// loadCustomLoopActions reads and validates .belmont/actions.json. No file
+// means no custom actions.
+func loadCustomLoopActions(root string) ([]customLoopAction, error) {
+	cfg, err := loadLoopActionsFile(root)
+	return cfg.Actions, err
+}
+
+// loadLoopActionsFile reads and validates the whole of .belmont/actions.json:
+// custom actions and the review requirement. No file means neither.
+func loadLoopActionsFile(root string) (loopActionsFile, error) {
+	var cfg loopActionsFile
+	path := filepath.Join(root, ".belmont", customActionsFile)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return cfg, err
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return loopActionsFile{}, fmt.Errorf("%s: %w", path, err)
+	}
+	for _, wt := range cfg.Review.WorkTypes {
+		if !containsString([]string{"frontend", "backend", "config", "docs", "mixed", "minimal"}, wt) {
+			return loopActionsFile{}, fmt.Errorf("%s: review: unknown work type %q", path, wt)
+		}
+	}
+	seen := map[string]bool{}
+	for i := range cfg.Actions {
+		a := &cfg.Actions[i]
+		a.After, a.Before = strings.ToUpper(a.After), strings.ToUpper(a.Before)
+		if a.ScopeGuard == "" {
+			a.ScopeGuard = customGuardMilestone
+		}
+		if err := validateCustomAction(root, *a); err != nil {
+			return loopActionsFile{}, fmt.Errorf("%s: action %q: %w", path, a.Name, err)
+		}
+		if seen[a.Name] {
+			return loopActionsFile{}, fmt.Errorf("%s: action %q defined twice", path, a.Name)
+		}
+		seen[a.Name] = true
+	}
+	return cfg, nil
+}
+
+func validateCustomAction(root string, a customLoopAction) error {
+	switch {
+	case !customActionNameRe.MatchString(a.Name):
+		return fmt.Errorf("name must be UPPER_SNAKE_CASE")
+	case builtinLoopActions[loopActionType(a.Name)]:
+		return fmt.Errorf("name clashes with a built-in action")
+	case (a.After == "") == (a.Before == ""):
+		return fmt.Errorf("set exactly one of after or before")
+	case a.After != "" && (!customHookableActions[loopActionType(a.After)] || a.After == string(actionComplete)):
+		return fmt.Errorf("after %q: must be one of IMPLEMENT_MILESTONE, IMPLEMENT_NEXT, VERIFY, REVIEW, TRIAGE, FIX_ALL, DEBUG, REPLAN", a.After)
+	case a.Before != "" && !customHookableActions[loopActionType(a.Before)]:
+		return fmt.Errorf("before %q: must be a built-in action other than PAUSE, ERROR or SKIP_MILESTONE", a.Before)
+	}
+	n := 0
+	for _, s := range []string{a.Skill, a.Prompt, a.PromptFile} {
+		if s != "" {
+			n++
+		}
+	}
+	if n != 1 {
+		return fmt.Errorf("set exactly one of skill, prompt or prompt_file")
+	}
+	if a.PromptFile != "" && !fileExists(filepath.Join(root, a.PromptFile)) {
+		return fmt.Errorf("prompt_file %s not found", a.PromptFile)
+	}
+	if a.Tier != "" && a.Tier != "low" && a.Tier != "medium" && a.Tier != "high" {
+		return fmt.Errorf("tier %q is not low, medium or high", a.Tier)
+	}
+	switch a.ScopeGuard {
+	case customGuardMilestone, customGuardReadOnly, customGuardOff:
+	default:
+		return fmt.Errorf("scope_guard %q is not milestone, read_only or off", a.ScopeGuard)
+	}
+	return nil
+}
+
+// findCustomAction returns the custom action named name, or nil.
+func findCustomAction(customs []customLoopAction, name string) *customLoopAction {
+	for i := range customs {
+		if customs[i].Name == name {
+			return &customs[i]
+		}
+	}
+	return nil
+}
+
+// builtinHistory drops custom-action entries so the built-in rules see the
+// history they were written for.
+func builtinHistory(history []historyEntry) []historyEntry {
+	var out []historyEntry
+	for _, h := range history {
+		if h.Action.Custom == nil {
+			out = append(out, h)
+		}
+	}
+	if len(out) == len(history) {
+		return history
+	}
+	return out
+}
+
+// customActionMatches reports whether a's when-filters admit milestone msID.
+func customActionMatches(a customLoopAction, msID string, msStates map[string]*milestoneLoopState) bool {
+	if len(a.When.Milestones) > 0 && !containsString(a.When.Milestones, msID) {
+		return false
+	}
+	if len(a.When.WorkTypes) > 0 {
+		s, ok := msStates[msID]
+		if !ok || !containsString(a.When.WorkTypes, string(s.WorkType)) {
+			return false
+		}
+	}
+	return true
+}
+
+// customActionRanSince reports whether custom action name finished
+// (successfully, or at all when optional) after history index i.
+func customActionRanSince(history []historyEntry, name string, i int, optional bool) bool {
+	for j := len(history) - 1; j > i; j-- {
+		h := history[j]
+		if h.Action.Custom == nil || h.Action.Custom.Name != name {
+			continue
+		}
+		if optional || (h.Result != nil && h.Result.Success) {
+			return true
+		}
+	}
+	return false
+}
+
+// lastIndexOf returns the index of the last history entry whose action
+// type is in types (successful only when successOnly), or -1.
+func lastIndexOf(history []historyEntry, successOnly bool, types ...loopActionType) int {
+	for i := len(history) - 1; i >= 0; i-- {
+		h := history[i]
+		if h.Action.Custom != nil {
+			continue
+		}
+		for _, t := range types {
+			if h.Action.Type == t && (!successOnly || (h.Result != nil && h.Result.Success)) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// scheduleCustomAction returns a custom action to run instead of decided,
+// or nil when none is due. "after X" is due when the last built-in X
+// succeeded after the custom action last ran; "before X" is due when X is
+// about to run and the custom action hasn't run since the last
+// implement/fix action.
+func scheduleCustomAction(customs []customLoopAction, decided *loopAction, history []historyEntry, msStates map[string]*milestoneLoopState) *loopAction {
+	if len(customs) == 0 || decided == nil || decided.Type == actionPause || decided.Type == actionError {
+		return nil
+	}
+	for i := range customs {
+		a := &customs[i]
+		var msID string
+		switch {
+		case a.After != "":
+			idx := lastIndexOf(history, false, loopActionType(a.After))
+			if idx < 0 || idx != lastIndexOf(history, false, actionImplementMilestone, actionImplementNext, actionVerify, actionReview, actionTriage, actionFixAll, actionDebug, actionReplan) {
+				continue // a later built-in action already moved on
+			}
+			if h := history[idx]; h.Result == nil || !h.Result.Success || customActionRanSince(history, a.Name, idx, a.Optional) {
+				continue
+			}
+			msID = history[idx].Action.MilestoneID
+		case a.Before != "":
+			if string(decided.Type) != a.Before {
+				continue
+			}
+			work := lastIndexOf(history, true, actionImplementMilestone, actionImplementNext, actionFixAll, actionDebug)
+			if work < 0 || customActionRanSince(history, a.Name, work, a.Optional) {
+				continue
+			}
+			msID = decided.MilestoneID
+			if msID == "" {
+				msID = history[work].Action.MilestoneID
+			}
+		}
+		if msID == "" {
+			msID = lastMilestoneID(history)
+		}
+		if !customActionMatches(*a, msID, msStates) {
+			continue
+		}
+		hook := "after " + a.After
+		if a.Before != "" {
+			hook = "before " + a.Before
+		}
+		reason := fmt.Sprintf("Project action %s (%s)", a.Name, hook)
+		if a.Description != "" {
+			reason += " — " + a.Description
+		}
+		return &loopAction{Type: loopActionType(a.Name), Reason: reason, MilestoneID: msID, Custom: a}
+	}
+	return nil
+}
+
+// buildCustomActionPrompt renders a custom action's prompt. Templates see
+// {{.Feature}}, {{.Milestone}} and {{.FeatureDir}}.
+func buildCustomActionPrompt(root string, action loopAction, feature string) (string, error) {
+	a := action.Custom
+	var prompt string
+	if a.Skill != "" {
+		prompt = fmt.Sprintf("/belmont:%s --feature %s", a.Skill, feature)
+	} else {
+		text := a.Prompt
+		if a.PromptFile != "" {
+			data, err := os.ReadFile(filepath.Join(root, a.PromptFile))
+			if err != nil {
+				return "", err
+			}
+			text = string(data)
+		}
+		tmpl, err := template.New(a.Name).Parse(text)
+		if err != nil {
+			return "", fmt.Errorf("action %s: %w", a.Name, err)
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, map[string]string{
+			"Feature":    feature,
+			"Milestone":  action.MilestoneID,
+			"FeatureDir": filepath.ToSlash(filepath.Join(".belmont", "features", feature)),
+		}); err != nil {
+			return "", fmt.Errorf("action %s: %w", a.Name, err)
+		}
+		prompt = buf.String()
+	}
+	if action.MilestoneID != "" {
+		switch a.ScopeGuard {
+		case customGuardReadOnly:
+			prompt += fmt.Sprintf("\n\nSCOPE: This is the %s phase for milestone %s. Do NOT modify PROGRESS.md — report findings in your output only.", a.Name, action.MilestoneID)
+		default:
+			prompt += fmt.Sprintf("\n\nSCOPE: This is the %s phase for milestone %s. Record any issue you find as a follow-up task (ID containing \"FWLUP\") under %s in PROGRESS.md. Do NOT touch tasks or headings of any other milestone.", a.Name, action.MilestoneID, action.MilestoneID)
+		}
+	}
+	return prompt, nil
+}
+
+// runActionScopeGuard runs the scope guard after an agent action. Custom
+// actions use their own mode: read_only restores PROGRESS.md exactly, off
+// skips the guard, milestone is the regular guard.
+func runActionScopeGuard(cfg loopConfig, action loopAction, pre *progressSnapshot) {
+	if pre == nil {
+		return
+	}
+	if action.Custom == nil {
+		runScopeGuard(cfg, action, pre)
+		return
+	}
+	switch action.Custom.ScopeGuard {
+	case customGuardOff:
+		return
+	case customGuardReadOnly:
+		post, err := os.ReadFile(pre.Path)
+		if err != nil || string(post) == pre.Raw {
+			return
+		}
+		if err := os.WriteFile(pre.Path, []byte(pre.Raw), 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "\033[33m⚠ scope guard write failed: %s\033[0m\n", err)
+			return
+		}
+		fmt.Fprintf(os.Stderr, "\033[33m⚠ %s is read-only — reverted its PROGRESS.md changes\033[0m\n", action.Custom.Name)
+	default:
+		runScopeGuard(cfg, action, pre)
+	}
+}
+
+// customActionsForAI describes the custom actions for the AI decider.
+func customActionsForAI(customs []customLoopAction) []map[string]string {
+	var out []map[string]string
+	for _, a := range customs {
+		entry := map[string]string{"name": a.Name}
+		if a.After != "" {
+			entry["after"] = a.After
+		} else {
+			entry["before"] = a.Before
+		}
+		if a.Description != "" {
+			entry["description"] = a.Description
+		}
+		out = append(out, entry)
+	}
+	return out
+}
+
+// doctorCheckCustomActions validates .belmont/actions.json for `belmont doctor`.
+func doctorCheckCustomActions(root string) []doctorCheck {
+	customs, err := loadCustomLoopActions(root)
+	if err != nil {
+		return []doctorCheck{{Area: "actions.json", Status: doctorError, Message: err.Error(), Fix: "`belmont auto` refuses to start while it is invalid"}}
+	}
+	if len(customs) == 0 {
+		return nil
+	}
+	var checks []doctorCheck
+	for _, a := range customs {
+		if a.Skill != "" && !fileExists(filepath.Join(root, ".agents", "skills", "belmont", a.Skill, "SKILL.md")) {
+			checks = append(checks, doctorCheck{Area: "actions.json", Status: doctorError,
+				Message: fmt.Sprintf("action %s runs skill %s, which is not installed", a.Name, a.Skill),
+				Fix:     fmt.Sprintf("add .belmont/overrides/skills/%s/SKILL.md and run `belmont install`", a.Skill)})
+		}
+	}
+	if len(checks) == 0 {
+		checks = append(checks, doctorCheck{Area: "actions.json", Status: doctorOK, Message: fmt.Sprintf("%d custom action(s) valid", len(customs))})
+	}
+	return checks
+}
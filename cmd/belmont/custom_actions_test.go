@@ -0,0 +1,182 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoadCustomLoopActions_Validates(t *testing.T) {
+	dir := t.TempDir()
+	if got, err := loadCustomLoopActions(dir); err != nil || got != nil {
+		t.Fatalf("no file: got %v, %v", got, err)
+	}
+
+	writeFile(t, dir, ".belmont/actions.json", `{"actions": [
+		{"name": "SECURITY_REVIEW", "after": "verify", "when": {"work_types": ["backend"]}, "skill": "security-review", "tier": "high"},
+		{"name": "DOCS_UPDATE", "before": "COMPLETE", "prompt": "Update docs for {{.Feature}}", "scope_guard": "read_only"}
+	]}`)
+	got, err := loadCustomLoopActions(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].After != "VERIFY" || got[0].ScopeGuard != customGuardMilestone || got[1].ScopeGuard != customGuardReadOnly {
+		t.Fatalf("unexpected actions: %+v", got)
+	}
+
+	for name, body := range map[string]string{
+		"lowercase name":   `{"actions":[{"name":"docs","after":"VERIFY","skill":"x"}]}`,
+		"built-in name":    `{"actions":[{"name":"VERIFY","after":"TRIAGE","skill":"x"}]}`,
+		"no hook":          `{"actions":[{"name":"X","skill":"x"}]}`,
+		"both hooks":       `{"actions":[{"name":"X","after":"VERIFY","before":"COMPLETE","skill":"x"}]}`,
+		"after complete":   `{"actions":[{"name":"X","after":"COMPLETE","skill":"x"}]}`,
+		"before pause":     `{"actions":[{"name":"X","before":"PAUSE","skill":"x"}]}`,
+		"skill and prompt": `{"actions":[{"name":"X","after":"VERIFY","skill":"x","prompt":"y"}]}`,
+		"missing file":     `{"actions":[{"name":"X","after":"VERIFY","prompt_file":"nope.md"}]}`,
+		"bad tier":         `{"actions":[{"name":"X","after":"VERIFY","skill":"x","tier":"max"}]}`,
+		"bad guard":        `{"actions":[{"name":"X","after":"VERIFY","skill":"x","scope_guard":"strict"}]}`,
+		"duplicate":        `{"actions":[{"name":"X","after":"VERIFY","skill":"x"},{"name":"X","before":"COMPLETE","skill":"x"}]}`,
+	} {
+		writeFile(t, dir, ".belmont/actions.json", body)
+		if _, err := loadCustomLoopActions(dir); err == nil {
+			t.Errorf("%s: expected a validation error", name)
+		}
+	}
+}
+
+func TestScheduleCustomAction_AfterVerifyOnBackend(t *testing.T) {
+	customs := []customLoopAction{{Name: "SECURITY_REVIEW", After: "VERIFY", When: customActionWhen{WorkTypes: []string{"backend"}}, Skill: "security-review", ScopeGuard: customGuardMilestone}}
+	states := map[string]*milestoneLoopState{
+		"M1": {WorkType: workBackend},
+		"M2": {WorkType: workFrontend},
+	}
+	ok := &executionResult{Success: true}
+	history := []historyEntry{
+		{Action: loopAction{Type: actionImplementMilestone, MilestoneID: "M1"}, Result: ok},
+		{Action: loopAction{Type: actionVerify, MilestoneID: "M1"}, Result: ok},
+	}
+	next := &loopAction{Type: actionImplementMilestone, MilestoneID: "M2"}
+
+	got := scheduleCustomAction(customs, next, history, states)
+	if got == nil || got.Type != "SECURITY_REVIEW" || got.MilestoneID != "M1" || got.Custom == nil {
+		t.Fatalf("want SECURITY_REVIEW for M1, got %+v", got)
+	}
+
+	// Once it has run, the built-in decision stands.
+	history = append(history, historyEntry{Action: *got, Result: ok})
+	if got := scheduleCustomAction(customs, next, history, states); got != nil {
+		t.Fatalf("already ran: got %+v", got)
+	}
+
+	// A failed run is retried; an optional one is not.
+	failed := append(history[:2:2], historyEntry{Action: loopAction{Type: "SECURITY_REVIEW", Custom: &customs[0]}, Result: &executionResult{}})
+	if got := scheduleCustomAction(customs, next, failed, states); got == nil {
+		t.Fatal("failed run should be retried")
+	}
+	customs[0].Optional = true
+	if got := scheduleCustomAction(customs, next, failed, states); got != nil {
+		t.Fatalf("optional failed run should not be retried: %+v", got)
+	}
+	customs[0].Optional = false
+
+	// Frontend milestones don't match the filter.
+	history = []historyEntry{
+		{Action: loopAction{Type: actionImplementMilestone, MilestoneID: "M2"}, Result: ok},
+		{Action: loopAction{Type: actionVerify, MilestoneID: "M2"}, Result: ok},
+	}
+	if got := scheduleCustomAction(customs, &loopAction{Type: actionComplete}, history, states); got != nil {
+		t.Fatalf("frontend milestone: got %+v", got)
+	}
+
+	// A failed verify doesn't trigger it.
+	history = []historyEntry{
+		{Action: loopAction{Type: actionImplementMilestone, MilestoneID: "M1"}, Result: ok},
+		{Action: loopAction{Type: actionVerify, MilestoneID: "M1"}, Result: &executionResult{}},
+	}
+	if got := scheduleCustomAction(customs, next, history, states); got != nil {
+		t.Fatalf("failed verify: got %+v", got)
+	}
+}
+
+func TestScheduleCustomAction_BeforeComplete(t *testing.T) {
+	customs := []customLoopAction{{Name: "DOCS_UPDATE", Before: "COMPLETE", Prompt: "docs", ScopeGuard: customGuardMilestone}}
+	ok := &executionResult{Success: true}
+	history := []historyEntry{
+		{Action: loopAction{Type: actionImplementMilestone, MilestoneID: "M1"}, Result: ok},
+		{Action: loopAction{Type: actionVerify, MilestoneID: "M1"}, Result: ok},
+	}
+	complete := &loopAction{Type: actionComplete}
+
+	if got := scheduleCustomAction(customs, &loopAction{Type: actionVerify}, history, nil); got != nil {
+		t.Fatalf("not before VERIFY: got %+v", got)
+	}
+	got := scheduleCustomAction(customs, complete, history, nil)
+	if got == nil || got.Type != "DOCS_UPDATE" || got.MilestoneID != "M1" {
+		t.Fatalf("want DOCS_UPDATE before COMPLETE, got %+v", got)
+	}
+	history = append(history, historyEntry{Action: *got, Result: ok})
+	if got := scheduleCustomAction(customs, complete, history, nil); got != nil {
+		t.Fatalf("already ran since the last implement: got %+v", got)
+	}
+	// New work since the last run makes it due again.
+	history = append(history, historyEntry{Action: loopAction{Type: actionFixAll}, Result: ok})
+	if got := scheduleCustomAction(customs, complete, history, nil); got == nil {
+		t.Fatal("should run again after FIX_ALL")
+	}
+	if got := scheduleCustomAction(customs, &loopAction{Type: actionPause}, history, nil); got != nil {
+		t.Fatalf("never replaces PAUSE: got %+v", got)
+	}
+}
+
+func TestBuiltinHistory_DropsCustomEntries(t *testing.T) {
+	custom := &customLoopAction{Name: "X"}
+	history := []historyEntry{
+		{Action: loopAction{Type: actionImplementMilestone}},
+		{Action: loopAction{Type: actionVerify}},
+		{Action: loopAction{Type: "X", Custom: custom}},
+	}
+	got := builtinHistory(history)
+	if len(got) != 2 || lastActionType(got) != actionVerify {
+		t.Fatalf("got %+v", got)
+	}
+}
+
+func TestBuildCustomActionPrompt(t *testing.T) {
+	dir := t.TempDir()
+	skill := loopAction{Type: "SECURITY_REVIEW", MilestoneID: "M2", Custom: &customLoopAction{Name: "SECURITY_REVIEW", Skill: "security-review", ScopeGuard: customGuardMilestone}}
+	got, err := buildCustomActionPrompt(dir, skill, "auth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(got, "/belmont:security-review --feature auth") || !strings.Contains(got, "under M2 in PROGRESS.md") {
+		t.Errorf("skill prompt: %q", got)
+	}
+
+	writeFile(t, dir, "prompts/docs.md", "Update docs for {{.Feature}} ({{.Milestone}}) from {{.FeatureDir}}/PRD.md")
+	file := loopAction{Type: "DOCS_UPDATE", MilestoneID: "M3", Custom: &customLoopAction{Name: "DOCS_UPDATE", PromptFile: "prompts/docs.md", ScopeGuard: customGuardReadOnly}}
+	got, err = buildCustomActionPrompt(dir, file, "auth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(got, "Update docs for auth (M3) from .belmont/features/auth/PRD.md") || !strings.Contains(got, "Do NOT modify PROGRESS.md") {
+		t.Errorf("template prompt: %q", got)
+	}
+}
+
+func TestDoctorCheckCustomActions(t *testing.T) {
+	dir := t.TempDir()
+	if got := doctorCheckCustomActions(dir); got != nil {
+		t.Fatalf("no file: %+v", got)
+	}
+	writeFile(t, dir, ".belmont/actions.json", `{"actions":[{"name":"SECURITY_REVIEW","after":"VERIFY","skill":"security-review"}]}`)
+	if got := doctorStatuses(doctorCheckCustomActions(dir)); got != "actions.json=error" {
+		t.Errorf("missing skill: %s", got)
+	}
+	writeFile(t, dir, ".agents/skills/belmont/security-review/SKILL.md", "# Security review\n")
+	if got := doctorStatuses(doctorCheckCustomActions(dir)); got != "actions.json=ok" {
+		t.Errorf("installed skill: %s", got)
+	}
+	writeFile(t, dir, ".belmont/actions.json", `{"actions":[{"name":"bad"}]}`)
+	if got := doctorStatuses(doctorCheckCustomActions(dir)); got != "actions.json=error" {
+		t.Errorf("invalid file: %s", got)
+	}
+}
@@ -0,0 +1,612 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// ============================================================================
+// belmont doctor
+//
+// Most auto-mode failures trace back to setup: an agent CLI that is missing
+// or not logged in, a malformed models.yaml or local-llms.json, worktree.json
+// hooks that can't run, an auto.json or worktrees left behind by a crashed
+// run, skills older than the binary, or a .gitignore without Belmont's
+// entries. `belmont doctor` checks all of these without touching the
+// network, prints each problem with a suggested fix, and with --fix applies
+// the fixes that can't lose work: gitignore entries, a stale auto.json,
+// `git worktree prune` and re-syncing skills and agents. Preserved worktrees
+// and hook failures are left to the user (`belmont recover`).
+// ============================================================================
+
+// Doctor check results.
+const (
+	doctorOK    = "ok"
+	doctorWarn  = "warn"
+	doctorError = "error"
+	doctorFixed = "fixed"
+)
+
+// doctorCheck is one finding.
+type doctorCheck struct {
+	Area    string `json:"area"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	Fix     string `json:"fix,omitempty"`     // what to do about it
+	Fixable bool   `json:"fixable,omitempty"` // --fix can apply it
+
+	apply func() error
+}
+
+// doctorLookPath and doctorAutoRunning are vars so tests can stub the host.
+var (
+	doctorLookPath    = exec.LookPath
+	doctorAutoRunning = belmontAutoProcessRunning
+)
+
+func runDoctorCmd(args []string) error {
+	fs := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	var root, format string
+	var fix bool
+	fs.StringVar(&root, "root", ".", "project root")
+	fs.StringVar(&format, "format", "text", "output format (text|json)")
+	fs.BoolVar(&fix, "fix", false, "apply the safe fixes")
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("doctor: %w", err)
+	}
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return fmt.Errorf("doctor: resolve root: %w", err)
+	}
+
+	checks := runDoctorChecks(absRoot, fix)
+
+	switch strings.ToLower(format) {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(checks); err != nil {
+			return err
+		}
+	default:
+		renderDoctorReport(os.Stdout, checks, fix)
+	}
+
+	if n := countDoctorStatus(checks, doctorError); n > 0 {
+		return fmt.Errorf("doctor: found %d problem(s)", n)
+	}
+	return nil
+}
+
+// runDoctorChecks runs every check against root. With fix, fixable
+// findings are applied and reported as fixed (or stay failed with the
+// error appended).
+func runDoctorChecks(root string, fix bool) []doctorCheck {
+	var checks []doctorCheck
+	for _, check := range []func(string) []doctorCheck{
+		doctorCheckGit,
+		doctorCheckTools,
+		doctorCheckModels,
+		doctorCheckLocalLLMs,
+		doctorCheckWorktreeJSON,
+		doctorCheckAutoState,
+		doctorCheckSkills,
+		doctorCheckCustomActions,
+		doctorCheckPin,
+		doctorCheckGitignore,
+	} {
+		checks = append(checks, check(root)...)
+	}
+	if fix {
+		for i := range checks {
+			c := &checks[i]
+			if !c.Fixable || c.apply == nil || c.Status == doctorOK {
+				continue
+			}
+			if err := c.apply(); err != nil {
+				c.Message += fmt.Sprintf(" (fix failed: %s)", err)
+				continue
+			}
+			c.Status = doctorFixed
+			c.Fixable = false
+		}
+	}
+	return checks
+}
+
+func countDoctorStatus(checks []doctorCheck, status string) int {
+	n := 0
+	for _, c := range checks {
+		if c.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+func renderDoctorReport(w io.Writer, checks []doctorCheck, fixed bool) {
+	for _, c := range checks {
+		var icon string
+		switch c.Status {
+		case doctorOK:
+			icon = "\033[32m✓\033[0m"
+		case doctorFixed:
+			icon = "\033[32m✓ fixed:\033[0m"
+		case doctorWarn:
+			icon = "\033[33m⚠\033[0m"
+		default:
+			icon = "\033[31m✗\033[0m"
+		}
+		fmt.Fprintf(w, "%s %s: %s\n", icon, c.Area, c.Message)
+		if c.Fix != "" && (c.Status == doctorWarn || c.Status == doctorError) {
+			fmt.Fprintf(w, "    \033[2m→ %s\033[0m\n", c.Fix)
+		}
+	}
+	errs, warns := countDoctorStatus(checks, doctorError), countDoctorStatus(checks, doctorWarn)
+	fixable := 0
+	for _, c := range checks {
+		if c.Fixable && c.Status != doctorOK {
+			fixable++
+		}
+	}
+	fmt.Fprintln(w)
+	switch {
+	case errs == 0 && warns == 0:
+		fmt.Fprintln(w, "\033[32m✓ No problems found.\033[0m")
+	default:
+		fmt.Fprintf(w, "%d problem(s), %d warning(s).", errs, warns)
+		if fixable > 0 && !fixed {
+			fmt.Fprintf(w, " Run `belmont doctor --fix` to fix %d of them.", fixable)
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+func doctorCheckGit(root string) []doctorCheck {
+	if _, err := doctorLookPath("git"); err != nil {
+		return []doctorCheck{{Area: "git", Status: doctorError, Message: "git is not on PATH", Fix: "install git"}}
+	}
+	if _, err := gitOutputIn(root, "rev-parse", "--git-dir"); err != nil {
+		return []doctorCheck{{Area: "git", Status: doctorError, Message: root + " is not a git repository", Fix: "run `git init` — auto mode needs git worktrees"}}
+	}
+	if _, err := gitOutputIn(root, "rev-parse", "--verify", "HEAD"); err != nil {
+		return []doctorCheck{{Area: "git", Status: doctorWarn, Message: "the repository has no commits", Fix: "make an initial commit — worktrees branch from HEAD"}}
+	}
+	return []doctorCheck{{Area: "git", Status: doctorOK, Message: "repository found"}}
+}
+
+// toolAuthHints lists, per agent CLI, the env vars and the files under
+// $HOME that indicate a login. They are hints: a tool can keep its
+// credentials in a system keychain, so a miss is only a warning.
+var toolAuthHints = map[string]struct {
+	Env   []string
+	Files []string
+}{
+	"claude":  {Env: []string{"ANTHROPIC_API_KEY", "CLAUDE_CODE_OAUTH_TOKEN"}, Files: []string{".claude/.credentials.json", ".claude.json"}},
+	"codex":   {Env: []string{"OPENAI_API_KEY", "CODEX_API_KEY"}, Files: []string{".codex/auth.json"}},
+	"gemini":  {Env: []string{"GEMINI_API_KEY", "GOOGLE_API_KEY"}, Files: []string{".gemini/oauth_creds.json"}},
+	"copilot": {Env: []string{"GH_TOKEN", "GITHUB_TOKEN"}, Files: []string{".copilot/config.json", ".config/gh/hosts.yml"}},
+	"cursor":  {Env: []string{"CURSOR_API_KEY"}, Files: []string{".cursor/cli-config.json"}},
+	"pi":      {Files: []string{".pi/agent/auth.json", ".pi/agent/models.json"}},
+}
+
+func doctorCheckTools(root string) []doctorCheck {
+	var checks []doctorCheck
+	var found []string
+	for _, tool := range []string{"claude", "codex", "gemini", "copilot", "cursor", "windsurf", "pi"} {
+		if _, err := doctorLookPath(toolBinary(tool)); err == nil {
+			found = append(found, tool)
+		}
+	}
+	if len(found) == 0 {
+		return []doctorCheck{{Area: "agent CLI", Status: doctorError, Message: "no supported agent CLI on PATH (claude, codex, gemini, copilot, cursor, pi)", Fix: "install one and log in — auto mode runs it for every action"}}
+	}
+	home, _ := os.UserHomeDir()
+	for _, tool := range found {
+		hints, ok := toolAuthHints[tool]
+		if !ok {
+			checks = append(checks, doctorCheck{Area: "agent CLI", Status: doctorOK, Message: tool + " found"})
+			continue
+		}
+		authed := false
+		for _, v := range hints.Env {
+			if os.Getenv(v) != "" {
+				authed = true
+			}
+		}
+		for _, f := range hints.Files {
+			if home != "" && fileExists(filepath.Join(home, f)) {
+				authed = true
+			}
+		}
+		if authed {
+			checks = append(checks, doctorCheck{Area: "agent CLI", Status: doctorOK, Message: tool + " found and has credentials"})
+		} else {
+			fix := fmt.Sprintf("run `%s` once to log in", toolBinary(tool))
+			if len(hints.Env) > 0 {
+				fix += ", or set " + strings.Join(hints.Env, " / ")
+			}
+			checks = append(checks, doctorCheck{Area: "agent CLI", Status: doctorWarn,
+				Message: tool + " found, but no login was detected",
+				Fix:     fix})
+		}
+	}
+	// Tools wired into this project whose CLI is gone
+	for _, tool := range detectTools(root) {
+		if !containsString(found, tool) && dirExists(filepath.Join(root, "."+tool)) && tool != "windsurf" {
+			checks = append(checks, doctorCheck{Area: "agent CLI", Status: doctorWarn,
+				Message: fmt.Sprintf("project is set up for %s, but %s is not on PATH", tool, toolBinary(tool)),
+				Fix:     "install it, or pass --tool to `belmont auto`"})
+		}
+	}
+	return checks
+}
+
+// validModelAgents are the agent names models.yaml tiers may set.
+var validModelAgents = []string{"codebase", "design", "implementation", "verification", "code-review", "reconciliation"}
+
+func doctorCheckModels(root string) []doctorCheck {
+	matches, _ := filepath.Glob(filepath.Join(root, ".belmont", "features", "*", "models.yaml"))
+	var checks []doctorCheck
+	for _, path := range matches {
+		rel, _ := filepath.Rel(root, path)
+		cfg, err := parseModelTiers(path)
+		if err != nil {
+			checks = append(checks, doctorCheck{Area: "models.yaml", Status: doctorError, Message: fmt.Sprintf("%s: %s", rel, err)})
+			continue
+		}
+		var problems []string
+		if p := cfg.Planning; p != "" && p != "low" && p != "medium" && p != "high" {
+			problems = append(problems, fmt.Sprintf("planning tier %q is not low, medium or high", p))
+		}
+		agents := make([]string, 0, len(cfg.Tiers))
+		for agent := range cfg.Tiers {
+			agents = append(agents, agent)
+		}
+		sort.Strings(agents)
+		for _, agent := range agents {
+			tier := cfg.Tiers[agent]
+			if !containsString(validModelAgents, agent) {
+				problems = append(problems, fmt.Sprintf("unknown agent %q", agent))
+			}
+			if tier != "low" && tier != "medium" && tier != "high" {
+				problems = append(problems, fmt.Sprintf("%s: tier %q is not low, medium or high", agent, tier))
+			}
+		}
+		if len(problems) > 0 {
+			checks = append(checks, doctorCheck{Area: "models.yaml", Status: doctorError,
+				Message: fmt.Sprintf("%s: %s", rel, strings.Join(problems, "; ")),
+				Fix:     "tiers map an agent (" + strings.Join(validModelAgents, ", ") + ") to low, medium or high; anything else is silently ignored"})
+		}
+	}
+	if len(matches) > 0 && len(checks) == 0 {
+		checks = append(checks, doctorCheck{Area: "models.yaml", Status: doctorOK, Message: fmt.Sprintf("%d file(s) valid", len(matches))})
+	}
+	return checks
+}
+
+func doctorCheckLocalLLMs(root string) []doctorCheck {
+	var checks []doctorCheck
+	for _, path := range []string{userLocalLLMsPath(), projectLocalLLMsPath(root)} {
+		cfg, err := readLocalLLMsFile(path)
+		if err != nil {
+			checks = append(checks, doctorCheck{Area: "local-llms.json", Status: doctorError, Message: fmt.Sprintf("%s: %s", path, err), Fix: "fix the JSON — Pi runs fail while it is malformed"})
+			continue
+		}
+		if cfg == nil {
+			continue
+		}
+		var problems []string
+		if cfg.Pi != nil {
+			for tier, t := range cfg.Pi.Tiers {
+				if tier != "low" && tier != "medium" && tier != "high" {
+					problems = append(problems, fmt.Sprintf("pi tier %q is not low, medium or high", tier))
+				} else if t.Model == "" {
+					problems = append(problems, fmt.Sprintf("pi tier %q has no model", tier))
+				}
+			}
+		}
+		sort.Strings(problems)
+		if len(problems) > 0 {
+			checks = append(checks, doctorCheck{Area: "local-llms.json", Status: doctorWarn, Message: fmt.Sprintf("%s: %s", path, strings.Join(problems, "; "))})
+		} else {
+			checks = append(checks, doctorCheck{Area: "local-llms.json", Status: doctorOK, Message: path + " valid"})
+		}
+	}
+	return checks
+}
+
+// shellBuiltins are hook command words that needn't be on PATH.
+var shellBuiltins = map[string]bool{
+	"cd": true, "export": true, "source": true, ".": true, "test": true, "[": true, "echo": true,
+	"true": true, "false": true, "set": true, "exit": true, "if": true, "for": true, "while": true,
+	"case": true, "exec": true, "command": true, "eval": true, "unset": true, "{": true, "(": true,
+}
+
+func doctorCheckWorktreeJSON(root string) []doctorCheck {
+	path := filepath.Join(root, ".belmont", "worktree.json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var hooks worktreeHooks
+	if err := json.Unmarshal(data, &hooks); err != nil {
+		return []doctorCheck{{Area: "worktree.json", Status: doctorError, Message: err.Error(), Fix: "fix the JSON — worktrees are created without hooks, caches or services while it is malformed"}}
+	}
+	var checks []doctorCheck
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&worktreeHooks{}); err != nil {
+		checks = append(checks, doctorCheck{Area: "worktree.json", Status: doctorWarn, Message: err.Error(), Fix: "check the field name against docs/worktree-isolation.md — unknown fields are ignored"})
+	}
+
+	type hookSet struct {
+		label    string
+		commands []string
+	}
+	sets := []hookSet{{"setup", hooks.Setup}, {"teardown", hooks.Teardown}}
+	ids := make([]string, 0, len(hooks.Workspaces))
+	for id := range hooks.Workspaces {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		sets = append(sets, hookSet{"workspaces." + id + ".setup", hooks.Workspaces[id].Setup})
+		sets = append(sets, hookSet{"workspaces." + id + ".gate", hooks.Workspaces[id].Gate})
+		if p := hooks.Workspaces[id].Path; p != "" && !dirExists(filepath.Join(root, p)) {
+			checks = append(checks, doctorCheck{Area: "worktree.json", Status: doctorError, Message: fmt.Sprintf("workspaces.%s.path %q does not exist", id, p)})
+		}
+	}
+	if hooks.MergeGate != nil {
+		sets = append(sets, hookSet{"merge_gate.commands", hooks.MergeGate.Commands}, hookSet{"merge_gate.workspace_commands", hooks.MergeGate.WorkspaceCommands})
+	}
+	inContainer := hooks.Container != nil
+	for _, set := range sets {
+		for _, cmdStr := range set.commands {
+			if out, err := exec.Command("sh", "-n", "-c", cmdStr).CombinedOutput(); err != nil {
+				checks = append(checks, doctorCheck{Area: "worktree.json", Status: doctorError,
+					Message: fmt.Sprintf("%s: %q is not valid shell: %s", set.label, cmdStr, strings.TrimSpace(string(out)))})
+				continue
+			}
+			if word := hookCommandWord(cmdStr); word != "" && !inContainer {
+				if _, err := doctorLookPath(word); err != nil && !fileExists(filepath.Join(root, word)) {
+					checks = append(checks, doctorCheck{Area: "worktree.json", Status: doctorError,
+						Message: fmt.Sprintf("%s: %q needs %s, which is not on PATH", set.label, cmdStr, word),
+						Fix:     "install it, or fix the command — the hook fails every worktree it runs in"})
+				}
+			}
+		}
+	}
+	if c := hooks.Container; c != nil {
+		runtimeName := c.Runtime
+		if runtimeName == "" {
+			runtimeName = "docker"
+		}
+		if _, err := doctorLookPath(runtimeName); err != nil {
+			checks = append(checks, doctorCheck{Area: "worktree.json", Status: doctorError, Message: "container runtime " + runtimeName + " is not on PATH", Fix: "install it or remove the container block"})
+		}
+	}
+	if m := hooks.Merge; m != nil {
+		if err := validateMergeMode(m); err != nil {
+			checks = append(checks, doctorCheck{Area: "worktree.json", Status: doctorError, Message: err.Error(), Fix: "see Pull request mode in docs/worktree-isolation.md"})
+		} else if m.Mode == mergeModePR && m.Forge.Type != forgeCommand {
+			if _, err := newForgeAdapter(root, m); err != nil {
+				checks = append(checks, doctorCheck{Area: "worktree.json", Status: doctorWarn, Message: "merge: " + err.Error(), Fix: "finished branches fail to open a pull request until this is fixed"})
+			}
+		}
+	}
+	for name, svc := range hooks.Services {
+		client := map[string]string{serviceTypePostgres: "psql", serviceTypeRedis: "redis-cli"}[svc.Type]
+		if client == "" {
+			continue
+		}
+		if _, err := doctorLookPath(client); err != nil {
+			checks = append(checks, doctorCheck{Area: "worktree.json", Status: doctorError, Message: fmt.Sprintf("service %s needs %s, which is not on PATH", name, client)})
+		}
+	}
+	if len(checks) == 0 {
+		checks = append(checks, doctorCheck{Area: "worktree.json", Status: doctorOK, Message: "valid, hook commands found"})
+	}
+	return checks
+}
+
+// hookCommandWord returns the program a hook command runs first, skipping
+// leading VAR=value assignments. Empty for shell builtins and anything too
+// complex to guess.
+func hookCommandWord(cmdStr string) string {
+	for _, f := range strings.Fields(cmdStr) {
+		if strings.Contains(f, "=") && !strings.HasPrefix(f, "=") {
+			continue
+		}
+		if shellBuiltins[f] || strings.ContainsAny(f, "$`\"'|&;<>(){}") {
+			return ""
+		}
+		return f
+	}
+	return ""
+}
+
+// belmontAutoProcessRunning reports whether a `belmont auto` / `belmont
+// loop` process is running. ok is false when it can't tell (no ps).
+func belmontAutoProcessRunning() (running, ok bool) {
+	if runtime.GOOS == "windows" {
+		return false, false
+	}
+	out, err := exec.Command("ps", "-eo", "args=").Output()
+	if err != nil {
+		return false, false
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		f := strings.Fields(line)
+		if len(f) >= 2 && strings.TrimSuffix(filepath.Base(f[0]), ".exe") == "belmont" && (f[1] == "auto" || f[1] == "loop") {
+			return true, true
+		}
+	}
+	return false, true
+}
+
+func doctorCheckAutoState(root string) []doctorCheck {
+	var checks []doctorCheck
+	autoPath := filepath.Join(root, ".belmont", "auto.json")
+	aj := readActiveAutoJSONOrNil(root)
+	active := map[string]bool{}
+	if aj != nil {
+		running, known := doctorAutoRunning()
+		allGone := len(aj.Worktrees) > 0
+		for _, e := range aj.Worktrees {
+			active[e.Path] = true
+			if dirExists(e.Path) {
+				allGone = false
+			}
+		}
+		if (known && !running) || allGone {
+			checks = append(checks, doctorCheck{Area: "auto state", Status: doctorWarn,
+				Message: fmt.Sprintf(".belmont/auto.json says a run started %s is active, but no auto run is alive", aj.Started),
+				Fix:     "remove .belmont/auto.json — `belmont status` shows the dead run's worktrees as live until then",
+				Fixable: true,
+				apply:   func() error { return os.Remove(autoPath) }})
+			active = map[string]bool{}
+		}
+	}
+
+	var leftover []string
+	for _, wt := range listPreservedWorktrees(root) {
+		if !active[wt.Path] {
+			leftover = append(leftover, filepath.Base(wt.Path))
+		}
+	}
+	if len(leftover) > 0 {
+		checks = append(checks, doctorCheck{Area: "worktrees", Status: doctorWarn,
+			Message: fmt.Sprintf("%d worktree(s) left from earlier runs: %s", len(leftover), strings.Join(leftover, ", ")),
+			Fix:     "`belmont recover --list` to inspect, `--merge <slug>` to keep the work or `--clean <slug>` to drop it"})
+	}
+	if out, err := gitOutputIn(root, "worktree", "prune", "--dry-run"); err == nil && strings.TrimSpace(out) != "" {
+		n := len(strings.Split(strings.TrimSpace(out), "\n"))
+		checks = append(checks, doctorCheck{Area: "worktrees", Status: doctorWarn,
+			Message: fmt.Sprintf("git still registers %d worktree(s) whose directory is gone", n),
+			Fix:     "`git worktree prune`",
+			Fixable: true,
+			apply: func() error {
+				_, err := gitOutputIn(root, "worktree", "prune")
+				return err
+			}})
+	}
+	if len(checks) == 0 {
+		checks = append(checks, doctorCheck{Area: "auto state", Status: doctorOK, Message: "no stale auto run or worktrees"})
+	}
+	return checks
+}
+
+// doctorCheckSkills compares the installed skills and agents with the
+// copies embedded in this binary. Files replaced by a project override are
+// expected to differ; unresolved override conflicts are reported instead.
+func doctorCheckSkills(root string) []doctorCheck {
+	skillsDir := filepath.Join(root, ".agents", "skills", "belmont")
+	agentsDir := filepath.Join(root, ".agents", "belmont")
+	if !dirExists(skillsDir) {
+		return []doctorCheck{{Area: "skills", Status: doctorWarn, Message: "Belmont is not installed in this project", Fix: "`belmont install`"}}
+	}
+	if !hasEmbeddedFiles {
+		return []doctorCheck{{Area: "skills", Status: doctorOK, Message: "installed (development build: not compared)"}}
+	}
+	var checks []doctorCheck
+	overridden := map[string]bool{}
+	var conflicts []string
+	for _, o := range listProjectOverrides(root) {
+		overridden[o.Target] = true
+		if overrideStatus(o) == "conflict" {
+			conflicts = append(conflicts, o.Rel)
+		}
+	}
+	if len(conflicts) > 0 {
+		checks = append(checks, doctorCheck{Area: "overrides", Status: doctorWarn,
+			Message: "upstream changes conflict with " + strings.Join(conflicts, ", "),
+			Fix:     "`belmont overrides diff <path>`, resolve, then `belmont overrides accept <path>`"})
+	}
+
+	var stale []string
+	entries, _ := fs.ReadDir(embeddedSkills, "skills/belmont")
+	for _, e := range entries {
+		if !e.IsDir() || strings.HasPrefix(e.Name(), "_") || e.Name() == "references" {
+			continue
+		}
+		want, err := fs.ReadFile(embeddedSkills, "skills/belmont/"+e.Name()+"/SKILL.md")
+		if err != nil {
+			continue
+		}
+		path := filepath.Join(skillsDir, e.Name(), "SKILL.md")
+		if overridden[path] {
+			continue
+		}
+		if have, err := os.ReadFile(path); err != nil || !bytes.Equal(have, want) {
+			stale = append(stale, e.Name())
+		}
+	}
+	agents, _ := fs.ReadDir(embeddedAgents, "agents/belmont")
+	for _, e := range agents {
+		if e.IsDir() {
+			continue
+		}
+		want, _ := fs.ReadFile(embeddedAgents, "agents/belmont/"+e.Name())
+		path := filepath.Join(agentsDir, e.Name())
+		if overridden[path] {
+			continue
+		}
+		if have, err := os.ReadFile(path); err != nil || !bytes.Equal(have, want) {
+			stale = append(stale, strings.TrimSuffix(e.Name(), ".md"))
+		}
+	}
+	if len(stale) == 0 {
+		return append(checks, doctorCheck{Area: "skills", Status: doctorOK, Message: "skills and agents match belmont " + Version})
+	}
+	return append(checks, doctorCheck{Area: "skills", Status: doctorWarn,
+		Message: fmt.Sprintf("%d skill/agent file(s) differ from belmont %s: %s", len(stale), Version, strings.Join(stale, ", ")),
+		Fix:     "`belmont install` re-syncs them",
+		Fixable: true,
+		apply: func() error {
+			if err := syncEmbeddedDir(embeddedAgents, "agents/belmont", agentsDir); err != nil {
+				return err
+			}
+			if err := syncEmbeddedSkillsFolderDir(embeddedSkills, "skills/belmont", skillsDir); err != nil {
+				return err
+			}
+			return applyProjectOverrides(root, true)
+		}})
+}
+
+// belmontGitignoreEntries are the paths auto mode expects .gitignore to cover.
+var belmontGitignoreEntries = []string{".belmont/auto.json", ".belmont/worktrees/"}
+
+func doctorCheckGitignore(root string) []doctorCheck {
+	content, _ := os.ReadFile(filepath.Join(root, ".gitignore"))
+	var missing []string
+	for _, entry := range belmontGitignoreEntries {
+		if !strings.Contains(string(content), entry) {
+			missing = append(missing, entry)
+		}
+	}
+	if len(missing) == 0 {
+		return []doctorCheck{{Area: ".gitignore", Status: doctorOK, Message: "covers Belmont's runtime files"}}
+	}
+	return []doctorCheck{{Area: ".gitignore", Status: doctorWarn,
+		Message: "missing " + strings.Join(missing, ", "),
+		Fix:     "add them — otherwise run state shows up in `git status` and blocks the clean-tree preflight",
+		Fixable: true,
+		apply: func() error {
+			for _, entry := range missing {
+				ensureGitignoreEntry(root, entry)
+			}
+			return nil
+		}}}
+}
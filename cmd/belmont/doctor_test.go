@@ -0,0 +1,147 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// doctorStatuses returns "area=status" for each check, joined by commas.
+func doctorStatuses(checks []doctorCheck) string {
+	var out []string
+	for _, c := range checks {
+		out = append(out, c.Area+"="+c.Status)
+	}
+	return strings.Join(out, ",")
+}
+
+func TestDoctorCheckModels_FlagsBadTiersAndUnknownAgents(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, ".belmont/features/ok/models.yaml", "profile: fullstack\nplanning: high\ntiers:\n  implementation: high\n")
+	writeFile(t, dir, ".belmont/features/bad/models.yaml", "planning: max\ntiers:\n  implementation: huge\n  implementor: low\n")
+
+	checks := doctorCheckModels(dir)
+	if len(checks) != 1 || checks[0].Status != doctorError {
+		t.Fatalf("checks = %+v", checks)
+	}
+	for _, want := range []string{"bad/models.yaml", `planning tier "max"`, `tier "huge"`, `unknown agent "implementor"`} {
+		if !strings.Contains(checks[0].Message, want) {
+			t.Errorf("message %q missing %q", checks[0].Message, want)
+		}
+	}
+}
+
+func TestDoctorCheckLocalLLMs_Malformed(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	writeFile(t, dir, ".belmont/local-llms.json", `{"pi": {"tiers": {"low": {"provider": "ollama"}`)
+	checks := doctorCheckLocalLLMs(dir)
+	if len(checks) != 1 || checks[0].Status != doctorError {
+		t.Fatalf("checks = %+v", checks)
+	}
+
+	writeFile(t, dir, ".belmont/local-llms.json", `{"pi": {"tiers": {"low": {"provider": "ollama"}, "turbo": {"model": "x"}}}}`)
+	checks = doctorCheckLocalLLMs(dir)
+	if len(checks) != 1 || checks[0].Status != doctorWarn ||
+		!strings.Contains(checks[0].Message, `"low" has no model`) || !strings.Contains(checks[0].Message, `"turbo" is not low`) {
+		t.Fatalf("checks = %+v", checks)
+	}
+}
+
+func TestDoctorCheckWorktreeJSON_UnknownFieldsAndMissingCommands(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, ".belmont/worktree.json", `{"setup": ["FOO=1 no-such-tool-xyz install", "cd web && npm ci"], "teardwon": ["true"], "teardown": ["echo 'unterminated"]}`)
+
+	checks := doctorCheckWorktreeJSON(dir)
+	var msgs []string
+	for _, c := range checks {
+		msgs = append(msgs, c.Status+": "+c.Message)
+	}
+	all := strings.Join(msgs, "\n")
+	for _, want := range []string{`warn: json: unknown field "teardwon"`, "needs no-such-tool-xyz", "teardown:", "is not valid shell"} {
+		if !strings.Contains(all, want) {
+			t.Errorf("missing %q in:\n%s", want, all)
+		}
+	}
+	if strings.Contains(all, "needs cd") {
+		t.Errorf("builtins should not be looked up:\n%s", all)
+	}
+
+	writeFile(t, dir, ".belmont/worktree.json", `{"setup": ["sh -c true"]}`)
+	if got := doctorStatuses(doctorCheckWorktreeJSON(dir)); got != "worktree.json=ok" {
+		t.Errorf("valid config = %s", got)
+	}
+}
+
+func TestHookCommandWord(t *testing.T) {
+	for cmd, want := range map[string]string{
+		"pnpm install":           "pnpm",
+		"NODE_ENV=test npm ci":   "npm",
+		"cd api && make":         "",
+		"$BELMONT_BIN/setup":     "",
+		"./scripts/bootstrap.sh": "./scripts/bootstrap.sh",
+	} {
+		if got := hookCommandWord(cmd); got != want {
+			t.Errorf("hookCommandWord(%q) = %q, want %q", cmd, got, want)
+		}
+	}
+}
+
+func TestDoctorFix_GitignoreAndStaleAutoJSON(t *testing.T) {
+	root := setupPoolRepo(t, `{}`)
+	writeFile(t, root, ".belmont/auto.json", `{"active": true, "started": "2026-10-01T10:00:00Z", "worktrees": {}}`)
+	orig := doctorAutoRunning
+	doctorAutoRunning = func() (bool, bool) { return false, true }
+	defer func() { doctorAutoRunning = orig }()
+
+	checks := runDoctorChecks(root, false)
+	got := doctorStatuses(checks)
+	for _, want := range []string{"auto state=warn", ".gitignore=warn"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("before fix: %s missing %s", got, want)
+		}
+	}
+
+	checks = runDoctorChecks(root, true)
+	got = doctorStatuses(checks)
+	for _, want := range []string{"auto state=fixed", ".gitignore=fixed"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("after fix: %s missing %s", got, want)
+		}
+	}
+	if fileExists(filepath.Join(root, ".belmont", "auto.json")) {
+		t.Error("stale auto.json should be removed")
+	}
+	data, _ := os.ReadFile(filepath.Join(root, ".gitignore"))
+	if !strings.Contains(string(data), ".belmont/auto.json") || !strings.Contains(string(data), ".belmont/worktrees/") {
+		t.Errorf(".gitignore = %q", data)
+	}
+	if got := doctorStatuses(doctorCheckGitignore(root)); got != ".gitignore=ok" {
+		t.Errorf("second run = %s", got)
+	}
+}
+
+func TestDoctorCheckAutoState_LiveRunIsNotStale(t *testing.T) {
+	root := setupPoolRepo(t, `{}`)
+	writeFile(t, root, ".belmont/auto.json", `{"active": true, "started": "2026-10-01T10:00:00Z", "worktrees": {}}`)
+	orig := doctorAutoRunning
+	doctorAutoRunning = func() (bool, bool) { return true, true }
+	defer func() { doctorAutoRunning = orig }()
+
+	if got := doctorStatuses(doctorCheckAutoState(root)); got != "auto state=ok" {
+		t.Errorf("statuses = %s", got)
+	}
+}
+
+func TestDoctorCheckTools_NoAgentCLI(t *testing.T) {
+	orig := doctorLookPath
+	doctorLookPath = func(string) (string, error) { return "", exec.ErrNotFound }
+	defer func() { doctorLookPath = orig }()
+
+	checks := doctorCheckTools(t.TempDir())
+	if len(checks) != 1 || checks[0].Status != doctorError {
+		t.Fatalf("checks = %+v", checks)
+	}
+}
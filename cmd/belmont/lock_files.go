@@ -0,0 +1,213 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ============================================================================
+// Lock file regeneration
+//
+// A conflicted lock file is never merged by hand: it is deleted and rebuilt
+// by its package manager once its manifest is settled. The command runs in
+// the lock file's own directory, so a go.sum or Cargo.lock that belongs to
+// one workspace is regenerated there rather than at the repo root. A root
+// lock that covers several workspaces waits while any of their manifests is
+// still conflicted. Ecosystems with an offline mode try it first and only
+// reach the network when the local cache can't satisfy the resolve.
+// Projects add or override rules with lock_files in worktree.json.
+// ============================================================================
+
+// lockFileRule says how to rebuild one kind of lock file. Command runs
+// through `sh -c`; Offline, when set, is tried first.
+type lockFileRule struct {
+	Command   string   `json:"command"`
+	Offline   string   `json:"offline,omitempty"`
+	Manifests []string `json:"manifests,omitempty"` // beside the lock file; the rule applies only when one exists
+}
+
+// lockFileMap holds the built-in rules keyed by lock file basename.
+var lockFileMap = map[string]lockFileRule{
+	"package-lock.json": {Command: "npm install --prefer-offline --no-audit --no-fund", Manifests: []string{"package.json"}},
+	"pnpm-lock.yaml":    {Command: "pnpm install --prefer-offline", Manifests: []string{"package.json"}},
+	"yarn.lock":         {Command: "yarn install", Manifests: []string{"package.json"}},
+	"bun.lockb":         {Command: "bun install", Manifests: []string{"package.json"}},
+	"bun.lock":          {Command: "bun install", Manifests: []string{"package.json"}},
+	"Cargo.lock":        {Command: "cargo generate-lockfile", Offline: "cargo generate-lockfile --offline", Manifests: []string{"Cargo.toml"}},
+	"go.sum":            {Command: "go mod tidy", Offline: "GOPROXY=off go mod tidy", Manifests: []string{"go.mod"}},
+	"Gemfile.lock":      {Command: "bundle install", Offline: "bundle install --local", Manifests: []string{"Gemfile"}},
+	"poetry.lock":       {Command: "poetry lock --no-update", Manifests: []string{"pyproject.toml"}},
+	"uv.lock":           {Command: "uv lock", Offline: "uv lock --offline", Manifests: []string{"pyproject.toml"}},
+	"pdm.lock":          {Command: "pdm lock", Manifests: []string{"pyproject.toml"}},
+	"Pipfile.lock":      {Command: "pipenv lock", Manifests: []string{"Pipfile"}},
+	"composer.lock": {
+		Command:   "composer update --no-install --no-interaction --no-scripts",
+		Offline:   "COMPOSER_DISABLE_NETWORK=1 composer update --no-install --no-interaction --no-scripts",
+		Manifests: []string{"composer.json"},
+	},
+	"mix.lock": {Command: "mix deps.get", Offline: "HEX_OFFLINE=1 mix deps.get", Manifests: []string{"mix.exs"}},
+	"gradle.lockfile": {
+		Command:   "gradle dependencies --write-locks",
+		Offline:   "gradle dependencies --write-locks --offline",
+		Manifests: []string{"build.gradle", "build.gradle.kts"},
+	},
+	// maven-lockfile plugin output; only claimed when a pom.xml sits beside it.
+	"lockfile.json": {
+		Command:   "mvn -q io.github.chains-project:maven-lockfile:generate",
+		Offline:   "mvn -o -q io.github.chains-project:maven-lockfile:generate",
+		Manifests: []string{"pom.xml"},
+	},
+}
+
+// lockFileRuleFor finds the rule for a repo-relative path. worktree.json
+// entries keyed by the exact path win over those keyed by basename, which
+// win over the built-ins. A configured rule with an empty command turns
+// regeneration off for that file.
+func lockFileRuleFor(hooks *worktreeHooks, file string) (lockFileRule, bool) {
+	base := path.Base(file)
+	if hooks != nil {
+		if r, ok := hooks.LockFiles[file]; ok {
+			return r, r.Command != ""
+		}
+		if r, ok := hooks.LockFiles[base]; ok {
+			return r, r.Command != ""
+		}
+	}
+	r, ok := lockFileMap[base]
+	return r, ok
+}
+
+// isLockFile reports whether file is handled by lock regeneration rather
+// than merged as text or structure. A worktree.json rule decides before the
+// built-ins, so a lock file the project turns off is merged like any other
+// file.
+func isLockFile(hooks *worktreeHooks, file string) bool {
+	_, ok := lockFileRuleFor(hooks, file)
+	return ok
+}
+
+// lockManifests lists the manifests a lock file in dir is derived from:
+// the rule's manifests beside it that exist on disk, plus those of every
+// workspace nested under dir (a root lock in a JS or Cargo workspace covers
+// its members). Paths are repo-relative.
+func lockManifests(root, dir string, rule lockFileRule, workspaces []workspaceInfo) (beside, nested []string) {
+	for _, m := range rule.Manifests {
+		p := path.Join(dir, m)
+		if fileExists(filepath.Join(root, p)) {
+			beside = append(beside, p)
+		}
+	}
+	for _, ws := range workspaces {
+		wsPath := filepath.ToSlash(filepath.Clean(ws.Path))
+		if wsPath == "." || wsPath == dir || (dir != "." && !strings.HasPrefix(wsPath, dir+"/")) {
+			continue
+		}
+		for _, m := range rule.Manifests {
+			nested = append(nested, path.Join(wsPath, m))
+		}
+	}
+	return beside, nested
+}
+
+// autoResolveLockFiles detects conflicted lock files and regenerates them.
+// Only handles lock files whose manifests are NOT conflicted (if a manifest
+// is also conflicted, the AI agent needs to handle both together).
+func autoResolveLockFiles(root string) {
+	cmd := exec.Command("git", "diff", "--name-only", "--diff-filter=U")
+	cmd.Dir = root
+	out, err := cmd.Output()
+	if err != nil {
+		return
+	}
+
+	conflicted := make(map[string]bool)
+	var files []string
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			conflicted[line] = true
+			files = append(files, line)
+		}
+	}
+	// Deepest first: a workspace's own lock is rebuilt before a root lock
+	// that may read it.
+	sort.SliceStable(files, func(i, j int) bool {
+		return strings.Count(files[i], "/") > strings.Count(files[j], "/")
+	})
+
+	hooks := loadWorktreeHooks(root)
+	workspaces, _, _ := resolveWorkspaces(root, hooks)
+
+	for _, file := range files {
+		if strings.HasPrefix(file, ".belmont/") {
+			continue
+		}
+		rule, isLock := lockFileRuleFor(hooks, file)
+		if !isLock {
+			continue
+		}
+		dir := path.Dir(file)
+		beside, nested := lockManifests(root, dir, rule, workspaces)
+		if len(rule.Manifests) > 0 && len(beside) == 0 {
+			continue // not this ecosystem's lock file (e.g. an unrelated lockfile.json)
+		}
+		pending := false
+		for _, m := range append(beside, nested...) {
+			if conflicted[m] {
+				pending = true
+			}
+		}
+		if pending {
+			// Manifest still conflicted — leave for the AI agent to handle together
+			continue
+		}
+
+		where := ""
+		if dir != "." {
+			where = " in " + dir
+			if ws := owningWorkspace(workspaces, file); ws != nil {
+				where = fmt.Sprintf(" in %s (workspace %s)", dir, ws.ID)
+			}
+		}
+
+		// Delete the conflicted lock file
+		os.Remove(filepath.Join(root, file))
+
+		ran, installOut, err := regenerateLockFile(filepath.Join(root, dir), rule)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "  \033[33m⚠ Failed to regenerate %s%s: %s\033[0m\n", file, where, tailOutput(installOut, 2000))
+			// Restore the conflicted version so git knows it's still unresolved
+			checkoutCmd := exec.Command("git", "checkout", "--merge", "--", file)
+			checkoutCmd.Dir = root
+			checkoutCmd.Run()
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "  \033[2mAuto-resolved %s via %s%s\033[0m\n", file, ran, where)
+
+		// Stage the regenerated lock file
+		addCmd := exec.Command("git", "add", "--", file)
+		addCmd.Dir = root
+		addCmd.Run()
+	}
+}
+
+// regenerateLockFile runs the rule in dir, offline variant first. Returns
+// the command that succeeded (or last failed) and its output.
+func regenerateLockFile(dir string, rule lockFileRule) (string, string, error) {
+	if rule.Offline != "" {
+		c := exec.Command("sh", "-c", rule.Offline)
+		c.Dir = dir
+		if out, err := c.CombinedOutput(); err == nil {
+			return rule.Offline, string(out), nil
+		}
+	}
+	c := exec.Command("sh", "-c", rule.Command)
+	c.Dir = dir
+	out, err := c.CombinedOutput()
+	return rule.Command, string(out), err
+}
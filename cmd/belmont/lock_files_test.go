@@ -0,0 +1,98 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLockFileRuleFor_Precedence(t *testing.T) {
+	hooks := &worktreeHooks{LockFiles: map[string]lockFileRule{
+		"go.sum":             {Command: "custom tidy"},
+		"apps/legacy/go.sum": {Command: ""},
+		"deno.lock":          {Command: "deno cache main.ts"},
+	}}
+	if r, ok := lockFileRuleFor(hooks, "services/api/go.sum"); !ok || r.Command != "custom tidy" {
+		t.Errorf("basename override = %+v, %v", r, ok)
+	}
+	if _, ok := lockFileRuleFor(hooks, "apps/legacy/go.sum"); ok {
+		t.Error("empty command should disable regeneration for that path")
+	}
+	if isLockFile(hooks, "apps/legacy/go.sum") || !isLockFile(hooks, "services/api/go.sum") || !isLockFile(hooks, "deno.lock") || isLockFile(nil, "deno.lock") {
+		t.Error("isLockFile should follow configured rules before the built-ins")
+	}
+	off := &worktreeHooks{LockFiles: map[string]lockFileRule{"package-lock.json": {Command: ""}}}
+	if structuredFormatFor(off, "package-lock.json") == nil || structuredFormatFor(nil, "package-lock.json") != nil {
+		t.Error("a lock file the project turns off should be merged as its file type")
+	}
+	if r, ok := lockFileRuleFor(nil, "uv.lock"); !ok || r.Offline == "" {
+		t.Errorf("uv.lock built-in = %+v, %v", r, ok)
+	}
+}
+
+func TestLockManifests_RootLockCoversNestedWorkspaces(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "package.json", "{}")
+	workspaces := []workspaceInfo{{ID: "web", Path: "apps/web"}, {ID: "api", Path: "services/api"}}
+	rule := lockFileMap["pnpm-lock.yaml"]
+
+	beside, nested := lockManifests(root, ".", rule, workspaces)
+	if !equalStringSlices(beside, []string{"package.json"}) ||
+		!equalStringSlices(nested, []string{"apps/web/package.json", "services/api/package.json"}) {
+		t.Errorf("root: beside=%v nested=%v", beside, nested)
+	}
+	beside, nested = lockManifests(root, "services/api", lockFileMap["go.sum"], workspaces)
+	if len(beside) != 0 || len(nested) != 0 {
+		t.Errorf("workspace lock without go.mod: beside=%v nested=%v", beside, nested)
+	}
+}
+
+func TestAutoResolveLockFiles_RunsInLockDirOfflineFirst(t *testing.T) {
+	root := t.TempDir()
+	runGit(t, root, "init", "-q", "-b", "main")
+	runGit(t, root, "config", "user.email", "t@example.com")
+	runGit(t, root, "config", "user.name", "t")
+	writeFile(t, root, ".belmont/worktree.json", `{"lock_files": {"deps.lock": {
+		"command": "echo online > deps.lock",
+		"offline": "basename \"$PWD\" > deps.lock",
+		"manifests": ["deps.txt"]
+	}}}`)
+	writeFile(t, root, "services/api/deps.txt", "a\n")
+	writeFile(t, root, "services/api/deps.lock", "a 1\n")
+	writeFile(t, root, "tools/deps.txt", "x\n")
+	writeFile(t, root, "tools/deps.lock", "x 1\n")
+	runGit(t, root, "add", "-A")
+	runGit(t, root, "commit", "-q", "-m", "base")
+
+	runGit(t, root, "checkout", "-q", "-b", "side")
+	writeFile(t, root, "services/api/deps.lock", "a 2\n")
+	writeFile(t, root, "tools/deps.txt", "x\ny\n")
+	writeFile(t, root, "tools/deps.lock", "x 2\n")
+	runGit(t, root, "commit", "-q", "-am", "side")
+	runGit(t, root, "checkout", "-q", "main")
+	writeFile(t, root, "services/api/deps.lock", "a 3\n")
+	writeFile(t, root, "tools/deps.txt", "x\nz\n")
+	writeFile(t, root, "tools/deps.lock", "x 3\n")
+	runGit(t, root, "commit", "-q", "-am", "main")
+	merge := exec.Command("git", "merge", "--no-ff", "side", "-m", "merge")
+	merge.Dir = root
+	if err := merge.Run(); err == nil {
+		t.Fatal("expected a conflict")
+	}
+
+	autoResolveLockFiles(root)
+
+	data, err := os.ReadFile(filepath.Join(root, "services/api/deps.lock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(string(data)) != "api" {
+		t.Errorf("deps.lock = %q, want the offline command run in services/api", data)
+	}
+	// tools/deps.txt is still conflicted, so its lock is left for the agent.
+	if out := runGit(t, root, "diff", "--name-only", "--diff-filter=U"); out != "tools/deps.lock\ntools/deps.txt" {
+		t.Errorf("still conflicted:\n%s", out)
+	}
+}
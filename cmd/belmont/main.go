@@ -215,6 +215,8 @@ func actionAgent(t loopActionType) string {
 		return "verification"
 	case actionTriage:
 		return "verification" // triage reads verification output; share its tier
+	case actionReview:
+		return "code-review"
 	case actionReplan:
 		return "" // planning uses planningTier, handled separately
 	default:
@@ -273,6 +275,10 @@ type milestone struct {
 	Tasks     []task   // tasks in this milestone (from PROGRESS.md)
 	Deps      []string // e.g. ["M1", "M3"] — nil for no explicit deps
 	LiveFrom  string   `json:"live_from,omitempty"` // worktree path when state was read from an active worktree (buildStatus only)
+
+	// Workspaces are the monorepo workspace IDs the milestone affects
+	// (buildStatus only; see milestoneScope).
+	Workspaces []string `json:"workspaces,omitempty"`
 }
 
 // Milestone computed state helpers
@@ -352,6 +358,7 @@ type statusReport struct {
 	Features         []featureSummary
 	ArchivedFeatures []featureSummary `json:",omitempty"`
 	Monorepo         *monorepoReport  `json:",omitempty"`
+	Worktrees        []worktreeStatus `json:",omitempty"` // running worktrees' ports (from auto.json)
 }
 
 // monorepoReport summarizes detected monorepo workspaces for status output.
@@ -363,8 +370,9 @@ type monorepoReport struct {
 }
 
 type monorepoWorkspace struct {
-	ID   string `json:"id"`
-	Path string `json:"path"`
+	ID   string   `json:"id"`
+	Path string   `json:"path"`
+	Deps []string `json:"deps,omitempty"` // IDs of the workspaces this one depends on
 }
 
 type config struct {
@@ -386,6 +394,7 @@ const (
 	actionDebug              loopActionType = "DEBUG"
 	actionTriage             loopActionType = "TRIAGE"
 	actionFixAll             loopActionType = "FIX_ALL"
+	actionReview             loopActionType = "REVIEW"
 )
 
 var errFeaturePaused = fmt.Errorf("feature paused")
@@ -400,6 +409,13 @@ type loopAction struct {
 	MilestoneID     string
 	TriageDecision  string // "fix_and_reverify", "fix_and_proceed", "defer_and_proceed" — set after triage
 	ReverifyScope   string // "full" or "focused" — set by triage
+
+	// ReviewBase and ReviewHead bound the diff a REVIEW action reviews.
+	ReviewBase string
+	ReviewHead string
+
+	// Custom is set for project-defined actions from .belmont/actions.json.
+	Custom *customLoopAction
 }
 
 type executionResult struct {
@@ -445,6 +461,7 @@ type milestoneLoopState struct {
 	Verified        bool
 	VerifyFailed    int
 	VerifySucceeded int // how many times verification passed for this milestone
+	ReviewSucceeded int // how many times REVIEW passed for this milestone
 	WorkType        workType
 	FilesChanged    int
 	FwlupFixRounds  int // how many triage+fix cycles have run for this milestone
@@ -468,6 +485,9 @@ type loopConfig struct {
 	MaxIterations    int
 	MaxFailures      int
 	MaxParallel      int
+	Scheduler        string // "waves" (default) or "critical-path"; only consulted when MaxParallel > 1
+	MergeAsYouGo     bool   // merge each parallel unit on completion and rebase in-flight siblings at action boundaries
+	MainRoot         string // main repo root when a worktree loop should rebase onto it between actions (--merge-as-you-go)
 	DryRun           bool
 	Port             int               // assigned port for worktree isolation (0 = not in worktree)
 	WorktreeEnv      map[string]string // extra env vars from worktree.json
@@ -477,6 +497,23 @@ type loopConfig struct {
 	Workspaces       []workspaceInfo   // monorepo workspaces (nil for single-package projects)
 	PrimaryWorkspace string            // primary workspace ID (empty for single-package)
 	MonorepoType     monorepoType      // detected/declared monorepo type (empty for single-package)
+
+	// Review is the saved `recover --merge --dry-run` review; files reviewed
+	// against the same two sides apply without another AI pass.
+	Review *reconciliationDecisionSet
+
+	// Audit collects the history record of the merge being reconciled.
+	Audit *reconciliationAudit
+
+	// Container runs the worktree's agent and triage calls inside the
+	// project's container (nil = on the host).
+	Container *worktreeContainer
+
+	// CustomActions are the project's loop actions from .belmont/actions.json.
+	CustomActions []customLoopAction
+
+	// CodeReview is the "review" block of .belmont/actions.json.
+	CodeReview loopReviewConfig
 }
 
 // worktreeHooks defines lifecycle hooks for worktree isolation.
@@ -492,6 +529,20 @@ type worktreeHooks struct {
 	Env              map[string]string            `json:"env"`
 	PrimaryWorkspace string                       `json:"primary_workspace,omitempty"`
 	Workspaces       map[string]workspaceOverride `json:"workspaces,omitempty"`
+	MergeGate        *mergeGateConfig             `json:"merge_gate,omitempty"`
+	LockFiles        map[string]lockFileRule      `json:"lock_files,omitempty"` // keyed by basename or repo-relative path
+	Pool             *worktreePoolConfig          `json:"pool,omitempty"`
+	Cache            []string                     `json:"cache,omitempty"` // shared cache strategies (see worktreeCacheStrategies)
+	Container        *worktreeContainerConfig     `json:"container,omitempty"`
+
+	// Services are per-worktree databases, queues and temp dirs (see services.go).
+	Services map[string]worktreeServiceConfig `json:"services,omitempty"`
+
+	// Ports are named ports allocated per worktree on top of BELMONT_PORT (see ports.go).
+	Ports []string `json:"ports,omitempty"`
+
+	// Merge switches finished branches from a local merge to a pull request (see pull_request.go).
+	Merge *mergeModeConfig `json:"merge,omitempty"`
 }
 
 // workspaceOverride is a user-supplied workspace declaration in worktree.json.
@@ -501,6 +552,9 @@ type worktreeHooks struct {
 type workspaceOverride struct {
 	Path     string   `json:"path"`
 	EnvFiles []string `json:"env_files,omitempty"`
+	Gate     []string `json:"gate,omitempty"`  // merge gate commands for this workspace (overrides merge_gate.workspace_commands)
+	Ports    []string `json:"ports,omitempty"` // named ports for this workspace, exported as BELMONT_PORT_<WORKSPACE>_<NAME>
+	Setup    []string `json:"setup,omitempty"` // setup commands run inside this workspace when a worktree's milestone affects it
 }
 
 // loadWorktreeHooks reads .belmont/worktree.json from the project root.
@@ -551,7 +605,13 @@ func allocatePort() (int, error) {
 // BELMONT_WORKTREE, BELMONT_MONOREPO* (when applicable), and any user-defined
 // env vars from worktree.json.
 func buildWorktreeEnv(port int, extraEnv map[string]string, workspaces []workspaceInfo, primary string, mType monorepoType) []string {
-	env := os.Environ()
+	return append(os.Environ(), worktreeEnvVars(port, extraEnv, workspaces, primary, mType)...)
+}
+
+// worktreeEnvVars returns only the worktree-specific additions to the host
+// environment, as KEY=VALUE pairs.
+func worktreeEnvVars(port int, extraEnv map[string]string, workspaces []workspaceInfo, primary string, mType monorepoType) []string {
+	var env []string
 	if port != 0 {
 		baseURL := fmt.Sprintf("http://localhost:%d", port)
 		env = append(env,
@@ -579,13 +639,16 @@ func buildWorktreeEnv(port int, extraEnv map[string]string, workspaces []workspa
 	return env
 }
 
-// runWorktreeHookCommands executes a list of shell commands in the worktree directory.
-func runWorktreeHookCommands(commands []string, wtPath string, port int, extraEnv map[string]string, workspaces []workspaceInfo, primary string, mType monorepoType) error {
-	env := buildWorktreeEnv(port, extraEnv, workspaces, primary, mType)
+// runWorktreeHookCommands executes a list of shell commands in the worktree
+// directory, inside ctr when the project runs worktrees in a container.
+func runWorktreeHookCommands(commands []string, wtPath string, port int, extraEnv map[string]string, workspaces []workspaceInfo, primary string, mType monorepoType, ctr *worktreeContainer) error {
+	vars := worktreeEnvVars(port, extraEnv, workspaces, primary, mType)
+	env := append(os.Environ(), vars...)
 	for _, cmdStr := range commands {
 		cmd := exec.Command("sh", "-c", cmdStr)
 		cmd.Dir = wtPath
 		cmd.Env = env
+		ctr.wrap(cmd, port, vars)
 		cmd.Stdout = os.Stderr
 		cmd.Stderr = os.Stderr
 		if err := cmd.Run(); err != nil {
@@ -731,6 +794,13 @@ const (
 	monorepoGo        monorepoType = "go"
 	monorepoUv        monorepoType = "uv"
 	monorepoPoetry    monorepoType = "poetry"
+
+	// Build-system monorepos (build_workspaces.go).
+	monorepoGradle monorepoType = "gradle"
+	monorepoMaven  monorepoType = "maven"
+	monorepoBazel  monorepoType = "bazel"
+	monorepoDotnet monorepoType = "dotnet"
+	monorepoMix    monorepoType = "mix"
 )
 
 // envSignals indicates whether a workspace consumes env at install/build time.
@@ -740,10 +810,14 @@ type envSignals struct {
 	DotenvDep     bool // deps include dotenv / dotenv-cli / drizzle-kit / tsx / vite-node
 	BuildRs       bool // Rust workspace has build.rs
 	PythonScripts bool // pyproject has [project.scripts] or [tool.poetry.scripts]
+
+	// RuntimeConfig: a JVM/.NET/Mix workspace reads env at build or boot
+	// (Spring Boot, Flyway/Liquibase, user secrets, config/runtime.exs, ...).
+	RuntimeConfig bool
 }
 
 func (s envSignals) consumesEnv() bool {
-	return s.Postinstall || s.PrismaDep || s.DotenvDep || s.BuildRs || s.PythonScripts
+	return s.Postinstall || s.PrismaDep || s.DotenvDep || s.BuildRs || s.PythonScripts || s.RuntimeConfig
 }
 
 // workspaceInfo describes a discovered workspace.
@@ -753,6 +827,10 @@ type workspaceInfo struct {
 	Manifest string      // absolute path to manifest file (may be empty for synthetic entries)
 	Signals  envSignals
 	HasDev   bool // package.json has scripts.dev / Cargo bin target / etc. (used for primary selection)
+
+	// Deps are the IDs of other workspaces this one depends on, set by
+	// resolveWorkspaces (see workspace_graph.go).
+	Deps []string
 }
 
 // detectWorkspaces probes root for monorepo signal files and returns the
@@ -860,6 +938,24 @@ func detectWorkspaces(root string) ([]workspaceInfo, monorepoType) {
 		}
 		ws = append(ws, pWs...)
 	}
+	// Build-system monorepos: Gradle, Maven, Bazel, .NET, Mix umbrella.
+	for _, b := range []struct {
+		t     monorepoType
+		parse func(string) ([]workspaceInfo, bool)
+	}{
+		{monorepoGradle, parseGradleWorkspaces},
+		{monorepoMaven, parseMavenWorkspaces},
+		{monorepoBazel, parseBazelWorkspaces},
+		{monorepoDotnet, parseDotnetWorkspaces},
+		{monorepoMix, parseMixUmbrella},
+	} {
+		if bWs, ok := b.parse(root); ok && len(bWs) > 0 {
+			if mType == monorepoNone {
+				mType = b.t
+			}
+			ws = append(ws, bWs...)
+		}
+	}
 
 	if len(ws) == 0 {
 		return nil, monorepoNone
@@ -1503,11 +1599,14 @@ func resolveWorkspaces(root string, hooks *worktreeHooks) ([]workspaceInfo, stri
 					if fileExists(filepath.Join(root, info.Path, "build.rs")) {
 						info.Signals.BuildRs = true
 					}
+				default:
+					info.Signals, info.HasDev = buildManifestSignals(info.Manifest)
 				}
 			}
 			ws = append(ws, info)
 		}
 		sort.Slice(ws, func(i, j int) bool { return ws[i].ID < ws[j].ID })
+		linkWorkspaceDeps(root, ws)
 		_, mType := detectWorkspaces(root)
 		return ws, pickPrimary(ws, hooks.PrimaryWorkspace), mType
 	}
@@ -1515,6 +1614,7 @@ func resolveWorkspaces(root string, hooks *worktreeHooks) ([]workspaceInfo, stri
 	if len(ws) == 0 {
 		return nil, "", monorepoNone
 	}
+	linkWorkspaceDeps(root, ws)
 	primary := ""
 	if hooks != nil {
 		primary = hooks.PrimaryWorkspace
@@ -1523,15 +1623,17 @@ func resolveWorkspaces(root string, hooks *worktreeHooks) ([]workspaceInfo, stri
 }
 
 // guessManifest returns the absolute path to the workspace's manifest file
-// (package.json / Cargo.toml / pyproject.toml / go.mod), or "" if none found.
+// (package.json / Cargo.toml / pyproject.toml / go.mod, then the build-system
+// manifests), or "" if none found.
 func guessManifest(absDir string) string {
-	for _, name := range []string{"package.json", "Cargo.toml", "pyproject.toml", "go.mod"} {
+	for _, name := range []string{"package.json", "Cargo.toml", "pyproject.toml", "go.mod",
+		"build.gradle.kts", "build.gradle", "pom.xml", "mix.exs", "BUILD.bazel", "BUILD"} {
 		p := filepath.Join(absDir, name)
 		if fileExists(p) {
 			return p
 		}
 	}
-	return ""
+	return firstMatch(absDir, "*.csproj", "*.fsproj", "*.vbproj")
 }
 
 // pickPrimary chooses the primary workspace ID. Order: explicit override
@@ -1563,13 +1665,14 @@ func monorepoEnvVars(workspaces []workspaceInfo, primary string, mType monorepoT
 		return nil
 	}
 	type wsEntry struct {
-		ID   string `json:"id"`
-		Path string `json:"path"`
+		ID   string   `json:"id"`
+		Path string   `json:"path"`
+		Deps []string `json:"deps,omitempty"`
 	}
 	entries := make([]wsEntry, 0, len(workspaces))
 	primaryPath := ""
 	for _, w := range workspaces {
-		entries = append(entries, wsEntry{ID: w.ID, Path: w.Path})
+		entries = append(entries, wsEntry{ID: w.ID, Path: w.Path, Deps: w.Deps})
 		if w.ID == primary {
 			primaryPath = w.Path
 		}
@@ -1628,6 +1731,8 @@ func main() {
 		os.Exit(1)
 	}
 
+	must(enforceProjectPin(os.Args[1], os.Args[2:]))
+
 	switch os.Args[1] {
 	case "status":
 		must(runStatus(os.Args[2:]))
@@ -1643,8 +1748,18 @@ func main() {
 		must(runSteerCmd(os.Args[2:]))
 	case "validate":
 		must(runValidateCmd(os.Args[2:]))
+	case "doctor":
+		must(runDoctorCmd(os.Args[2:]))
+	case "overrides":
+		must(runOverridesCmd(os.Args[2:]))
+	case "milestone":
+		must(runMilestoneCmd(os.Args[2:]))
+	case "plan":
+		must(runPlanCmd(os.Args[2:]))
 	case "reverify":
 		must(runReverifyCmd(os.Args[2:]))
+	case "pool":
+		must(runPoolCmd(os.Args[2:]))
 	case "sync":
 		must(runSyncCmd(os.Args[2:]))
 	case "version", "--version", "-v":
@@ -1664,15 +1779,20 @@ func printUsage(w io.Writer) {
 	fmt.Fprintln(w, "")
 	fmt.Fprintln(w, "Usage:")
 	fmt.Fprintln(w, "  belmont install [--source PATH] [--project PATH] [--tools all|none|claude,codex,...]")
-	fmt.Fprintln(w, "  belmont update [--check] [--force] [--no-commit]")
+	fmt.Fprintln(w, "  belmont update [--check] [--force] [--no-commit] [--version vX.Y.Z] [--from-file PATH [--checksums PATH]] [--source URL] [--no-verify]")
 	fmt.Fprintln(w, "  belmont status [--root PATH] [--feature SLUG] [--format text|json] [--color auto|always|never]")
-	fmt.Fprintln(w, "  belmont auto --feature SLUG [--from M1] [--to M5] [--tool claude|codex|gemini|copilot|cursor|pi] [--policy autonomous|milestone|every_action] [--max-iterations N] [--max-parallel N] [--allow-dirty] [--root PATH]")
+	fmt.Fprintln(w, "  belmont auto --feature SLUG [--from M1] [--to M5] [--tool claude|codex|gemini|copilot|cursor|pi] [--policy autonomous|milestone|every_action] [--max-iterations N] [--max-parallel N] [--scheduler critical-path|waves] [--merge-as-you-go] [--allow-dirty] [--root PATH]")
 	fmt.Fprintln(w, "    (alias: belmont loop)")
 	fmt.Fprintln(w, "  belmont reverify [--feature SLUG] [--from M1] [--to M5] [--root PATH] [--format text|json]")
 	fmt.Fprintln(w, "  belmont sync [--root PATH]")
-	fmt.Fprintln(w, "  belmont recover [--list] [--merge SLUG] [--clean SLUG] [--clean-all] [--tool claude|codex|gemini|copilot|cursor|pi] [--root PATH] [--format text|json]")
+	fmt.Fprintln(w, "  belmont pool [list|warm|clean] [--size N] [--root PATH] [--format text|json]")
+	fmt.Fprintln(w, "  belmont recover [--list] [--merge SLUG [--dry-run]] [--clean SLUG] [--clean-all] [--history|--hotspots] [--tool claude|codex|gemini|copilot|cursor|pi] [--root PATH] [--format text|json]")
 	fmt.Fprintln(w, "  belmont steer [--feature SLUG] [--milestone M5] [--message \"text\" | --file PATH | -] [--root PATH]")
 	fmt.Fprintln(w, "  belmont validate [--feature SLUG] [--root PATH] [--format text|json]")
+	fmt.Fprintln(w, "  belmont doctor [--fix] [--root PATH] [--format text|json]")
+	fmt.Fprintln(w, "  belmont overrides [list | diff PATH | accept PATH] [--root PATH]")
+	fmt.Fprintln(w, "  belmont plan [--feature SLUG] [--root PATH] [--format text|json|dot|mermaid]")
+	fmt.Fprintln(w, "  belmont milestone add|split|move|deps [--feature SLUG] [--milestone M3] [--name NAME] [--after M2 | --before M4] [--at TASK-ID] [--depends M1,M2] [--add M1] [--remove M1] [--clear] [--dry-run] [--yes] [--root PATH]")
 	fmt.Fprintln(w, "  belmont version")
 }
 
@@ -1768,10 +1888,12 @@ func buildStatus(root string, maxName int, feature string) (statusReport, error)
 
 	// Detect monorepo workspaces. Honor explicit overrides in worktree.json
 	// over auto-detection. Returns nil for single-package projects.
+	var workspaces []workspaceInfo
 	if ws, primary, mType := resolveWorkspaces(root, loadWorktreeHooks(root)); mType != monorepoNone && len(ws) > 0 {
+		workspaces = ws
 		entries := make([]monorepoWorkspace, 0, len(ws))
 		for _, w := range ws {
-			entries = append(entries, monorepoWorkspace{ID: w.ID, Path: w.Path})
+			entries = append(entries, monorepoWorkspace{ID: w.ID, Path: w.Path, Deps: w.Deps})
 		}
 		report.Monorepo = &monorepoReport{
 			Type:       string(mType),
@@ -1780,6 +1902,8 @@ func buildStatus(root string, maxName int, feature string) (statusReport, error)
 		}
 	}
 
+	report.Worktrees = activeWorktreeStatuses(root)
+
 	// Check for PR_FAQ
 	prfaqPath := filepath.Join(root, ".belmont", "PR_FAQ.md")
 	report.PRFAQReady = fileHasRealContent(prfaqPath)
@@ -1834,6 +1958,19 @@ func buildStatus(root string, maxName int, feature string) (statusReport, error)
 			report.Milestones = overlayLiveMilestones(report.Milestones, perMilestoneLive)
 		}
 
+		// Affected workspaces per milestone: task tags, the files a live
+		// worktree has changed, then the PRD's Target Workspace(s).
+		if len(workspaces) > 0 {
+			targets := prdTargetWorkspaces(string(prdContent), workspaces)
+			for i, m := range report.Milestones {
+				var changed []string
+				if m.LiveFrom != "" {
+					changed = worktreeChangedFiles(root, liveWorktreeRoot(m.LiveFrom))
+				}
+				report.Milestones[i].Workspaces = milestoneScope(m, targets, workspaces, changed)
+			}
+		}
+
 		report.Tasks = flattenTasks(report.Milestones, maxName)
 
 		report.TaskCounts["total"] = len(report.Tasks)
@@ -2803,6 +2940,7 @@ func renderStatus(report statusReport, color bool, showArchived bool) string {
 		}
 		sb.WriteString(fmt.Sprintf("Monorepo: %s (%d workspaces%s)\n\n", report.Monorepo.Type, len(report.Monorepo.Workspaces), primaryLabel))
 	}
+	renderWorktreePorts(&sb, report.Worktrees)
 	sb.WriteString(fmt.Sprintf("Feature: %s\n\n", report.Feature))
 	sb.WriteString(fmt.Sprintf("Tech Plan: %s\n\n", techPlan))
 	sb.WriteString(fmt.Sprintf("Status: %s\n\n", colorStatus(report.OverallStatus, color)))
@@ -2824,6 +2962,9 @@ func renderStatus(report statusReport, color bool, showArchived bool) string {
 		for _, m := range report.Milestones {
 			icon := milestoneStatusIcon(m, color)
 			line := fmt.Sprintf("  %s %s: %s", icon, m.ID, m.Name)
+			if len(m.Workspaces) > 0 {
+				line += fmt.Sprintf(" [%s]", strings.Join(m.Workspaces, ", "))
+			}
 			if m.LiveFrom != "" {
 				anyLive = true
 				if color {
@@ -2996,6 +3137,7 @@ func renderFeatureListing(report statusReport, color bool, showArchived bool) st
 		}
 		sb.WriteString(fmt.Sprintf("Monorepo: %s (%d workspaces%s)\n\n", report.Monorepo.Type, len(report.Monorepo.Workspaces), primaryLabel))
 	}
+	renderWorktreePorts(&sb, report.Worktrees)
 	sb.WriteString(fmt.Sprintf("Product: %s\n\n", report.Feature))
 	sb.WriteString(fmt.Sprintf("PR/FAQ: %s\n", prfaq))
 	sb.WriteString(fmt.Sprintf("Master Tech Plan: %s\n\n", techPlan))
@@ -3145,10 +3287,14 @@ func runInstall(args []string) error {
 	var project string
 	var toolsFlag string
 	var noPrompt bool
+	var noPin, allowDowngrade, applyOverridesOnly bool
 	fsFlags.StringVar(&source, "source", "", "belmont source directory")
 	fsFlags.StringVar(&project, "project", ".", "project directory")
 	fsFlags.StringVar(&toolsFlag, "tools", "", "all|none|comma list")
 	fsFlags.BoolVar(&noPrompt, "no-prompt", false, "disable interactive prompts")
+	fsFlags.BoolVar(&noPin, "no-pin", false, "do not check or update the project version pin")
+	fsFlags.BoolVar(&applyOverridesOnly, "apply-overrides-only", false, "apply .belmont/overrides without writing override bases, merges or conflict files back — for worktree installs")
+	fsFlags.BoolVar(&allowDowngrade, "allow-downgrade", false, "install even if the project is pinned to a newer belmont")
 	if err := fsFlags.Parse(args); err != nil {
 		return fmt.Errorf("install: %w", err)
 	}
@@ -3158,6 +3304,11 @@ func runInstall(args []string) error {
 		return err
 	}
 
+	// Refuse to overwrite skills generated by a newer belmont with older ones.
+	if pin, _ := readProjectPin(projectRoot); pin != nil && !noPin && !allowDowngrade && Version != "dev" && isNewer("v"+pin.Version, "v"+Version) {
+		return fmt.Errorf("install: this project is pinned to belmont v%s, newer than this binary (v%s) — run `belmont update --pinned`, or pass --allow-downgrade", pin.Version, Version)
+	}
+
 	// Determine mode: embedded (release binary) vs source (developer)
 	useEmbedded := (source == "" && os.Getenv("BELMONT_SOURCE") == "") && hasEmbeddedFiles
 
@@ -3225,6 +3376,10 @@ func runInstall(args []string) error {
 		fmt.Println("")
 	}
 
+	if err := applyProjectOverrides(projectRoot, !applyOverridesOnly); err != nil {
+		return fmt.Errorf("install: %w", err)
+	}
+
 	// Phase 2: AGENTS.md / GEMINI.md routing sections are no longer written —
 	// every supported CLI auto-discovers `.agents/skills/<name>/SKILL.md` per
 	// agentskills.io. Legacy install dirs from earlier Belmont versions are
@@ -3250,6 +3405,18 @@ func runInstall(args []string) error {
 		return err
 	}
 
+	if !noPin && Version != "dev" {
+		prev, err := writeProjectPin(projectRoot, Version)
+		if err != nil {
+			return err
+		}
+		if prev != "" && prev != Version {
+			fmt.Printf("  ~ .belmont/%s (v%s → v%s)\n", projectPinFile, prev, Version)
+		} else if prev == "" {
+			fmt.Printf("  + .belmont/%s (v%s)\n", projectPinFile, Version)
+		}
+	}
+
 	fmt.Println("")
 	fmt.Println("Belmont installed!")
 	fmt.Println("")
@@ -4633,9 +4800,18 @@ func runUpdate(args []string) error {
 	var check bool
 	var force bool
 	var noCommit bool
+	var noVerify bool
+	var pinned bool
+	var version, fromFile, checksums, source string
 	fsFlags.BoolVar(&check, "check", false, "check for updates without installing")
 	fsFlags.BoolVar(&force, "force", false, "force update even if same version")
 	fsFlags.BoolVar(&noCommit, "no-commit", false, "do not auto-commit Belmont-managed files after install")
+	fsFlags.StringVar(&version, "version", "", "install this release tag (pin or downgrade) instead of the latest")
+	fsFlags.StringVar(&fromFile, "from-file", "", "install from a downloaded binary or .tar.gz/.zip archive (offline)")
+	fsFlags.StringVar(&checksums, "checksums", "", "checksums file for --from-file (default: checksums.txt next to it)")
+	fsFlags.StringVar(&source, "source", "", "release API base URL (default: $BELMONT_RELEASE_URL or GitHub)")
+	fsFlags.BoolVar(&noVerify, "no-verify", false, "skip checksum verification (not recommended)")
+	fsFlags.BoolVar(&pinned, "pinned", false, "install the version this project is pinned to (.belmont/version.json)")
 	if err := fsFlags.Parse(args); err != nil {
 		return fmt.Errorf("update: %w", err)
 	}
@@ -4643,15 +4819,42 @@ func runUpdate(args []string) error {
 	if Version == "dev" {
 		return errors.New("update: development build detected — use git pull && scripts/build.sh to update")
 	}
+	if fromFile != "" && (check || version != "" || source != "" || pinned) {
+		return errors.New("update: --from-file cannot be combined with --check, --version, --pinned or --source")
+	}
+	if pinned {
+		if version != "" {
+			return errors.New("update: --pinned and --version are mutually exclusive")
+		}
+		pin, err := readProjectPin(".")
+		if err != nil {
+			return fmt.Errorf("update: %w", err)
+		}
+		if pin == nil || pin.Version == "" {
+			return fmt.Errorf("update: no .belmont/%s in this directory — nothing to pin to", projectPinFile)
+		}
+		version = pin.Version
+	}
+
+	if fromFile != "" {
+		return updateFromFile(fromFile, checksums, force, noVerify, noCommit)
+	}
 
-	release, err := fetchLatestRelease()
+	tag := normalizeReleaseTag(version)
+	release, err := fetchRelease(releaseSource(source), tag)
 	if err != nil {
 		return fmt.Errorf("update: %w", err)
 	}
 
-	if !force && !isNewer(release.TagName, "v"+Version) {
-		fmt.Printf("Already up to date (v%s)\n", Version)
-		return nil
+	if !force {
+		if tag == "" && !isNewer(release.TagName, "v"+Version) {
+			fmt.Printf("Already up to date (v%s)\n", Version)
+			return nil
+		}
+		if tag != "" && release.TagName == "v"+Version {
+			fmt.Printf("Already at %s\n", release.TagName)
+			return nil
+		}
 	}
 
 	if check {
@@ -4663,42 +4866,110 @@ func runUpdate(args []string) error {
 		return nil
 	}
 
-	assetName := fmt.Sprintf("belmont-%s-%s", runtime.GOOS, runtime.GOARCH)
-	if runtime.GOOS == "windows" {
-		assetName += ".exe"
+	assetName := releaseAssetName()
+	exePath, err := updateTargetPath()
+	if err != nil {
+		return err
 	}
 
-	var downloadURL string
-	for _, asset := range release.Assets {
-		if asset.Name == assetName {
-			downloadURL = asset.BrowserDownloadURL
-			break
+	fmt.Printf("Downloading %s...\n", assetName)
+	tmpPath := exePath + ".tmp"
+	if err := downloadReleaseBinary(release, assetName, tmpPath, noVerify); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("update: %w", err)
+	}
+	if noVerify {
+		fmt.Printf("%s⚠ Checksum not verified (--no-verify)%s\n", ansiYellow, ansiReset)
+	} else {
+		fmt.Printf("%s✓%s Verified against %s\n", ansiGreen, ansiReset, checksumsAssetName)
+	}
+	if err := replaceExecutable(tmpPath, exePath); err != nil {
+		return err
+	}
+
+	fmt.Printf("\nUpdated: v%s → %s\n", Version, release.TagName)
+	if release.Body != "" {
+		fmt.Println("\nRelease notes:")
+		fmt.Println(release.Body)
+	}
+	finishUpdate(exePath, release.TagName, noCommit)
+	return nil
+}
+
+// updateFromFile installs a hand-downloaded binary or archive after
+// verifying it against a local checksums file. Nothing touches the network.
+func updateFromFile(path, checksums string, force, noVerify, noCommit bool) error {
+	if !fileExists(path) {
+		return fmt.Errorf("update: %s not found", path)
+	}
+	if !noVerify {
+		if checksums == "" {
+			checksums = filepath.Join(filepath.Dir(path), checksumsAssetName)
+		}
+		if !fileExists(checksums) {
+			return fmt.Errorf("update: no %s to verify %s against — copy the release's %s next to it, pass --checksums, or use --no-verify", filepath.Base(checksums), filepath.Base(path), checksumsAssetName)
 		}
 	}
-	if downloadURL == "" {
-		return fmt.Errorf("update: no binary found for %s/%s in release %s", runtime.GOOS, runtime.GOARCH, release.TagName)
+
+	exePath, err := updateTargetPath()
+	if err != nil {
+		return err
+	}
+	tmpPath := exePath + ".tmp"
+	if err := extractUpdateBinary(path, releaseAssetName(), tmpPath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("update: %w", err)
+	}
+	if noVerify {
+		fmt.Printf("%s⚠ Checksum not verified (--no-verify)%s\n", ansiYellow, ansiReset)
+	} else {
+		name, err := verifyUpdateFile(path, tmpPath, releaseAssetName(), checksums)
+		if err != nil {
+			os.Remove(tmpPath)
+			return fmt.Errorf("update: %w", err)
+		}
+		fmt.Printf("%s✓%s Verified %s (%s in %s)\n", ansiGreen, ansiReset, filepath.Base(path), name, filepath.Base(checksums))
 	}
+	if err := os.Chmod(tmpPath, 0o755); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	tag := binaryVersionTag(tmpPath)
+	if tag == "" {
+		os.Remove(tmpPath)
+		return fmt.Errorf("update: %s does not contain a belmont binary for %s/%s", filepath.Base(path), runtime.GOOS, runtime.GOARCH)
+	}
+	if !force && tag == "v"+Version {
+		os.Remove(tmpPath)
+		fmt.Printf("Already at %s\n", tag)
+		return nil
+	}
+	if err := replaceExecutable(tmpPath, exePath); err != nil {
+		return err
+	}
+	fmt.Printf("\nUpdated: v%s → %s\n", Version, tag)
+	finishUpdate(exePath, tag, noCommit)
+	return nil
+}
 
+// updateTargetPath resolves the running binary and checks it can be replaced.
+func updateTargetPath() (string, error) {
 	exePath, err := os.Executable()
 	if err != nil {
-		return fmt.Errorf("update: %w", err)
+		return "", fmt.Errorf("update: %w", err)
 	}
 	exePath, err = filepath.EvalSymlinks(exePath)
 	if err != nil {
-		return fmt.Errorf("update: %w", err)
+		return "", fmt.Errorf("update: %w", err)
 	}
-
 	if err := checkWriteAccess(filepath.Dir(exePath)); err != nil {
-		return fmt.Errorf("update: cannot write to %s — try running with sudo or reinstall to ~/.local/bin", filepath.Dir(exePath))
-	}
-
-	fmt.Printf("Downloading %s...\n", assetName)
-	tmpPath := exePath + ".tmp"
-	if err := downloadFile(downloadURL, tmpPath); err != nil {
-		os.Remove(tmpPath)
-		return fmt.Errorf("update: download failed: %w", err)
+		return "", fmt.Errorf("update: cannot write to %s — try running with sudo or reinstall to ~/.local/bin", filepath.Dir(exePath))
 	}
+	return exePath, nil
+}
 
+// replaceExecutable moves the downloaded tmpPath over exePath.
+func replaceExecutable(tmpPath, exePath string) error {
 	if err := os.Chmod(tmpPath, 0o755); err != nil {
 		os.Remove(tmpPath)
 		return err
@@ -4717,34 +4988,35 @@ func runUpdate(args []string) error {
 		os.Remove(tmpPath)
 		return fmt.Errorf("update: %w", err)
 	}
+	return nil
+}
 
-	fmt.Printf("\nUpdated: v%s → %s\n", Version, release.TagName)
-	if release.Body != "" {
-		fmt.Println("\nRelease notes:")
-		fmt.Println(release.Body)
-	}
-
+// finishUpdate re-installs skills and agents with the new binary when run
+// inside a project, then commits the Belmont-managed files.
+func finishUpdate(exePath, tag string, noCommit bool) {
 	// Auto-install if .belmont/ exists in cwd
 	if dirExists(filepath.Join(".", ".belmont")) {
+		installArgs := []string{"install", "--no-prompt", "--tools", "all", "--allow-downgrade"}
+		if !offerPinSync(".", tag) {
+			installArgs = append(installArgs, "--no-pin")
+		}
 		fmt.Println("\nRe-installing skills and agents...")
-		cmd := exec.Command(exePath, "install", "--no-prompt", "--tools", "all")
+		cmd := exec.Command(exePath, installArgs...)
 		cmd.Stdout = os.Stdout
 		cmd.Stderr = os.Stderr
 		if err := cmd.Run(); err != nil {
 			fmt.Fprintf(os.Stderr, "Auto-install failed: %v\nRun 'belmont install' manually.\n", err)
 		} else if noCommit {
 			fmt.Println("\nSkipping auto-commit (--no-commit).")
-			fmt.Println("To commit manually: git add .agents .claude/agents/belmont .claude/commands/belmont .cursor/rules/belmont .windsurf/rules/belmont AGENTS.md GEMINI.md && git commit -m \"Update Belmont to " + release.TagName + "\"")
+			fmt.Println("To commit manually: git add .agents .belmont/version.json .claude/agents/belmont .claude/commands/belmont .cursor/rules/belmont .windsurf/rules/belmont AGENTS.md GEMINI.md && git commit -m \"Update Belmont to " + tag + "\"")
 		} else {
-			if err := commitBelmontUpdate(".", release.TagName); err != nil {
+			if err := commitBelmontUpdate(".", tag); err != nil {
 				fmt.Fprintln(os.Stderr, err.Error())
 			}
 		}
 	} else {
 		fmt.Println("\nTo update skills in a project: cd ~/your-project && belmont install")
 	}
-
-	return nil
 }
 
 // commitBelmontUpdate stages and commits only Belmont-managed files after a
@@ -4846,46 +5118,12 @@ func commitBelmontUpdate(root, version string) error {
 	return nil
 }
 
-func fetchLatestRelease() (*githubRelease, error) {
-	url := "https://api.github.com/repos/blake-simpson/belmont/releases/latest"
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("Accept", "application/vnd.github+json")
-	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
-		req.Header.Set("Authorization", "token "+token)
-	}
-
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to reach GitHub (are you offline?): %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode == 403 || resp.StatusCode == 429 {
-		return nil, fmt.Errorf("GitHub API rate limited — set GITHUB_TOKEN env var to authenticate")
-	}
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("GitHub API returned %d", resp.StatusCode)
-	}
-
-	var release githubRelease
-	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
-		return nil, err
-	}
-	return &release, nil
-}
-
 func downloadFile(url, dest string) error {
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
 		return err
 	}
-	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
-		req.Header.Set("Authorization", "token "+token)
-	}
+	setReleaseAuth(req)
 
 	client := &http.Client{Timeout: 5 * time.Minute}
 	resp, err := client.Do(req)
@@ -4970,6 +5208,8 @@ func runAutoCmd(args []string) error {
 	fs.IntVar(&cfg.MaxIterations, "max-iterations", 50, "maximum loop iterations")
 	fs.IntVar(&cfg.MaxFailures, "max-failures", 3, "consecutive failures before stopping")
 	fs.IntVar(&cfg.MaxParallel, "max-parallel", 5, "max concurrent goroutines for parallel execution")
+	fs.StringVar(&cfg.Scheduler, "scheduler", schedulerWaves, "parallel scheduler (waves|critical-path)")
+	fs.BoolVar(&cfg.MergeAsYouGo, "merge-as-you-go", false, "merge each parallel unit as soon as it completes and rebase in-flight siblings onto it")
 	fs.BoolVar(&cfg.DryRun, "dry-run", false, "show execution plan without running")
 	fs.BoolVar(&allowDirty, "allow-dirty", false, "skip the clean-working-tree check (not recommended — risks merge failures)")
 	fs.StringVar(&cfg.Root, "root", ".", "project root")
@@ -4999,6 +5239,9 @@ func runAutoCmd(args []string) error {
 	default:
 		return fmt.Errorf("auto: invalid --policy %q (use autonomous, milestone, or every_action)", policyStr)
 	}
+	if err := validateSchedulerName(cfg.Scheduler); err != nil {
+		return fmt.Errorf("auto: %w", err)
+	}
 
 	absRoot, err := filepath.Abs(cfg.Root)
 	if err != nil {
@@ -5415,9 +5658,26 @@ func runAutoMultiFeature(cfg loopConfig, slugs []string) error {
 	for _, f := range allFeatures {
 		bySlug[f.Slug] = f
 	}
+	// Features whose pull request hasn't landed (merge mode "pr"), opened by
+	// an earlier run or this one, plus their dependents. Held, not failed:
+	// dependents skip until the code is in main. The state of a feature in
+	// review is only synced once it lands, so it is left out of the run
+	// rather than started again. A dry run reads the records unchecked.
+	awaitingSlugs := make(map[string]bool)
+	var waiting []string
+	prs := loadPullRequests(cfg.Root)
+	if !cfg.DryRun {
+		prs = refreshPullRequests(cfg.Root, "")
+	}
+	for _, rec := range prs {
+		if rec.Milestone == "" {
+			awaitingSlugs[rec.Feature] = true
+		}
+	}
+
 	var features []featureSummary
 	for _, slug := range slugs {
-		if f, ok := bySlug[slug]; ok {
+		if f, ok := bySlug[slug]; ok && !awaitingSlugs[slug] {
 			features = append(features, f)
 		}
 	}
@@ -5465,7 +5725,7 @@ func runAutoMultiFeature(cfg loopConfig, slugs []string) error {
 	} else {
 		fmt.Fprintf(os.Stderr, "\033[1mBelmont Auto (multi-feature) — %d features in %d waves\033[0m\n", len(slugs), len(waves))
 	}
-	fmt.Fprintf(os.Stderr, "\033[2mTool: %s | Max parallel: %d\033[0m\n", cfg.Tool, cfg.MaxParallel)
+	fmt.Fprintf(os.Stderr, "\033[2mTool: %s | Max parallel: %d | Scheduler: %s\033[0m\n", cfg.Tool, cfg.MaxParallel, schedulerLabel(cfg))
 
 	// Print wave execution plan
 	fmt.Fprintf(os.Stderr, "\n\033[1mExecution plan:\033[0m\n")
@@ -5516,18 +5776,122 @@ func runAutoMultiFeature(cfg loopConfig, slugs []string) error {
 	pausedSlugs := make(map[string]bool)
 	totalMerged := 0
 
+	// Critical-path scheduling (opt-in with `--scheduler critical-path`):
+	// launch each feature as soon as its own deps have merged, longest
+	// dependent chain and highest master-table priority first, instead of
+	// waiting for every wave sibling. The default keeps the strict layering
+	// below; MaxParallel == 1 always uses the serial wave path.
+	useSchedule := cfg.MaxParallel > 1 && cfg.Scheduler == schedulerCriticalPath
+	if useSchedule {
+		var units []scheduleUnit
+		for _, w := range waves {
+			for _, f := range w.Features {
+				units = append(units, scheduleUnit{ID: f.Slug, Deps: f.Deps, Priority: f.Priority})
+			}
+		}
+		// Deps outside the run count as satisfied, so features held by an
+		// earlier run's pull request are taken out here.
+		if held := reviewHolds(units, awaitingSlugs); len(held) > 0 {
+			var runnable []scheduleUnit
+			for _, u := range units {
+				if dep, ok := held[u.ID]; ok {
+					fmt.Fprintf(os.Stderr, "\033[36m⊘ %s skipped\033[0m — dependency %s awaiting review\n", u.ID, dep)
+					awaitingSlugs[u.ID] = true
+					waiting = append(waiting, fmt.Sprintf("%s — waiting on %s", u.ID, dep))
+					continue
+				}
+				runnable = append(runnable, u)
+			}
+			units = runnable
+		}
+		err := runSchedule(units, cfg.MaxParallel, scheduleHooks{
+			Start: func(slug string) (func() error, error) {
+				branch := fmt.Sprintf("belmont/auto/%s", slug)
+				wtPath := filepath.Join(worktreeBasePath(cfg.Root), slug)
+				resumed, err := handleStaleWorktree(cfg.Root, slug, branch, wtPath)
+				if err != nil {
+					return nil, err
+				}
+				activeWorktrees.add(slug, wtPath, branch)
+				fmt.Fprintf(os.Stderr, "\033[36m▶ %s\033[0m — starting in worktree\n", slug)
+				return func() error {
+					return runFeatureInWorktree(cfg, slug, branch, wtPath, activeWorktrees, resumed)
+				}, nil
+			},
+			Settle: func(slug string, runErr error) (bool, error) {
+				branch := fmt.Sprintf("belmont/auto/%s", slug)
+				wtPath := filepath.Join(worktreeBasePath(cfg.Root), slug)
+				if runErr != nil {
+					if errors.Is(runErr, errFeaturePaused) {
+						fmt.Fprintf(os.Stderr, "\033[33m⏸ %s paused\033[0m — has unresolved blockers\n", slug)
+						pausedSlugs[slug] = true
+					} else {
+						fmt.Fprintf(os.Stderr, "\033[31m✗ %s failed: %s\033[0m\n", slug, runErr)
+						failedSlugs[slug] = true
+					}
+					allFailures = append(allFailures, featureResult{Slug: slug, Branch: branch, WorktreePath: wtPath, Err: runErr})
+					return false, nil
+				}
+				fmt.Fprintf(os.Stderr, "\033[32m✓ %s complete\033[0m — merging...\n", slug)
+				if err := ensureCleanMergeState(cfg.Root); err != nil {
+					fmt.Fprintf(os.Stderr, "\033[33m⚠ %s — skipping merge\033[0m\n", err)
+					allFailures = append(allFailures, featureResult{Slug: slug, Branch: branch, WorktreePath: wtPath, Err: fmt.Errorf("skipped: unclean merge state")})
+					failedSlugs[slug] = true
+					return false, nil
+				}
+				if err := mergeFeatureBranch(cfg, slug, branch, wtPath, activeWorktrees); err != nil {
+					if errors.Is(err, errAwaitingReview) {
+						awaitingSlugs[slug] = true
+						return false, nil
+					}
+					fmt.Fprintf(os.Stderr, "\033[31m✗ merge failed for %s: %s\033[0m\n", slug, err)
+					allFailures = append(allFailures, featureResult{Slug: slug, Branch: branch, WorktreePath: wtPath, Err: err})
+					failedSlugs[slug] = true
+					return false, nil
+				}
+				totalMerged++
+				return true, nil
+			},
+			Skip: func(slug, blocker string) {
+				if failedSlugs[blocker] {
+					fmt.Fprintf(os.Stderr, "\033[31m⊘ %s skipped\033[0m — dependency %s failed\n", slug, blocker)
+					failedSlugs[slug] = true
+					allFailures = append(allFailures, featureResult{Slug: slug, Err: fmt.Errorf("dependency %s failed", blocker)})
+				} else if awaitingSlugs[blocker] {
+					fmt.Fprintf(os.Stderr, "\033[36m⊘ %s skipped\033[0m — dependency %s awaiting review\n", slug, blocker)
+					awaitingSlugs[slug] = true
+					waiting = append(waiting, fmt.Sprintf("%s — waiting on %s", slug, blocker))
+				} else {
+					fmt.Fprintf(os.Stderr, "\033[33m⊘ %s skipped\033[0m — dependency %s paused\n", slug, blocker)
+					pausedSlugs[slug] = true
+					allFailures = append(allFailures, featureResult{Slug: slug, Err: fmt.Errorf("dependency %s paused", blocker)})
+				}
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
 	// Execute wave by wave
 	for _, w := range waves {
+		if useSchedule {
+			break
+		}
 		// Partition the wave into runnable vs skipped using the failed/paused
 		// dep sets. A skipped feature inherits its blocker's reason and is
 		// added to the matching set so its own dependents cascade-skip in
 		// later waves.
-		waveFeatures, skipped := filterWaveByBlocked(w.Features, failedSlugs, pausedSlugs)
+		waveFeatures, skipped := filterWaveByBlocked(w.Features, failedSlugs, withAwaiting(pausedSlugs, awaitingSlugs))
 		for _, s := range skipped {
 			if s.Reason == "failed" {
 				fmt.Fprintf(os.Stderr, "\033[31m⊘ %s skipped\033[0m — dependency %s failed\n", s.Slug, s.DepSlug)
 				failedSlugs[s.Slug] = true
 				allFailures = append(allFailures, featureResult{Slug: s.Slug, Err: fmt.Errorf("dependency %s failed", s.DepSlug)})
+			} else if awaitingSlugs[s.DepSlug] {
+				fmt.Fprintf(os.Stderr, "\033[36m⊘ %s skipped\033[0m — dependency %s awaiting review\n", s.Slug, s.DepSlug)
+				awaitingSlugs[s.Slug] = true
+				waiting = append(waiting, fmt.Sprintf("%s — waiting on %s", s.Slug, s.DepSlug))
 			} else {
 				fmt.Fprintf(os.Stderr, "\033[33m⊘ %s skipped\033[0m — dependency %s paused\n", s.Slug, s.DepSlug)
 				pausedSlugs[s.Slug] = true
@@ -5587,6 +5951,10 @@ func runAutoMultiFeature(cfg loopConfig, slugs []string) error {
 					continue
 				}
 				if err := mergeFeatureBranch(cfg, slug, branch, wtPath, activeWorktrees); err != nil {
+					if errors.Is(err, errAwaitingReview) {
+						awaitingSlugs[slug] = true
+						continue
+					}
 					fmt.Fprintf(os.Stderr, "\033[31m✗ merge failed for %s: %s\033[0m\n", slug, err)
 					fmt.Fprintf(os.Stderr, "  Worktree preserved at: %s\n", wtPath)
 					fmt.Fprintf(os.Stderr, "  Branch: %s\n", branch)
@@ -5645,8 +6013,34 @@ func runAutoMultiFeature(cfg loopConfig, slugs []string) error {
 			close(results)
 		}()
 
-		// Collect wave results
+		// mergeOne merges a single successful feature, recording a failed
+		// merge in the usual sets. A non-nil return means the repo is not in
+		// a clean merge state and merging must stop.
+		attempted := map[string]bool{}
+		mergeOne := func(s featureResult) error {
+			if err := ensureCleanMergeState(cfg.Root); err != nil {
+				return err
+			}
+			attempted[s.Slug] = true
+			if err := mergeFeatureBranch(cfg, s.Slug, s.Branch, s.WorktreePath, activeWorktrees); errors.Is(err, errAwaitingReview) {
+				awaitingSlugs[s.Slug] = true
+			} else if err != nil {
+				fmt.Fprintf(os.Stderr, "\033[31m✗ merge failed for %s: %s\033[0m\n", s.Slug, err)
+				fmt.Fprintf(os.Stderr, "  Worktree preserved at: %s\n", s.WorktreePath)
+				fmt.Fprintf(os.Stderr, "  Branch: %s\n", s.Branch)
+				allFailures = append(allFailures, featureResult{Slug: s.Slug, Err: err})
+				failedSlugs[s.Slug] = true
+			} else {
+				totalMerged++
+			}
+			return nil
+		}
+
+		// Collect wave results. With --merge-as-you-go each success merges
+		// as soon as it arrives so in-flight siblings rebase onto it at their
+		// next action boundary; otherwise merges wait for the whole wave.
 		var waveSuccesses []featureResult
+		mergeHalted := false
 		for r := range results {
 			if r.Err != nil {
 				if errors.Is(r.Err, errFeaturePaused) {
@@ -5665,31 +6059,31 @@ func runAutoMultiFeature(cfg loopConfig, slugs []string) error {
 			} else {
 				fmt.Fprintf(os.Stderr, "\033[32m✓ %s complete\033[0m — merging...\n", r.Slug)
 				waveSuccesses = append(waveSuccesses, r)
+				if cfg.MergeAsYouGo && !mergeHalted {
+					mergeHalted = mergeOne(r) != nil
+				}
 			}
 		}
 
-		// Merge this wave's successes before proceeding to next wave
+		// Merge this wave's remaining successes before proceeding to next wave
 		// State is NOT committed before merge — only after successful merge.
 		// This prevents "phantom completion" where state says complete but code never merged.
-		for i, s := range waveSuccesses {
+		var pending []featureResult
+		for _, s := range waveSuccesses {
+			if !attempted[s.Slug] {
+				pending = append(pending, s)
+			}
+		}
+		for i, s := range pending {
 			// Ensure repo is in a clean merge state before each merge
-			if err := ensureCleanMergeState(cfg.Root); err != nil {
-				fmt.Fprintf(os.Stderr, "\033[33m⚠ %s — skipping remaining %d merge(s)\033[0m\n", err, len(waveSuccesses)-i)
-				for _, remaining := range waveSuccesses[i:] {
+			if err := mergeOne(s); err != nil {
+				fmt.Fprintf(os.Stderr, "\033[33m⚠ %s — skipping remaining %d merge(s)\033[0m\n", err, len(pending)-i)
+				for _, remaining := range pending[i:] {
 					allFailures = append(allFailures, featureResult{Slug: remaining.Slug, Err: fmt.Errorf("skipped: unclean merge state")})
 					failedSlugs[remaining.Slug] = true
 				}
 				break
 			}
-			if err := mergeFeatureBranch(cfg, s.Slug, s.Branch, s.WorktreePath, activeWorktrees); err != nil {
-				fmt.Fprintf(os.Stderr, "\033[31m✗ merge failed for %s: %s\033[0m\n", s.Slug, err)
-				fmt.Fprintf(os.Stderr, "  Worktree preserved at: %s\n", s.WorktreePath)
-				fmt.Fprintf(os.Stderr, "  Branch: %s\n", s.Branch)
-				allFailures = append(allFailures, featureResult{Slug: s.Slug, Err: err})
-				failedSlugs[s.Slug] = true
-			} else {
-				totalMerged++
-			}
 		}
 	}
 
@@ -5744,6 +6138,8 @@ func runAutoMultiFeature(cfg loopConfig, slugs []string) error {
 		}
 	}
 
+	printAwaitingReview(cfg.Root, "", awaitingSlugs, waiting)
+
 	// Clean up auto.json now that all features are processed
 	activeWorktrees.removeAutoJSON()
 
@@ -5857,17 +6253,9 @@ func handleStaleWorktree(root, id, branch, wtPath string) (resumed bool, err err
 // runFeatureInWorktree creates a worktree for a feature, installs belmont, and runs the full loop.
 func runFeatureInWorktree(cfg loopConfig, slug, branch, wtPath string, tracker *worktreeTracker, resumed bool) error {
 	if !resumed {
-		// Create worktree directory
-		wtDir := filepath.Dir(wtPath)
-		if err := os.MkdirAll(wtDir, 0755); err != nil {
-			return fmt.Errorf("create worktree dir: %w", err)
-		}
-
-		// Create git worktree
-		cmd := exec.Command("git", "worktree", "add", "-b", branch, wtPath, "HEAD")
-		cmd.Dir = cfg.Root
-		if out, err := cmd.CombinedOutput(); err != nil {
-			return fmt.Errorf("git worktree add: %w (%s)", err, strings.TrimSpace(string(out)))
+		// Create the git worktree (from the pool when a warm slot is idle)
+		if err := createUnitWorktree(cfg.Root, branch, wtPath); err != nil {
+			return err
 		}
 
 		// Copy .belmont state into worktree (isolated copy, not symlink)
@@ -5891,12 +6279,34 @@ func runFeatureInWorktree(cfg loopConfig, slug, branch, wtPath string, tracker *
 	// In monorepo mode, also seed env files into qualifying workspace dirs.
 	copyEnvFiles(cfg.Root, wtPath, workspaces, overrides)
 
+	// Shared package stores / build caches from worktree.json
+	wtEnv := prepareWorktreeCaches(cfg.Root, wtPath, hooks, workspaces)
+
+	// Project container for the agent and hooks (worktree.json "container")
+	ctr := newWorktreeContainer(cfg.Root, wtPath, hooks)
+	if err := ctr.prepare(cfg.Root); err != nil {
+		return err
+	}
+
+	// Workspaces this feature affects (task tags, PRD targets, changes so far)
+	scope := worktreeScope(cfg.Root, slug, "", wtPath, workspaces, resumed)
+	if len(scope) > 0 {
+		fmt.Fprintf(os.Stderr, "  Affected workspaces for %s: %s\n", slug, strings.Join(scope, ", "))
+	}
+
+	// Per-worktree databases, queues and temp dirs (worktree.json "services")
+	svcEnv, err := provisionWorktreeServices(cfg.Root, wtPath, scopedWorktreeHooks(hooks, scope), wtEnv)
+	if err != nil {
+		return fmt.Errorf("worktree services for %s: %w", slug, err)
+	}
+	wtEnv = svcEnv
+
 	// Run belmont install in the worktree
 	exePath, err := os.Executable()
 	if err != nil {
 		return fmt.Errorf("find executable: %w", err)
 	}
-	installCmd := exec.Command(exePath, "install", "--project", wtPath, "--no-prompt")
+	installCmd := exec.Command(exePath, "install", "--project", wtPath, "--no-prompt", "--no-pin", "--apply-overrides-only")
 	installCmd.Dir = wtPath
 	if out, err := installCmd.CombinedOutput(); err != nil {
 		fmt.Fprintf(os.Stderr, "  \033[33mInstall warning for %s: %s\033[0m\n", slug, strings.TrimSpace(string(out)))
@@ -5913,6 +6323,20 @@ func runFeatureInWorktree(cfg loopConfig, slug, branch, wtPath string, tracker *
 		tracker.setPort(slug, port)
 	}
 
+	// Named ports from worktree.json (BELMONT_PORT_<NAME>)
+	if ports, err := allocateNamedPorts(hooks, port); err != nil {
+		fmt.Fprintf(os.Stderr, "  \033[33m⚠ %s\033[0m\n", err)
+	} else if len(ports) > 0 {
+		fmt.Fprintf(os.Stderr, "  Named ports for %s: %s\n", slug, formatNamedPorts(ports))
+		wtEnv = withNamedPorts(wtEnv, ports)
+		ctr.publish(ports)
+		if tracker != nil {
+			tracker.setPorts(slug, ports)
+		}
+	}
+
+	wtEnv = withAffectedWorkspaces(wtEnv, scope)
+
 	if mType != monorepoNone {
 		fmt.Fprintf(os.Stderr, "  Detected %s monorepo (%d workspaces, primary=%s)\n", mType, len(workspaces), primary)
 	}
@@ -5920,14 +6344,18 @@ func runFeatureInWorktree(cfg loopConfig, slug, branch, wtPath string, tracker *
 	// Run worktree setup hooks
 	if hooks != nil && len(hooks.Setup) > 0 {
 		fmt.Fprintf(os.Stderr, "  Running worktree setup hooks for %s...\n", slug)
-		if err := runWorktreeHookCommands(hooks.Setup, wtPath, port, hooks.Env, workspaces, primary, mType); err != nil {
+		if err := runWorktreeHookCommands(hooks.Setup, wtPath, port, wtEnv, workspaces, primary, mType, ctr); err != nil {
 			return fmt.Errorf("worktree setup for %s: %w", slug, err)
 		}
-	} else if hooks == nil {
+	}
+	if err := runWorkspaceSetupHooks(hooks, scope, wtPath, port, wtEnv, workspaces, primary, mType, ctr); err != nil {
+		return fmt.Errorf("worktree setup for %s: %w", slug, err)
+	}
+	if hooks == nil {
 		// No worktree.json — auto-detect dependency install from lock files
 		if cmds := detectAutoInstallCommands(cfg.Root); len(cmds) > 0 {
 			fmt.Fprintf(os.Stderr, "  Auto-installing dependencies for %s (%s)...\n", slug, strings.Join(cmds, ", "))
-			if err := runWorktreeHookCommands(cmds, wtPath, port, nil, workspaces, primary, mType); err != nil {
+			if err := runWorktreeHookCommands(cmds, wtPath, port, nil, workspaces, primary, mType, nil); err != nil {
 				fmt.Fprintf(os.Stderr, "  \033[33m⚠ Auto-install failed for %s: %s (continuing)\033[0m\n", slug, err)
 			}
 		}
@@ -5938,12 +6366,16 @@ func runFeatureInWorktree(cfg loopConfig, slug, branch, wtPath string, tracker *
 	mCfg.Root = wtPath
 	mCfg.Feature = slug
 	mCfg.Port = port
+	mCfg.Tracker = tracker
+	mCfg.TrackerID = slug
+	if cfg.MergeAsYouGo {
+		mCfg.MainRoot = cfg.Root
+	}
 	mCfg.Workspaces = workspaces
 	mCfg.PrimaryWorkspace = primary
 	mCfg.MonorepoType = mType
-	if hooks != nil {
-		mCfg.WorktreeEnv = hooks.Env
-	}
+	mCfg.WorktreeEnv = wtEnv
+	mCfg.Container = ctr
 
 	// Load per-feature model tiers from the worktree's copy of models.yaml.
 	if t, err := parseModelTiers(filepath.Join(wtPath, ".belmont", "features", slug, "models.yaml")); err == nil {
@@ -5961,6 +6393,13 @@ func mergeFeatureBranch(cfg loopConfig, slug, branch, wtPath string, tracker *wo
 		fmt.Fprintf(os.Stderr, "  \033[33m⚠ Failed to commit worktree changes for %s: %s\033[0m\n", slug, err)
 	}
 
+	// In pull-request mode the branch goes up for review instead.
+	if m := pullRequestMode(loadWorktreeHooks(cfg.Root)); m != nil {
+		prCfg := cfg
+		prCfg.Feature = slug
+		return openPullRequest(prCfg, m, "", branch, wtPath, tracker)
+	}
+
 	commitMsg := fmt.Sprintf("belmont: merge feature %s", slug)
 
 	if err := attemptMerge(cfg, commitMsg, branch, slug); err != nil {
@@ -5980,9 +6419,9 @@ func mergeFeatureBranch(cfg loopConfig, slug, branch, wtPath string, tracker *wo
 	// Clean up reconciliation report if it exists
 	os.Remove(filepath.Join(cfg.Root, ".belmont", "reconciliation-report.json"))
 
-	// Run teardown hooks and clean up worktree
+	// Run teardown hooks, then return the worktree to the pool or remove it
 	tracker.teardownEntry(slug)
-	removeWorktree(cfg.Root, wtPath, slug)
+	releaseUnitWorktree(cfg.Root, wtPath, slug)
 	tracker.remove(slug)
 
 	// Delete the branch
@@ -6035,7 +6474,25 @@ func runLoop(cfg loopConfig) error {
 	}
 	fmt.Fprintln(os.Stderr)
 
+	actionsFile, err := loadLoopActionsFile(cfg.Root)
+	if err != nil {
+		return fmt.Errorf("auto: %w", err)
+	}
+	cfg.CustomActions = actionsFile.Actions
+	cfg.CodeReview = actionsFile.Review
+
+	var steeredMainTip string
 	for i := 1; i <= cfg.MaxIterations; i++ {
+		// 0. --merge-as-you-go: pick up sibling merges at the action boundary
+		if cfg.MainRoot != "" && i > 1 {
+			steeredMainTip = rebaseOnMainAtBoundary(cfg, steeredMainTip)
+		}
+		// 0b. Parallel worktrees: trial-merge against siblings and steer on
+		// predicted conflicts while the agents can still avoid them.
+		if i > 1 {
+			predictSiblingConflicts(cfg)
+		}
+
 		// 1. Read current state
 		report, err := buildStatus(cfg.Root, 55, cfg.Feature)
 		if err != nil {
@@ -6043,11 +6500,13 @@ func runLoop(cfg loopConfig) error {
 			return fmt.Errorf("auto: state read failed: %w", err)
 		}
 
-		// 2. Derive extra signals
+		// 2. Derive extra signals. The built-in rules only see built-in
+		// actions; custom ones are scheduled on top in step 5b.
+		builtin := builtinHistory(history)
 		hasFwlup := detectFwlupTasks(cfg.Root, cfg.Feature, report)
-		currentMsID := lastMilestoneID(history)
+		currentMsID := lastMilestoneID(builtin)
 		hasMsFwlup := currentMsID != "" && detectFwlupTasksForMilestone(cfg.Root, cfg.Feature, report, currentMsID)
-		msStates := buildMilestoneLoopStates(history, report.Milestones)
+		msStates := buildMilestoneLoopStates(builtin, report.Milestones)
 
 		// Range-scoped signals: only consider tasks/FWLUPs under milestones within --from/--to
 		pendingInRange := pendingTasksInRange(cfg.Root, cfg.Feature, cfg.From, cfg.To)
@@ -6061,12 +6520,12 @@ func runLoop(cfg loopConfig) error {
 
 		// 4. If no guardrail triggered, try smart rules first
 		if action == nil {
-			action = decideLoopActionSmart(report, history, cfg, hasFwlup, hasMsFwlup, pendingInRange, fwlupInRange, msStates)
+			action = decideLoopActionSmart(report, builtin, cfg, hasFwlup, hasMsFwlup, pendingInRange, fwlupInRange, msStates)
 		}
 
 		// 4b. If smart rules returned nil, check stuck detection before AI
-		if action == nil && isLoopStuck(history) {
-			action = &loopAction{Type: actionPause, Reason: fmt.Sprintf("Loop appears stuck — no state change after 2 iterations (last action: %s)", history[len(history)-1].Action.Type)}
+		if action == nil && isLoopStuck(builtin) {
+			action = &loopAction{Type: actionPause, Reason: fmt.Sprintf("Loop appears stuck — no state change after 2 iterations (last action: %s)", builtin[len(builtin)-1].Action.Type)}
 		}
 
 		// 5. If smart rules returned nil, use AI decisions (with rules fallback)
@@ -6074,13 +6533,23 @@ func runLoop(cfg loopConfig) error {
 			aiAction, err := decideLoopActionAI(report, history, cfg, hasFwlup, lastOutput, msStates)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "\033[33m  AI decision failed: %s — falling back to rules\033[0m\n", err)
-				decided := decideLoopAction(report, history, cfg, fwlupInRange, pendingInRange)
+				decided := decideLoopAction(report, builtin, cfg, fwlupInRange, pendingInRange)
 				action = &decided
 			} else {
 				action = aiAction
 			}
 		}
 
+		// 5a. A required review holds COMPLETE until every milestone has one
+		if gated := requireReviewBeforeComplete(action, report, builtin, cfg, msStates); gated != nil {
+			action = gated
+		}
+
+		// 5b. Project-defined actions hooked before/after the decided one
+		if custom := scheduleCustomAction(cfg.CustomActions, action, history, msStates); custom != nil {
+			action = custom
+		}
+
 		label := describeMilestone(action, report)
 		actionLabel := shortActionLabel(action.Type)
 		if label != "" {
@@ -6136,7 +6605,9 @@ func runLoop(cfg loopConfig) error {
 		}
 
 		// 7. Checkpoint policy check
-		if shouldLoopCheckpoint(*action, cfg.Policy, lastActionType(history)) {
+		// Custom actions run as part of the phase they hook, so they don't
+		// checkpoint on their own and don't count as the last action.
+		if action.Custom == nil && shouldLoopCheckpoint(*action, cfg.Policy, lastActionType(builtin)) {
 			fmt.Fprintf(os.Stderr, "\n\033[33m⏸ Checkpoint\033[0m — %s\n", action.Reason)
 			fmt.Fprintf(os.Stderr, "Resume with: belmont auto --feature %s", cfg.Feature)
 			if cfg.From != "" {
@@ -6151,6 +6622,9 @@ func runLoop(cfg loopConfig) error {
 
 		// 8. Capture pre-action SHA
 		preSHA := captureGitSHA(cfg.Root)
+		if action.Type == actionReview && action.ReviewBase == "" {
+			action.ReviewBase, action.ReviewHead = milestoneReviewRange(history, action.MilestoneID)
+		}
 
 		// 9. Execute action
 		result := executeLoopAction(*action, cfg)
@@ -6260,8 +6734,8 @@ func decideLoopAction(report statusReport, history []historyEntry, cfg loopConfi
 		return loopAction{Type: actionPause, Reason: "Loop appears stuck — no state change after 2 iterations"}
 	}
 
-	// Rule 4: FWLUP tasks after VERIFY → TRIAGE
-	if hasFwlup && (last == actionVerify || last == actionFixAll) {
+	// Rule 4: FWLUP tasks after VERIFY or REVIEW → TRIAGE
+	if hasFwlup && (last == actionVerify || last == actionReview || last == actionFixAll) {
 		return loopAction{Type: actionTriage, Reason: "Triaging follow-up tasks"}
 	}
 
@@ -6541,7 +7015,16 @@ func describeMilestone(action *loopAction, report statusReport) string {
 }
 
 func executeLoopAction(action loopAction, cfg loopConfig) executionResult {
-	prompt := adaptPromptForTool(buildLoopPrompt(action, cfg.Feature), cfg.Tool)
+	var prompt string
+	if action.Custom != nil {
+		customPrompt, err := buildCustomActionPrompt(cfg.Root, action, cfg.Feature)
+		if err != nil {
+			return executionResult{Success: false, Error: err.Error()}
+		}
+		prompt = adaptPromptForTool(customPrompt, cfg.Tool)
+	} else {
+		prompt = adaptPromptForTool(buildLoopPrompt(action, cfg.Feature), cfg.Tool)
+	}
 
 	// Snapshot PROGRESS.md before the agent runs — the post-phase scope guard
 	// uses this baseline to revert out-of-scope milestone structure changes.
@@ -6551,7 +7034,11 @@ func executeLoopAction(action loopAction, cfg loopConfig) executionResult {
 	// shell-out so a single injection maps to one agent run — the auto loop
 	// re-enters the same milestone across phases and we don't want the same
 	// instruction to fire repeatedly.
-	steeringBlock, steeringCount := consumePendingSteering(cfg.Root, cfg.Feature, action.MilestoneID, string(action.Type))
+	var steeringBlock string
+	var steeringCount int
+	cfg.Tracker.withSteering(func() {
+		steeringBlock, steeringCount = consumePendingSteering(cfg.Root, cfg.Feature, action.MilestoneID, string(action.Type))
+	})
 	if steeringCount > 0 {
 		logSteeringInjection(cfg.Feature, action.MilestoneID, steeringCount, steeringBlock)
 	}
@@ -6568,7 +7055,11 @@ func executeLoopAction(action loopAction, cfg loopConfig) executionResult {
 		prompt = steeringBlock + prompt
 	}
 
-	modelFlags := resolveModelFlags(cfg.Tool, tierForAction(action.Type, cfg.ModelTiers), cfg.Root)
+	tier := tierForAction(action.Type, cfg.ModelTiers)
+	if action.Custom != nil {
+		tier = action.Custom.Tier
+	}
+	modelFlags := resolveModelFlags(cfg.Tool, tier, cfg.Root)
 
 	args := toolHeadlessArgs(cfg.Tool, prompt, cfg.Root, modelFlags, true)
 	if args == nil {
@@ -6582,6 +7073,10 @@ func executeLoopAction(action loopAction, cfg loopConfig) executionResult {
 		cmd.Env = buildWorktreeEnv(cfg.Port, cfg.WorktreeEnv, cfg.Workspaces, cfg.PrimaryWorkspace, cfg.MonorepoType)
 		setSysProcAttr(cmd)
 	}
+	// A configured container always applies, port or not.
+	if cfg.Container != nil {
+		cfg.Container.wrap(cmd, cfg.Port, worktreeEnvVars(cfg.Port, cfg.WorktreeEnv, cfg.Workspaces, cfg.PrimaryWorkspace, cfg.MonorepoType))
+	}
 
 	var prefix string
 	if cfg.Feature != "" {
@@ -6653,7 +7148,7 @@ func executeLoopAction(action loopAction, cfg loopConfig) executionResult {
 	durationMs := time.Since(start).Milliseconds()
 
 	if err != nil {
-		runScopeGuard(cfg, action, preSnap)
+		runActionScopeGuard(cfg, action, preSnap)
 		runEvidenceCheck(cfg, action, preSnap)
 		return executionResult{
 			Success:    false,
@@ -6663,7 +7158,7 @@ func executeLoopAction(action loopAction, cfg loopConfig) executionResult {
 		}
 	}
 
-	runScopeGuard(cfg, action, preSnap)
+	runActionScopeGuard(cfg, action, preSnap)
 	runEvidenceCheck(cfg, action, preSnap)
 	return executionResult{
 		Success:    true,
@@ -6716,6 +7211,10 @@ Output JSON: {"decision":"defer_and_proceed|fix_and_proceed|fix_and_reverify","b
 		cmd.Env = buildWorktreeEnv(cfg.Port, cfg.WorktreeEnv, cfg.Workspaces, cfg.PrimaryWorkspace, cfg.MonorepoType)
 		setSysProcAttr(cmd)
 	}
+	// A configured container always applies, port or not.
+	if cfg.Container != nil {
+		cfg.Container.wrap(cmd, cfg.Port, worktreeEnvVars(cfg.Port, cfg.WorktreeEnv, cfg.Workspaces, cfg.PrimaryWorkspace, cfg.MonorepoType))
+	}
 
 	var triagePrefix string
 	if cfg.Port != 0 && cfg.Feature != "" {
@@ -6838,6 +7337,8 @@ func adaptPromptForTool(prompt, tool string) string {
 
 func buildLoopPrompt(action loopAction, feature string) string {
 	switch action.Type {
+	case actionReview:
+		return buildReviewPrompt(action, feature)
 	case actionImplementMilestone:
 		prompt := fmt.Sprintf("/belmont:implement --feature %s", feature)
 		if action.MilestoneID != "" {
@@ -7118,6 +7619,10 @@ func buildMilestoneLoopStates(history []historyEntry, milestones []milestone) ma
 					s.FwlupFixRounds++
 				}
 			}
+		case actionReview:
+			if s, ok := states[h.Action.MilestoneID]; ok && h.Result != nil && h.Result.Success {
+				s.ReviewSucceeded++
+			}
 		}
 	}
 	return states
@@ -7196,8 +7701,25 @@ func decideLoopActionSmart(report statusReport, history []historyEntry, cfg loop
 		return &loopAction{Type: actionVerify, Reason: "Verifying completed milestone", MilestoneID: last.Action.MilestoneID}
 	}
 
-	// Rule 2: After VERIFY success + no follow-ups in this milestone → next undone milestone or COMPLETE
+	// Rule 2a: After VERIFY success + no follow-ups, when the project requires
+	// review and the milestone changed since its last one → REVIEW
 	if lastType == actionVerify && lastSuccess && !hasMsFwlup {
+		msID := last.Action.MilestoneID
+		if msID == "" {
+			msID = lastMilestoneID(history)
+		}
+		if reviewRequired(cfg.CodeReview, msID, msStates) && reviewDue(history, msID) {
+			return &loopAction{Type: actionReview, Reason: fmt.Sprintf("Verification passed — reviewing %s before it counts as verified", msID), MilestoneID: msID}
+		}
+	}
+
+	// Rule 2b: After REVIEW failure → retry (consecutive failures stop the loop)
+	if lastType == actionReview && !lastSuccess {
+		return &loopAction{Type: actionReview, Reason: "Review run failed — retrying", MilestoneID: last.Action.MilestoneID}
+	}
+
+	// Rule 2: After VERIFY or REVIEW success + no follow-ups in this milestone → next undone milestone or COMPLETE
+	if (lastType == actionVerify || lastType == actionReview) && lastSuccess && !hasMsFwlup {
 		inRange := milestonesInRange(report.Milestones, cfg.From, cfg.To)
 		for _, m := range inRange {
 			if !milestoneAllDone(m) {
@@ -7212,10 +7734,14 @@ func decideLoopActionSmart(report statusReport, history []historyEntry, cfg loop
 		return &loopAction{Type: actionComplete, Reason: "All milestones in range verified and complete"}
 	}
 
-	// Rule 3: After VERIFY success + follow-ups exist in this milestone → TRIAGE (AI reads the actual FWLUPs)
-	if lastType == actionVerify && lastSuccess && hasMsFwlup {
+	// Rule 3: After VERIFY or REVIEW success + follow-ups exist in this milestone → TRIAGE (AI reads the actual FWLUPs)
+	if (lastType == actionVerify || lastType == actionReview) && lastSuccess && hasMsFwlup {
 		msID := lastMilestoneID(history)
-		return &loopAction{Type: actionTriage, Reason: "Triaging follow-up tasks after verification", MilestoneID: msID}
+		reason := "Triaging follow-up tasks after verification"
+		if lastType == actionReview {
+			reason = "Triaging follow-up tasks from code review"
+		}
+		return &loopAction{Type: actionTriage, Reason: reason, MilestoneID: msID}
 	}
 
 	// Rule 3b: After TRIAGE → decide based on triage decision
@@ -7406,6 +7932,7 @@ func decideLoopActionAI(report statusReport, history []historyEntry, cfg loopCon
 		Verified       bool   `json:"verified"`
 		VerifyFailures  int    `json:"verify_failures,omitempty"`
 		VerifySuccesses int    `json:"verify_successes,omitempty"`
+		ReviewSuccesses int    `json:"review_successes,omitempty"`
 		WorkType        string `json:"work_type,omitempty"`
 		FilesChanged   int    `json:"files_changed,omitempty"`
 	}
@@ -7417,6 +7944,7 @@ func decideLoopActionAI(report statusReport, history []historyEntry, cfg loopCon
 			ms.Verified = s.Verified
 			ms.VerifyFailures = s.VerifyFailed
 			ms.VerifySuccesses = s.VerifySucceeded
+			ms.ReviewSuccesses = s.ReviewSucceeded
 			ms.WorkType = string(s.WorkType)
 			ms.FilesChanged = s.FilesChanged
 		}
@@ -7488,6 +8016,12 @@ func decideLoopActionAI(report statusReport, history []historyEntry, cfg loopCon
 	if lastOutput != "" {
 		state["previous_output"] = truncateTail(lastOutput, 1500)
 	}
+	if len(cfg.CustomActions) > 0 {
+		state["custom_actions"] = customActionsForAI(cfg.CustomActions)
+	}
+	if cfg.CodeReview.Required {
+		state["review_required"] = true
+	}
 
 	stateJSON, err := json.Marshal(state)
 	if err != nil {
@@ -7509,6 +8043,7 @@ AVAILABLE ACTIONS:
 - IMPLEMENT_MILESTONE: Implement next incomplete milestone (set milestone_id)
 - IMPLEMENT_NEXT: Fix a single follow-up task
 - VERIFY: Run verification on completed milestones
+- REVIEW: Run an independent code review of a milestone's diff; findings become follow-up tasks (set milestone_id)
 - TRIAGE: Run AI triage to classify follow-up tasks as blocking vs polish
 - FIX_ALL: Fix all blocking follow-up tasks in batch before re-verification
 - REPLAN: Re-run tech planning when current approach has systemic issues
@@ -7535,6 +8070,9 @@ Respond with ONLY valid JSON: {"action":"...","reason":"...","milestone_id":"...
 		}
 		prompt = buf.String()
 	}
+	if len(cfg.CustomActions) > 0 {
+		prompt += "\n\nPROJECT ACTIONS: state.custom_actions lists project-defined actions. You may choose one by name (set milestone_id) when its after/before hook applies and it has not run yet for that milestone."
+	}
 
 	// AI decision calls are short classification tasks — use the low tier.
 	decisionFlags := resolveModelFlags(cfg.Tool, "low", cfg.Root)
@@ -7556,17 +8094,20 @@ Respond with ONLY valid JSON: {"action":"...","reason":"...","milestone_id":"...
 
 	// Validate action type
 	actionType := loopActionType(decision.Action)
+	custom := findCustomAction(cfg.CustomActions, decision.Action)
 	switch actionType {
 	case actionImplementMilestone, actionImplementNext, actionVerify,
 		actionReplan, actionSkipMilestone, actionComplete, actionPause, actionDebug,
-		actionTriage, actionFixAll:
+		actionTriage, actionFixAll, actionReview:
 		// valid
 	default:
-		return nil, fmt.Errorf("unknown action %q from AI", decision.Action)
+		if custom == nil {
+			return nil, fmt.Errorf("unknown action %q from AI", decision.Action)
+		}
 	}
 
 	// Validate milestone_id required for certain actions
-	if (actionType == actionImplementMilestone || actionType == actionSkipMilestone) && decision.MilestoneID == "" {
+	if (actionType == actionImplementMilestone || actionType == actionSkipMilestone || actionType == actionReview) && decision.MilestoneID == "" {
 		return nil, fmt.Errorf("action %s requires milestone_id", actionType)
 	}
 
@@ -7574,6 +8115,7 @@ Respond with ONLY valid JSON: {"action":"...","reason":"...","milestone_id":"...
 		Type:        actionType,
 		Reason:      decision.Reason,
 		MilestoneID: decision.MilestoneID,
+		Custom:      custom,
 	}, nil
 }
 
@@ -8242,7 +8784,37 @@ func runAutoParallel(cfg loopConfig, milestones []milestone) error {
 	}
 
 	fmt.Fprintf(os.Stderr, "\033[1mBelmont Auto (parallel) — %s\033[0m\n", cfg.Feature)
-	fmt.Fprintf(os.Stderr, "\033[2mTool: %s | Max parallel: %d\033[0m\n", cfg.Tool, cfg.MaxParallel)
+	fmt.Fprintf(os.Stderr, "\033[2mTool: %s | Max parallel: %d | Scheduler: %s\033[0m\n", cfg.Tool, cfg.MaxParallel, schedulerLabel(cfg))
+
+	// Milestones opened as pull requests by an earlier run (merge mode "pr")
+	// are left out of the run: their state is synced only once they land.
+	// The ones depending on a PR still in review wait.
+	awaiting := map[string]bool{}
+	for _, rec := range refreshPullRequests(cfg.Root, cfg.Feature) {
+		if rec.Milestone != "" {
+			awaiting[rec.Milestone] = true
+		}
+	}
+	var waiting []string
+	if len(awaiting) > 0 {
+		var units []scheduleUnit
+		for _, m := range milestones {
+			units = append(units, scheduleUnit{ID: m.ID, Deps: m.Deps})
+		}
+		held := reviewHolds(units, awaiting)
+		var runnable []milestone
+		for _, m := range milestones {
+			if awaiting[m.ID] {
+				continue
+			}
+			if dep, ok := held[m.ID]; ok {
+				waiting = append(waiting, fmt.Sprintf("%s — waiting on %s", m.ID, dep))
+				continue
+			}
+			runnable = append(runnable, m)
+		}
+		milestones = runnable
+	}
 
 	waves, err := computeWaves(milestones)
 	if err != nil {
@@ -8250,6 +8822,10 @@ func runAutoParallel(cfg loopConfig, milestones []milestone) error {
 	}
 
 	if len(waves) == 0 {
+		if len(waiting) > 0 {
+			printAwaitingReview(cfg.Root, cfg.Feature, awaiting, waiting)
+			return nil
+		}
 		fmt.Fprintf(os.Stderr, "\n\033[32m✓ Complete\033[0m — all milestones already done\n")
 		return nil
 	}
@@ -8286,7 +8862,18 @@ func runAutoParallel(cfg loopConfig, milestones []milestone) error {
 		os.Exit(1)
 	}()
 
-	for _, w := range waves {
+	// With `--scheduler critical-path`, scheduling replaces the wave loop
+	// whenever milestones can actually run concurrently: each milestone
+	// starts as soon as its own deps have merged. MaxParallel == 1 keeps the
+	// serial wave path.
+	if cfg.MaxParallel > 1 && cfg.Scheduler == schedulerCriticalPath {
+		if err := runMilestoneSchedule(cfg, waves, activeWorktrees, awaiting, &waiting); err != nil {
+			return err
+		}
+		waves = nil
+	}
+
+	for i, w := range waves {
 		fmt.Fprintf(os.Stderr, "\033[1m━━ Wave %d ━━\033[0m\n", w.Index+1)
 
 		// Every wave — including single-milestone waves — runs through the
@@ -8295,12 +8882,24 @@ func runAutoParallel(cfg loopConfig, milestones []milestone) error {
 		// is a worktree remove rather than a reset, and state visibility via
 		// `belmont status` behaves the same regardless of wave size. See
 		// knowledge/auto-mode/parallel-wave-orchestration.md.
-		if err := runWaveParallel(cfg, w, activeWorktrees); err != nil {
+		if err := runWaveParallel(cfg, w, activeWorktrees, awaiting); err != nil {
 			return err
 		}
 
 		fmt.Fprintf(os.Stderr, "\033[32m  ✓ Wave %d complete\033[0m\n\n", w.Index+1)
+
+		// Waves are strict layers: once a milestone is in review, every
+		// later wave waits for it.
+		if len(awaiting) > 0 {
+			for _, later := range waves[i+1:] {
+				for _, m := range later.Milestones {
+					waiting = append(waiting, fmt.Sprintf("%s — waiting on wave %d", m.ID, w.Index+1))
+				}
+			}
+			break
+		}
 	}
+	printAwaitingReview(cfg.Root, cfg.Feature, awaiting, waiting)
 
 	// Sync master PROGRESS.md with actual feature states
 	featuresDir := filepath.Join(cfg.Root, ".belmont", "features")
@@ -8314,6 +8913,10 @@ func runAutoParallel(cfg loopConfig, milestones []milestone) error {
 	// Clean up auto.json
 	activeWorktrees.removeAutoJSON()
 
+	if len(awaiting) > 0 {
+		fmt.Fprintf(os.Stderr, "\n\033[36m⧗ Stopped for review\033[0m (%.1fs total)\n", time.Since(startTime).Seconds())
+		return nil
+	}
 	fmt.Fprintf(os.Stderr, "\n\033[32m✓ All waves complete\033[0m (%.1fs total)\n", time.Since(startTime).Seconds())
 	return nil
 }
@@ -8323,7 +8926,8 @@ type worktreeEntry struct {
 	Path   string
 	Branch string
 	Port   int
-	Pgid   int // process group ID for cleanup
+	Ports  map[string]int // named ports from worktree.json, keyed "name" or "workspace/name"
+	Pgid   int            // process group ID for cleanup
 }
 
 // worktreeTracker keeps track of active worktrees for cleanup on interrupt.
@@ -8334,6 +8938,8 @@ type worktreeTracker struct {
 	mode    string                   // "single-feature-parallel" | "multi-feature" (used by live-status readers)
 	entries map[string]worktreeEntry // ID -> worktree entry (milestone IDs in single-feature, feature slugs in multi-feature)
 	hooks   *worktreeHooks           // shared hooks config (nil if no worktree.json)
+
+	predicted map[string]bool // conflict predictions already steered (see merge_predict.go)
 }
 
 // autoJSON is the on-disk format for .belmont/auto.json, enabling belmont status
@@ -8349,8 +8955,10 @@ type autoJSON struct {
 }
 
 type autoJSONEntry struct {
-	Path   string `json:"path"`
-	Branch string `json:"branch"`
+	Path   string         `json:"path"`
+	Branch string         `json:"branch"`
+	Port   int            `json:"port,omitempty"`
+	Ports  map[string]int `json:"ports,omitempty"`
 }
 
 func (wt *worktreeTracker) add(id, path, branch string) {
@@ -8366,6 +8974,18 @@ func (wt *worktreeTracker) setPort(id string, port int) {
 	if entry, ok := wt.entries[id]; ok {
 		entry.Port = port
 		wt.entries[id] = entry
+		wt.persistAutoJSON()
+	}
+}
+
+// setPorts records a worktree's named ports (see ports.go).
+func (wt *worktreeTracker) setPorts(id string, ports map[string]int) {
+	wt.mu.Lock()
+	defer wt.mu.Unlock()
+	if entry, ok := wt.entries[id]; ok {
+		entry.Ports = ports
+		wt.entries[id] = entry
+		wt.persistAutoJSON()
 	}
 }
 
@@ -8399,7 +9019,7 @@ func (wt *worktreeTracker) persistAutoJSON() {
 		Worktrees: make(map[string]autoJSONEntry),
 	}
 	for id, entry := range wt.entries {
-		aj.Worktrees[id] = autoJSONEntry{Path: entry.Path, Branch: entry.Branch}
+		aj.Worktrees[id] = autoJSONEntry{Path: entry.Path, Branch: entry.Branch, Port: entry.Port, Ports: entry.Ports}
 	}
 	data, err := json.MarshalIndent(aj, "", "  ")
 	if err != nil {
@@ -8452,8 +9072,9 @@ func (wt *worktreeTracker) teardownEntry(id string) {
 		// Teardown runs without monorepo env vars; teardown commands are
 		// typically simple (kill servers, remove volumes) and don't need
 		// workspace context. Passing nil keeps the call site lean.
-		_ = runWorktreeHookCommands(hooks.Teardown, entry.Path, entry.Port, hooks.Env, nil, "", monorepoNone)
+		_ = runWorktreeHookCommands(hooks.Teardown, entry.Path, entry.Port, hooks.Env, nil, "", monorepoNone, newWorktreeContainer(wt.root, entry.Path, hooks))
 	}
+	newWorktreeContainer(wt.root, entry.Path, hooks).stop()
 }
 
 func (wt *worktreeTracker) cleanupAll(root string) {
@@ -8466,8 +9087,9 @@ func (wt *worktreeTracker) cleanupAll(root string) {
 			signalProcessGroup(entry.Pgid)
 		}
 		// Run teardown hooks
+		ctr := newWorktreeContainer(root, entry.Path, wt.hooks)
 		if wt.hooks != nil && len(wt.hooks.Teardown) > 0 {
-			_ = runWorktreeHookCommands(wt.hooks.Teardown, entry.Path, entry.Port, wt.hooks.Env, nil, "", monorepoNone)
+			_ = runWorktreeHookCommands(wt.hooks.Teardown, entry.Path, entry.Port, wt.hooks.Env, nil, "", monorepoNone, ctr)
 		}
 		removeWorktree(root, entry.Path, id)
 		// Also delete the branch to prevent stale branch on restart
@@ -8495,9 +9117,11 @@ func (wt *worktreeTracker) gracefulShutdown(root string) {
 			signalProcessGroup(entry.Pgid)
 		}
 		// Run teardown hooks (release ports, stop dev servers)
+		ctr := newWorktreeContainer(root, entry.Path, wt.hooks)
 		if wt.hooks != nil && len(wt.hooks.Teardown) > 0 {
-			_ = runWorktreeHookCommands(wt.hooks.Teardown, entry.Path, entry.Port, wt.hooks.Env, nil, "", monorepoNone)
+			_ = runWorktreeHookCommands(wt.hooks.Teardown, entry.Path, entry.Port, wt.hooks.Env, nil, "", monorepoNone, ctr)
 		}
+		ctr.stop()
 		// Preserve worktree and branch for resume
 		recoverSlug := filepath.Base(entry.Path)
 		fmt.Fprintf(os.Stderr, "  Worktree preserved for %s at %s\n", id, entry.Path)
@@ -8511,7 +9135,9 @@ func (wt *worktreeTracker) gracefulShutdown(root string) {
 // single-milestone waves. Uniform behavior is worth the small startup cost;
 // see knowledge/auto-mode/parallel-wave-orchestration.md on the removed
 // master-tree shortcut.
-func runWaveParallel(cfg loopConfig, w wave, tracker *worktreeTracker) error {
+//
+// Milestones that went up for review (merge mode "pr") are added to awaiting.
+func runWaveParallel(cfg loopConfig, w wave, tracker *worktreeTracker, awaiting map[string]bool) error {
 	type result struct {
 		MilestoneID  string
 		Branch       string
@@ -8554,7 +9180,10 @@ func runWaveParallel(cfg loopConfig, w wave, tracker *worktreeTracker) error {
 				continue
 			}
 			reportMergeOverlap(cfg.Root, branch, m.ID, mergedFiles)
-			if err := mergeWorktreeBranch(cfg, m.ID, branch, wtPath, tracker); err != nil {
+			if err := mergeWorktreeBranch(cfg, m.ID, branch, wtPath, tracker); errors.Is(err, errAwaitingReview) {
+				awaiting[m.ID] = true
+				continue
+			} else if err != nil {
 				return fmt.Errorf("auto: merge failed for %s: %w", m.ID, err)
 			}
 			for _, f := range branchTouchedFiles(cfg.Root, branch) {
@@ -8590,6 +9219,9 @@ func runWaveParallel(cfg loopConfig, w wave, tracker *worktreeTracker) error {
 		msPreResolved[m.ID] = resumed
 	}
 
+	// Milestones in this wave that touch dependent workspaces
+	warnWaveWorkspaceDependencies(cfg.Root, cfg.Feature, w.Milestones, "")
+
 	for _, m := range w.Milestones {
 		wg.Add(1)
 		go func(ms milestone, resumed bool) {
@@ -8620,52 +9252,104 @@ func runWaveParallel(cfg loopConfig, w wave, tracker *worktreeTracker) error {
 		close(results)
 	}()
 
-	// Collect results
-	var successes []result
-	var failures []result
-	for r := range results {
-		if r.Err != nil {
-			fmt.Fprintf(os.Stderr, "  \033[31m✗ %s failed: %s\033[0m\n", r.MilestoneID, r.Err)
-			failures = append(failures, r)
-		} else {
-			fmt.Fprintf(os.Stderr, "  \033[32m✓ %s complete\033[0m\n", r.MilestoneID)
-			successes = append(successes, r)
-		}
-	}
-
-	// Merge successful branches in milestone ID order
-	sort.Slice(successes, func(i, j int) bool {
-		return parseMilestoneNum(successes[i].MilestoneID) < parseMilestoneNum(successes[j].MilestoneID)
-	})
-
 	// Track files already merged from earlier siblings in this wave so we
 	// can warn when a later merge overlaps with them — the signature of
 	// cross-milestone scope leak that survived Layer 1 guards.
 	mergedFiles := map[string][]string{} // file -> []milestoneIDs that touched it
+	merged := map[string]bool{}
 
-	for i, s := range successes {
-		// Ensure repo is in a clean merge state before each merge
+	// mergeOne merges a single successful milestone. unclean is non-nil when
+	// the repo is not in a clean merge state (the caller stops merging);
+	// fatal is a failed merge, which ends the run as before.
+	mergeOne := func(s result) (unclean, fatal error) {
 		if err := ensureCleanMergeState(cfg.Root); err != nil {
-			fmt.Fprintf(os.Stderr, "  \033[33m⚠ %s — skipping remaining %d merge(s)\033[0m\n", err, len(successes)-i)
-			for _, remaining := range successes[i:] {
-				failures = append(failures, result{MilestoneID: remaining.MilestoneID, WorktreePath: remaining.WorktreePath, Err: fmt.Errorf("skipped: unclean merge state")})
-			}
-			break
+			return err, nil
 		}
 
 		// Pre-merge overlap report: list files this branch touches that are
 		// also touched by siblings we've already merged. Does not block.
 		reportMergeOverlap(cfg.Root, s.Branch, s.MilestoneID, mergedFiles)
 
-		if err := mergeWorktreeBranch(cfg, s.MilestoneID, s.Branch, s.WorktreePath, tracker); err != nil {
-			return fmt.Errorf("auto: merge failed for %s: %w", s.MilestoneID, err)
+		// Record touched files before the merge deletes the branch.
+		touched := branchTouchedFiles(cfg.Root, s.Branch)
+		if err := mergeWorktreeBranch(cfg, s.MilestoneID, s.Branch, s.WorktreePath, tracker); errors.Is(err, errAwaitingReview) {
+			awaiting[s.MilestoneID] = true
+			merged[s.MilestoneID] = true
+			return nil, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("auto: merge failed for %s: %w", s.MilestoneID, err)
 		}
-
-		// Record this branch's touched files so the next iteration can detect
-		// overlap.
-		for _, f := range branchTouchedFiles(cfg.Root, s.Branch) {
+		for _, f := range touched {
 			mergedFiles[f] = append(mergedFiles[f], s.MilestoneID)
 		}
+		merged[s.MilestoneID] = true
+		return nil, nil
+	}
+
+	// Collect results. With --merge-as-you-go each success merges as soon as
+	// it arrives so in-flight siblings can rebase onto it at their next
+	// action boundary; otherwise merges wait for the whole wave.
+	var successes []result
+	var failures []result
+	var mergeFatal error
+	mergeHalted := false
+	for r := range results {
+		if r.Err != nil {
+			fmt.Fprintf(os.Stderr, "  \033[31m✗ %s failed: %s\033[0m\n", r.MilestoneID, r.Err)
+			failures = append(failures, r)
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "  \033[32m✓ %s complete\033[0m\n", r.MilestoneID)
+		successes = append(successes, r)
+		if cfg.MergeAsYouGo && !mergeHalted {
+			unclean, fatal := mergeOne(r)
+			if fatal != nil {
+				mergeFatal = fatal
+			}
+			mergeHalted = unclean != nil || fatal != nil
+		}
+	}
+	if mergeFatal != nil {
+		return mergeFatal
+	}
+
+	// Merge the remaining successful branches in milestone ID order, with
+	// upstream workspaces ahead of the workspaces that depend on them
+	var pending []result
+	for _, s := range successes {
+		if !merged[s.MilestoneID] {
+			pending = append(pending, s)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		return parseMilestoneNum(pending[i].MilestoneID) < parseMilestoneNum(pending[j].MilestoneID)
+	})
+	if len(pending) > 1 {
+		ids := make([]string, len(pending))
+		branches := map[string]string{}
+		byID := map[string]result{}
+		for i, s := range pending {
+			ids[i] = s.MilestoneID
+			branches[s.MilestoneID] = s.Branch
+			byID[s.MilestoneID] = s
+		}
+		for i, id := range workspaceMergeOrder(cfg.Root, cfg.Feature, w.Milestones, ids, branches) {
+			pending[i] = byID[id]
+		}
+	}
+
+	for i, s := range pending {
+		unclean, fatal := mergeOne(s)
+		if fatal != nil {
+			return fatal
+		}
+		if unclean != nil {
+			fmt.Fprintf(os.Stderr, "  \033[33m⚠ %s — skipping remaining %d merge(s)\033[0m\n", unclean, len(pending)-i)
+			for _, remaining := range pending[i:] {
+				failures = append(failures, result{MilestoneID: remaining.MilestoneID, WorktreePath: remaining.WorktreePath, Err: fmt.Errorf("skipped: unclean merge state")})
+			}
+			break
+		}
 	}
 
 	// Clean up failed worktrees (preserve for manual intervention)
@@ -8684,17 +9368,9 @@ func runWaveParallel(cfg loopConfig, w wave, tracker *worktreeTracker) error {
 // runMilestoneInWorktree creates a worktree, installs belmont, copies state, and runs the loop.
 func runMilestoneInWorktree(cfg loopConfig, ms milestone, branch, wtPath string, tracker *worktreeTracker, resumed bool) error {
 	if !resumed {
-		// Create worktree directory
-		wtDir := filepath.Dir(wtPath)
-		if err := os.MkdirAll(wtDir, 0755); err != nil {
-			return fmt.Errorf("create worktree dir: %w", err)
-		}
-
-		// Create git worktree
-		cmd := exec.Command("git", "worktree", "add", "-b", branch, wtPath, "HEAD")
-		cmd.Dir = cfg.Root
-		if out, err := cmd.CombinedOutput(); err != nil {
-			return fmt.Errorf("git worktree add: %w (%s)", err, strings.TrimSpace(string(out)))
+		// Create the git worktree (from the pool when a warm slot is idle)
+		if err := createUnitWorktree(cfg.Root, branch, wtPath); err != nil {
+			return err
 		}
 	}
 
@@ -8718,12 +9394,34 @@ func runMilestoneInWorktree(cfg loopConfig, ms milestone, branch, wtPath string,
 	// In monorepo mode, also seed env files into qualifying workspace dirs.
 	copyEnvFiles(cfg.Root, wtPath, workspaces, overrides)
 
+	// Shared package stores / build caches from worktree.json
+	wtEnv := prepareWorktreeCaches(cfg.Root, wtPath, hooks, workspaces)
+
+	// Project container for the agent and hooks (worktree.json "container")
+	ctr := newWorktreeContainer(cfg.Root, wtPath, hooks)
+	if err := ctr.prepare(cfg.Root); err != nil {
+		return err
+	}
+
+	// Workspaces this milestone affects (task tags, PRD targets, changes so far)
+	scope := worktreeScope(cfg.Root, cfg.Feature, ms.ID, wtPath, workspaces, resumed)
+	if len(scope) > 0 {
+		fmt.Fprintf(os.Stderr, "    Affected workspaces for %s: %s\n", ms.ID, strings.Join(scope, ", "))
+	}
+
+	// Per-worktree databases, queues and temp dirs (worktree.json "services")
+	svcEnv, err := provisionWorktreeServices(cfg.Root, wtPath, scopedWorktreeHooks(hooks, scope), wtEnv)
+	if err != nil {
+		return fmt.Errorf("worktree services for %s: %w", ms.ID, err)
+	}
+	wtEnv = svcEnv
+
 	// Run belmont install in the worktree (shell out to self)
 	exePath, err := os.Executable()
 	if err != nil {
 		return fmt.Errorf("find executable: %w", err)
 	}
-	installCmd := exec.Command(exePath, "install", "--project", wtPath, "--no-prompt")
+	installCmd := exec.Command(exePath, "install", "--project", wtPath, "--no-prompt", "--no-pin", "--apply-overrides-only")
 	installCmd.Dir = wtPath
 	if out, err := installCmd.CombinedOutput(); err != nil {
 		fmt.Fprintf(os.Stderr, "    \033[33mInstall warning for %s: %s\033[0m\n", ms.ID, strings.TrimSpace(string(out)))
@@ -8740,6 +9438,20 @@ func runMilestoneInWorktree(cfg loopConfig, ms milestone, branch, wtPath string,
 		tracker.setPort(ms.ID, port)
 	}
 
+	// Named ports from worktree.json (BELMONT_PORT_<NAME>)
+	if ports, err := allocateNamedPorts(hooks, port); err != nil {
+		fmt.Fprintf(os.Stderr, "    \033[33m⚠ %s\033[0m\n", err)
+	} else if len(ports) > 0 {
+		fmt.Fprintf(os.Stderr, "    Named ports for %s: %s\n", ms.ID, formatNamedPorts(ports))
+		wtEnv = withNamedPorts(wtEnv, ports)
+		ctr.publish(ports)
+		if tracker != nil {
+			tracker.setPorts(ms.ID, ports)
+		}
+	}
+
+	wtEnv = withAffectedWorkspaces(wtEnv, scope)
+
 	if mType != monorepoNone {
 		fmt.Fprintf(os.Stderr, "    Detected %s monorepo (%d workspaces, primary=%s)\n", mType, len(workspaces), primary)
 	}
@@ -8747,14 +9459,18 @@ func runMilestoneInWorktree(cfg loopConfig, ms milestone, branch, wtPath string,
 	// Run worktree setup hooks
 	if hooks != nil && len(hooks.Setup) > 0 {
 		fmt.Fprintf(os.Stderr, "    Running worktree setup hooks for %s...\n", ms.ID)
-		if err := runWorktreeHookCommands(hooks.Setup, wtPath, port, hooks.Env, workspaces, primary, mType); err != nil {
+		if err := runWorktreeHookCommands(hooks.Setup, wtPath, port, wtEnv, workspaces, primary, mType, ctr); err != nil {
 			return fmt.Errorf("worktree setup for %s: %w", ms.ID, err)
 		}
-	} else if hooks == nil {
+	}
+	if err := runWorkspaceSetupHooks(hooks, scope, wtPath, port, wtEnv, workspaces, primary, mType, ctr); err != nil {
+		return fmt.Errorf("worktree setup for %s: %w", ms.ID, err)
+	}
+	if hooks == nil {
 		// No worktree.json — auto-detect dependency install from lock files
 		if cmds := detectAutoInstallCommands(cfg.Root); len(cmds) > 0 {
 			fmt.Fprintf(os.Stderr, "    Auto-installing dependencies for %s (%s)...\n", ms.ID, strings.Join(cmds, ", "))
-			if err := runWorktreeHookCommands(cmds, wtPath, port, nil, workspaces, primary, mType); err != nil {
+			if err := runWorktreeHookCommands(cmds, wtPath, port, nil, workspaces, primary, mType, nil); err != nil {
 				fmt.Fprintf(os.Stderr, "    \033[33m⚠ Auto-install failed for %s: %s (continuing)\033[0m\n", ms.ID, err)
 			}
 		}
@@ -8768,12 +9484,14 @@ func runMilestoneInWorktree(cfg loopConfig, ms milestone, branch, wtPath string,
 	mCfg.Port = port
 	mCfg.Tracker = tracker
 	mCfg.TrackerID = ms.ID
+	if cfg.MergeAsYouGo {
+		mCfg.MainRoot = cfg.Root
+	}
 	mCfg.Workspaces = workspaces
 	mCfg.PrimaryWorkspace = primary
 	mCfg.MonorepoType = mType
-	if hooks != nil {
-		mCfg.WorktreeEnv = hooks.Env
-	}
+	mCfg.WorktreeEnv = wtEnv
+	mCfg.Container = ctr
 
 	// Load per-feature model tiers from the worktree's copy of models.yaml.
 	if t, err := parseModelTiers(filepath.Join(wtPath, ".belmont", "features", cfg.Feature, "models.yaml")); err == nil {
@@ -8802,6 +9520,10 @@ func mergeWorktreeBranch(cfg loopConfig, milestoneID, branch, wtPath string, tra
 		fmt.Fprintf(os.Stderr, "  \033[33m⚠ Failed to commit worktree changes for %s: %s\033[0m\n", milestoneID, err)
 	}
 
+	if m := pullRequestMode(loadWorktreeHooks(cfg.Root)); m != nil {
+		return openPullRequest(cfg, m, milestoneID, branch, wtPath, tracker)
+	}
+
 	commitMsg := fmt.Sprintf("belmont: merge %s (%s)", milestoneID, msName)
 
 	if err := attemptMerge(cfg, commitMsg, branch, milestoneID); err != nil {
@@ -8819,9 +9541,9 @@ func mergeWorktreeBranch(cfg loopConfig, milestoneID, branch, wtPath string, tra
 	// Clean up reconciliation report if it exists
 	os.Remove(filepath.Join(cfg.Root, ".belmont", "reconciliation-report.json"))
 
-	// Run teardown hooks and clean up worktree
+	// Run teardown hooks, then return the worktree to the pool or remove it
 	tracker.teardownEntry(milestoneID)
-	removeWorktree(cfg.Root, wtPath, milestoneID)
+	releaseUnitWorktree(cfg.Root, wtPath, milestoneID)
 	tracker.remove(milestoneID)
 
 	// Delete the branch
@@ -8850,12 +9572,39 @@ func runReconciliationAgent(cfg loopConfig, milestoneID, branch string) error {
 		return fmt.Errorf("no conflicted files found")
 	}
 
+	// Files reviewed with `recover --merge --dry-run` against these exact
+	// sides apply straight from the saved review; only the rest are analysed.
+	if cfg.Review != nil {
+		reused, rejected, rest := partitionReviewedConflicts(cfg.Root, cfg.Review, strings.Split(conflictedFiles, "\n"))
+		if len(rejected) > 0 {
+			return fmt.Errorf("resolution rejected in review: %s", strings.Join(rejected, ", "))
+		}
+		if len(reused) > 0 {
+			fmt.Fprintf(os.Stderr, "  \033[2mApplying %d reviewed resolution(s)\033[0m\n", len(reused))
+			if err := applyReconciliationReport(cfg, reconciliationReport{Files: reused}); err != nil {
+				return err
+			}
+			var reusedFiles []string
+			for _, f := range reused {
+				reusedFiles = append(reusedFiles, f.File)
+				cfg.Audit.record(cfg.Review.reviewed(f), resolutionReviewed)
+			}
+			if err := verifyNoConflictMarkers(cfg.Root, reusedFiles); err != nil {
+				return err
+			}
+		}
+		if len(rest) == 0 {
+			return nil
+		}
+		conflictedFiles = strings.Join(rest, "\n")
+	}
+
 	reportPath := filepath.Join(cfg.Root, ".belmont", "reconciliation-report.json")
 
 	// Pass 1: AI analysis — writes structured report to disk
 	if err := runReconciliationAnalysis(cfg, milestoneID, branch, conflictedFiles, reportPath); err != nil {
 		fmt.Fprintf(os.Stderr, "  \033[33m⚠ Analysis failed, falling back to legacy resolve...\033[0m\n")
-		return runLegacyReconciliation(cfg, milestoneID, branch, conflictedFiles)
+		return runAuditedLegacyReconciliation(cfg, milestoneID, branch, conflictedFiles)
 	}
 
 	// Read and parse the report
@@ -8863,7 +9612,7 @@ func runReconciliationAgent(cfg loopConfig, milestoneID, branch string) error {
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "  \033[33m⚠ Invalid report (%v), falling back to legacy resolve...\033[0m\n", err)
 		os.Remove(reportPath)
-		return runLegacyReconciliation(cfg, milestoneID, branch, conflictedFiles)
+		return runAuditedLegacyReconciliation(cfg, milestoneID, branch, conflictedFiles)
 	}
 
 	// Pass 2: Apply resolutions
@@ -8901,6 +9650,8 @@ Conflicted files:
 Milestone/Feature: %s
 Branch: %s
 
+Import-only and end-of-file append hunks have already been resolved deterministically; source files may still hold other hunks, shown in diff3 form (ours, then the common base after "|||||||", then theirs).
+
 TASK: For each conflicted file:
 1. Read the file to see the conflict markers
 2. Understand what each side intended (both sides are valid completed work)
@@ -9008,8 +9759,12 @@ func applyReconciliationReport(cfg loopConfig, report reconciliationReport) erro
 
 	if len(unresolvable) > 0 {
 		fmt.Fprintf(os.Stderr, "  \033[31m✗ %d file(s) marked unresolvable — aborting merge:\033[0m\n", len(unresolvable))
+		for _, f := range report.Files {
+			cfg.Audit.record(f, "")
+		}
 		for _, f := range unresolvable {
 			fmt.Fprintf(os.Stderr, "    %s: %s\n", f.File, f.Reason)
+			cfg.Audit.record(f, resolutionUnresolvable)
 		}
 		return fmt.Errorf("unresolvable conflicts in %d file(s)", len(unresolvable))
 	}
@@ -9042,10 +9797,19 @@ func applyReconciliationReport(cfg loopConfig, report reconciliationReport) erro
 			addCmd := exec.Command("git", "add", f.File)
 			addCmd.Dir = cfg.Root
 			addCmd.Run()
+			cfg.Audit.record(f, resolutionRegenerated)
 			continue
 		}
 
 		if f.Confidence == "high" || !interactive || autoAll {
+			switch {
+			case f.Confidence == "high":
+				cfg.Audit.record(f, resolutionAuto)
+			case autoAll:
+				cfg.Audit.record(f, resolutionAcceptedAll)
+			default:
+				cfg.Audit.record(f, resolutionUnattended)
+			}
 			// Auto-apply
 			if err := writeReconciliationResolution(filePath, f.ResolvedContent); err != nil {
 				return fmt.Errorf("write %s: %w", f.File, err)
@@ -9067,6 +9831,7 @@ func applyReconciliationReport(cfg loopConfig, report reconciliationReport) erro
 		switch choice {
 		case "auto":
 			autoAll = true
+			cfg.Audit.record(f, resolutionAcceptedAll)
 			// Apply this file and all remaining
 			if err := writeReconciliationResolution(filePath, f.ResolvedContent); err != nil {
 				return fmt.Errorf("write %s: %w", f.File, err)
@@ -9077,6 +9842,11 @@ func applyReconciliationReport(cfg loopConfig, report reconciliationReport) erro
 				return fmt.Errorf("git add %s: %w", f.File, err)
 			}
 		case "accept", "edited":
+			if choice == "accept" {
+				cfg.Audit.record(f, resolutionAccepted)
+			} else {
+				cfg.Audit.record(f, resolutionEdited)
+			}
 			// File already written by reviewConflict for "edited", write for "accept"
 			if choice == "accept" {
 				if err := writeReconciliationResolution(filePath, f.ResolvedContent); err != nil {
@@ -9219,7 +9989,7 @@ func reviewConflict(root string, f reconciliationFile) (string, error) {
 
 // verifyNoConflictMarkers scans resolved files for leftover conflict markers.
 func verifyNoConflictMarkers(root string, files []string) error {
-	markers := []string{"<<<<<<<", "=======", ">>>>>>>"}
+	markers := []string{"<<<<<<<", "|||||||", "=======", ">>>>>>>"}
 	var badFiles []string
 
 	for _, file := range files {
@@ -9256,6 +10026,8 @@ Conflicted files:
 Milestone/Feature: %s
 Branch: %s
 
+Import-only and end-of-file append hunks have already been resolved; remaining hunks in source files are in diff3 form (ours, then the common base after "|||||||", then theirs).
+
 Rules:
 1. ALWAYS combine both sides — never choose one side over the other. This is non-negotiable.
 2. Include all imports from both sides (remove exact duplicates only)
@@ -9371,8 +10143,79 @@ func announceWorktreeRebase(id string, newCommits int, err error) {
 	}
 }
 
-// removeWorktree removes a git worktree and its directory.
+// rebaseOnMainAtBoundary is the --merge-as-you-go hook runLoop calls between
+// actions inside a worktree. When a sibling has merged to main since this
+// worktree forked, pending work is committed and the worktree is rebased
+// onto the new tip so the next action builds on it. A conflicting rebase is
+// aborted and turned into a steering entry — once per main tip — so the
+// agent reconciles while it still has context, rather than leaving it to
+// the reconciliation agent at merge time. Returns the main tip that was
+// last steered about.
+func rebaseOnMainAtBoundary(cfg loopConfig, steeredTip string) string {
+	mainSHA := captureGitSHA(cfg.MainRoot)
+	if mainSHA == "" || mainSHA == steeredTip {
+		return steeredTip
+	}
+	ancCmd := exec.Command("git", "merge-base", "--is-ancestor", mainSHA, "HEAD")
+	ancCmd.Dir = cfg.Root
+	if ancCmd.Run() == nil {
+		return steeredTip // already on top of main
+	}
+
+	label := cfg.Feature
+	if cfg.TrackerID != "" {
+		label = cfg.TrackerID
+	}
+	if out, err := exec.Command("git", "-C", cfg.Root, "status", "--porcelain").Output(); err == nil && strings.TrimSpace(string(out)) != "" {
+		exec.Command("git", "-C", cfg.Root, "add", "-A").Run()
+		if out, err := exec.Command("git", "-C", cfg.Root, "commit", "-m", fmt.Sprintf("belmont: checkpoint %s before rebase", label)).CombinedOutput(); err != nil {
+			fmt.Fprintf(os.Stderr, "  \033[33m⚠ Could not checkpoint %s before rebase: %s\033[0m\n", label, strings.TrimSpace(string(out)))
+			return steeredTip
+		}
+	}
+
+	n, err := rebaseWorktreeOnMain(cfg.MainRoot, cfg.Root)
+	announceWorktreeRebase(label, n, err)
+	if err == nil || errors.Is(err, errWorktreeDirty) {
+		return steeredTip
+	}
+
+	// Conflict: name the files both sides touched so the agent knows where
+	// to look, then ask it to merge main in and resolve.
+	var overlap []string
+	ours := map[string]bool{}
+	for _, f := range branchTouchedFiles(cfg.MainRoot, captureGitSHA(cfg.Root)) {
+		ours[f] = true
+	}
+	for _, f := range branchTouchedFiles(cfg.Root, mainSHA) {
+		if ours[f] && !strings.HasPrefix(f, ".belmont/") {
+			overlap = append(overlap, f)
+		}
+	}
+	var body strings.Builder
+	short := mainSHA
+	if len(short) > 12 {
+		short = short[:12]
+	}
+	fmt.Fprintf(&body, "Sibling work has merged to main (now at %s) and this worktree no longer rebases cleanly onto it.\n", short)
+	if len(overlap) > 0 {
+		fmt.Fprintf(&body, "Files changed on both sides: %s.\n", strings.Join(overlap, ", "))
+	}
+	fmt.Fprintf(&body, "Before anything else, run `git merge %s` in this worktree, resolve the conflicts so both sides' changes survive, make sure the build still passes, and commit the merge.", short)
+	path := filepath.Join(cfg.Root, ".belmont", "features", cfg.Feature, "STEERING.md")
+	if err := appendSteeringEntry(path, time.Now().UTC().Format(time.RFC3339), "", body.String()); err != nil {
+		fmt.Fprintf(os.Stderr, "  \033[33m⚠ Failed to write conflict steering for %s: %s\033[0m\n", label, err)
+		return steeredTip
+	}
+	fmt.Fprintf(os.Stderr, "  \033[36m↻ Asked %s to merge main and resolve conflicts at its next action\033[0m\n", label)
+	return mainSHA
+}
+
+// removeWorktree removes a git worktree and its directory, along with any
+// container still running for it.
 func removeWorktree(root, wtPath, _ string) {
+	newWorktreeContainer(root, wtPath, loadWorktreeHooks(root)).stop()
+	teardownWorktreeServices(root, wtPath)
 	cmd := exec.Command("git", "worktree", "remove", "--force", wtPath)
 	cmd.Dir = root
 	if err := cmd.Run(); err != nil {
@@ -9425,6 +10268,8 @@ func getCurrentBranch(root string) string {
 // auto-commit (to scope `git add` so unrelated user work isn't swept up).
 //
 // Phase 2 actively writes: `.agents/belmont/`, `.agents/skills/belmont/`,
+// `.belmont/version.json` (the project version pin), `.belmont/overrides/`
+// (merged project overrides and their upstream bases),
 // `.claude/agents/belmont` (sub-agent symlink), and `.claude/commands/belmont/`
 // (per-skill slash-command symlinks → .agents/skills/belmont/<skill>/SKILL.md).
 // Every other entry below is a legacy path kept in the list so deletions of
@@ -9433,6 +10278,8 @@ func getCurrentBranch(root string) string {
 var belmontManagedPaths = []string{
 	".agents/belmont",
 	".agents/skills/belmont",
+	".belmont/version.json",
+	".belmont/overrides",
 	".claude/agents/belmont",
 	".claude/commands/belmont",
 	// Legacy (may be staged for deletion):
@@ -10077,9 +10924,21 @@ func attemptMerge(cfg loopConfig, commitMsg, branch, id string) error {
 	}
 
 handleConflict:
+	// Every path that conflicted, before any resolver runs — the merge gate
+	// checks these even when no agent was needed.
+	conflicted := conflictedPaths(cfg.Root)
+
 	// Try auto-resolving .belmont/ conflicts first (common with parallel milestones)
 	autoResolveBelmontConflicts(cfg.Root)
 
+	// Key-level union of manifests/config (package.json, go.mod, Cargo.toml,
+	// YAML). Runs before lock files so a resolved manifest unblocks its lock.
+	autoResolveStructuredConflicts(cfg.Root)
+
+	// Import-only and end-of-file append hunks in source files. Other hunks
+	// stay conflicted (with a diff3 base section) for the agent.
+	autoResolveSourceHunks(cfg.Root)
+
 	// Try auto-resolving lock files (delete + regenerate via package manager)
 	autoResolveLockFiles(cfg.Root)
 
@@ -10088,7 +10947,14 @@ handleConflict:
 		checkCmd := exec.Command("git", "diff", "--name-only", "--diff-filter=U")
 		checkCmd.Dir = cfg.Root
 		if checkOut, err := checkCmd.Output(); err == nil && strings.TrimSpace(string(checkOut)) == "" {
-			// All conflicts resolved — commit the merge
+			// All conflicts resolved — gate the combined tree like an agent
+			// reconciliation (a failure gets the repair pass), then commit.
+			if gateErr := runMergeGate(cfg, id, conflicted); gateErr != nil {
+				abortCmd := exec.Command("git", "merge", "--abort")
+				abortCmd.Dir = cfg.Root
+				abortCmd.Run()
+				return fmt.Errorf("merge gate failed for %s after auto-resolving conflicts: %w", id, gateErr)
+			}
 			commitCmd2 := exec.Command("git", "commit", "--no-edit")
 			commitCmd2.Dir = cfg.Root
 			if _, err := commitCmd2.CombinedOutput(); err == nil {
@@ -10100,6 +10966,16 @@ handleConflict:
 
 	fmt.Fprintf(os.Stderr, "  \033[33m⚠ Merge conflict for %s — invoking reconciliation agent...\033[0m\n", id)
 
+	reconciled := conflictedPaths(cfg.Root)
+	cfg.Audit = newReconciliationAudit(cfg, id, branch, reconciled)
+	err = reconcileAndCommit(cfg, commitMsg, branch, id, reconciled)
+	cfg.Audit.finish(cfg.Root, err)
+	return err
+}
+
+// reconcileAndCommit runs the reconciliation agent and the merge gate on a
+// conflicted merge in cfg.Root and commits it, aborting the merge on failure.
+func reconcileAndCommit(cfg loopConfig, commitMsg, branch, id string, reconciled []string) error {
 	reconcileErr := runReconciliationAgent(cfg, id, branch)
 	if reconcileErr != nil {
 		abortCmd := exec.Command("git", "merge", "--abort")
@@ -10108,6 +10984,14 @@ handleConflict:
 		return fmt.Errorf("merge conflict resolution failed for %s: %w", id, reconcileErr)
 	}
 
+	// Build/test the reconciled tree before committing (merge_gate in worktree.json)
+	if gateErr := runMergeGate(cfg, id, reconciled); gateErr != nil {
+		abortCmd := exec.Command("git", "merge", "--abort")
+		abortCmd.Dir = cfg.Root
+		abortCmd.Run()
+		return fmt.Errorf("merge gate failed for %s: %w", id, gateErr)
+	}
+
 	// Reconciliation succeeded — commit the merge
 	commitCmd := exec.Command("git", "commit", "-m", commitMsg)
 	commitCmd.Dir = cfg.Root
@@ -10268,81 +11152,6 @@ func resolveProgressConflict(root, relPath, filePath string) bool {
 	return true
 }
 
-// autoResolveLockFiles detects conflicted lock files and regenerates them.
-// Only handles lock files whose corresponding manifest is NOT conflicted
-// (if the manifest is also conflicted, the AI agent needs to handle both together).
-func autoResolveLockFiles(root string) {
-	cmd := exec.Command("git", "diff", "--name-only", "--diff-filter=U")
-	cmd.Dir = root
-	out, err := cmd.Output()
-	if err != nil {
-		return
-	}
-
-	// Map lock file → (package manager install command, manifest file)
-	lockFileMap := map[string]struct {
-		installCmd string
-		manifest   string
-	}{
-		"package-lock.json": {"npm install", "package.json"},
-		"pnpm-lock.yaml":    {"pnpm install", "package.json"},
-		"yarn.lock":         {"yarn install", "package.json"},
-		"bun.lockb":         {"bun install", "package.json"},
-		"Cargo.lock":        {"cargo generate-lockfile", "Cargo.toml"},
-		"go.sum":            {"go mod tidy", "go.mod"},
-		"Gemfile.lock":      {"bundle install", "Gemfile"},
-		"poetry.lock":       {"poetry lock --no-update", "pyproject.toml"},
-	}
-
-	conflicted := make(map[string]bool)
-	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
-		line = strings.TrimSpace(line)
-		if line != "" {
-			conflicted[line] = true
-		}
-	}
-
-	for _, file := range strings.Split(strings.TrimSpace(string(out)), "\n") {
-		file = strings.TrimSpace(file)
-		// Only handle lock files in the repo root (basename match)
-		baseName := filepath.Base(file)
-		info, isLock := lockFileMap[baseName]
-		if !isLock {
-			continue
-		}
-
-		// Check if the corresponding manifest is also conflicted
-		manifestPath := filepath.Join(filepath.Dir(file), info.manifest)
-		if conflicted[manifestPath] {
-			// Both conflicted — leave for the AI agent to handle together
-			continue
-		}
-
-		fmt.Fprintf(os.Stderr, "  \033[2mAuto-resolving %s via %s\033[0m\n", file, info.installCmd)
-
-		// Delete the conflicted lock file
-		os.Remove(filepath.Join(root, file))
-
-		// Run the package manager to regenerate
-		parts := strings.Fields(info.installCmd)
-		installCmd := exec.Command(parts[0], parts[1:]...)
-		installCmd.Dir = root
-		if installOut, err := installCmd.CombinedOutput(); err != nil {
-			fmt.Fprintf(os.Stderr, "  \033[33m⚠ Failed to regenerate %s: %s\033[0m\n", file, strings.TrimSpace(string(installOut)))
-			// Restore the conflicted version so git knows it's still unresolved
-			checkoutCmd := exec.Command("git", "checkout", "--merge", "--", file)
-			checkoutCmd.Dir = root
-			checkoutCmd.Run()
-			continue
-		}
-
-		// Stage the regenerated lock file
-		addCmd := exec.Command("git", "add", file)
-		addCmd.Dir = root
-		addCmd.Run()
-	}
-}
-
 // listPreservedWorktrees finds worktrees that still exist.
 // Checks both the new sibling location and the legacy .belmont/worktrees/ path.
 func listPreservedWorktrees(root string) []worktreeEntry {
@@ -10730,6 +11539,9 @@ func runRecover(args []string) error {
 	var clean string
 	var cleanAll bool
 	var tool string
+	var dryRun bool
+	var history bool
+	var hotspots bool
 	fs.StringVar(&root, "root", ".", "project root")
 	fs.StringVar(&format, "format", "text", "text or json")
 	fs.BoolVar(&list, "list", false, "list preserved worktrees")
@@ -10737,19 +11549,29 @@ func runRecover(args []string) error {
 	fs.StringVar(&clean, "clean", "", "delete worktree and branch for slug")
 	fs.BoolVar(&cleanAll, "clean-all", false, "clean all preserved worktrees")
 	fs.StringVar(&tool, "tool", "", "CLI tool for reconciliation (claude|codex|gemini|copilot|cursor|pi) — auto-detected if omitted")
+	fs.BoolVar(&dryRun, "dry-run", false, "with --merge: review proposed resolutions without writing anything")
+	fs.BoolVar(&history, "history", false, "list archived reconciliation records and conflict hotspots")
+	fs.BoolVar(&hotspots, "hotspots", false, "list only files that conflicted in more than one milestone")
 	if err := fs.Parse(args); err != nil {
 		return fmt.Errorf("recover: %w", err)
 	}
+	if dryRun && merge == "" {
+		return fmt.Errorf("recover: --dry-run requires --merge")
+	}
 
 	root, err := filepath.Abs(root)
 	if err != nil {
 		return err
 	}
 
+	if history || hotspots {
+		return recoverHistory(root, format, hotspots)
+	}
+
 	worktrees := listPreservedWorktrees(root)
 
 	if merge != "" {
-		return recoverMerge(root, merge, tool, worktrees)
+		return recoverMerge(root, merge, tool, dryRun, worktrees)
 	}
 	if clean != "" {
 		return recoverClean(root, clean, worktrees)
@@ -10797,8 +11619,11 @@ func recoverList(root string, worktrees []worktreeEntry, format string) error {
 	}
 	fmt.Println("Actions:")
 	fmt.Println("  belmont recover --merge <slug>    Retry merge with improved logic (uses --tool for reconciliation)")
+	fmt.Println("  belmont recover --merge <slug> --dry-run")
+	fmt.Println("                                    Review proposed resolutions as diffs; decisions are saved for the next --merge")
 	fmt.Println("  belmont recover --clean <slug>    Delete worktree and branch")
 	fmt.Println("  belmont recover --clean-all       Clean all preserved worktrees")
+	fmt.Println("  belmont recover --history         List past reconciliations and conflict hotspots")
 	return nil
 }
 
@@ -10811,7 +11636,7 @@ func findWorktree(worktrees []worktreeEntry, slug string) *worktreeEntry {
 	return nil
 }
 
-func recoverMerge(root, slug, tool string, worktrees []worktreeEntry) error {
+func recoverMerge(root, slug, tool string, dryRun bool, worktrees []worktreeEntry) error {
 	wt := findWorktree(worktrees, slug)
 	if wt == nil {
 		return fmt.Errorf("no preserved worktree found for slug: %s", slug)
@@ -10833,15 +11658,31 @@ func recoverMerge(root, slug, tool string, worktrees []worktreeEntry) error {
 		}
 	}
 
+	if dryRun {
+		return recoverMergeDryRun(root, slug, tool, wt)
+	}
+
+	review, err := loadReviewDecisions(root, slug)
+	if err != nil {
+		return fmt.Errorf("recover: %w", err)
+	}
+	if rejected := review.rejected(); len(rejected) > 0 {
+		return fmt.Errorf("recover: %d resolution(s) rejected in review (%s)\n\nRe-run belmont recover --merge %s --dry-run for fresh proposals, or delete %s to merge without the review", len(rejected), strings.Join(rejected, ", "), slug, reviewDecisionsPath(root, slug))
+	}
+	if review != nil {
+		fmt.Fprintf(os.Stderr, "  \033[2mUsing saved review (%d file(s)) from %s\033[0m\n", len(review.Files), review.UpdatedAt)
+	}
+
 	commitMsg := fmt.Sprintf("belmont: merge recovered %s", slug)
-	cfg := loopConfig{Root: root, Tool: tool}
+	cfg := loopConfig{Root: root, Tool: tool, Review: review}
 
 	if err := attemptMerge(cfg, commitMsg, wt.Branch, slug); err != nil {
 		return fmt.Errorf("merge failed for %s: %w", slug, err)
 	}
 
-	// Clean up reconciliation report if it exists
+	// Clean up reconciliation report and saved review if they exist
 	os.Remove(filepath.Join(root, ".belmont", "reconciliation-report.json"))
+	os.Remove(reviewDecisionsPath(root, slug))
 
 	// Clean up worktree and branch
 	removeWorktree(root, wt.Path, slug)
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ============================================================================
+// Post-reconciliation merge gate
+//
+// Reconciliation removes conflict markers; it does not prove the combined
+// code still builds. When .belmont/worktree.json configures a merge_gate,
+// attemptMerge runs its commands on the merged tree after the reconciliation
+// agent and before the merge commit. In a monorepo only the workspaces the
+// merge touches are checked. A failure either gets one repair pass from the
+// agent (on_failure "reconcile", the default) or aborts the merge, which
+// preserves the worktree for `belmont recover`.
+// ============================================================================
+
+// Merge gate failure policies.
+const (
+	gateOnFailureReconcile = "reconcile"
+	gateOnFailureAbort     = "abort"
+)
+
+// mergeGateConfig is the merge_gate block of worktree.json.
+type mergeGateConfig struct {
+	Commands          []string `json:"commands,omitempty"`           // run at the project root
+	WorkspaceCommands []string `json:"workspace_commands,omitempty"` // run inside each affected workspace
+	OnFailure         string   `json:"on_failure,omitempty"`         // "reconcile" (default) or "abort"
+}
+
+// gateStep is one directory's worth of gate commands.
+type gateStep struct {
+	Label    string // "root" or the workspace ID
+	Dir      string // relative to the project root ("." for the root)
+	Commands []string
+}
+
+// gateFailure describes the first gate command that failed.
+type gateFailure struct {
+	Step    gateStep
+	Command string
+	Output  string
+}
+
+// mergeGatePlan decides which gate commands to run for a merge that changed
+// the given paths. Single-package projects run the root commands. In a
+// monorepo each affected workspace runs its own `gate` override or the
+// shared workspace_commands; the root commands run when a changed file lies
+// outside every workspace, or when no workspace step applies. Pure apart
+// from workspace detection.
+func mergeGatePlan(root string, hooks *worktreeHooks, changed []string) []gateStep {
+	if hooks == nil || hooks.MergeGate == nil {
+		return nil
+	}
+	gate := hooks.MergeGate
+	var relevant []string
+	for _, f := range changed {
+		if f != "" && !strings.HasPrefix(f, ".belmont/") {
+			relevant = append(relevant, f)
+		}
+	}
+	if len(relevant) == 0 {
+		return nil
+	}
+	rootStep := gateStep{Label: "root", Dir: ".", Commands: gate.Commands}
+
+	workspaces, _, _ := resolveWorkspaces(root, hooks)
+	if len(workspaces) == 0 {
+		if len(rootStep.Commands) == 0 {
+			return nil
+		}
+		return []gateStep{rootStep}
+	}
+
+	affected := map[string]bool{}
+	outside := false
+	for _, f := range relevant {
+		if ws := owningWorkspace(workspaces, f); ws != nil {
+			affected[ws.ID] = true
+		} else {
+			outside = true
+		}
+	}
+
+	var steps []gateStep
+	for _, ws := range workspaces {
+		if !affected[ws.ID] {
+			continue
+		}
+		cmds := gate.WorkspaceCommands
+		if o, ok := hooks.Workspaces[ws.ID]; ok && len(o.Gate) > 0 {
+			cmds = o.Gate
+		}
+		if len(cmds) > 0 {
+			steps = append(steps, gateStep{Label: ws.ID, Dir: ws.Path, Commands: cmds})
+		}
+	}
+	sort.Slice(steps, func(i, j int) bool { return steps[i].Label < steps[j].Label })
+	if len(rootStep.Commands) > 0 && (outside || len(steps) == 0) {
+		steps = append([]gateStep{rootStep}, steps...)
+	}
+	return steps
+}
+
+// owningWorkspace returns the deepest workspace whose path contains file.
+func owningWorkspace(workspaces []workspaceInfo, file string) *workspaceInfo {
+	var best *workspaceInfo
+	for i := range workspaces {
+		p := filepath.ToSlash(filepath.Clean(workspaces[i].Path))
+		if p == "." || p == "" {
+			continue
+		}
+		if file == p || strings.HasPrefix(file, p+"/") {
+			if best == nil || len(p) > len(filepath.ToSlash(best.Path)) {
+				best = &workspaces[i]
+			}
+		}
+	}
+	return best
+}
+
+// mergeChangedFiles lists the paths a merge in progress changes relative to
+// HEAD, as staged in the index.
+func mergeChangedFiles(root string) []string {
+	cmd := exec.Command("git", "diff", "--cached", "--name-only", "HEAD")
+	cmd.Dir = root
+	out, err := cmd.Output()
+	if err != nil {
+		return nil
+	}
+	var files []string
+	for _, l := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if l = strings.TrimSpace(l); l != "" {
+			files = append(files, l)
+		}
+	}
+	return files
+}
+
+// runGateSteps runs each step's commands in order and stops at the first
+// failure. Output is captured so it can be handed to the repair pass.
+func runGateSteps(root string, steps []gateStep, env []string) *gateFailure {
+	for _, step := range steps {
+		for _, c := range step.Commands {
+			fmt.Fprintf(os.Stderr, "    \033[2m[%s] %s\033[0m\n", step.Label, c)
+			cmd := exec.Command("sh", "-c", c)
+			cmd.Dir = filepath.Join(root, step.Dir)
+			cmd.Env = env
+			out, err := cmd.CombinedOutput()
+			if err != nil {
+				return &gateFailure{Step: step, Command: c, Output: string(out)}
+			}
+		}
+	}
+	return nil
+}
+
+// tailOutput keeps the last max bytes of command output for prompts/logs.
+func tailOutput(s string, max int) string {
+	s = strings.TrimSpace(s)
+	if len(s) <= max {
+		return s
+	}
+	return "…" + s[len(s)-max:]
+}
+
+// runMergeGate checks the resolved merge in cfg.Root before it is
+// committed. reconciled lists the files that conflicted, whether the agent
+// or a deterministic resolver settled them; a repair pass is told to keep
+// its edits to those. Returns nil when no gate is
+// configured or the gate passes.
+func runMergeGate(cfg loopConfig, id string, reconciled []string) error {
+	hooks := loadWorktreeHooks(cfg.Root)
+	steps := mergeGatePlan(cfg.Root, hooks, mergeChangedFiles(cfg.Root))
+	if len(steps) == 0 {
+		return nil
+	}
+	workspaces, primary, mType := resolveWorkspaces(cfg.Root, hooks)
+	env := buildWorktreeEnv(0, hooks.Env, workspaces, primary, mType)
+
+	var labels []string
+	for _, s := range steps {
+		labels = append(labels, s.Label)
+	}
+	fmt.Fprintf(os.Stderr, "  \033[36m▶ Merge gate for %s\033[0m (%s)\n", id, strings.Join(labels, ", "))
+	fail := runGateSteps(cfg.Root, steps, env)
+	if fail == nil {
+		fmt.Fprintf(os.Stderr, "  \033[32m✓ Merge gate passed for %s\033[0m\n", id)
+		cfg.Audit.setGate(gateResultPassed)
+		return nil
+	}
+	fmt.Fprintf(os.Stderr, "  \033[31m✗ Merge gate failed for %s: [%s] %s\033[0m\n", id, fail.Step.Label, fail.Command)
+	fmt.Fprintf(os.Stderr, "\033[2m%s\033[0m\n", tailOutput(fail.Output, 2000))
+	cfg.Audit.setGate(gateResultFailed)
+
+	switch hooks.MergeGate.OnFailure {
+	case gateOnFailureAbort:
+		return fmt.Errorf("[%s] %s failed", fail.Step.Label, fail.Command)
+	case "", gateOnFailureReconcile:
+	default:
+		fmt.Fprintf(os.Stderr, "  \033[33m⚠ Unknown merge_gate.on_failure %q — using %q\033[0m\n", hooks.MergeGate.OnFailure, gateOnFailureReconcile)
+	}
+
+	fmt.Fprintf(os.Stderr, "  \033[33m⚠ Handing the failure back to the reconciliation agent for one more pass...\033[0m\n")
+	if err := runGateRepairAgent(cfg, id, fail, reconciled); err != nil {
+		return fmt.Errorf("repair pass: %w", err)
+	}
+	// Stage whatever the repair touched; untracked build output is left alone.
+	addCmd := exec.Command("git", "add", "-u")
+	addCmd.Dir = cfg.Root
+	addCmd.Run()
+
+	fmt.Fprintf(os.Stderr, "  \033[36m▶ Re-running merge gate for %s\033[0m\n", id)
+	if fail := runGateSteps(cfg.Root, steps, env); fail != nil {
+		fmt.Fprintf(os.Stderr, "\033[2m%s\033[0m\n", tailOutput(fail.Output, 2000))
+		return fmt.Errorf("[%s] %s still failing after repair", fail.Step.Label, fail.Command)
+	}
+	fmt.Fprintf(os.Stderr, "  \033[32m✓ Merge gate passed for %s after repair\033[0m\n", id)
+	cfg.Audit.setGate(gateResultRepaired)
+	return nil
+}
+
+// runGateRepairAgent gives the reconciliation agent one pass at fixing a
+// merged tree that no longer builds or passes tests.
+func runGateRepairAgent(cfg loopConfig, id string, fail *gateFailure, reconciled []string) error {
+	prompt := fmt.Sprintf(`You are a merge conflict reconciliation agent. A merge was just reconciled, but the combined code fails the project's merge gate. Fix it.
+
+CRITICAL: Read the file .agents/belmont/reconciliation-agent.md (or agents/belmont/reconciliation-agent.md) for full instructions. Those instructions are authoritative.
+
+CORE PRINCIPLE: Both sides of the merge are intentional, completed work. Fix the combination — do not delete functionality from either side to make the gate pass. If the failure cannot be fixed without losing work, make no changes.
+
+Milestone/Feature: %s
+Files resolved during reconciliation:
+%s
+
+Failing command (run in %s):
+%s
+
+Output:
+%s
+
+Rules:
+1. Prefer editing the reconciled files listed above; touch other files only when the failure is clearly caused by the merge
+2. Do not edit tests to make them pass unless the test itself was mis-merged
+3. Do NOT commit and do NOT run git merge/abort — the caller re-runs the gate and commits`, id, strings.Join(reconciled, "\n"), fail.Step.Dir, fail.Command, tailOutput(fail.Output, 6000))
+
+	flags := resolveModelFlags(cfg.Tool, reconciliationTier(cfg.ModelTiers), cfg.Root)
+	cmd := buildToolCommand(cfg.Tool, prompt, cfg.Root, flags...)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func gateLabels(steps []gateStep) []string {
+	var out []string
+	for _, s := range steps {
+		out = append(out, s.Label+":"+strings.Join(s.Commands, ";"))
+	}
+	return out
+}
+
+func TestMergeGatePlan_SinglePackage(t *testing.T) {
+	root := t.TempDir()
+	hooks := &worktreeHooks{MergeGate: &mergeGateConfig{Commands: []string{"go build ./...", "go test ./..."}}}
+	got := gateLabels(mergeGatePlan(root, hooks, []string{"main.go"}))
+	if !equalStringSlices(got, []string{"root:go build ./...;go test ./..."}) {
+		t.Errorf("plan = %v", got)
+	}
+	if steps := mergeGatePlan(root, hooks, []string{".belmont/features/a/PROGRESS.md"}); len(steps) != 0 {
+		t.Errorf("state-only merge should not gate, got %v", gateLabels(steps))
+	}
+	if steps := mergeGatePlan(root, &worktreeHooks{}, []string{"main.go"}); len(steps) != 0 {
+		t.Errorf("no merge_gate configured should not gate, got %v", gateLabels(steps))
+	}
+}
+
+func TestMergeGatePlan_OnlyAffectedWorkspaces(t *testing.T) {
+	root := t.TempDir()
+	hooks := &worktreeHooks{
+		Workspaces: map[string]workspaceOverride{
+			"web":    {Path: "apps/web"},
+			"api":    {Path: "services/api", Gate: []string{"go test ./..."}},
+			"shared": {Path: "packages/shared"},
+		},
+		MergeGate: &mergeGateConfig{
+			Commands:          []string{"make lint"},
+			WorkspaceCommands: []string{"npm test --if-present"},
+		},
+	}
+
+	got := gateLabels(mergeGatePlan(root, hooks, []string{"apps/web/page.tsx", "services/api/main.go"}))
+	if !equalStringSlices(got, []string{"api:go test ./...", "web:npm test --if-present"}) {
+		t.Errorf("plan = %v", got)
+	}
+
+	got = gateLabels(mergeGatePlan(root, hooks, []string{"apps/web/page.tsx", "Makefile"}))
+	if !equalStringSlices(got, []string{"root:make lint", "web:npm test --if-present"}) {
+		t.Errorf("file outside workspaces should add the root step, plan = %v", got)
+	}
+}
+
+func TestRunMergeGate_AbortPolicyFailsAndPassingGateSucceeds(t *testing.T) {
+	root := setupConflictedMerge(t)
+	writeFile(t, root, "notes.txt", "beta\ngamma\n")
+	runGit(t, root, "add", "notes.txt")
+
+	writeFile(t, root, ".belmont/worktree.json", `{"merge_gate": {"commands": ["grep -q beta notes.txt", "test -f built.txt"], "on_failure": "abort"}}`)
+	err := runMergeGate(loopConfig{Root: root}, "side", []string{"notes.txt"})
+	if err == nil || !strings.Contains(err.Error(), "test -f built.txt") {
+		t.Fatalf("err = %v, want failure naming the failing command", err)
+	}
+
+	writeFile(t, root, "built.txt", "")
+	if err := runMergeGate(loopConfig{Root: root}, "side", []string{"notes.txt"}); err != nil {
+		t.Errorf("passing gate returned %v", err)
+	}
+	// The gate never commits: the merge is still in progress for the caller.
+	if err := exec.Command("git", "-C", root, "rev-parse", "-q", "--verify", "MERGE_HEAD").Run(); err != nil {
+		t.Error("MERGE_HEAD gone — gate must leave the commit to attemptMerge")
+	}
+}
+
+func TestAttemptMerge_GatesAutoResolvedConflicts(t *testing.T) {
+	setup := func(gate string) string {
+		root := t.TempDir()
+		runGit(t, root, "init", "-q", "-b", "main")
+		runGit(t, root, "config", "user.email", "t@example.com")
+		runGit(t, root, "config", "user.name", "t")
+		writeFile(t, root, "app.py", "import os\n\n\ndef main():\n    pass\n")
+		runGit(t, root, "add", "-A")
+		runGit(t, root, "commit", "-q", "-m", "base")
+		runGit(t, root, "checkout", "-q", "-b", "side")
+		writeFile(t, root, "app.py", "import os\nimport re\n\n\ndef main():\n    pass\n")
+		runGit(t, root, "commit", "-q", "-am", "re")
+		runGit(t, root, "checkout", "-q", "main")
+		writeFile(t, root, "app.py", "import os\nimport json\n\n\ndef main():\n    pass\n")
+		runGit(t, root, "commit", "-q", "-am", "json")
+		writeFile(t, root, ".belmont/worktree.json", `{"merge_gate": {"commands": ["`+gate+`"], "on_failure": "abort"}}`)
+		return root
+	}
+
+	root := setup("false")
+	head := runGit(t, root, "rev-parse", "HEAD")
+	if err := attemptMerge(loopConfig{Root: root}, "merge side", "side", "side"); err == nil || !strings.Contains(err.Error(), "merge gate failed") {
+		t.Fatalf("err = %v, want merge gate failure", err)
+	}
+	if got := runGit(t, root, "rev-parse", "HEAD"); got != head {
+		t.Error("auto-resolved merge was committed despite a failing gate")
+	}
+	if err := exec.Command("git", "-C", root, "rev-parse", "-q", "--verify", "MERGE_HEAD").Run(); err == nil {
+		t.Error("MERGE_HEAD left behind after the gate failed")
+	}
+
+	root = setup("grep -q json app.py && grep -q re app.py")
+	if err := attemptMerge(loopConfig{Root: root}, "merge side", "side", "side"); err != nil {
+		t.Fatalf("attemptMerge: %v", err)
+	}
+	if out := runGit(t, root, "log", "-1", "--format=%P"); len(strings.Fields(out)) != 2 {
+		t.Errorf("HEAD is not a merge commit: %q", out)
+	}
+}
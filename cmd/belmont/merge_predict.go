@@ -0,0 +1,188 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ============================================================================
+// Pre-merge conflict prediction
+//
+// reportMergeOverlap only speaks up at merge time, after every sibling has
+// finished and the reconciliation agent is the only way out. At each action
+// boundary a parallel worktree instead trial-merges its HEAD against every
+// in-flight sibling branch (and against main) with `git merge-tree`, which
+// touches no worktree or index. A predicted conflict is turned into steering
+// for both sides while the agents can still keep their edits compatible.
+// ============================================================================
+
+// predictMergeConflicts trial-merges two commits with `git merge-tree
+// --write-tree` (git >= 2.38) and returns the paths that would conflict,
+// ignoring .belmont/ state. ok is false when git cannot answer (older git,
+// unknown commit); callers then skip the prediction silently.
+func predictMergeConflicts(root, ours, theirs string) (files []string, ok bool) {
+	if ours == "" || theirs == "" || ours == theirs {
+		return nil, true
+	}
+	cmd := exec.Command("git", "merge-tree", "--write-tree", "--name-only", "--no-messages", ours, theirs)
+	cmd.Dir = root
+	out, err := cmd.Output()
+	if err == nil {
+		return nil, true
+	}
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 1 {
+		return nil, false
+	}
+	// Exit 1 means conflicts: first line is the tree OID, then one path per line.
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	for _, l := range lines[1:] {
+		l = strings.TrimSpace(l)
+		if l == "" || strings.HasPrefix(l, ".belmont/") {
+			continue
+		}
+		files = append(files, l)
+	}
+	return files, true
+}
+
+// siblingsOf returns a copy of every tracked worktree except id.
+func (wt *worktreeTracker) siblingsOf(id string) map[string]worktreeEntry {
+	wt.mu.Lock()
+	defer wt.mu.Unlock()
+	out := make(map[string]worktreeEntry, len(wt.entries))
+	for k, e := range wt.entries {
+		if k != id {
+			out[k] = e
+		}
+	}
+	return out
+}
+
+// markPredicted records a conflict prediction and reports whether it is new,
+// so a pair is steered once per conflicting file set rather than at every
+// boundary of both sides.
+func (wt *worktreeTracker) markPredicted(key string) bool {
+	wt.mu.Lock()
+	defer wt.mu.Unlock()
+	if wt.predicted == nil {
+		wt.predicted = make(map[string]bool)
+	}
+	if wt.predicted[key] {
+		return false
+	}
+	wt.predicted[key] = true
+	return true
+}
+
+// featureFor returns the feature slug a tracked worktree works on: the run's
+// feature in single-feature-parallel mode, the ID itself in multi-feature mode.
+func (wt *worktreeTracker) featureFor(id string) string {
+	if wt.feature != "" {
+		return wt.feature
+	}
+	return id
+}
+
+// predictSiblingConflicts is the action-boundary hook runLoop calls inside a
+// tracked worktree. Predictions against siblings steer both worktrees;
+// predictions against main steer only this one, and are skipped under
+// --merge-as-you-go where the boundary rebase already surfaces them.
+func predictSiblingConflicts(cfg loopConfig) {
+	if cfg.Tracker == nil || cfg.TrackerID == "" {
+		return
+	}
+	self := captureGitSHA(cfg.Root)
+	if self == "" {
+		return
+	}
+
+	siblings := cfg.Tracker.siblingsOf(cfg.TrackerID)
+	ids := make([]string, 0, len(siblings))
+	for id := range siblings {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		sib := siblings[id]
+		theirs := resolveBranchSHA(cfg.Root, sib.Branch)
+		files, ok := predictMergeConflicts(cfg.Root, self, theirs)
+		if !ok || len(files) == 0 {
+			continue
+		}
+		pair := []string{cfg.TrackerID, id}
+		sort.Strings(pair)
+		if !cfg.Tracker.markPredicted(strings.Join(pair, "|") + ":" + strings.Join(files, ",")) {
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "  \033[33m⚠ Predicted merge conflict between %s and %s: %s\033[0m\n", cfg.TrackerID, id, strings.Join(files, ", "))
+		steerPredictedConflict(cfg.Tracker, cfg.Root, cfg.Tracker.featureFor(cfg.TrackerID), cfg.TrackerID, siblingConflictSteering(id, files))
+		steerPredictedConflict(cfg.Tracker, sib.Path, cfg.Tracker.featureFor(id), id, siblingConflictSteering(cfg.TrackerID, files))
+	}
+
+	if cfg.MainRoot != "" {
+		return
+	}
+	mainSHA := captureGitSHA(cfg.Tracker.root)
+	files, ok := predictMergeConflicts(cfg.Root, self, mainSHA)
+	if !ok || len(files) == 0 {
+		return
+	}
+	if !cfg.Tracker.markPredicted("main|" + cfg.TrackerID + ":" + strings.Join(files, ",")) {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "  \033[33m⚠ Predicted merge conflict between %s and main: %s\033[0m\n", cfg.TrackerID, strings.Join(files, ", "))
+	body := fmt.Sprintf("Main has changed %s since this worktree forked, and a trial merge with your branch conflicts there. Keep your edits to those files minimal and additive (no reformatting, reordering or renames) so the merge back stays clean.", strings.Join(files, ", "))
+	steerPredictedConflict(cfg.Tracker, cfg.Root, cfg.Tracker.featureFor(cfg.TrackerID), cfg.TrackerID, body)
+}
+
+// siblingConflictSteering is the steering text for one side of a predicted
+// sibling conflict.
+func siblingConflictSteering(other string, files []string) string {
+	return fmt.Sprintf("%s is also editing %s in a parallel worktree, and a trial merge of the two branches conflicts there. Keep your additions to those files additive — append new entries instead of editing or reordering existing lines, and don't reformat or rename shared code — so both branches merge cleanly.", other, strings.Join(files, ", "))
+}
+
+// withSteering runs fn while holding the tracker's mutex. Relayed steering is
+// written into a sibling worktree from another goroutine, so both that write
+// and each worktree's own consume (read, then rewrite or delete) of
+// STEERING.md go through here. A nil tracker (sequential runs) just calls fn.
+func (wt *worktreeTracker) withSteering(fn func()) {
+	if wt == nil {
+		fn()
+		return
+	}
+	wt.mu.Lock()
+	defer wt.mu.Unlock()
+	fn()
+}
+
+// steerPredictedConflict appends a pending steering entry to a worktree's
+// feature STEERING.md under the tracker's mutex. Best-effort: failures only
+// warn.
+func steerPredictedConflict(wt *worktreeTracker, wtPath, feature, id, body string) {
+	path := filepath.Join(wtPath, ".belmont", "features", feature, "STEERING.md")
+	var err error
+	wt.withSteering(func() {
+		err = appendSteeringEntry(path, time.Now().UTC().Format(time.RFC3339), "", body)
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "  \033[33m⚠ Failed to steer %s about predicted conflict: %s\033[0m\n", id, err)
+	}
+}
+
+// resolveBranchSHA returns the commit a branch points at, or "" if unknown.
+func resolveBranchSHA(root, branch string) string {
+	cmd := exec.Command("git", "rev-parse", "--verify", "-q", branch)
+	cmd.Dir = root
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
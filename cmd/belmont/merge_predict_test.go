@@ -0,0 +1,77 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPredictMergeConflicts(t *testing.T) {
+	mainRoot, wtPath, _ := setupMainAndWorktree(t)
+
+	mustWrite(t, filepath.Join(wtPath, "app.txt"), "worktree\n")
+	mustWrite(t, filepath.Join(wtPath, "new.txt"), "only here\n")
+	runGit(t, wtPath, "add", "-A")
+	runGit(t, wtPath, "commit", "-q", "-m", "feature edit")
+	wtSHA := runGit(t, wtPath, "rev-parse", "HEAD")
+
+	// Disjoint change on main: no conflict predicted.
+	mustWrite(t, filepath.Join(mainRoot, "other.txt"), "x\n")
+	runGit(t, mainRoot, "add", "-A")
+	runGit(t, mainRoot, "commit", "-q", "-m", "disjoint")
+	files, ok := predictMergeConflicts(mainRoot, wtSHA, runGit(t, mainRoot, "rev-parse", "HEAD"))
+	if !ok {
+		t.Skip("git merge-tree --write-tree unsupported by this git")
+	}
+	if len(files) != 0 {
+		t.Errorf("disjoint edits predicted to conflict: %v", files)
+	}
+
+	mustWrite(t, filepath.Join(mainRoot, "app.txt"), "main\n")
+	runGit(t, mainRoot, "commit", "-q", "-am", "same line")
+	files, ok = predictMergeConflicts(mainRoot, wtSHA, runGit(t, mainRoot, "rev-parse", "HEAD"))
+	if !ok || !equalStringSlices(files, []string{"app.txt"}) {
+		t.Errorf("want app.txt conflict, got %v (ok=%v)", files, ok)
+	}
+}
+
+func TestPredictSiblingConflicts_SteersBothSidesOnce(t *testing.T) {
+	mainRoot, wtA, branchA := setupMainAndWorktree(t)
+	wtB := filepath.Join(t.TempDir(), "feature-b")
+	branchB := "belmont/auto/feature-b"
+	runGit(t, mainRoot, "worktree", "add", "-b", branchB, wtB, "HEAD")
+
+	if _, ok := predictMergeConflicts(mainRoot, "HEAD", "HEAD~0"); !ok {
+		t.Skip("git merge-tree --write-tree unsupported by this git")
+	}
+
+	mustWrite(t, filepath.Join(wtA, "app.txt"), "a\n")
+	runGit(t, wtA, "commit", "-q", "-am", "a")
+	mustWrite(t, filepath.Join(wtB, "app.txt"), "b\n")
+	runGit(t, wtB, "commit", "-q", "-am", "b")
+
+	tracker := &worktreeTracker{entries: map[string]worktreeEntry{
+		"feature-a": {Path: wtA, Branch: branchA},
+		"feature-b": {Path: wtB, Branch: branchB},
+	}, root: mainRoot}
+	cfg := loopConfig{Root: wtA, Feature: "feature-a", Tracker: tracker, TrackerID: "feature-a"}
+
+	predictSiblingConflicts(cfg)
+	predictSiblingConflicts(cfg)
+
+	a, err := os.ReadFile(filepath.Join(wtA, ".belmont", "features", "feature-a", "STEERING.md"))
+	if err != nil {
+		t.Fatalf("feature-a not steered: %v", err)
+	}
+	b, err := os.ReadFile(filepath.Join(wtB, ".belmont", "features", "feature-b", "STEERING.md"))
+	if err != nil {
+		t.Fatalf("feature-b not steered: %v", err)
+	}
+	if !strings.Contains(string(a), "feature-b is also editing app.txt") || !strings.Contains(string(b), "feature-a is also editing app.txt") {
+		t.Errorf("unexpected steering:\nA: %s\nB: %s", a, b)
+	}
+	if strings.Count(string(a), "(pending)") != 1 {
+		t.Errorf("prediction steered more than once:\n%s", a)
+	}
+}
@@ -0,0 +1,672 @@
+package main
+
+// ============================================================================
+// belmont milestone — restructure a feature's milestones without re-running
+// /belmont:tech-plan.
+//
+// Operations work on PROGRESS.md text directly: the file is split into the
+// preamble, one block per `### M<n>:` heading, and the tail that starts at the
+// next level-2 heading. Blocks are edited, renumbered when their order changes,
+// and re-rendered. Renumbering rewrites `(depends: ...)` annotations and task
+// IDs that embed a milestone number (P3-FWLUP-M2-1, P5-M5-FWLUP-1) so
+// `belmont validate`'s cross_milestone_task_id rule stays clean.
+//
+// Every operation prints the resulting wave plan (via computeWaves) before
+// anything is written, so dependency mistakes surface before auto trusts them.
+// ============================================================================
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// progressDoc is PROGRESS.md split at milestone boundaries.
+type progressDoc struct {
+	Head   []string        // lines before the first milestone heading
+	Blocks []progressBlock // milestone blocks in file order
+	Tail   []string        // from the first level-2 heading after the milestones to EOF
+}
+
+// progressBlock is one `### M<n>: Name (depends: ...)` heading plus the lines
+// below it, up to the next milestone heading or level-2 heading.
+type progressBlock struct {
+	Num    int
+	Marker string // optional status emoji before the ID (legacy headers)
+	Name   string
+	Deps   []string
+	Body   []string
+}
+
+func (b progressBlock) id() string { return fmt.Sprintf("M%d", b.Num) }
+
+var milestoneHeadingRe = regexp.MustCompile(`^###\s+(?:([✅⬜🔄🚫])\s*)?M(\d+):\s*(.+)$`)
+var milestoneDepsRe = regexp.MustCompile(`\(depends:\s*(M[\d]+(?:\s*,\s*M[\d]+)*)\)\s*$`)
+var milestoneTaskLineRe = regexp.MustCompile(`^(\s*-\s+\[.\]\s+)(P\d+-[\w][\w-]*)(:.*)$`)
+
+// parseProgressDoc splits PROGRESS.md content into a progressDoc.
+func parseProgressDoc(content string) progressDoc {
+	var doc progressDoc
+	lines := strings.Split(content, "\n")
+	var current *progressBlock
+	inTail := false
+	for _, line := range lines {
+		if inTail {
+			doc.Tail = append(doc.Tail, line)
+			continue
+		}
+		if m := milestoneHeadingRe.FindStringSubmatch(line); m != nil {
+			if current != nil {
+				doc.Blocks = append(doc.Blocks, *current)
+			}
+			num, _ := strconv.Atoi(m[2])
+			name := strings.TrimSpace(m[3])
+			var deps []string
+			if dm := milestoneDepsRe.FindStringSubmatch(name); dm != nil {
+				name = strings.TrimSpace(milestoneDepsRe.ReplaceAllString(name, ""))
+				for _, d := range strings.Split(dm[1], ",") {
+					deps = append(deps, strings.TrimSpace(d))
+				}
+			}
+			current = &progressBlock{Num: num, Marker: m[1], Name: name, Deps: deps}
+			continue
+		}
+		trim := strings.TrimSpace(line)
+		if current != nil && strings.HasPrefix(trim, "## ") {
+			doc.Blocks = append(doc.Blocks, *current)
+			current = nil
+			inTail = true
+			doc.Tail = append(doc.Tail, line)
+			continue
+		}
+		if current != nil {
+			current.Body = append(current.Body, line)
+		} else {
+			doc.Head = append(doc.Head, line)
+		}
+	}
+	if current != nil {
+		doc.Blocks = append(doc.Blocks, *current)
+	}
+	return doc
+}
+
+// render serialises the document back to PROGRESS.md text.
+func (d progressDoc) render() string {
+	var lines []string
+	lines = append(lines, d.Head...)
+	for _, b := range d.Blocks {
+		header := "### "
+		if b.Marker != "" {
+			header += b.Marker + " "
+		}
+		header += b.id() + ": " + b.Name
+		if len(b.Deps) > 0 {
+			header += " (depends: " + strings.Join(b.Deps, ", ") + ")"
+		}
+		lines = append(lines, header)
+		lines = append(lines, b.Body...)
+	}
+	lines = append(lines, d.Tail...)
+	return strings.Join(lines, "\n")
+}
+
+// blockIndex returns the index of the block with the given milestone ID, or -1.
+func (d progressDoc) blockIndex(id string) int {
+	for i, b := range d.Blocks {
+		if strings.EqualFold(b.id(), id) {
+			return i
+		}
+	}
+	return -1
+}
+
+// milestoneRenumber is the outcome of renumbering: old → new milestone IDs
+// and old → new task IDs, for preview output.
+type milestoneRenumber struct {
+	Milestones map[string]string
+	Tasks      map[string]string
+}
+
+// renumberBlocks assigns contiguous numbers (M1..Mn) in file order, starting
+// from the lowest existing number, and rewrites deps and milestone-embedding
+// task IDs to match. origin maps a block index to the milestone ID its
+// embedded task IDs should be resolved against (used by split, where moved
+// tasks still say M3 but must follow the new half); nil means "the block's
+// own pre-renumber ID".
+func renumberBlocks(d *progressDoc, origin map[int]string) milestoneRenumber {
+	result := milestoneRenumber{Milestones: map[string]string{}, Tasks: map[string]string{}}
+	if len(d.Blocks) == 0 {
+		return result
+	}
+	// Blocks inserted by add/split carry Num 0 until they are numbered here.
+	start := 0
+	for _, b := range d.Blocks {
+		if b.Num > 0 && (start == 0 || b.Num < start) {
+			start = b.Num
+		}
+	}
+	if start == 0 {
+		start = 1
+	}
+
+	// Old IDs can collide (split/add insert a block that has no number yet),
+	// so the mapping is computed per block index, and only uniquely-owned old
+	// IDs feed the dep/task rewrite table.
+	oldIDs := make([]string, len(d.Blocks))
+	owners := map[string]int{}
+	for i, b := range d.Blocks {
+		oldIDs[i] = b.id()
+		owners[oldIDs[i]]++
+	}
+	idMap := map[string]string{}
+	for i := range d.Blocks {
+		newID := fmt.Sprintf("M%d", start+i)
+		if d.Blocks[i].Num > 0 && owners[oldIDs[i]] == 1 {
+			idMap[oldIDs[i]] = newID
+			if oldIDs[i] != newID {
+				result.Milestones[oldIDs[i]] = newID
+			}
+		}
+	}
+
+	for i := range d.Blocks {
+		b := &d.Blocks[i]
+		newID := fmt.Sprintf("M%d", start+i)
+
+		var deps []string
+		for _, dep := range b.Deps {
+			if mapped, ok := idMap[dep]; ok {
+				dep = mapped
+			}
+			deps = append(deps, dep)
+		}
+		b.Deps = deps
+
+		// Task IDs that name this block's (pre-renumber) milestone follow the
+		// block; IDs naming another milestone follow that milestone's mapping.
+		self := oldIDs[i]
+		if o, ok := origin[i]; ok {
+			self = o
+		}
+		for j, line := range b.Body {
+			tm := milestoneTaskLineRe.FindStringSubmatch(line)
+			if tm == nil {
+				continue
+			}
+			taskID := tm[2]
+			im := taskIDMilestoneRefRe.FindStringSubmatchIndex(taskID)
+			if im == nil {
+				continue
+			}
+			ref := "M" + taskID[im[2]:im[3]]
+			target := ""
+			if ref == self {
+				target = newID
+			} else if mapped, ok := idMap[ref]; ok {
+				target = mapped
+			}
+			if target == "" || target == ref {
+				continue
+			}
+			newTaskID := taskID[:im[2]] + strings.TrimPrefix(target, "M") + taskID[im[3]:]
+			b.Body[j] = tm[1] + newTaskID + tm[3]
+			result.Tasks[taskID] = newTaskID
+		}
+		b.Num = start + i
+	}
+	return result
+}
+
+// runMilestoneCmd implements `belmont milestone <add|split|move|deps>`.
+func runMilestoneCmd(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("milestone: subcommand required (add|split|move|deps)")
+	}
+	op := args[0]
+
+	fs := flag.NewFlagSet("milestone "+op, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	var root, feature, msID, name, after, before, at, depends, add, remove string
+	var clear, dryRun, yes bool
+	fs.StringVar(&root, "root", ".", "project root")
+	fs.StringVar(&feature, "feature", "", "feature slug (auto-detected if only one exists)")
+	fs.StringVar(&msID, "milestone", "", "milestone to operate on (e.g. M3)")
+	fs.StringVar(&name, "name", "", "milestone name (add, split)")
+	fs.StringVar(&after, "after", "", "insert/move after this milestone")
+	fs.StringVar(&before, "before", "", "insert/move before this milestone")
+	fs.StringVar(&at, "at", "", "task ID where the split begins (split)")
+	fs.StringVar(&depends, "depends", "", "comma-separated deps for the new milestone, or replacement deps (deps)")
+	fs.StringVar(&add, "add", "", "comma-separated deps to add (deps)")
+	fs.StringVar(&remove, "remove", "", "comma-separated deps to remove (deps)")
+	fs.BoolVar(&clear, "clear", false, "remove all deps (deps)")
+	fs.BoolVar(&dryRun, "dry-run", false, "preview the wave plan without writing")
+	fs.BoolVar(&yes, "yes", false, "skip the confirmation prompt")
+	if err := fs.Parse(args[1:]); err != nil {
+		return fmt.Errorf("milestone: %w", err)
+	}
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return fmt.Errorf("milestone: resolve root: %w", err)
+	}
+
+	slug, err := resolveSingleFeature(absRoot, feature)
+	if err != nil {
+		return fmt.Errorf("milestone: %w", err)
+	}
+	progressPath := filepath.Join(absRoot, ".belmont", "features", slug, "PROGRESS.md")
+	data, err := os.ReadFile(progressPath)
+	if err != nil {
+		return fmt.Errorf("milestone: read %s: %w", progressPath, err)
+	}
+	doc := parseProgressDoc(string(data))
+
+	var renum milestoneRenumber
+	var summary string
+	switch op {
+	case "add":
+		renum, summary, err = milestoneAdd(&doc, name, after, before, splitIDList(depends))
+	case "split":
+		renum, summary, err = milestoneSplit(&doc, msID, at, name)
+	case "move":
+		renum, summary, err = milestoneMove(&doc, msID, after, before)
+	case "deps":
+		summary, err = milestoneDeps(&doc, msID, depends, add, remove, clear, flagWasSet(fs, "depends"))
+	default:
+		return fmt.Errorf("milestone: unknown subcommand %q (use add, split, move, or deps)", op)
+	}
+	if err != nil {
+		return fmt.Errorf("milestone %s: %w", op, err)
+	}
+
+	// Renumbering an in-flight feature would orphan its worktree branches
+	// (belmont/auto/<slug>/m3) and auto.json entries keyed by milestone ID.
+	if len(renum.Milestones) > 0 && featureHasActiveAutoRun(absRoot, slug) {
+		return fmt.Errorf("milestone %s: %s has an active auto run — renumbering milestones now would orphan its worktrees; wait for it to finish", op, slug)
+	}
+
+	newContent := doc.render()
+	milestones := parseMilestones(newContent)
+	if err := validateMilestoneDeps(milestones); err != nil {
+		return fmt.Errorf("milestone %s: %w", op, err)
+	}
+	waves, err := computeWaves(milestones)
+	if err != nil {
+		return fmt.Errorf("milestone %s: %w", op, err)
+	}
+
+	printMilestonePreview(os.Stderr, slug, summary, renum, waves, detectViolations(slug, milestones))
+
+	if dryRun {
+		fmt.Fprintf(os.Stderr, "\033[2m(dry run — %s not modified)\033[0m\n", progressPath)
+		return nil
+	}
+	if !yes && isTerminal(os.Stdin) {
+		fmt.Fprintf(os.Stderr, "Write changes to %s? [y/N] ", progressPath)
+		scanner := bufio.NewScanner(os.Stdin)
+		if !scanner.Scan() {
+			return fmt.Errorf("milestone %s: no input", op)
+		}
+		answer := strings.ToLower(strings.TrimSpace(scanner.Text()))
+		if answer != "y" && answer != "yes" {
+			return fmt.Errorf("milestone %s: cancelled by user", op)
+		}
+	}
+	if err := os.WriteFile(progressPath, []byte(newContent), 0644); err != nil {
+		return fmt.Errorf("milestone %s: write %s: %w", op, progressPath, err)
+	}
+	fmt.Fprintf(os.Stderr, "\033[32m✓\033[0m Updated %s\n", progressPath)
+	return nil
+}
+
+// milestoneAdd inserts a new empty milestone after/before an existing one
+// (default: at the end) and renumbers everything behind it.
+func milestoneAdd(doc *progressDoc, name, after, before string, deps []string) (milestoneRenumber, string, error) {
+	if strings.TrimSpace(name) == "" {
+		return milestoneRenumber{}, "", fmt.Errorf("--name is required")
+	}
+	pos, err := insertPosition(*doc, after, before, len(doc.Blocks))
+	if err != nil {
+		return milestoneRenumber{}, "", err
+	}
+	for _, d := range deps {
+		if doc.blockIndex(d) < 0 {
+			return milestoneRenumber{}, "", fmt.Errorf("unknown dependency %s", d)
+		}
+	}
+	nb := progressBlock{Name: strings.TrimSpace(name), Deps: deps, Body: []string{""}}
+	if len(doc.Blocks) == 0 {
+		nb.Num = 1
+	}
+	doc.Blocks = append(doc.Blocks[:pos], append([]progressBlock{nb}, doc.Blocks[pos:]...)...)
+	renum := renumberBlocks(doc, nil)
+	return renum, fmt.Sprintf("Add %s: %s", doc.Blocks[pos].id(), doc.Blocks[pos].Name), nil
+}
+
+// milestoneSplit moves the tasks from atTask onward into a new milestone
+// directly after the source. The new half depends on the source, and every
+// milestone that depended on the source now depends on both halves.
+func milestoneSplit(doc *progressDoc, msID, atTask, name string) (milestoneRenumber, string, error) {
+	if msID == "" || atTask == "" {
+		return milestoneRenumber{}, "", fmt.Errorf("--milestone and --at are required")
+	}
+	idx := doc.blockIndex(msID)
+	if idx < 0 {
+		return milestoneRenumber{}, "", fmt.Errorf("milestone %s not found", msID)
+	}
+	src := doc.Blocks[idx]
+	splitAt := -1
+	firstTask := -1
+	for i, line := range src.Body {
+		tm := milestoneTaskLineRe.FindStringSubmatch(line)
+		if tm == nil {
+			continue
+		}
+		if firstTask < 0 {
+			firstTask = i
+		}
+		if tm[2] == atTask {
+			splitAt = i
+			break
+		}
+	}
+	if splitAt < 0 {
+		return milestoneRenumber{}, "", fmt.Errorf("task %s not found in %s", atTask, src.id())
+	}
+	if splitAt == firstTask {
+		return milestoneRenumber{}, "", fmt.Errorf("%s is the first task of %s — nothing would remain in the original milestone", atTask, src.id())
+	}
+	// Trailing non-task lines (blank separator, notes) stay with the tail
+	// half; the head half gets a blank separator so headings stay spaced.
+	head := append([]string{}, src.Body[:splitAt]...)
+	tail := append([]string{}, src.Body[splitAt:]...)
+	if len(head) == 0 || strings.TrimSpace(head[len(head)-1]) != "" {
+		head = append(head, "")
+	}
+	if name == "" {
+		name = src.Name + " (part 2)"
+	}
+	srcID := src.id()
+	doc.Blocks[idx].Body = head
+	nb := progressBlock{Name: name, Deps: []string{srcID}, Body: tail}
+	doc.Blocks = append(doc.Blocks[:idx+1], append([]progressBlock{nb}, doc.Blocks[idx+1:]...)...)
+
+	// Dependents of the source milestone need the whole of its old scope, so
+	// they depend on both halves. The placeholder is rewritten by renumber.
+	const placeholder = "M0split"
+	for i := range doc.Blocks {
+		if i == idx+1 {
+			continue
+		}
+		for _, d := range doc.Blocks[i].Deps {
+			if d == srcID {
+				doc.Blocks[i].Deps = append(doc.Blocks[i].Deps, placeholder)
+				break
+			}
+		}
+	}
+	renum := renumberBlocks(doc, map[int]string{idx + 1: srcID})
+	newID := doc.Blocks[idx+1].id()
+	for i := range doc.Blocks {
+		for j, d := range doc.Blocks[i].Deps {
+			if d == placeholder {
+				doc.Blocks[i].Deps[j] = newID
+			}
+		}
+	}
+	return renum, fmt.Sprintf("Split %s at %s → %s: %s", srcID, atTask, newID, name), nil
+}
+
+// milestoneMove relocates a milestone and renumbers the file.
+func milestoneMove(doc *progressDoc, msID, after, before string) (milestoneRenumber, string, error) {
+	if msID == "" {
+		return milestoneRenumber{}, "", fmt.Errorf("--milestone is required")
+	}
+	if after == "" && before == "" {
+		return milestoneRenumber{}, "", fmt.Errorf("one of --after or --before is required")
+	}
+	idx := doc.blockIndex(msID)
+	if idx < 0 {
+		return milestoneRenumber{}, "", fmt.Errorf("milestone %s not found", msID)
+	}
+	if strings.EqualFold(after, msID) || strings.EqualFold(before, msID) {
+		return milestoneRenumber{}, "", fmt.Errorf("cannot move %s relative to itself", msID)
+	}
+	moving := doc.Blocks[idx]
+	doc.Blocks = append(doc.Blocks[:idx], doc.Blocks[idx+1:]...)
+	pos, err := insertPosition(*doc, after, before, len(doc.Blocks))
+	if err != nil {
+		return milestoneRenumber{}, "", err
+	}
+	doc.Blocks = append(doc.Blocks[:pos], append([]progressBlock{moving}, doc.Blocks[pos:]...)...)
+	renum := renumberBlocks(doc, nil)
+	return renum, fmt.Sprintf("Move %s → %s", moving.id(), doc.Blocks[pos].id()), nil
+}
+
+// milestoneDeps edits one milestone's `(depends: ...)` annotation. Milestone
+// numbers don't change, so no renumbering happens.
+func milestoneDeps(doc *progressDoc, msID, set, add, remove string, clear, setGiven bool) (string, error) {
+	if msID == "" {
+		return "", fmt.Errorf("--milestone is required")
+	}
+	idx := doc.blockIndex(msID)
+	if idx < 0 {
+		return "", fmt.Errorf("milestone %s not found", msID)
+	}
+	if !clear && !setGiven && add == "" && remove == "" {
+		return "", fmt.Errorf("one of --depends, --add, --remove, or --clear is required")
+	}
+	b := &doc.Blocks[idx]
+	deps := append([]string{}, b.Deps...)
+	if clear {
+		deps = nil
+	}
+	if setGiven {
+		deps = splitIDList(set)
+	}
+	for _, d := range splitIDList(add) {
+		if !containsString(deps, d) {
+			deps = append(deps, d)
+		}
+	}
+	if rm := splitIDList(remove); len(rm) > 0 {
+		var kept []string
+		for _, d := range deps {
+			if !containsString(rm, d) {
+				kept = append(kept, d)
+			}
+		}
+		deps = kept
+	}
+	for _, d := range deps {
+		if strings.EqualFold(d, b.id()) {
+			return "", fmt.Errorf("%s cannot depend on itself", b.id())
+		}
+		if doc.blockIndex(d) < 0 {
+			return "", fmt.Errorf("unknown dependency %s", d)
+		}
+	}
+	sort.Slice(deps, func(i, j int) bool { return parseMilestoneNum(deps[i]) < parseMilestoneNum(deps[j]) })
+	b.Deps = deps
+	if len(deps) == 0 {
+		return fmt.Sprintf("%s: no dependencies", b.id()), nil
+	}
+	return fmt.Sprintf("%s: depends on %s", b.id(), strings.Join(deps, ", ")), nil
+}
+
+// insertPosition resolves --after/--before into a block index; fallback is
+// used when neither is given.
+func insertPosition(doc progressDoc, after, before string, fallback int) (int, error) {
+	if after != "" && before != "" {
+		return 0, fmt.Errorf("--after and --before are mutually exclusive")
+	}
+	if after != "" {
+		idx := doc.blockIndex(after)
+		if idx < 0 {
+			return 0, fmt.Errorf("milestone %s not found", after)
+		}
+		return idx + 1, nil
+	}
+	if before != "" {
+		idx := doc.blockIndex(before)
+		if idx < 0 {
+			return 0, fmt.Errorf("milestone %s not found", before)
+		}
+		return idx, nil
+	}
+	return fallback, nil
+}
+
+// validateMilestoneDeps rejects deps that name milestones that don't exist.
+// computeWaves silently treats unknown deps as satisfied, which is exactly
+// the kind of drift this command is meant to prevent.
+func validateMilestoneDeps(milestones []milestone) error {
+	known := map[string]bool{}
+	for _, m := range milestones {
+		known[m.ID] = true
+	}
+	for _, m := range milestones {
+		for _, d := range m.Deps {
+			if !known[d] {
+				return fmt.Errorf("%s depends on unknown milestone %s", m.ID, d)
+			}
+		}
+	}
+	return nil
+}
+
+// printMilestonePreview renders the change summary, renames, and resulting
+// wave plan in the same shape runAutoParallel prints its execution plan.
+func printMilestonePreview(w io.Writer, slug, summary string, renum milestoneRenumber, waves []wave, violations []validationViolation) {
+	fmt.Fprintf(w, "\033[1mBelmont Milestone\033[0m — %s\n", slug)
+	fmt.Fprintf(w, "  %s\n", summary)
+	if len(renum.Milestones) > 0 {
+		var olds []string
+		for old := range renum.Milestones {
+			olds = append(olds, old)
+		}
+		sort.Slice(olds, func(i, j int) bool { return parseMilestoneNum(olds[i]) < parseMilestoneNum(olds[j]) })
+		fmt.Fprintf(w, "\n\033[1mRenumbered:\033[0m\n")
+		for _, old := range olds {
+			fmt.Fprintf(w, "  %s → %s\n", old, renum.Milestones[old])
+		}
+	}
+	if len(renum.Tasks) > 0 {
+		var olds []string
+		for old := range renum.Tasks {
+			olds = append(olds, old)
+		}
+		sort.Strings(olds)
+		fmt.Fprintf(w, "\n\033[1mTask IDs rewritten:\033[0m\n")
+		for _, old := range olds {
+			fmt.Fprintf(w, "  %s → %s\n", old, renum.Tasks[old])
+		}
+	}
+	fmt.Fprintf(w, "\n\033[1mExecution plan:\033[0m\n")
+	if len(waves) == 0 {
+		fmt.Fprintf(w, "  \033[2m(all milestones done)\033[0m\n")
+	}
+	for _, wv := range waves {
+		var ids []string
+		for _, m := range wv.Milestones {
+			ids = append(ids, m.ID)
+		}
+		parallel := ""
+		if len(wv.Milestones) > 1 {
+			parallel = " (parallel)"
+		}
+		fmt.Fprintf(w, "  Wave %d: %s%s\n", wv.Index+1, strings.Join(ids, ", "), parallel)
+	}
+	if len(violations) > 0 {
+		fmt.Fprintf(w, "\n\033[33m⚠ %d milestone-structure violation(s) after this change:\033[0m\n", len(violations))
+		for _, v := range violations {
+			if v.TaskID != "" {
+				fmt.Fprintf(w, "  [%s/%s] %s\n", v.Milestone, v.TaskID, v.Rule)
+			} else {
+				fmt.Fprintf(w, "  [%s] %s\n", v.Milestone, v.Rule)
+			}
+		}
+	}
+	fmt.Fprintln(w)
+}
+
+// resolveSingleFeature returns the requested feature slug after checking it
+// exists, or the only feature in the project when none was requested.
+func resolveSingleFeature(root, feature string) (string, error) {
+	featuresDir := filepath.Join(root, ".belmont", "features")
+	if feature != "" {
+		if !dirExists(filepath.Join(featuresDir, feature)) {
+			return "", fmt.Errorf("feature %q not found at %s", feature, filepath.Join(featuresDir, feature))
+		}
+		return feature, nil
+	}
+	entries, err := os.ReadDir(featuresDir)
+	if err != nil {
+		return "", fmt.Errorf("no features directory at %s", featuresDir)
+	}
+	var dirs []string
+	for _, e := range entries {
+		if e.IsDir() && !strings.HasPrefix(e.Name(), ".") {
+			dirs = append(dirs, e.Name())
+		}
+	}
+	if len(dirs) == 0 {
+		return "", fmt.Errorf("no features found")
+	}
+	if len(dirs) > 1 {
+		return "", fmt.Errorf("multiple features found, use --feature to specify one: %s", strings.Join(dirs, ", "))
+	}
+	return dirs[0], nil
+}
+
+// featureHasActiveAutoRun reports whether auto.json names slug as in flight.
+func featureHasActiveAutoRun(root, slug string) bool {
+	aj := readActiveAutoJSONOrNil(root)
+	if aj == nil {
+		return false
+	}
+	if aj.Feature == slug {
+		return true
+	}
+	_, ok := aj.Worktrees[slug]
+	return ok
+}
+
+// splitIDList splits "M1, m2" into ["M1", "M2"].
+func splitIDList(s string) []string {
+	var out []string
+	for _, p := range strings.Split(s, ",") {
+		p = strings.ToUpper(strings.TrimSpace(p))
+		if p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// flagWasSet reports whether the named flag appeared on the command line.
+func flagWasSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
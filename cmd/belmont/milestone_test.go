@@ -0,0 +1,145 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const milestoneFixture = `# Progress: Auth
+
+## Milestones
+
+### M1: Scaffold
+- [x] P0-1: Route
+- [x] P0-2: Layout
+
+### M2: API (depends: M1)
+- [ ] P1-1: Endpoint
+- [ ] P1-2: Validation
+- [ ] P1-M2-FWLUP-1: Error shape
+
+### M3: UI (depends: M2)
+- [ ] P2-1: Form
+- [ ] P2-FWLUP-M3-1: Focus ring
+
+## Session History
+
+| Date | Action | Details |
+`
+
+func TestParseProgressDoc_RoundTrip(t *testing.T) {
+	doc := parseProgressDoc(milestoneFixture)
+	if len(doc.Blocks) != 3 {
+		t.Fatalf("want 3 blocks, got %d", len(doc.Blocks))
+	}
+	if got := doc.Blocks[1].Deps; len(got) != 1 || got[0] != "M1" {
+		t.Errorf("M2 deps: got %v", got)
+	}
+	if doc.render() != milestoneFixture {
+		t.Errorf("render is not byte-identical:\n%s", doc.render())
+	}
+}
+
+func TestMilestoneAdd_RenumbersAndRewritesTaskIDs(t *testing.T) {
+	doc := parseProgressDoc(milestoneFixture)
+	renum, _, err := milestoneAdd(&doc, "Schema", "M1", "", []string{"M1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := doc.render()
+	for _, want := range []string{
+		"### M2: Schema (depends: M1)",
+		"### M3: API (depends: M1)",
+		"### M4: UI (depends: M3)",
+		"- [ ] P1-M3-FWLUP-1: Error shape",
+		"- [ ] P2-FWLUP-M4-1: Focus ring",
+		"## Session History",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+	if renum.Milestones["M2"] != "M3" || renum.Milestones["M3"] != "M4" {
+		t.Errorf("unexpected milestone renames: %v", renum.Milestones)
+	}
+	if v := detectViolations("auth", parseMilestones(out)); len(v) != 0 {
+		t.Errorf("renumber left violations: %+v", v)
+	}
+}
+
+func TestMilestoneSplit_MovesTailTasksAndRewiresDependents(t *testing.T) {
+	doc := parseProgressDoc(milestoneFixture)
+	_, _, err := milestoneSplit(&doc, "M2", "P1-2", "API validation")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := doc.render()
+	ms := parseMilestones(out)
+	if len(ms) != 4 {
+		t.Fatalf("want 4 milestones, got %d:\n%s", len(ms), out)
+	}
+	if ms[1].ID != "M2" || len(ms[1].Tasks) != 1 || ms[1].Tasks[0].ID != "P1-1" {
+		t.Errorf("head half wrong: %+v", ms[1])
+	}
+	if ms[2].ID != "M3" || ms[2].Name != "API validation" || len(ms[2].Tasks) != 2 {
+		t.Errorf("tail half wrong: %+v", ms[2])
+	}
+	if !equalStringSlices(ms[2].Deps, []string{"M2"}) {
+		t.Errorf("tail half deps: got %v", ms[2].Deps)
+	}
+	if ms[2].Tasks[1].ID != "P1-M3-FWLUP-1" {
+		t.Errorf("moved FWLUP should follow the new half, got %s", ms[2].Tasks[1].ID)
+	}
+	// UI depended on M2 (now M2+M3 after the split) and is renumbered to M4.
+	if ms[3].ID != "M4" || !equalStringSlices(ms[3].Deps, []string{"M2", "M3"}) {
+		t.Errorf("dependent not rewired: %+v", ms[3])
+	}
+	if v := detectViolations("auth", ms); len(v) != 0 {
+		t.Errorf("split left violations: %+v", v)
+	}
+}
+
+func TestMilestoneSplit_RejectsFirstTask(t *testing.T) {
+	doc := parseProgressDoc(milestoneFixture)
+	if _, _, err := milestoneSplit(&doc, "M2", "P1-1", ""); err == nil {
+		t.Fatal("expected error splitting at the first task")
+	}
+}
+
+func TestMilestoneMove_Reorders(t *testing.T) {
+	doc := parseProgressDoc(milestoneFixture)
+	if _, _, err := milestoneMove(&doc, "M3", "", "M2"); err != nil {
+		t.Fatal(err)
+	}
+	ms := parseMilestones(doc.render())
+	if ms[1].Name != "UI" || ms[1].ID != "M2" || !equalStringSlices(ms[1].Deps, []string{"M3"}) {
+		t.Errorf("moved milestone wrong: %+v", ms[1])
+	}
+	if ms[2].Name != "API" || ms[2].ID != "M3" || ms[2].Tasks[2].ID != "P1-M3-FWLUP-1" {
+		t.Errorf("displaced milestone wrong: %+v", ms[2])
+	}
+}
+
+func TestMilestoneDeps_EditsAndValidates(t *testing.T) {
+	doc := parseProgressDoc(milestoneFixture)
+	if _, err := milestoneDeps(&doc, "M3", "", "M1", "M2", false, false); err != nil {
+		t.Fatal(err)
+	}
+	if got := doc.Blocks[2].Deps; !equalStringSlices(got, []string{"M1"}) {
+		t.Errorf("deps after add/remove: %v", got)
+	}
+	if _, err := milestoneDeps(&doc, "M3", "M9", "", "", false, true); err == nil {
+		t.Error("expected unknown dependency error")
+	}
+	if _, err := milestoneDeps(&doc, "M3", "M3", "", "", false, true); err == nil {
+		t.Error("expected self-dependency error")
+	}
+	waves, err := computeWaves(parseMilestones(doc.render()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// M1 done; M2 and M3 both depend only on M1 now.
+	if len(waves) != 1 || len(waves[0].Milestones) != 2 {
+		t.Errorf("want one parallel wave, got %+v", waves)
+	}
+}
@@ -0,0 +1,339 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ============================================================================
+// Project overrides for shipped skills and agents
+//
+// install/update rewrite .agents/belmont and .agents/skills/belmont from the
+// binary, so local edits there are lost. Projects put their versions in
+// .belmont/overrides/ instead, mirroring the installed layout:
+//
+//   .belmont/overrides/agents/implementation-agent.md  → .agents/belmont/
+//   .belmont/overrides/skills/verify/SKILL.md          → .agents/skills/belmont/
+//
+// After every sync the overrides are copied over the shipped files. The
+// shipped file an override was written against is kept in
+// .belmont/overrides/.base/. When an update changes that file, Belmont
+// three-way merges the upstream change into the override (`git merge-file`).
+// A clean merge updates the override; a conflicting one leaves it alone,
+// prints what upstream changed, and writes <override>.merge with conflict
+// markers plus .base/<path>.next for `belmont overrides accept`.
+// ============================================================================
+
+const (
+	overridesDir     = "overrides"
+	overrideBaseDir  = ".base"
+	overrideMergeExt = ".merge"
+	overrideNextExt  = ".next"
+)
+
+// overrideTargets maps an override subdirectory to the installed directory
+// it overlays, relative to the project root.
+var overrideTargets = map[string]string{
+	"agents": filepath.Join(".agents", "belmont"),
+	"skills": filepath.Join(".agents", "skills", "belmont"),
+}
+
+// projectOverride is one file under .belmont/overrides.
+type projectOverride struct {
+	Rel    string // "agents/implementation-agent.md"
+	Path   string // the override file
+	Target string // the installed file it replaces
+	Base   string // the upstream copy it was written against
+}
+
+func overridesRoot(root string) string {
+	return filepath.Join(root, ".belmont", overridesDir)
+}
+
+// listProjectOverrides returns every override in a stable order.
+func listProjectOverrides(root string) []projectOverride {
+	base := overridesRoot(root)
+	var out []projectOverride
+	subs := make([]string, 0, len(overrideTargets))
+	for sub := range overrideTargets {
+		subs = append(subs, sub)
+	}
+	sort.Strings(subs)
+	for _, sub := range subs {
+		dir := filepath.Join(base, sub)
+		filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return nil
+			}
+			if strings.HasSuffix(path, overrideMergeExt) || strings.HasPrefix(d.Name(), ".") {
+				return nil
+			}
+			inner, _ := filepath.Rel(dir, path)
+			rel := filepath.ToSlash(filepath.Join(sub, inner))
+			out = append(out, projectOverride{
+				Rel:    rel,
+				Path:   path,
+				Target: filepath.Join(root, overrideTargets[sub], inner),
+				Base:   filepath.Join(base, overrideBaseDir, filepath.FromSlash(rel)),
+			})
+			return nil
+		})
+	}
+	return out
+}
+
+// applyProjectOverrides overlays the overrides onto the freshly synced
+// install. record controls whether base copies, merged overrides and
+// conflict files are written back to .belmont/overrides; it is false only
+// for `install --apply-overrides-only` (worktree installs), independent of
+// --no-pin.
+func applyProjectOverrides(root string, record bool) error {
+	overrides := listProjectOverrides(root)
+	if len(overrides) == 0 {
+		return nil
+	}
+	fmt.Println("Applying project overrides from .belmont/overrides/...")
+	for _, o := range overrides {
+		if err := applyProjectOverride(o, record); err != nil {
+			return fmt.Errorf("override %s: %w", o.Rel, err)
+		}
+	}
+	fmt.Println("")
+	return nil
+}
+
+func applyProjectOverride(o projectOverride, record bool) error {
+	mine, err := os.ReadFile(o.Path)
+	if err != nil {
+		return err
+	}
+	upstream, err := os.ReadFile(o.Target)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return err
+		}
+		// Not shipped: the override adds a file.
+		fmt.Printf("  + %s (project addition)\n", o.Rel)
+		return writeOverrideFile(o.Target, mine)
+	}
+
+	base, err := os.ReadFile(o.Base)
+	switch {
+	case err != nil:
+		// First install with this override: what's shipped now is its base.
+		if record {
+			if err := writeOverrideFile(o.Base, upstream); err != nil {
+				return err
+			}
+		}
+	case !bytes.Equal(base, upstream):
+		merged, clean, mergeErr := mergeOverride(mine, base, upstream)
+		switch {
+		case mergeErr == nil && clean:
+			fmt.Printf("  \033[32m✓\033[0m %s: merged upstream changes into the override\n", o.Rel)
+			mine = merged
+			if record {
+				if err := writeOverrideFile(o.Path, merged); err != nil {
+					return err
+				}
+				if err := writeOverrideFile(o.Base, upstream); err != nil {
+					return err
+				}
+				os.Remove(o.Base + overrideNextExt)
+				os.Remove(o.Path + overrideMergeExt)
+			}
+		default:
+			fmt.Printf("  \033[33m⚠ %s: upstream changed a file you override and the changes conflict — keeping your version\033[0m\n", o.Rel)
+			if diff := overrideDiff(o.Base, o.Target); diff != "" {
+				fmt.Printf("    \033[2mUpstream change (base → new):\033[0m\n%s", indentLines(diff, "    "))
+			}
+			if record {
+				if mergeErr == nil {
+					if err := writeOverrideFile(o.Path+overrideMergeExt, merged); err != nil {
+						return err
+					}
+				}
+				if err := writeOverrideFile(o.Base+overrideNextExt, upstream); err != nil {
+					return err
+				}
+				rel := filepath.ToSlash(filepath.Join(".belmont", overridesDir, o.Rel))
+				fmt.Printf("    Resolve %s (conflict markers in %s%s), then run `belmont overrides accept %s`\n", rel, rel, overrideMergeExt, o.Rel)
+			}
+		}
+	}
+
+	if bytes.Equal(mine, upstream) {
+		fmt.Printf("  = %s (override matches upstream)\n", o.Rel)
+	} else {
+		fmt.Printf("  * %s (override)\n", o.Rel)
+	}
+	return writeOverrideFile(o.Target, mine)
+}
+
+// mergeOverride three-way merges the upstream change (base → upstream) into
+// mine with `git merge-file`. clean is false when the result has conflict
+// markers.
+func mergeOverride(mine, base, upstream []byte) (merged []byte, clean bool, err error) {
+	dir, err := os.MkdirTemp("", "belmont-override-")
+	if err != nil {
+		return nil, false, err
+	}
+	defer os.RemoveAll(dir)
+	paths := map[string][]byte{"override": mine, "base": base, "upstream": upstream}
+	for name, data := range paths {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			return nil, false, err
+		}
+	}
+	cmd := exec.Command("git", "merge-file", "-p", "--diff3",
+		"-L", "override", "-L", "base", "-L", "upstream",
+		filepath.Join(dir, "override"), filepath.Join(dir, "base"), filepath.Join(dir, "upstream"))
+	out, err := cmd.Output()
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return out, true, nil
+	case errors.As(err, &exitErr) && exitErr.ExitCode() > 0 && exitErr.ExitCode() < 128:
+		// Exit code is the number of conflicts.
+		return out, false, nil
+	default:
+		return nil, false, fmt.Errorf("git merge-file: %w", err)
+	}
+}
+
+// overrideDiff returns a unified diff between two files, "" when equal or
+// when git is unavailable.
+func overrideDiff(a, b string) string {
+	out, _ := exec.Command("git", "diff", "--no-index", "--no-color", "--", a, b).Output()
+	return string(out)
+}
+
+func indentLines(s, prefix string) string {
+	lines := strings.SplitAfter(s, "\n")
+	var b strings.Builder
+	for _, l := range lines {
+		if l != "" {
+			b.WriteString(prefix + l)
+		}
+	}
+	return b.String()
+}
+
+func writeOverrideFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// overrideStatus describes an override for `belmont overrides list`.
+func overrideStatus(o projectOverride) string {
+	switch {
+	case fileExists(o.Base + overrideNextExt):
+		return "conflict"
+	case !fileExists(o.Base):
+		if fileExists(o.Target) {
+			return "not yet installed"
+		}
+		return "addition"
+	}
+	return "ok"
+}
+
+// runOverridesCmd handles `belmont overrides [list|diff|accept]`.
+func runOverridesCmd(args []string) error {
+	sub := "list"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		sub, args = args[0], args[1:]
+	}
+	fs := flag.NewFlagSet("overrides", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	var root string
+	fs.StringVar(&root, "root", ".", "project root")
+	// Allow the path before or after the flags.
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return fmt.Errorf("overrides: %w", err)
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+	overrides := listProjectOverrides(root)
+
+	find := func() (*projectOverride, error) {
+		if len(positional) != 1 {
+			return nil, fmt.Errorf("overrides %s: expected one override path (e.g. agents/implementation-agent.md)", sub)
+		}
+		want := filepath.ToSlash(strings.TrimPrefix(positional[0], ".belmont/overrides/"))
+		for i := range overrides {
+			if overrides[i].Rel == want {
+				return &overrides[i], nil
+			}
+		}
+		return nil, fmt.Errorf("overrides %s: no override %s", sub, want)
+	}
+
+	switch sub {
+	case "list":
+		if len(overrides) == 0 {
+			fmt.Println("No overrides in .belmont/overrides/.")
+			return nil
+		}
+		for _, o := range overrides {
+			status := overrideStatus(o)
+			icon := "\033[32m✓\033[0m"
+			if status == "conflict" {
+				icon = "\033[33m⚠\033[0m"
+			}
+			fmt.Printf("%s %s (%s)\n", icon, o.Rel, status)
+		}
+	case "diff":
+		o, err := find()
+		if err != nil {
+			return err
+		}
+		next := o.Base + overrideNextExt
+		if !fileExists(next) {
+			fmt.Printf("Your changes to upstream (%s):\n", o.Rel)
+			fmt.Print(overrideDiff(o.Base, o.Path))
+			return nil
+		}
+		fmt.Printf("Upstream change (base → new) for %s:\n", o.Rel)
+		fmt.Print(overrideDiff(o.Base, next))
+		fmt.Printf("\nYour override against the new upstream:\n")
+		fmt.Print(overrideDiff(next, o.Path))
+	case "accept":
+		o, err := find()
+		if err != nil {
+			return err
+		}
+		next := o.Base + overrideNextExt
+		if !fileExists(next) {
+			return fmt.Errorf("overrides accept: %s has no pending upstream change", o.Rel)
+		}
+		if data, err := os.ReadFile(o.Path); err == nil && bytes.Contains(data, []byte("<<<<<<< override")) {
+			return fmt.Errorf("overrides accept: %s still has conflict markers", o.Rel)
+		}
+		if err := os.Rename(next, o.Base); err != nil {
+			return fmt.Errorf("overrides accept: %w", err)
+		}
+		os.Remove(o.Path + overrideMergeExt)
+		fmt.Printf("\033[32m✓\033[0m %s now tracks the new upstream. Run `belmont install` to apply it.\n", o.Rel)
+	default:
+		return fmt.Errorf("overrides: unknown subcommand %q (use list, diff or accept)", sub)
+	}
+	return nil
+}
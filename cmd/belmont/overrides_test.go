@@ -0,0 +1,94 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func readString(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestApplyProjectOverrides_MergesUpstreamAndReportsConflicts(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, ".agents/belmont/implementation-agent.md")
+	override := filepath.Join(dir, ".belmont/overrides/agents/implementation-agent.md")
+	upstream := func(content string) { writeFile(t, dir, ".agents/belmont/implementation-agent.md", content) }
+
+	// First install: the shipped file becomes the override's base.
+	upstream("# Agent\nRule one.\n\nSpacer.\n\nRule two.\nRule three.\n")
+	writeFile(t, dir, ".belmont/overrides/agents/implementation-agent.md", "# Agent\nRule one.\n\nSpacer.\n\nRule two, house style.\nRule three.\n")
+	if err := applyProjectOverrides(dir, true); err != nil {
+		t.Fatal(err)
+	}
+	if got := readString(t, target); !strings.Contains(got, "house style") {
+		t.Errorf("override not applied: %q", got)
+	}
+	if got := readString(t, filepath.Join(dir, ".belmont/overrides/.base/agents/implementation-agent.md")); strings.Contains(got, "house style") {
+		t.Errorf("base should be the shipped file: %q", got)
+	}
+
+	// Upstream edits another line: merged into the override.
+	upstream("# Agent\nRule one, clarified.\n\nSpacer.\n\nRule two.\nRule three.\n")
+	if err := applyProjectOverrides(dir, true); err != nil {
+		t.Fatal(err)
+	}
+	want := "# Agent\nRule one, clarified.\n\nSpacer.\n\nRule two, house style.\nRule three.\n"
+	if got := readString(t, override); got != want {
+		t.Errorf("merged override = %q", got)
+	}
+	if got := readString(t, target); got != want {
+		t.Errorf("installed = %q", got)
+	}
+
+	// Upstream edits the overridden line: conflict, override kept.
+	upstream("# Agent\nRule one, clarified.\n\nSpacer.\n\nRule two, rewritten upstream.\nRule three.\n")
+	if err := applyProjectOverrides(dir, true); err != nil {
+		t.Fatal(err)
+	}
+	if got := readString(t, target); got != want {
+		t.Errorf("conflict should keep the override installed: %q", got)
+	}
+	if got := readString(t, override+overrideMergeExt); !strings.Contains(got, "<<<<<<< override") || !strings.Contains(got, "rewritten upstream") {
+		t.Errorf(".merge = %q", got)
+	}
+	o := listProjectOverrides(dir)
+	if len(o) != 1 || overrideStatus(o[0]) != "conflict" {
+		t.Fatalf("overrides = %+v", o)
+	}
+
+	// Resolve and accept: the new upstream becomes the base.
+	writeFile(t, dir, ".belmont/overrides/agents/implementation-agent.md", "# Agent\nRule one, clarified.\n\nSpacer.\n\nRule two, rewritten upstream, house style.\nRule three.\n")
+	if err := runOverridesCmd([]string{"accept", "agents/implementation-agent.md", "--root", dir}); err != nil {
+		t.Fatal(err)
+	}
+	if overrideStatus(listProjectOverrides(dir)[0]) != "ok" || fileExists(override+overrideMergeExt) {
+		t.Error("accept should clear the conflict")
+	}
+}
+
+func TestApplyProjectOverrides_WorktreeInstallDoesNotRecord(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, ".agents/skills/belmont/verify/SKILL.md", "shipped\n")
+	writeFile(t, dir, ".belmont/overrides/skills/verify/SKILL.md", "ours\n")
+	writeFile(t, dir, ".belmont/overrides/agents/compliance-agent.md", "new agent\n")
+	if err := applyProjectOverrides(dir, false); err != nil {
+		t.Fatal(err)
+	}
+	if got := readString(t, filepath.Join(dir, ".agents/skills/belmont/verify/SKILL.md")); got != "ours\n" {
+		t.Errorf("skill override = %q", got)
+	}
+	if got := readString(t, filepath.Join(dir, ".agents/belmont/compliance-agent.md")); got != "new agent\n" {
+		t.Errorf("addition = %q", got)
+	}
+	if dirExists(filepath.Join(dir, ".belmont/overrides/.base")) {
+		t.Error("worktree installs must not write bases")
+	}
+}
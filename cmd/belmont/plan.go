@@ -0,0 +1,496 @@
+package main
+
+// ============================================================================
+// belmont plan — render the wave plan auto would execute, without running it.
+//
+// With --feature the units are that feature's milestones (computeWaves); without
+// it the units are the project's features (computeFeatureWaves over the master
+// PROGRESS.md dependency table). Both share one planGraph so every output
+// format (text, json, dot, mermaid) reports the same thing: waves, per-wave
+// parallelism, the critical path, and which units are already terminal.
+// ============================================================================
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// planNode is one schedulable unit — a milestone or a feature.
+type planNode struct {
+	ID       string   `json:"id"`
+	Name     string   `json:"name"`
+	Status   string   `json:"status"`
+	Deps     []string `json:"deps,omitempty"`
+	Priority string   `json:"priority,omitempty"`
+	Terminal bool     `json:"terminal"`
+}
+
+// planWave is one Kahn layer of non-terminal units.
+type planWave struct {
+	Index       int      `json:"index"` // 1-based, matches "Wave N" in auto output
+	Units       []string `json:"units"`
+	Parallelism int      `json:"parallelism"`
+}
+
+// planGraph is the format-independent plan rendered by `belmont plan`.
+type planGraph struct {
+	Scope              string     `json:"scope"` // "feature" (milestone units) or "project" (feature units)
+	Feature            string     `json:"feature,omitempty"`
+	Sequential         bool       `json:"sequential,omitempty"` // no (depends: ...) annotations — auto runs milestones one at a time
+	Nodes              []planNode `json:"nodes"`
+	Waves              []planWave `json:"waves"`
+	CriticalPath       []string   `json:"critical_path"`
+	CriticalPathLength int        `json:"critical_path_length"`
+	MaxParallelism     int        `json:"max_parallelism"`
+	Terminal           []string   `json:"terminal"`
+}
+
+// runPlanCmd implements `belmont plan`.
+func runPlanCmd(args []string) error {
+	fs := flag.NewFlagSet("plan", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	var root, feature, format string
+	fs.StringVar(&root, "root", ".", "project root")
+	fs.StringVar(&feature, "feature", "", "feature slug (milestone waves); omit for project-level feature waves")
+	fs.StringVar(&format, "format", "text", "output format (text|json|dot|mermaid)")
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("plan: %w", err)
+	}
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return fmt.Errorf("plan: resolve root: %w", err)
+	}
+
+	var g planGraph
+	if feature != "" {
+		g, err = buildMilestonePlan(absRoot, feature)
+	} else {
+		g, err = buildFeaturePlan(absRoot)
+	}
+	if err != nil {
+		return fmt.Errorf("plan: %w", err)
+	}
+
+	switch strings.ToLower(format) {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(g)
+	case "dot":
+		fmt.Print(renderPlanDOT(g))
+	case "mermaid":
+		fmt.Print(renderPlanMermaid(g))
+	case "text":
+		useColor, _ := shouldColor("auto", os.Stdout)
+		fmt.Print(renderPlanText(g, useColor))
+	default:
+		return fmt.Errorf("plan: unknown format %q (use text, json, dot, or mermaid)", format)
+	}
+	return nil
+}
+
+// buildMilestonePlan plans one feature's milestones. Live worktree state is
+// preferred over the master copy, same as `belmont status`.
+func buildMilestonePlan(root, slug string) (planGraph, error) {
+	featureDir := filepath.Join(root, ".belmont", "features", slug)
+	if !dirExists(featureDir) {
+		return planGraph{}, fmt.Errorf("feature %q not found at %s", slug, featureDir)
+	}
+	progressPath := filepath.Join(featureDir, "PROGRESS.md")
+	if override := loadAutoWorktrees(root); override != nil {
+		if wtFeature, ok := override[slug]; ok {
+			if p := filepath.Join(wtFeature, "PROGRESS.md"); fileExists(p) {
+				progressPath = p
+			}
+		}
+	}
+	data, err := os.ReadFile(progressPath)
+	if err != nil {
+		return planGraph{}, fmt.Errorf("read %s: %w", progressPath, err)
+	}
+	milestones := parseMilestones(string(data))
+	waves, err := computeWaves(milestones)
+	if err != nil {
+		return planGraph{}, err
+	}
+
+	g := planGraph{Scope: "feature", Feature: slug, Sequential: true}
+	for _, m := range milestones {
+		if len(m.Deps) > 0 {
+			g.Sequential = false
+			break
+		}
+	}
+	// Without any (depends: ...) annotation auto takes the runLoop path and
+	// works milestones strictly in order, so the plan is one per wave.
+	if g.Sequential {
+		waves = nil
+		for _, m := range milestones {
+			if milestoneAllDone(m) {
+				continue
+			}
+			waves = append(waves, wave{Index: len(waves), Milestones: []milestone{m}})
+		}
+	}
+	for _, m := range milestones {
+		g.Nodes = append(g.Nodes, planNode{
+			ID:       m.ID,
+			Name:     m.Name,
+			Status:   milestonePlanStatus(m),
+			Deps:     m.Deps,
+			Terminal: milestoneAllDone(m),
+		})
+	}
+	for _, w := range waves {
+		var ids []string
+		for _, m := range w.Milestones {
+			ids = append(ids, m.ID)
+		}
+		g.Waves = append(g.Waves, planWave{Index: w.Index + 1, Units: ids, Parallelism: len(ids)})
+	}
+	finishPlanGraph(&g)
+	return g, nil
+}
+
+// buildFeaturePlan plans every feature in the project using master-table deps.
+func buildFeaturePlan(root string) (planGraph, error) {
+	featuresDir := filepath.Join(root, ".belmont", "features")
+	features := listFeatures(featuresDir, 50)
+	if len(features) == 0 {
+		return planGraph{}, fmt.Errorf("no features found in %s", featuresDir)
+	}
+	populateFeatureDeps(features, root)
+	waves, err := computeFeatureWaves(features)
+	if err != nil {
+		return planGraph{}, err
+	}
+
+	g := planGraph{Scope: "project"}
+	for _, f := range features {
+		g.Nodes = append(g.Nodes, planNode{
+			ID:       f.Slug,
+			Name:     f.Name,
+			Status:   f.Status,
+			Deps:     f.Deps,
+			Priority: f.Priority,
+			Terminal: isFeatureTerminal(f.Status),
+		})
+	}
+	for _, w := range waves {
+		var ids []string
+		for _, f := range w.Features {
+			ids = append(ids, f.Slug)
+		}
+		g.Waves = append(g.Waves, planWave{Index: w.Index + 1, Units: ids, Parallelism: len(ids)})
+	}
+	finishPlanGraph(&g)
+	return g, nil
+}
+
+// milestonePlanStatus mirrors the labels auto --dry-run prints per milestone.
+func milestonePlanStatus(m milestone) string {
+	switch {
+	case milestoneAllVerified(m):
+		return "verified"
+	case milestoneAllDone(m):
+		return "done"
+	case !milestoneNotStarted(m):
+		return "in progress"
+	}
+	return "pending"
+}
+
+// finishPlanGraph fills the derived fields: terminal list, max parallelism,
+// and the critical path.
+func finishPlanGraph(g *planGraph) {
+	g.Terminal = []string{}
+	for _, n := range g.Nodes {
+		if n.Terminal {
+			g.Terminal = append(g.Terminal, n.ID)
+		}
+	}
+	if g.Waves == nil {
+		g.Waves = []planWave{}
+	}
+	for _, w := range g.Waves {
+		if w.Parallelism > g.MaxParallelism {
+			g.MaxParallelism = w.Parallelism
+		}
+	}
+	if g.Sequential {
+		g.CriticalPath = []string{}
+		for _, w := range g.Waves {
+			g.CriticalPath = append(g.CriticalPath, w.Units...)
+		}
+	} else {
+		g.CriticalPath = planCriticalPath(g.Nodes, g.Waves)
+	}
+	g.CriticalPathLength = len(g.CriticalPath)
+}
+
+// planCriticalPath returns the longest chain of non-terminal units through
+// the dependency graph (by unit count). Terminal and unknown deps don't
+// lengthen a chain — they are already satisfied. Waves give a topological
+// order, so one pass suffices. Ties go to the earlier unit in wave order.
+func planCriticalPath(nodes []planNode, waves []planWave) []string {
+	byID := map[string]planNode{}
+	for _, n := range nodes {
+		byID[n.ID] = n
+	}
+	depth := map[string]int{}
+	prev := map[string]string{}
+	best := ""
+	for _, w := range waves {
+		for _, id := range w.Units {
+			depth[id] = 1
+			for _, dep := range byID[id].Deps {
+				d, ok := depth[dep]
+				if !ok {
+					continue
+				}
+				if d+1 > depth[id] {
+					depth[id] = d + 1
+					prev[id] = dep
+				}
+			}
+			if best == "" || depth[id] > depth[best] {
+				best = id
+			}
+		}
+	}
+	if best == "" {
+		return []string{}
+	}
+	var path []string
+	for id := best; id != ""; id = prev[id] {
+		path = append([]string{id}, path...)
+	}
+	return path
+}
+
+// renderPlanText is the human-readable plan. ANSI styling is only added
+// when color is set (stdout is a terminal and NO_COLOR is unset).
+func renderPlanText(g planGraph, color bool) string {
+	style := func(code, s string) string {
+		if !color {
+			return s
+		}
+		return code + s + ansiReset
+	}
+	var b strings.Builder
+	if g.Scope == "feature" {
+		fmt.Fprintf(&b, "%s — %s (%d milestones)\n\n", style(ansiBold, "Belmont Plan"), g.Feature, len(g.Nodes))
+	} else {
+		fmt.Fprintf(&b, "%s — project (%d features)\n\n", style(ansiBold, "Belmont Plan"), len(g.Nodes))
+	}
+	byID := map[string]planNode{}
+	for _, n := range g.Nodes {
+		byID[n.ID] = n
+	}
+	if len(g.Waves) == 0 {
+		b.WriteString(style(ansiGreen, "✓") + " Nothing to schedule — every unit is already terminal\n")
+	}
+	for _, w := range g.Waves {
+		parallel := ""
+		if w.Parallelism > 1 {
+			parallel = " " + style(ansiDim, fmt.Sprintf("(%d parallel)", w.Parallelism))
+		}
+		fmt.Fprintf(&b, "%s%s\n", style(ansiBold, fmt.Sprintf("Wave %d", w.Index)), parallel)
+		for _, id := range w.Units {
+			n := byID[id]
+			line := "  • " + id
+			if n.Name != "" && n.Name != id {
+				line += ": " + n.Name
+			}
+			if len(n.Deps) > 0 {
+				line += " " + style(ansiDim, fmt.Sprintf("(depends: %s)", strings.Join(n.Deps, ", ")))
+			}
+			if n.Priority != "" {
+				line += " " + style(ansiDim, "["+n.Priority+"]")
+			}
+			b.WriteString(line + "\n")
+		}
+	}
+	if len(g.Terminal) > 0 {
+		b.WriteString("\n" + style(ansiDim, "Already terminal: "+strings.Join(g.Terminal, ", ")) + "\n")
+	}
+	fmt.Fprintf(&b, "\nCritical path (%d): %s\n", g.CriticalPathLength, strings.Join(g.CriticalPath, " → "))
+	fmt.Fprintf(&b, "Waves: %d | Max parallelism: %d\n", len(g.Waves), g.MaxParallelism)
+	if g.Sequential {
+		b.WriteString(style(ansiDim, "No (depends: ...) annotations — auto runs these milestones sequentially.") + "\n")
+	}
+	return b.String()
+}
+
+// renderPlanDOT emits a Graphviz digraph. Edges point from a dependency to
+// its dependent; each wave is a cluster, terminal units are greyed and the
+// critical path is drawn bold.
+func renderPlanDOT(g planGraph) string {
+	var b strings.Builder
+	title := "project"
+	if g.Scope == "feature" {
+		title = g.Feature
+	}
+	fmt.Fprintf(&b, "digraph %s {\n", dotQuote(title))
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box, style=rounded];\n")
+
+	critical := planCriticalEdges(g.CriticalPath)
+	for _, n := range g.Nodes {
+		attrs := []string{"label=" + dotQuote(planNodeLabel(n))}
+		if n.Terminal {
+			attrs = append(attrs, `style="rounded,filled"`, `fillcolor="#e0e0e0"`, `fontcolor="#666666"`)
+		} else if critical[n.ID] {
+			attrs = append(attrs, "penwidth=2")
+		}
+		fmt.Fprintf(&b, "  %s [%s];\n", dotQuote(n.ID), strings.Join(attrs, ", "))
+	}
+	for _, w := range g.Waves {
+		fmt.Fprintf(&b, "  subgraph cluster_wave%d {\n", w.Index)
+		fmt.Fprintf(&b, "    label=%s;\n", dotQuote(fmt.Sprintf("Wave %d", w.Index)))
+		b.WriteString("    style=dashed;\n")
+		for _, id := range w.Units {
+			fmt.Fprintf(&b, "    %s;\n", dotQuote(id))
+		}
+		b.WriteString("  }\n")
+	}
+	known := planKnownIDs(g.Nodes)
+	for _, n := range g.Nodes {
+		for _, dep := range n.Deps {
+			if !known[dep] {
+				continue
+			}
+			attr := ""
+			if critical[dep+"->"+n.ID] {
+				attr = " [penwidth=2, color=\"#c0392b\"]"
+			}
+			fmt.Fprintf(&b, "  %s -> %s%s;\n", dotQuote(dep), dotQuote(n.ID), attr)
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// renderPlanMermaid emits a Mermaid flowchart suitable for pasting into
+// Markdown (PR descriptions, design docs).
+func renderPlanMermaid(g planGraph) string {
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	critical := planCriticalEdges(g.CriticalPath)
+	byID := map[string]planNode{}
+	for _, n := range g.Nodes {
+		byID[n.ID] = n
+	}
+	for _, w := range g.Waves {
+		fmt.Fprintf(&b, "  subgraph wave%d[\"Wave %d\"]\n", w.Index, w.Index)
+		for _, id := range w.Units {
+			fmt.Fprintf(&b, "    %s[\"%s\"]\n", mermaidID(id), mermaidLabel(planNodeLabel(byID[id])))
+		}
+		b.WriteString("  end\n")
+	}
+	// Terminal units sit outside every wave.
+	for _, n := range g.Nodes {
+		if n.Terminal {
+			fmt.Fprintf(&b, "  %s[\"%s\"]\n", mermaidID(n.ID), mermaidLabel(planNodeLabel(n)))
+		}
+	}
+	known := planKnownIDs(g.Nodes)
+	for _, n := range g.Nodes {
+		for _, dep := range n.Deps {
+			if !known[dep] {
+				continue
+			}
+			arrow := "-->"
+			if critical[dep+"->"+n.ID] {
+				arrow = "==>"
+			}
+			fmt.Fprintf(&b, "  %s %s %s\n", mermaidID(dep), arrow, mermaidID(n.ID))
+		}
+	}
+	b.WriteString("  classDef terminal fill:#e0e0e0,color:#666666\n")
+	b.WriteString("  classDef critical stroke:#c0392b,stroke-width:2px\n")
+	var terminal, crit []string
+	for _, n := range g.Nodes {
+		if n.Terminal {
+			terminal = append(terminal, mermaidID(n.ID))
+		} else if critical[n.ID] {
+			crit = append(crit, mermaidID(n.ID))
+		}
+	}
+	if len(terminal) > 0 {
+		fmt.Fprintf(&b, "  class %s terminal\n", strings.Join(terminal, ","))
+	}
+	if len(crit) > 0 {
+		fmt.Fprintf(&b, "  class %s critical\n", strings.Join(crit, ","))
+	}
+	return b.String()
+}
+
+// planNodeLabel is "<id>: <name>\n(<status>)", trimmed for features whose
+// name is the slug.
+func planNodeLabel(n planNode) string {
+	label := n.ID
+	if n.Name != "" && n.Name != n.ID {
+		label += ": " + n.Name
+	}
+	if n.Status != "" {
+		label += "\n(" + strings.ToLower(n.Status) + ")"
+	}
+	return label
+}
+
+// planCriticalEdges indexes the critical path by node ID and by "a->b" edge.
+func planCriticalEdges(path []string) map[string]bool {
+	out := map[string]bool{}
+	for i, id := range path {
+		out[id] = true
+		if i > 0 {
+			out[path[i-1]+"->"+id] = true
+		}
+	}
+	return out
+}
+
+func planKnownIDs(nodes []planNode) map[string]bool {
+	known := map[string]bool{}
+	for _, n := range nodes {
+		known[n.ID] = true
+	}
+	return known
+}
+
+// dotQuote returns s as a DOT quoted string; newlines become DOT's \n escape.
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return `"` + s + `"`
+}
+
+// mermaidLabel escapes quotes and line breaks for a quoted Mermaid label.
+func mermaidLabel(s string) string {
+	s = strings.ReplaceAll(s, `"`, "#quot;")
+	return strings.ReplaceAll(s, "\n", "<br/>")
+}
+
+// mermaidID turns a slug like "user-auth" into a safe Mermaid node ID. Every
+// byte outside [A-Za-z0-9] is written as "_" and two hex digits ("_" itself
+// included), so distinct IDs such as "a-b" and "a_b" never share a node.
+func mermaidID(id string) string {
+	var b strings.Builder
+	b.WriteString("n_")
+	for i := 0; i < len(id); i++ {
+		c := id[i]
+		if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "_%02x", c)
+		}
+	}
+	return b.String()
+}
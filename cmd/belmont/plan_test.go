@@ -0,0 +1,157 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const planFeatureProgress = `# Progress: Shop
+
+## Milestones
+
+### M1: Scaffold
+- [x] P0-1: Route
+
+### M2: Catalog (depends: M1)
+- [ ] P1-1: List
+
+### M3: Cart (depends: M1)
+- [ ] P2-1: Add to cart
+
+### M4: Checkout (depends: M3)
+- [ ] P3-1: Pay
+
+## Session History
+`
+
+func TestBuildMilestonePlan_WavesAndCriticalPath(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, ".belmont/features/shop/PROGRESS.md", planFeatureProgress)
+
+	g, err := buildMilestonePlan(root, "shop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Waves) != 2 {
+		t.Fatalf("want 2 waves, got %+v", g.Waves)
+	}
+	if !equalStringSlices(g.Waves[0].Units, []string{"M2", "M3"}) || g.Waves[0].Parallelism != 2 {
+		t.Errorf("wave 1 wrong: %+v", g.Waves[0])
+	}
+	if !equalStringSlices(g.CriticalPath, []string{"M3", "M4"}) || g.CriticalPathLength != 2 {
+		t.Errorf("critical path: got %v", g.CriticalPath)
+	}
+	if !equalStringSlices(g.Terminal, []string{"M1"}) {
+		t.Errorf("terminal: got %v", g.Terminal)
+	}
+	if g.MaxParallelism != 2 {
+		t.Errorf("max parallelism: got %d", g.MaxParallelism)
+	}
+}
+
+func TestBuildMilestonePlan_NoDepsIsSequential(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, ".belmont/features/seq/PROGRESS.md", "## Milestones\n\n### M1: A\n- [ ] P0-1: a\n\n### M2: B\n- [ ] P1-1: b\n")
+
+	g, err := buildMilestonePlan(root, "seq")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !g.Sequential || len(g.Waves) != 2 || g.MaxParallelism != 1 {
+		t.Errorf("want sequential one-per-wave plan, got %+v", g)
+	}
+	if !equalStringSlices(g.CriticalPath, []string{"M1", "M2"}) {
+		t.Errorf("critical path: got %v", g.CriticalPath)
+	}
+}
+
+func TestBuildFeaturePlan_UsesMasterDeps(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, ".belmont/PROGRESS.md", `# Project Progress
+
+## Features
+
+| Feature | Slug | Priority | Dependencies | Status | Milestones | Tasks |
+|---------|------|----------|--------------|--------|------------|-------|
+| Auth | auth | P0 | None | In Progress | 0/1 | 0/1 |
+| Billing | billing | P1 | auth | Not Started | 0/1 | 0/1 |
+`)
+	writeFile(t, root, ".belmont/features/auth/PROGRESS.md", "## Milestones\n\n### M1: Login\n- [ ] P0-1: Form\n")
+	writeFile(t, root, ".belmont/features/billing/PROGRESS.md", "## Milestones\n\n### M1: Invoices\n- [ ] P0-1: List\n")
+
+	g, err := buildFeaturePlan(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Scope != "project" || len(g.Waves) != 2 {
+		t.Fatalf("want 2 project waves, got %+v", g.Waves)
+	}
+	if !equalStringSlices(g.CriticalPath, []string{"auth", "billing"}) {
+		t.Errorf("critical path: got %v", g.CriticalPath)
+	}
+}
+
+func TestRenderPlanDOTAndMermaid(t *testing.T) {
+	g := planGraph{
+		Scope:   "feature",
+		Feature: "shop",
+		Nodes: []planNode{
+			{ID: "M1", Name: "Scaffold", Status: "done", Terminal: true},
+			{ID: "M2", Name: "Cart \"v2\"", Status: "pending", Deps: []string{"M1"}},
+			{ID: "M3", Name: "Checkout", Status: "pending", Deps: []string{"M2"}},
+		},
+		Waves: []planWave{
+			{Index: 1, Units: []string{"M2"}, Parallelism: 1},
+			{Index: 2, Units: []string{"M3"}, Parallelism: 1},
+		},
+	}
+	finishPlanGraph(&g)
+
+	if plain := renderPlanText(g, false); strings.Contains(plain, "\033") || !strings.Contains(plain, "Wave 2\n  • M3: Checkout (depends: M2)") {
+		t.Errorf("plain text plan:\n%s", plain)
+	}
+	if colored := renderPlanText(g, true); !strings.Contains(colored, ansiBold+"Wave 1"+ansiReset) {
+		t.Errorf("colored text plan:\n%s", colored)
+	}
+
+	dot := renderPlanDOT(g)
+	for _, want := range []string{
+		`digraph "shop" {`,
+		`subgraph cluster_wave2 {`,
+		`"M1" -> "M2";`,
+		`"M2" -> "M3" [penwidth=2`,
+		`Cart \"v2\"`,
+	} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("DOT missing %q:\n%s", want, dot)
+		}
+	}
+
+	mm := renderPlanMermaid(g)
+	for _, want := range []string{
+		"flowchart LR",
+		`subgraph wave1["Wave 1"]`,
+		"n_M2 ==> n_M3",
+		"n_M1 --> n_M2",
+		"class n_M1 terminal",
+		"#quot;v2#quot;",
+	} {
+		if !strings.Contains(mm, want) {
+			t.Errorf("Mermaid missing %q:\n%s", want, mm)
+		}
+	}
+}
+
+func TestMermaidID_DistinctIDsStayDistinct(t *testing.T) {
+	seen := map[string]string{}
+	for _, id := range []string{"a-b", "a_b", "a_2db", "M1.1", "M1_1", "user-auth"} {
+		got := mermaidID(id)
+		if prev, ok := seen[got]; ok {
+			t.Errorf("%q and %q both map to %s", prev, id, got)
+		}
+		seen[got] = id
+	}
+	if got := mermaidID("user-auth"); got != "n_user_2dauth" {
+		t.Errorf("mermaidID(user-auth) = %s", got)
+	}
+}
@@ -0,0 +1,446 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ============================================================================
+// Worktree pool and shared caches
+//
+// A fresh worktree has no node_modules/, target/ or build cache, so every
+// milestone pays for a full install. With pool.size set in worktree.json,
+// a worktree whose branch merged is not deleted: it is reset to main, its
+// ignored files (installed dependencies, build output, .env copies) are
+// kept, and it is parked as a pool slot. The next unit takes a slot, resets
+// it to its fork point and runs the usual setup, which on a warm tree is an
+// incremental install. `belmont pool warm` pre-provisions slots ahead of a
+// run. The cache list shares package stores and build caches between all
+// worktrees of the project whether or not the pool is on.
+// ============================================================================
+
+// worktreePoolConfig is the pool block of worktree.json.
+type worktreePoolConfig struct {
+	Size int `json:"size"` // idle worktrees to keep; 0 disables the pool
+}
+
+// worktreePoolMu serialises slot handout between parallel units.
+var worktreePoolMu sync.Mutex
+
+const poolSlotPrefix = "slot-"
+
+// worktreeCacheStrategies maps a cache name to the env vars that point its
+// tool at a shared directory under the pool's cache/. Only caches that are
+// safe to share between concurrent builds belong here: Cargo's target dir,
+// for one, is locked per build, so it stays in each worktree (and warm in
+// its pool slot) and only CARGO_HOME is shared.
+var worktreeCacheStrategies = map[string]func(dir string) map[string]string{
+	"pnpm-store": func(dir string) map[string]string { return map[string]string{"npm_config_store_dir": dir} },
+	"npm-cache":  func(dir string) map[string]string { return map[string]string{"npm_config_cache": dir} },
+	"yarn-cache": func(dir string) map[string]string { return map[string]string{"YARN_CACHE_FOLDER": dir} },
+	"cargo-home": func(dir string) map[string]string { return map[string]string{"CARGO_HOME": dir} },
+	"go-build":   func(dir string) map[string]string { return map[string]string{"GOCACHE": dir} },
+}
+
+// cacheNodeModulesHardlink seeds a worktree's node_modules/ directories by
+// hardlinking the main checkout's, rather than setting an env var.
+const cacheNodeModulesHardlink = "node-modules-hardlink"
+
+// worktreePoolDir holds the project's pool slots and shared caches, next to
+// (not inside) its worktree directory so slots never show up in
+// `belmont recover --list`.
+func worktreePoolDir(root string) string {
+	base := worktreeBasePath(root)
+	return filepath.Join(filepath.Dir(base), ".pool", filepath.Base(base))
+}
+
+// poolSize is the configured pool size, or 0 when pooling is off.
+func poolSize(hooks *worktreeHooks) int {
+	if hooks == nil || hooks.Pool == nil || hooks.Pool.Size < 0 {
+		return 0
+	}
+	return hooks.Pool.Size
+}
+
+// poolSlots lists idle slots (git worktrees under the pool dir), in slot order.
+func poolSlots(root string) []string {
+	dir := worktreePoolDir(root)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var slots []string
+	for _, e := range entries {
+		if e.IsDir() && strings.HasPrefix(e.Name(), poolSlotPrefix) && fileExists(filepath.Join(dir, e.Name(), ".git")) {
+			slots = append(slots, filepath.Join(dir, e.Name()))
+		}
+	}
+	sort.Slice(slots, func(i, j int) bool { return slotNumber(slots[i]) < slotNumber(slots[j]) })
+	return slots
+}
+
+func slotNumber(path string) int {
+	n, _ := strconv.Atoi(strings.TrimPrefix(filepath.Base(path), poolSlotPrefix))
+	return n
+}
+
+// nextSlotPath returns an unused slot directory.
+func nextSlotPath(root string) string {
+	dir := worktreePoolDir(root)
+	for n := 1; ; n++ {
+		p := filepath.Join(dir, poolSlotPrefix+strconv.Itoa(n))
+		if _, err := os.Stat(p); os.IsNotExist(err) {
+			return p
+		}
+	}
+}
+
+// createUnitWorktree gives a unit its worktree at wtPath on a new branch
+// forked from HEAD: a pool slot when one is idle, otherwise `git worktree
+// add`.
+func createUnitWorktree(root, branch, wtPath string) error {
+	if err := os.MkdirAll(filepath.Dir(wtPath), 0755); err != nil {
+		return fmt.Errorf("create worktree dir: %w", err)
+	}
+	if slot, ok := acquirePoolSlot(root, branch, wtPath); ok {
+		fmt.Fprintf(os.Stderr, "    \033[2mReusing pooled worktree %s\033[0m\n", filepath.Base(slot))
+		return nil
+	}
+	cmd := exec.Command("git", "worktree", "add", "-b", branch, wtPath, "HEAD")
+	cmd.Dir = root
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git worktree add: %w (%s)", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// releaseUnitWorktree parks a merged unit's worktree in the pool, or
+// removes it when the pool is off or full.
+func releaseUnitWorktree(root, wtPath, id string) {
+	teardownWorktreeServices(root, wtPath)
+	if slot, ok := recyclePoolSlot(root, wtPath); ok {
+		fmt.Fprintf(os.Stderr, "  \033[2mReturned %s's worktree to the pool as %s\033[0m\n", id, filepath.Base(slot))
+		return
+	}
+	removeWorktree(root, wtPath, id)
+}
+
+// acquirePoolSlot moves an idle slot to wtPath and checks out a new branch
+// at the current HEAD of root. A slot that can't be moved or reset is
+// discarded and the next one tried.
+func acquirePoolSlot(root, branch, wtPath string) (string, bool) {
+	if poolSize(loadWorktreeHooks(root)) == 0 {
+		return "", false
+	}
+	check := exec.Command("git", "rev-parse", "--verify", "--quiet", "refs/heads/"+branch)
+	check.Dir = root
+	if check.Run() == nil {
+		return "", false // let `git worktree add -b` report the existing branch
+	}
+	fork := captureGitSHA(root)
+	if fork == "" {
+		return "", false
+	}
+
+	worktreePoolMu.Lock()
+	defer worktreePoolMu.Unlock()
+	for _, slot := range poolSlots(root) {
+		mv := exec.Command("git", "worktree", "move", slot, wtPath)
+		mv.Dir = root
+		if out, err := mv.CombinedOutput(); err != nil {
+			fmt.Fprintf(os.Stderr, "    \033[33m⚠ Discarding pool slot %s: %s\033[0m\n", filepath.Base(slot), strings.TrimSpace(string(out)))
+			removeWorktree(root, slot, "")
+			continue
+		}
+		if err := resetPooledWorktree(wtPath, fork, branch); err != nil {
+			fmt.Fprintf(os.Stderr, "    \033[33m⚠ Discarding pool slot %s: %v\033[0m\n", filepath.Base(slot), err)
+			removeWorktree(root, wtPath, "")
+			continue
+		}
+		return slot, true
+	}
+	return "", false
+}
+
+// recyclePoolSlot resets a worktree to root's HEAD (detached) and moves it
+// into the pool. Returns false, leaving the worktree alone, when the pool
+// is off or full or the reset fails.
+func recyclePoolSlot(root, wtPath string) (string, bool) {
+	size := poolSize(loadWorktreeHooks(root))
+	if size == 0 {
+		return "", false
+	}
+	worktreePoolMu.Lock()
+	defer worktreePoolMu.Unlock()
+	if len(poolSlots(root)) >= size {
+		return "", false
+	}
+	if err := resetPooledWorktree(wtPath, captureGitSHA(root), ""); err != nil {
+		fmt.Fprintf(os.Stderr, "  \033[33m⚠ Could not recycle worktree: %v\033[0m\n", err)
+		return "", false
+	}
+	slot := nextSlotPath(root)
+	if err := os.MkdirAll(filepath.Dir(slot), 0755); err != nil {
+		return "", false
+	}
+	mv := exec.Command("git", "worktree", "move", wtPath, slot)
+	mv.Dir = root
+	if err := mv.Run(); err != nil {
+		return "", false
+	}
+	return slot, true
+}
+
+// resetPooledWorktree puts a worktree back to rev with tracked files
+// restored and untracked files removed. Ignored files — installed
+// dependencies, build output — stay; that is what keeps the slot warm. The
+// orchestrator's .belmont/ copy (assume-unchanged, excluded) is dropped
+// first so the checkout restores the committed state. With a branch it is
+// created at rev and checked out; otherwise HEAD is detached at rev.
+func resetPooledWorktree(wtPath, rev, branch string) error {
+	if rev == "" {
+		return fmt.Errorf("no revision to reset to")
+	}
+	if ls, err := gitOutputIn(wtPath, "ls-files", ".belmont/"); err == nil {
+		for _, f := range strings.Split(ls, "\n") {
+			if f != "" {
+				c := exec.Command("git", "update-index", "--no-assume-unchanged", "--", f)
+				c.Dir = wtPath
+				c.Run()
+			}
+		}
+	}
+	os.RemoveAll(filepath.Join(wtPath, ".belmont"))
+
+	args := []string{"checkout", "-q", "-f", "--detach", rev}
+	if branch != "" {
+		args = []string{"checkout", "-q", "-f", "-B", branch, rev}
+	}
+	if _, err := gitOutputIn(wtPath, args...); err != nil {
+		return err
+	}
+	_, err := gitOutputIn(wtPath, "clean", "-fdq")
+	return err
+}
+
+// gitOutputIn runs git in dir and returns trimmed stdout, folding stderr
+// into the error.
+func gitOutputIn(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w (%s)", args[0], err, strings.TrimSpace(string(out)))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// prepareWorktreeCaches applies the cache strategies from worktree.json to
+// a new worktree and returns the env to run its hooks and agent with: the
+// cache variables overlaid by the user's own env block. Unknown strategy
+// names are warned about and skipped. Returns hooks.Env unchanged when no
+// caches are configured.
+func prepareWorktreeCaches(root, wtPath string, hooks *worktreeHooks, workspaces []workspaceInfo) map[string]string {
+	if hooks == nil {
+		return nil
+	}
+	if len(hooks.Cache) == 0 {
+		return hooks.Env
+	}
+	env := map[string]string{}
+	cacheRoot := filepath.Join(worktreePoolDir(root), "cache")
+	for _, name := range hooks.Cache {
+		if name == cacheNodeModulesHardlink {
+			if n := seedNodeModules(root, wtPath, workspaces); n > 0 {
+				fmt.Fprintf(os.Stderr, "    \033[2mHardlinked %d node_modules dir(s) from the main checkout\033[0m\n", n)
+			}
+			continue
+		}
+		strategy, ok := worktreeCacheStrategies[name]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "    \033[33m⚠ Unknown cache strategy %q in worktree.json — skipping\033[0m\n", name)
+			continue
+		}
+		dir := filepath.Join(cacheRoot, name)
+		os.MkdirAll(dir, 0755)
+		for k, v := range strategy(dir) {
+			env[k] = v
+		}
+	}
+	for k, v := range hooks.Env {
+		env[k] = v
+	}
+	return env
+}
+
+// seedNodeModules hardlinks node_modules/ from the main checkout (root and
+// each workspace) into a worktree that doesn't have one yet. Returns how
+// many directories were seeded.
+func seedNodeModules(root, wtPath string, workspaces []workspaceInfo) int {
+	dirs := []string{"."}
+	for _, ws := range workspaces {
+		dirs = append(dirs, ws.Path)
+	}
+	seeded := 0
+	for _, d := range uniqueStrings(dirs) {
+		src := filepath.Join(root, d, "node_modules")
+		dst := filepath.Join(wtPath, d, "node_modules")
+		if !dirExists(src) || dirExists(dst) || !dirExists(filepath.Dir(dst)) {
+			continue
+		}
+		if err := linkTree(src, dst); err != nil {
+			fmt.Fprintf(os.Stderr, "    \033[33m⚠ Could not hardlink %s: %v\033[0m\n", filepath.Join(d, "node_modules"), err)
+			os.RemoveAll(dst)
+			continue
+		}
+		seeded++
+	}
+	return seeded
+}
+
+// linkTree recreates src at dst with directories copied, symlinks
+// recreated as-is and regular files hardlinked (copied when linking fails,
+// e.g. across filesystems).
+func linkTree(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(src, path)
+		target := filepath.Join(dst, rel)
+		switch {
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case d.IsDir():
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			return os.MkdirAll(target, info.Mode().Perm())
+		default:
+			if os.Link(path, target) == nil {
+				return nil
+			}
+			return copyFile(path, target)
+		}
+	})
+}
+
+// runPoolCmd handles "belmont pool".
+func runPoolCmd(args []string) error {
+	op := "list"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		op, args = args[0], args[1:]
+	}
+	fs := flag.NewFlagSet("pool "+op, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	var root, format string
+	var size int
+	fs.StringVar(&root, "root", ".", "project root")
+	fs.StringVar(&format, "format", "text", "text or json (list)")
+	fs.IntVar(&size, "size", 0, "slots to provision (warm); defaults to pool.size")
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("pool: %w", err)
+	}
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return fmt.Errorf("pool: resolve root: %w", err)
+	}
+
+	switch op {
+	case "list":
+		return poolList(absRoot, format)
+	case "warm":
+		if size == 0 {
+			size = poolSize(loadWorktreeHooks(absRoot))
+		}
+		if size == 0 {
+			return fmt.Errorf("pool: no pool.size in .belmont/worktree.json (or pass --size)")
+		}
+		return poolWarm(absRoot, size)
+	case "clean":
+		slots := poolSlots(absRoot)
+		for _, s := range slots {
+			removeWorktree(absRoot, s, "")
+		}
+		fmt.Fprintf(os.Stderr, "Removed %d pooled worktree(s).\n", len(slots))
+		return nil
+	default:
+		return fmt.Errorf("pool: unknown subcommand %q (list|warm|clean)", op)
+	}
+}
+
+func poolList(root, format string) error {
+	slots := poolSlots(root)
+	if format == "json" {
+		out := struct {
+			Size  int      `json:"size"`
+			Slots []string `json:"slots"`
+			Dir   string   `json:"dir"`
+		}{poolSize(loadWorktreeHooks(root)), slots, worktreePoolDir(root)}
+		if out.Slots == nil {
+			out.Slots = []string{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	}
+	fmt.Printf("Worktree pool: %d idle of %d (%s)\n", len(slots), poolSize(loadWorktreeHooks(root)), worktreePoolDir(root))
+	for _, s := range slots {
+		fmt.Printf("  %s\n", filepath.Base(s))
+	}
+	return nil
+}
+
+// poolWarm provisions slots up to size: a detached worktree at HEAD with
+// env files seeded, caches applied and the setup hooks (or auto-detected
+// install) run, so the first units of the next run start warm.
+func poolWarm(root string, size int) error {
+	hooks := loadWorktreeHooks(root)
+	workspaces, primary, mType := resolveWorkspaces(root, hooks)
+	overrides := map[string]workspaceOverride{}
+	if hooks != nil {
+		overrides = hooks.Workspaces
+	}
+	worktreePoolMu.Lock()
+	defer worktreePoolMu.Unlock()
+
+	have := len(poolSlots(root))
+	for have < size {
+		slot := nextSlotPath(root)
+		if err := os.MkdirAll(filepath.Dir(slot), 0755); err != nil {
+			return fmt.Errorf("pool: %w", err)
+		}
+		if _, err := gitOutputIn(root, "worktree", "add", "--detach", slot, "HEAD"); err != nil {
+			return fmt.Errorf("pool: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "\033[36m▶ Provisioning %s\033[0m\n", filepath.Base(slot))
+		copyEnvFiles(root, slot, workspaces, overrides)
+		env := prepareWorktreeCaches(root, slot, hooks, workspaces)
+		cmds := detectAutoInstallCommands(root)
+		if hooks != nil {
+			cmds = hooks.Setup
+		}
+		if err := runWorktreeHookCommands(cmds, slot, 0, env, workspaces, primary, mType, newWorktreeContainer(root, slot, hooks)); err != nil {
+			removeWorktree(root, slot, "")
+			return fmt.Errorf("pool: provision %s: %w", filepath.Base(slot), err)
+		}
+		have++
+	}
+	fmt.Fprintf(os.Stderr, "\033[32m✓ Worktree pool has %d slot(s)\033[0m\n", have)
+	return nil
+}
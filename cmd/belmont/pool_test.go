@@ -0,0 +1,124 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func setupPoolRepo(t *testing.T, worktreeJSON string) string {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	root := filepath.Join(t.TempDir(), "app")
+	if err := os.MkdirAll(root, 0755); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, root, "init", "-q", "-b", "main")
+	runGit(t, root, "config", "user.email", "t@example.com")
+	runGit(t, root, "config", "user.name", "t")
+	writeFile(t, root, ".gitignore", "node_modules/\n")
+	writeFile(t, root, ".belmont/worktree.json", worktreeJSON)
+	writeFile(t, root, "app.txt", "v1\n")
+	runGit(t, root, "add", "-A")
+	runGit(t, root, "commit", "-q", "-m", "base")
+	return root
+}
+
+func TestWorktreePool_RecycleKeepsIgnoredFilesAndResetsToForkPoint(t *testing.T) {
+	root := setupPoolRepo(t, `{"pool": {"size": 1}}`)
+	base := worktreeBasePath(root)
+
+	first := filepath.Join(base, "m1")
+	if err := createUnitWorktree(root, "belmont/m1", first); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, first, "node_modules/dep/index.js", "warm\n")
+	writeFile(t, first, "scratch.txt", "untracked\n")
+	writeFile(t, first, "app.txt", "edited\n")
+	writeFile(t, first, ".belmont/features/x/PROGRESS.md", "worktree copy\n")
+
+	releaseUnitWorktree(root, first, "M1")
+	if dirExists(first) {
+		t.Fatal("released worktree should have moved into the pool")
+	}
+	if slots := poolSlots(root); len(slots) != 1 {
+		t.Fatalf("slots = %v", slots)
+	}
+
+	writeFile(t, root, "app.txt", "v2\n")
+	runGit(t, root, "commit", "-q", "-am", "main moves on")
+
+	second := filepath.Join(base, "m2")
+	if err := createUnitWorktree(root, "belmont/m2", second); err != nil {
+		t.Fatal(err)
+	}
+	if len(poolSlots(root)) != 0 {
+		t.Error("slot should have been handed out")
+	}
+	if got, want := runGit(t, second, "rev-parse", "HEAD"), runGit(t, root, "rev-parse", "HEAD"); got != want {
+		t.Errorf("HEAD = %s, want fork point %s", got, want)
+	}
+	if b := runGit(t, second, "rev-parse", "--abbrev-ref", "HEAD"); b != "belmont/m2" {
+		t.Errorf("branch = %s", b)
+	}
+	if data, _ := os.ReadFile(filepath.Join(second, "app.txt")); string(data) != "v2\n" {
+		t.Errorf("app.txt = %q", data)
+	}
+	if !fileExists(filepath.Join(second, "node_modules/dep/index.js")) {
+		t.Error("ignored node_modules should survive the pool")
+	}
+	if fileExists(filepath.Join(second, "scratch.txt")) || dirExists(filepath.Join(second, ".belmont/features/x")) {
+		t.Error("untracked files and the orchestrator's .belmont copy should be cleared")
+	}
+}
+
+func TestWorktreePool_FullPoolRemoves(t *testing.T) {
+	root := setupPoolRepo(t, `{"pool": {"size": 0}}`)
+	wt := filepath.Join(worktreeBasePath(root), "m1")
+	if err := createUnitWorktree(root, "belmont/m1", wt); err != nil {
+		t.Fatal(err)
+	}
+	releaseUnitWorktree(root, wt, "M1")
+	if dirExists(wt) || len(poolSlots(root)) != 0 {
+		t.Error("with the pool off the worktree should be removed")
+	}
+	if out := runGit(t, root, "worktree", "list"); strings.Count(out, "\n") != 0 {
+		t.Errorf("worktrees left:\n%s", out)
+	}
+}
+
+func TestPrepareWorktreeCaches(t *testing.T) {
+	root := setupPoolRepo(t, `{}`)
+	writeFile(t, root, "node_modules/dep/index.js", "x\n")
+	writeFile(t, root, "packages/web/node_modules/react/index.js", "r\n")
+	wt := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(wt, "packages/web"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	hooks := &worktreeHooks{
+		Cache: []string{"pnpm-store", "cargo-home", "node-modules-hardlink", "bogus"},
+		Env:   map[string]string{"CARGO_HOME": "/custom"},
+	}
+	env := prepareWorktreeCaches(root, wt, hooks, []workspaceInfo{{ID: "web", Path: "packages/web"}})
+
+	if want := filepath.Join(worktreePoolDir(root), "cache", "pnpm-store"); env["npm_config_store_dir"] != want || !dirExists(want) {
+		t.Errorf("pnpm store = %q, want %q", env["npm_config_store_dir"], want)
+	}
+	if env["CARGO_HOME"] != "/custom" {
+		t.Errorf("user env should win, CARGO_HOME = %q", env["CARGO_HOME"])
+	}
+	if _, ok := env["CARGO_TARGET_DIR"]; ok {
+		t.Error("CARGO_TARGET_DIR must not be shared between parallel worktrees")
+	}
+	for _, rel := range []string{"node_modules/dep/index.js", "packages/web/node_modules/react/index.js"} {
+		a, errA := os.Stat(filepath.Join(root, rel))
+		b, errB := os.Stat(filepath.Join(wt, rel))
+		if errA != nil || errB != nil || !os.SameFile(a, b) {
+			t.Errorf("%s not hardlinked (%v, %v)", rel, errA, errB)
+		}
+	}
+	if env := prepareWorktreeCaches(root, wt, &worktreeHooks{Env: map[string]string{"A": "1"}}, nil); env["A"] != "1" || len(env) != 1 {
+		t.Errorf("no caches should pass env through, got %v", env)
+	}
+}
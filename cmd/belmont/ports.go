@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ============================================================================
+// Named ports
+//
+// Each worktree gets one primary port (PORT / BELMONT_PORT) for its main dev
+// server. A monorepo that runs a web app, an API and Storybook side by side
+// declares the others by name — ports at the top of worktree.json, or a
+// workspace's own ports — and every worktree gets its own free port for
+// each. They are exported as BELMONT_PORT_<NAME> (BELMONT_PORT_<WORKSPACE>_
+// <NAME> for a workspace's ports) plus a BELMONT_PORTS JSON map, published
+// from the container in container mode, and recorded in auto.json so
+// `belmont status` can show which URL belongs to which running worktree.
+// ============================================================================
+
+// namedPortRequest is one port asked for in worktree.json.
+type namedPortRequest struct {
+	Workspace string // empty for a top-level port
+	Name      string
+}
+
+// key is how the port is recorded: "name" or "workspace/name".
+func (r namedPortRequest) key() string {
+	if r.Workspace == "" {
+		return r.Name
+	}
+	return r.Workspace + "/" + r.Name
+}
+
+// namedPortRequests lists the requested ports: top-level first, then each
+// workspace's in workspace ID order. Duplicates are dropped.
+func namedPortRequests(hooks *worktreeHooks) []namedPortRequest {
+	if hooks == nil {
+		return nil
+	}
+	var reqs []namedPortRequest
+	seen := map[string]bool{}
+	add := func(r namedPortRequest) {
+		if r.Name != "" && !seen[r.key()] {
+			seen[r.key()] = true
+			reqs = append(reqs, r)
+		}
+	}
+	for _, name := range hooks.Ports {
+		add(namedPortRequest{Name: name})
+	}
+	ids := make([]string, 0, len(hooks.Workspaces))
+	for id := range hooks.Workspaces {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		for _, name := range hooks.Workspaces[id].Ports {
+			add(namedPortRequest{Workspace: id, Name: name})
+		}
+	}
+	return reqs
+}
+
+var unsafeEnvNameChars = regexp.MustCompile(`[^A-Z0-9]+`)
+
+// namedPortEnvName is the variable a port key is exported as.
+func namedPortEnvName(key string) string {
+	return "BELMONT_PORT_" + strings.Trim(unsafeEnvNameChars.ReplaceAllString(strings.ToUpper(key), "_"), "_")
+}
+
+// allocateNamedPorts allocates a distinct free port for every requested
+// name, none equal to the primary port.
+func allocateNamedPorts(hooks *worktreeHooks, primary int) (map[string]int, error) {
+	reqs := namedPortRequests(hooks)
+	if len(reqs) == 0 {
+		return nil, nil
+	}
+	taken := map[int]bool{primary: true}
+	ports := make(map[string]int, len(reqs))
+	for _, r := range reqs {
+		var port int
+		for attempt := 0; attempt < 10; attempt++ {
+			p, err := allocatePort()
+			if err != nil {
+				return nil, fmt.Errorf("allocate port %s: %w", r.key(), err)
+			}
+			if !taken[p] {
+				port = p
+				break
+			}
+		}
+		if port == 0 {
+			return nil, fmt.Errorf("allocate port %s: no distinct free port", r.key())
+		}
+		taken[port] = true
+		ports[r.key()] = port
+	}
+	return ports, nil
+}
+
+// withNamedPorts returns a copy of env with the named port variables and
+// BELMONT_PORTS added.
+func withNamedPorts(env map[string]string, ports map[string]int) map[string]string {
+	out := make(map[string]string, len(env)+len(ports)+1)
+	for k, v := range env {
+		out[k] = v
+	}
+	if len(ports) == 0 {
+		return out
+	}
+	for key, port := range ports {
+		out[namedPortEnvName(key)] = fmt.Sprint(port)
+	}
+	data, _ := json.Marshal(ports)
+	out["BELMONT_PORTS"] = string(data)
+	return out
+}
+
+// formatNamedPorts renders ports as "name=port" pairs in key order.
+func formatNamedPorts(ports map[string]int) string {
+	keys := make([]string, 0, len(ports))
+	for k := range ports {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s=%d", k, ports[k]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// worktreeStatus is a running worktree's ports in `belmont status`.
+type worktreeStatus struct {
+	ID    string         `json:"id"`
+	Path  string         `json:"path"`
+	Port  int            `json:"port,omitempty"`
+	Ports map[string]int `json:"ports,omitempty"`
+}
+
+// activeWorktreeStatuses reads the ports of the running worktrees from
+// auto.json. Nil when no auto run is active or no worktree has a port yet.
+func activeWorktreeStatuses(root string) []worktreeStatus {
+	aj := readActiveAutoJSONOrNil(root)
+	if aj == nil {
+		return nil
+	}
+	var out []worktreeStatus
+	for id, e := range aj.Worktrees {
+		if e.Port == 0 && len(e.Ports) == 0 {
+			continue
+		}
+		out = append(out, worktreeStatus{ID: id, Path: e.Path, Port: e.Port, Ports: e.Ports})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// renderWorktreePorts writes the Worktrees block of the status output.
+func renderWorktreePorts(sb *strings.Builder, worktrees []worktreeStatus) {
+	if len(worktrees) == 0 {
+		return
+	}
+	sb.WriteString("Worktrees:\n")
+	for _, w := range worktrees {
+		line := "  " + w.ID
+		if w.Port != 0 {
+			line += fmt.Sprintf("  http://localhost:%d", w.Port)
+		}
+		keys := make([]string, 0, len(w.Ports))
+		for k := range w.Ports {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			line += fmt.Sprintf("  %s=http://localhost:%d", k, w.Ports[k])
+		}
+		sb.WriteString(line + "\n")
+	}
+	sb.WriteString("\n")
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestNamedPorts_RequestsAndEnvNames(t *testing.T) {
+	hooks := &worktreeHooks{
+		Ports: []string{"api", "api"},
+		Workspaces: map[string]workspaceOverride{
+			"web":       {Path: "apps/web", Ports: []string{"storybook"}},
+			"@acme/api": {Path: "apps/api", Ports: []string{"debug"}},
+		},
+	}
+	var keys []string
+	for _, r := range namedPortRequests(hooks) {
+		keys = append(keys, r.key())
+	}
+	if got := strings.Join(keys, ","); got != "api,@acme/api/debug,web/storybook" {
+		t.Errorf("requests = %s", got)
+	}
+	for key, want := range map[string]string{
+		"api":               "BELMONT_PORT_API",
+		"web/storybook":     "BELMONT_PORT_WEB_STORYBOOK",
+		"@acme/api/debug":   "BELMONT_PORT_ACME_API_DEBUG",
+		"admin-ui/dev-tool": "BELMONT_PORT_ADMIN_UI_DEV_TOOL",
+	} {
+		if got := namedPortEnvName(key); got != want {
+			t.Errorf("namedPortEnvName(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestNamedPorts_AllocateDistinctAndExport(t *testing.T) {
+	hooks := &worktreeHooks{Ports: []string{"api", "storybook", "docs"}}
+	ports, err := allocateNamedPorts(hooks, 4000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := map[int]bool{4000: true}
+	for k, p := range ports {
+		if p == 0 || seen[p] {
+			t.Errorf("port %s = %d is zero or duplicated", k, p)
+		}
+		seen[p] = true
+	}
+	if len(ports) != 3 {
+		t.Fatalf("ports = %v", ports)
+	}
+
+	base := map[string]string{"KEEP": "1"}
+	env := withNamedPorts(base, ports)
+	if len(base) != 1 {
+		t.Error("withNamedPorts must not modify its input")
+	}
+	if env["KEEP"] != "1" || env["BELMONT_PORT_STORYBOOK"] == "" {
+		t.Errorf("env = %v", env)
+	}
+	var decoded map[string]int
+	if err := json.Unmarshal([]byte(env["BELMONT_PORTS"]), &decoded); err != nil || decoded["api"] != ports["api"] {
+		t.Errorf("BELMONT_PORTS = %s (%v)", env["BELMONT_PORTS"], err)
+	}
+
+	if none, err := allocateNamedPorts(&worktreeHooks{}, 4000); err != nil || none != nil {
+		t.Errorf("no requests = %v, %v", none, err)
+	}
+}
+
+func TestNamedPorts_StatusShowsRunningWorktrees(t *testing.T) {
+	root := t.TempDir()
+	tracker := &worktreeTracker{root: root, entries: map[string]worktreeEntry{}}
+	writeFile(t, root, ".belmont/.keep", "")
+	tracker.add("M2", "/wt/feat-m2", "belmont/m2")
+	tracker.setPort("M2", 51000)
+	tracker.setPorts("M2", map[string]int{"storybook": 51002, "api": 51001})
+	tracker.add("M3", "/wt/feat-m3", "belmont/m3")
+
+	ws := activeWorktreeStatuses(root)
+	if len(ws) != 1 || ws[0].ID != "M2" || ws[0].Ports["api"] != 51001 {
+		t.Fatalf("statuses = %+v", ws)
+	}
+	var sb strings.Builder
+	renderWorktreePorts(&sb, ws)
+	want := "Worktrees:\n  M2  http://localhost:51000  api=http://localhost:51001  storybook=http://localhost:51002\n\n"
+	if sb.String() != want {
+		t.Errorf("render = %q, want %q", sb.String(), want)
+	}
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ============================================================================
+// Project version pin
+//
+// `belmont install` records the installing binary in .belmont/version.json:
+// its version (the skills and agents in .agents/ were generated by it) and
+// the state format it writes. Teammates run different binaries against the
+// same repo, so every command compares itself with the pin first:
+//
+//   - a newer state format than this binary understands is refused — an old
+//     binary would misread PROGRESS.md syntax it doesn't know;
+//   - a pin newer than the binary warns (refuses with "strict": true) — the
+//     installed skills expect CLI behaviour this binary lacks;
+//   - a binary newer than the pin warns that `belmont install` will re-sync
+//     skills and move the pin (refuses when strict).
+//
+// install, update, doctor, version and help are exempt: they are how a
+// mismatch gets fixed. BELMONT_SKIP_VERSION_CHECK=1 bypasses the check.
+// ============================================================================
+
+// projectStateFormat is the .belmont state format this binary reads and
+// writes. Bump it when PROGRESS.md, PRD.md or worktree.json gain syntax that
+// older binaries would silently misread.
+const projectStateFormat = 1
+
+const projectPinFile = "version.json"
+
+// projectPin is .belmont/version.json.
+type projectPin struct {
+	Version     string `json:"version"`          // belmont version that installed the skills, without "v"
+	StateFormat int    `json:"state_format"`     // projectStateFormat of that version
+	Strict      bool   `json:"strict,omitempty"` // refuse, rather than warn, on any mismatch
+}
+
+func projectPinPath(root string) string {
+	return filepath.Join(root, ".belmont", projectPinFile)
+}
+
+// readProjectPin returns nil when the project has no pin.
+func readProjectPin(root string) (*projectPin, error) {
+	data, err := os.ReadFile(projectPinPath(root))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var pin projectPin
+	if err := json.Unmarshal(data, &pin); err != nil {
+		return nil, fmt.Errorf("%s: %w", projectPinPath(root), err)
+	}
+	pin.Version = strings.TrimPrefix(strings.TrimSpace(pin.Version), "v")
+	return &pin, nil
+}
+
+// writeProjectPin records version as the project's pin, keeping an existing
+// strict setting. Returns the previous version ("" when there was none).
+func writeProjectPin(root, version string) (string, error) {
+	prev, _ := readProjectPin(root)
+	pin := projectPin{Version: strings.TrimPrefix(version, "v"), StateFormat: projectStateFormat}
+	old := ""
+	if prev != nil {
+		pin.Strict = prev.Strict
+		old = prev.Version
+		if prev.StateFormat > pin.StateFormat {
+			pin.StateFormat = prev.StateFormat
+		}
+	}
+	data, err := json.MarshalIndent(pin, "", "  ")
+	if err != nil {
+		return old, err
+	}
+	if err := os.MkdirAll(filepath.Join(root, ".belmont"), 0o755); err != nil {
+		return old, err
+	}
+	return old, os.WriteFile(projectPinPath(root), append(data, '\n'), 0o644)
+}
+
+// pinCompatibility compares a pin with the running binary. refuse is set
+// when the command must not run; warn when it may, but the user should know.
+// Development builds skip the version comparison but not the format check.
+func pinCompatibility(pin *projectPin, binVersion string) (warn, refuse string) {
+	if pin == nil {
+		return "", ""
+	}
+	pinTag := "v" + pin.Version
+	if pin.StateFormat > projectStateFormat {
+		return "", fmt.Sprintf("this project's .belmont state format (%d, written by belmont %s) is newer than this binary understands (%d) — run `belmont update --pinned`", pin.StateFormat, pinTag, projectStateFormat)
+	}
+	if binVersion == "dev" || pin.Version == "" || pin.Version == binVersion {
+		return "", ""
+	}
+	binTag := "v" + binVersion
+	var msg string
+	if isNewer(pinTag, binTag) {
+		msg = fmt.Sprintf("skills in this project were installed by belmont %s, newer than this binary (%s) — run `belmont update --pinned`", pinTag, binTag)
+	} else {
+		msg = fmt.Sprintf("skills in this project were installed by belmont %s; this binary is %s — run `belmont install` to re-sync them and move the pin", pinTag, binTag)
+	}
+	if pin.Strict {
+		return "", msg + " (strict pin in .belmont/" + projectPinFile + ")"
+	}
+	return msg, ""
+}
+
+// pinExemptCommands fix a version mismatch, so they never get refused.
+var pinExemptCommands = map[string]bool{
+	"install": true, "update": true, "doctor": true,
+	"version": true, "--version": true, "-v": true,
+	"help": true, "-h": true, "--help": true,
+}
+
+// enforceProjectPin runs before every command: it prints a warning or
+// returns an error when the project's pin and this binary disagree.
+func enforceProjectPin(cmd string, args []string) error {
+	if pinExemptCommands[cmd] || os.Getenv("BELMONT_SKIP_VERSION_CHECK") != "" {
+		return nil
+	}
+	root := projectRootFromArgs(args)
+	pin, err := readProjectPin(root)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "\033[33m⚠ %s\033[0m\n", err)
+		return nil
+	}
+	warn, refuse := pinCompatibility(pin, Version)
+	if refuse != "" {
+		return fmt.Errorf("%s: %s (set BELMONT_SKIP_VERSION_CHECK=1 to override)", cmd, refuse)
+	}
+	if warn != "" {
+		fmt.Fprintf(os.Stderr, "\033[33m⚠ %s\033[0m\n", warn)
+	}
+	return nil
+}
+
+// projectRootFromArgs finds a --root or --project value in a command's
+// arguments, without parsing its other flags. Defaults to ".".
+func projectRootFromArgs(args []string) string {
+	for i, a := range args {
+		for _, name := range []string{"root", "project"} {
+			for _, prefix := range []string{"--" + name, "-" + name} {
+				if a == prefix && i+1 < len(args) {
+					return args[i+1]
+				}
+				if strings.HasPrefix(a, prefix+"=") {
+					return strings.TrimPrefix(a, prefix+"=")
+				}
+			}
+		}
+	}
+	return "."
+}
+
+// doctorCheckPin reports the pin from `belmont doctor`.
+func doctorCheckPin(root string) []doctorCheck {
+	pin, err := readProjectPin(root)
+	if err != nil {
+		return []doctorCheck{{Area: "version pin", Status: doctorError, Message: err.Error(), Fix: "fix the JSON, or delete it and run `belmont install`"}}
+	}
+	if pin == nil {
+		if !dirExists(filepath.Join(root, ".agents", "skills", "belmont")) {
+			return nil
+		}
+		return []doctorCheck{{Area: "version pin", Status: doctorWarn, Message: "no .belmont/" + projectPinFile + " — teammates' binaries can't be checked against the installed skills", Fix: "`belmont install` records it"}}
+	}
+	warn, refuse := pinCompatibility(pin, Version)
+	switch {
+	case refuse != "":
+		return []doctorCheck{{Area: "version pin", Status: doctorError, Message: refuse}}
+	case warn != "":
+		return []doctorCheck{{Area: "version pin", Status: doctorWarn, Message: warn}}
+	}
+	return []doctorCheck{{Area: "version pin", Status: doctorOK, Message: "project pinned to belmont v" + pin.Version}}
+}
+
+// offerPinSync asks whether `belmont update` should move the project pin to
+// tag. Without a TTY, or without an existing pin, it does.
+func offerPinSync(root, tag string) bool {
+	pin, err := readProjectPin(root)
+	if err != nil || pin == nil || "v"+pin.Version == normalizeReleaseTag(tag) || !isTerminal(os.Stdin) {
+		return true
+	}
+	fmt.Printf("\nThis project is pinned to belmont v%s. Move the pin to %s for everyone? [Y/n]: ", pin.Version, tag)
+	var answer string
+	fmt.Scanln(&answer)
+	if a := strings.ToLower(strings.TrimSpace(answer)); a == "n" || a == "no" {
+		fmt.Println("Keeping the pin; skills are re-synced locally only. Teammates keep v" + pin.Version + ".")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestPinCompatibility(t *testing.T) {
+	cases := []struct {
+		name         string
+		pin          *projectPin
+		bin          string
+		warn, refuse string
+	}{
+		{"no pin", nil, "0.11.0", "", ""},
+		{"same version", &projectPin{Version: "0.11.0", StateFormat: 1}, "0.11.0", "", ""},
+		{"pin newer", &projectPin{Version: "0.12.0", StateFormat: 1}, "0.11.0", "update --pinned", ""},
+		{"binary newer", &projectPin{Version: "0.10.0", StateFormat: 1}, "0.11.0", "belmont install", ""},
+		{"strict pin newer", &projectPin{Version: "0.12.0", StateFormat: 1, Strict: true}, "0.11.0", "", "strict pin"},
+		{"strict binary newer", &projectPin{Version: "0.10.0", StateFormat: 1, Strict: true}, "0.11.0", "", "strict pin"},
+		{"newer state format", &projectPin{Version: "0.12.0", StateFormat: projectStateFormat + 1}, "0.11.0", "", "state format"},
+		{"dev build skips versions", &projectPin{Version: "0.12.0", StateFormat: 1, Strict: true}, "dev", "", ""},
+		{"dev build checks format", &projectPin{Version: "0.12.0", StateFormat: projectStateFormat + 1}, "dev", "", "state format"},
+	}
+	for _, c := range cases {
+		warn, refuse := pinCompatibility(c.pin, c.bin)
+		if (c.warn == "") != (warn == "") || !strings.Contains(warn, c.warn) {
+			t.Errorf("%s: warn = %q, want %q", c.name, warn, c.warn)
+		}
+		if (c.refuse == "") != (refuse == "") || !strings.Contains(refuse, c.refuse) {
+			t.Errorf("%s: refuse = %q, want %q", c.name, refuse, c.refuse)
+		}
+	}
+}
+
+func TestWriteProjectPin_KeepsStrictAndReportsPrevious(t *testing.T) {
+	dir := t.TempDir()
+	if prev, err := writeProjectPin(dir, "0.10.0"); err != nil || prev != "" {
+		t.Fatalf("first write: %q, %v", prev, err)
+	}
+	writeFile(t, dir, ".belmont/version.json", `{"version": "v0.10.0", "state_format": 1, "strict": true}`)
+	prev, err := writeProjectPin(dir, "v0.11.0")
+	if err != nil || prev != "0.10.0" {
+		t.Fatalf("second write: %q, %v", prev, err)
+	}
+	pin, err := readProjectPin(dir)
+	if err != nil || pin.Version != "0.11.0" || !pin.Strict || pin.StateFormat != projectStateFormat {
+		t.Errorf("pin = %+v, %v", pin, err)
+	}
+}
+
+func TestEnforceProjectPin(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, ".belmont/version.json", `{"version": "99.0.0", "state_format": 1, "strict": true}`)
+	orig := Version
+	Version = "0.11.0"
+	defer func() { Version = orig }()
+	t.Setenv("BELMONT_SKIP_VERSION_CHECK", "")
+
+	if err := enforceProjectPin("status", []string{"--root", dir}); err == nil || !strings.Contains(err.Error(), "v99.0.0") {
+		t.Errorf("status should be refused: %v", err)
+	}
+	if err := enforceProjectPin("update", []string{"--root", dir}); err != nil {
+		t.Errorf("update is exempt: %v", err)
+	}
+	os.Setenv("BELMONT_SKIP_VERSION_CHECK", "1")
+	if err := enforceProjectPin("auto", []string{"--feature", "x", "--root=" + dir}); err != nil {
+		t.Errorf("override should bypass: %v", err)
+	}
+}
+
+func TestProjectRootFromArgs(t *testing.T) {
+	for want, args := range map[string][]string{
+		".":       {"--feature", "auth"},
+		"/a":      {"--feature", "auth", "--root", "/a"},
+		"/b":      {"-root=/b"},
+		"../proj": {"--no-prompt", "--project", "../proj"},
+	} {
+		if got := projectRootFromArgs(args); got != want {
+			t.Errorf("projectRootFromArgs(%v) = %q, want %q", args, got, want)
+		}
+	}
+}
@@ -0,0 +1,810 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// ============================================================================
+// Pull-request merge mode
+//
+// mergeFeatureBranch and mergeWorktreeBranch normally `git merge --no-ff`
+// the finished branch into the local checkout. With "merge": {"mode": "pr"}
+// in .belmont/worktree.json they push the branch instead and open a pull
+// request through a forge adapter — the GitHub or GitLab REST API, or a
+// shell command template for anything else (gh, glab, an internal tool).
+// The body is built from the unit's PROGRESS.md tasks, the Decisions Log
+// and the commits that evidence each verified task.
+//
+// The unit's worktree is released as after a merge, but the branch is kept,
+// the PR is recorded as awaiting review in .belmont/pull-requests.json and
+// the unit's feature state is saved beside it rather than synced. Nothing
+// that depends on the unit runs until it lands: this run skips dependents,
+// and later runs check every open record first (ancestry against
+// <remote>/<base>, then the forge API for squash merges), sync the state of
+// the ones that landed, and hold dependents of the ones still in review. A
+// pull request closed without merging is dropped, so its unit runs again.
+// ============================================================================
+
+// Merge modes and forge adapter types.
+const (
+	mergeModeLocal = "local"
+	mergeModePR    = "pr"
+
+	forgeGitHub  = "github"
+	forgeGitLab  = "gitlab"
+	forgeCommand = "command"
+)
+
+// Pull request states reported by a forge adapter ("" = unknown).
+const (
+	pullRequestOpen   = "open"
+	pullRequestMerged = "merged"
+	pullRequestClosed = "closed"
+)
+
+// pullRequestsFile lives in the main repo's .belmont/ and is excluded from
+// git, as is pullRequestStateDir, which holds each open unit's saved state.
+const (
+	pullRequestsFile    = "pull-requests.json"
+	pullRequestStateDir = "pull-requests"
+)
+
+// errAwaitingReview is returned by the merge functions when the branch was
+// pushed and a pull request opened instead of merging locally. Callers
+// treat the unit as neither merged nor failed and hold its dependents.
+var errAwaitingReview = errors.New("awaiting review")
+
+// mergeModeConfig is the merge block of worktree.json.
+type mergeModeConfig struct {
+	Mode   string      `json:"mode,omitempty"`   // "local" (default) or "pr"
+	Remote string      `json:"remote,omitempty"` // remote to push to; default "origin"
+	Base   string      `json:"base,omitempty"`   // PR target branch; default the branch auto runs from
+	Draft  bool        `json:"draft,omitempty"`  // open pull requests as drafts
+	Forge  forgeConfig `json:"forge"`
+}
+
+// forgeConfig selects and configures the forge adapter.
+type forgeConfig struct {
+	Type     string `json:"type"`                // "github", "gitlab" or "command"
+	APIURL   string `json:"api_url,omitempty"`   // default https://api.github.com / https://gitlab.com/api/v4
+	Repo     string `json:"repo,omitempty"`      // owner/name or GitLab project path; default derived from the remote URL
+	TokenEnv string `json:"token_env,omitempty"` // default GITHUB_TOKEN / GITLAB_TOKEN
+
+	// Command opens the pull request and prints its URL (command forge).
+	// StatusCommand, optional, prints open, merged or closed for a URL.
+	Command       string `json:"command,omitempty"`
+	StatusCommand string `json:"status_command,omitempty"`
+}
+
+// pullRequestMode returns the merge config when pull-request mode is on, or nil.
+func pullRequestMode(hooks *worktreeHooks) *mergeModeConfig {
+	if hooks == nil || hooks.Merge == nil || hooks.Merge.Mode != mergeModePR {
+		return nil
+	}
+	return hooks.Merge
+}
+
+// validateMergeMode checks a merge block without touching the network.
+func validateMergeMode(m *mergeModeConfig) error {
+	switch m.Mode {
+	case "", mergeModeLocal:
+		return nil
+	case mergeModePR:
+	default:
+		return fmt.Errorf("merge.mode %q must be %q or %q", m.Mode, mergeModeLocal, mergeModePR)
+	}
+	switch m.Forge.Type {
+	case forgeGitHub, forgeGitLab:
+	case forgeCommand:
+		if strings.TrimSpace(m.Forge.Command) == "" {
+			return fmt.Errorf("merge.forge.command is required for the command forge")
+		}
+	default:
+		return fmt.Errorf("merge.forge.type %q must be %s, %s or %s", m.Forge.Type, forgeGitHub, forgeGitLab, forgeCommand)
+	}
+	for _, t := range []string{m.Forge.Command, m.Forge.StatusCommand} {
+		if _, err := template.New("forge").Parse(t); err != nil {
+			return fmt.Errorf("merge.forge: %w", err)
+		}
+	}
+	return nil
+}
+
+// pullRequestRequest is what an adapter needs to open a pull request.
+type pullRequestRequest struct {
+	Title string
+	Body  string
+	Head  string // branch pushed to the remote
+	Base  string
+	Draft bool
+}
+
+// pullRequestRecord is one entry in .belmont/pull-requests.json.
+type pullRequestRecord struct {
+	Feature   string `json:"feature"`
+	Milestone string `json:"milestone,omitempty"` // empty for a feature-level pull request
+	Branch    string `json:"branch"`
+	Head      string `json:"head"` // branch tip SHA when the pull request was opened
+	Remote    string `json:"remote"`
+	Base      string `json:"base"`
+	URL       string `json:"url,omitempty"`
+	Number    int    `json:"number,omitempty"`
+	OpenedAt  string `json:"opened_at"`
+}
+
+// unit returns the ID the schedulers know the record by.
+func (r pullRequestRecord) unit() string {
+	if r.Milestone != "" {
+		return r.Milestone
+	}
+	return r.Feature
+}
+
+// forgeAdapter opens pull requests on one forge and reports their state.
+type forgeAdapter interface {
+	Open(req pullRequestRequest) (url string, number int, err error)
+	State(rec pullRequestRecord) (string, error)
+}
+
+// newForgeAdapter builds the adapter for m. root is used to derive the
+// repository from the remote URL and as the command forge's directory.
+func newForgeAdapter(root string, m *mergeModeConfig) (forgeAdapter, error) {
+	f := m.Forge
+	switch f.Type {
+	case forgeGitHub, forgeGitLab:
+		api, tokenEnv := "https://api.github.com", "GITHUB_TOKEN"
+		if f.Type == forgeGitLab {
+			api, tokenEnv = "https://gitlab.com/api/v4", "GITLAB_TOKEN"
+		}
+		if f.APIURL != "" {
+			api = f.APIURL
+		}
+		if f.TokenEnv != "" {
+			tokenEnv = f.TokenEnv
+		}
+		token := os.Getenv(tokenEnv)
+		if token == "" {
+			return nil, fmt.Errorf("%s is not set (merge.forge.token_env)", tokenEnv)
+		}
+		repo := f.Repo
+		if repo == "" {
+			remoteURL, err := gitOutputIn(root, "remote", "get-url", remoteOrDefault(m))
+			if err != nil {
+				return nil, err
+			}
+			if repo = repoFromRemoteURL(remoteURL); repo == "" {
+				return nil, fmt.Errorf("cannot derive the repository from %s — set merge.forge.repo", remoteURL)
+			}
+		}
+		api = strings.TrimRight(api, "/")
+		client := &http.Client{Timeout: 30 * time.Second}
+		if f.Type == forgeGitLab {
+			return &gitlabForge{api: api, project: repo, token: token, client: client}, nil
+		}
+		return &githubForge{api: api, repo: repo, token: token, client: client}, nil
+	case forgeCommand:
+		return &commandForge{root: root, remote: remoteOrDefault(m), open: f.Command, status: f.StatusCommand}, nil
+	}
+	return nil, fmt.Errorf("unknown merge.forge.type %q", f.Type)
+}
+
+func remoteOrDefault(m *mergeModeConfig) string {
+	if m.Remote != "" {
+		return m.Remote
+	}
+	return "origin"
+}
+
+var remoteRepoRe = regexp.MustCompile(`^(?:[a-z+]+://[^/]+/|[^@/]+@[^:/]+:)(.+?)(?:\.git)?/?$`)
+
+// repoFromRemoteURL extracts "owner/name" (or a nested GitLab group path)
+// from an https or scp-style remote URL.
+func repoFromRemoteURL(remoteURL string) string {
+	m := remoteRepoRe.FindStringSubmatch(strings.TrimSpace(remoteURL))
+	if m == nil || !strings.Contains(m[1], "/") {
+		return ""
+	}
+	return m[1]
+}
+
+// forgeJSON sends a JSON request and decodes a JSON response.
+func forgeJSON(client *http.Client, method, endpoint string, headers map[string]string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, endpoint, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%s %s: %s: %s", method, endpoint, resp.Status, strings.TrimSpace(string(data)))
+	}
+	return json.Unmarshal(data, out)
+}
+
+// githubForge talks to the GitHub REST API (or GitHub Enterprise via api_url).
+type githubForge struct {
+	api, repo, token string
+	client           *http.Client
+}
+
+func (g *githubForge) headers() map[string]string {
+	return map[string]string{"Authorization": "Bearer " + g.token, "Accept": "application/vnd.github+json"}
+}
+
+func (g *githubForge) Open(req pullRequestRequest) (string, int, error) {
+	in := map[string]interface{}{"title": req.Title, "head": req.Head, "base": req.Base, "body": req.Body, "draft": req.Draft}
+	var out struct {
+		HTMLURL string `json:"html_url"`
+		Number  int    `json:"number"`
+	}
+	if err := forgeJSON(g.client, "POST", g.api+"/repos/"+g.repo+"/pulls", g.headers(), in, &out); err != nil {
+		return "", 0, err
+	}
+	return out.HTMLURL, out.Number, nil
+}
+
+func (g *githubForge) State(rec pullRequestRecord) (string, error) {
+	if rec.Number == 0 {
+		return "", nil
+	}
+	var out struct {
+		State  string `json:"state"`
+		Merged bool   `json:"merged"`
+	}
+	if err := forgeJSON(g.client, "GET", fmt.Sprintf("%s/repos/%s/pulls/%d", g.api, g.repo, rec.Number), g.headers(), nil, &out); err != nil {
+		return "", err
+	}
+	switch {
+	case out.Merged:
+		return pullRequestMerged, nil
+	case out.State == "closed":
+		return pullRequestClosed, nil
+	}
+	return pullRequestOpen, nil
+}
+
+// gitlabForge talks to the GitLab REST API; pull requests are merge requests.
+type gitlabForge struct {
+	api, project, token string
+	client              *http.Client
+}
+
+func (g *gitlabForge) endpoint() string {
+	return g.api + "/projects/" + url.PathEscape(g.project) + "/merge_requests"
+}
+
+func (g *gitlabForge) Open(req pullRequestRequest) (string, int, error) {
+	title := req.Title
+	if req.Draft {
+		title = "Draft: " + title
+	}
+	in := map[string]interface{}{"source_branch": req.Head, "target_branch": req.Base, "title": title, "description": req.Body}
+	var out struct {
+		WebURL string `json:"web_url"`
+		IID    int    `json:"iid"`
+	}
+	if err := forgeJSON(g.client, "POST", g.endpoint(), map[string]string{"PRIVATE-TOKEN": g.token}, in, &out); err != nil {
+		return "", 0, err
+	}
+	return out.WebURL, out.IID, nil
+}
+
+func (g *gitlabForge) State(rec pullRequestRecord) (string, error) {
+	if rec.Number == 0 {
+		return "", nil
+	}
+	var out struct {
+		State string `json:"state"`
+	}
+	if err := forgeJSON(g.client, "GET", fmt.Sprintf("%s/%d", g.endpoint(), rec.Number), map[string]string{"PRIVATE-TOKEN": g.token}, nil, &out); err != nil {
+		return "", err
+	}
+	switch out.State {
+	case "merged":
+		return pullRequestMerged, nil
+	case "closed":
+		return pullRequestClosed, nil
+	}
+	return pullRequestOpen, nil
+}
+
+// commandForge runs shell command templates. Template fields are
+// shell-quoted; the same values are exported as BELMONT_PR_* variables.
+type commandForge struct {
+	root, remote string
+	open, status string
+}
+
+var forgeURLRe = regexp.MustCompile(`https?://\S+`)
+var trailingNumberRe = regexp.MustCompile(`(\d+)/?$`)
+
+func (c *commandForge) run(tmplText string, fields map[string]string) (string, error) {
+	quoted := make(map[string]string, len(fields))
+	env := os.Environ()
+	for k, v := range fields {
+		quoted[k] = "'" + strings.ReplaceAll(v, "'", `'\''`) + "'"
+		env = append(env, "BELMONT_PR_"+strings.ToUpper(k)+"="+v)
+	}
+	tmpl, err := template.New("forge").Option("missingkey=error").Parse(tmplText)
+	if err != nil {
+		return "", err
+	}
+	var cmdLine bytes.Buffer
+	if err := tmpl.Execute(&cmdLine, quoted); err != nil {
+		return "", err
+	}
+	cmd := exec.Command("sh", "-c", cmdLine.String())
+	cmd.Dir = c.root
+	cmd.Env = env
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%s: %w (%s)", cmdLine.String(), err, strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
+
+func (c *commandForge) Open(req pullRequestRequest) (string, int, error) {
+	bodyFile, err := os.CreateTemp("", "belmont-pr-*.md")
+	if err != nil {
+		return "", 0, err
+	}
+	defer os.Remove(bodyFile.Name())
+	bodyFile.WriteString(req.Body)
+	bodyFile.Close()
+
+	out, err := c.run(c.open, map[string]string{
+		"Title": req.Title, "Head": req.Head, "Base": req.Base, "Remote": c.remote,
+		"BodyFile": bodyFile.Name(), "Draft": strconv.FormatBool(req.Draft),
+	})
+	if err != nil {
+		return "", 0, err
+	}
+	urls := forgeURLRe.FindAllString(out, -1)
+	if len(urls) == 0 {
+		return "", 0, fmt.Errorf("merge.forge.command printed no pull request URL")
+	}
+	prURL := urls[len(urls)-1]
+	number := 0
+	if m := trailingNumberRe.FindStringSubmatch(prURL); m != nil {
+		number, _ = strconv.Atoi(m[1])
+	}
+	return prURL, number, nil
+}
+
+func (c *commandForge) State(rec pullRequestRecord) (string, error) {
+	if c.status == "" {
+		return "", nil
+	}
+	out, err := c.run(c.status, map[string]string{"URL": rec.URL, "Number": strconv.Itoa(rec.Number), "Head": rec.Branch, "Base": rec.Base})
+	if err != nil {
+		return "", err
+	}
+	switch s := strings.ToLower(strings.TrimSpace(out)); s {
+	case pullRequestOpen, pullRequestMerged, pullRequestClosed:
+		return s, nil
+	}
+	return "", nil
+}
+
+// ---------------------------------------------------------------------------
+// Pull request body
+// ---------------------------------------------------------------------------
+
+// prCommit is one commit on the branch being proposed.
+type prCommit struct {
+	SHA     string
+	Subject string
+	Message string
+}
+
+// branchCommits lists the commits on branch that are not on base, oldest first.
+func branchCommits(root, base, branch string) []prCommit {
+	out, err := gitOutputIn(root, "log", "--reverse", "--format=%h%x1f%s%x1f%B%x1e", base+".."+branch)
+	if err != nil {
+		return nil
+	}
+	var commits []prCommit
+	for _, rec := range strings.Split(out, "\x1e") {
+		parts := strings.SplitN(strings.TrimSpace(rec), "\x1f", 3)
+		if len(parts) == 3 {
+			commits = append(commits, prCommit{SHA: parts[0], Subject: parts[1], Message: parts[2]})
+		}
+	}
+	return commits
+}
+
+// buildPullRequestBody renders the pull request description from the unit's
+// PROGRESS.md (tasks of milestoneID, or every milestone when it is empty),
+// its Decisions Log, and the commits naming each verified task — the same
+// evidence the verify guard requires. Pure.
+func buildPullRequestBody(feature, milestoneID, progress string, commits []prCommit) string {
+	var b strings.Builder
+	unit := "feature `" + feature + "`"
+	if milestoneID != "" {
+		unit = fmt.Sprintf("milestone %s of feature `%s`", milestoneID, feature)
+	}
+	fmt.Fprintf(&b, "Implemented by `belmont auto` for %s.\n", unit)
+
+	var tasks []task
+	b.WriteString("\n## Tasks\n\n")
+	for _, m := range parseMilestones(progress) {
+		if milestoneID != "" && m.ID != milestoneID {
+			continue
+		}
+		if milestoneID == "" {
+			fmt.Fprintf(&b, "**%s: %s**\n\n", m.ID, m.Name)
+		}
+		for _, t := range m.Tasks {
+			mark, note := " ", ""
+			switch t.Status {
+			case taskVerified:
+				mark, note = "x", " — verified"
+			case taskDone:
+				mark, note = "x", " — not verified"
+			case taskBlocked:
+				note = " — blocked"
+			}
+			fmt.Fprintf(&b, "- [%s] %s: %s%s\n", mark, t.ID, t.Name, note)
+			tasks = append(tasks, t)
+		}
+		if milestoneID == "" {
+			b.WriteString("\n")
+		}
+	}
+	if len(tasks) == 0 {
+		b.WriteString("_No tasks recorded in PROGRESS.md._\n")
+	}
+
+	if decisions := parseSectionLines(progress, "## Decisions Log"); len(decisions) > 0 {
+		b.WriteString("\n## Decisions\n\n")
+		for _, d := range decisions {
+			if !strings.HasPrefix(d, "-") && !strings.HasPrefix(d, "*") {
+				d = "- " + d
+			}
+			b.WriteString(d + "\n")
+		}
+	}
+
+	b.WriteString("\n## Verification\n\n")
+	verified := 0
+	for _, t := range tasks {
+		if t.Status != taskVerified {
+			continue
+		}
+		verified++
+		pattern := regexp.MustCompile(`(^|[^A-Za-z0-9-])` + regexp.QuoteMeta(t.ID) + `([^A-Za-z0-9-]|$)`)
+		var shas []string
+		for _, c := range commits {
+			if pattern.MatchString(c.Message) {
+				shas = append(shas, "`"+c.SHA+"` "+c.Subject)
+			}
+		}
+		if len(shas) == 0 {
+			fmt.Fprintf(&b, "- %s: no commit on this branch names the task\n", t.ID)
+			continue
+		}
+		fmt.Fprintf(&b, "- %s: %s\n", t.ID, strings.Join(shas, "; "))
+	}
+	if verified == 0 {
+		b.WriteString("_No verified tasks._\n")
+	}
+	fmt.Fprintf(&b, "\n%d commit(s) on this branch. Work that depends on this pull request waits until it lands.\n", len(commits))
+	return b.String()
+}
+
+// ---------------------------------------------------------------------------
+// Records
+// ---------------------------------------------------------------------------
+
+func pullRequestsPath(root string) string {
+	return filepath.Join(root, ".belmont", pullRequestsFile)
+}
+
+// loadPullRequests reads the open pull request records (nil when none).
+func loadPullRequests(root string) []pullRequestRecord {
+	data, err := os.ReadFile(pullRequestsPath(root))
+	if err != nil {
+		return nil
+	}
+	var recs []pullRequestRecord
+	if json.Unmarshal(data, &recs) != nil {
+		return nil
+	}
+	return recs
+}
+
+func savePullRequests(root string, recs []pullRequestRecord) error {
+	if len(recs) == 0 {
+		err := os.Remove(pullRequestsPath(root))
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	data, err := json.MarshalIndent(recs, "", "  ")
+	if err != nil {
+		return err
+	}
+	excludeInRepo(root, ".belmont/"+pullRequestsFile)
+	return os.WriteFile(pullRequestsPath(root), append(data, '\n'), 0644)
+}
+
+// pullRequestStateRoot is where rec's feature state waits for the pull
+// request to land, laid out like a checkout (.belmont/features/<feature>)
+// so syncFeatureStateAfterMerge can copy it in and out.
+func pullRequestStateRoot(root string, rec pullRequestRecord) string {
+	key := rec.Feature
+	if rec.Milestone != "" {
+		key += "-" + strings.ToLower(rec.Milestone)
+	}
+	return filepath.Join(root, ".belmont", pullRequestStateDir, key)
+}
+
+// recordPullRequest adds rec, replacing an older record for the same unit.
+func recordPullRequest(root string, rec pullRequestRecord) error {
+	var recs []pullRequestRecord
+	for _, r := range loadPullRequests(root) {
+		if r.Feature != rec.Feature || r.Milestone != rec.Milestone {
+			recs = append(recs, r)
+		}
+	}
+	return savePullRequests(root, append(recs, rec))
+}
+
+// ---------------------------------------------------------------------------
+// Opening and landing
+// ---------------------------------------------------------------------------
+
+// openPullRequest pushes branch and opens a pull request for it in place of
+// a local merge, then cleans up the worktree the way a merge would — except
+// that the branch is kept. milestoneID is empty for a feature branch.
+// Returns errAwaitingReview on success.
+func openPullRequest(cfg loopConfig, m *mergeModeConfig, milestoneID, branch, wtPath string, tracker *worktreeTracker) error {
+	id := cfg.Feature
+	title := fmt.Sprintf("belmont: feature %s", cfg.Feature)
+	if milestoneID != "" {
+		id = milestoneID
+		title = fmt.Sprintf("belmont: %s %s", cfg.Feature, milestoneID)
+		progress, _ := os.ReadFile(filepath.Join(wtPath, ".belmont", "features", cfg.Feature, "PROGRESS.md"))
+		for _, ms := range parseMilestones(string(progress)) {
+			if ms.ID == milestoneID {
+				title = fmt.Sprintf("belmont: %s %s (%s)", cfg.Feature, milestoneID, ms.Name)
+			}
+		}
+	}
+	fail := func(err error) error {
+		fmt.Fprintf(os.Stderr, "  \033[31m✗ Could not open a pull request for %s: %s\033[0m\n", id, err)
+		fmt.Fprintf(os.Stderr, "    Worktree preserved at: %s\n", wtPath)
+		fmt.Fprintf(os.Stderr, "    Branch: %s\n", branch)
+		return err
+	}
+
+	remote := remoteOrDefault(m)
+	base := m.Base
+	if base == "" {
+		base = getCurrentBranch(cfg.Root)
+	}
+	if base == "" || base == "HEAD" {
+		return fail(fmt.Errorf("cannot tell which branch to target — set merge.base"))
+	}
+	adapter, err := newForgeAdapter(cfg.Root, m)
+	if err != nil {
+		return fail(err)
+	}
+	head, err := gitOutputIn(cfg.Root, "rev-parse", branch)
+	if err != nil {
+		return fail(err)
+	}
+	if _, err := gitOutputIn(cfg.Root, "push", "--force-with-lease", remote, branch+":refs/heads/"+branch); err != nil {
+		return fail(err)
+	}
+
+	progress, _ := os.ReadFile(filepath.Join(wtPath, ".belmont", "features", cfg.Feature, "PROGRESS.md"))
+	body := buildPullRequestBody(cfg.Feature, milestoneID, string(progress), branchCommits(cfg.Root, base, branch))
+	prURL, number, err := adapter.Open(pullRequestRequest{Title: title, Body: body, Head: branch, Base: base, Draft: m.Draft})
+	if err != nil {
+		return fail(err)
+	}
+	rec := pullRequestRecord{
+		Feature: cfg.Feature, Milestone: milestoneID, Branch: branch, Head: head,
+		Remote: remote, Base: base, URL: prURL, Number: number,
+		OpenedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+	if err := recordPullRequest(cfg.Root, rec); err != nil {
+		fmt.Fprintf(os.Stderr, "  \033[33m⚠ Failed to record pull request for %s: %s\033[0m\n", id, err)
+	}
+
+	// The state is final but describes code that isn't in the base branch
+	// yet: save it for refreshPullRequests to sync once the PR lands.
+	stateRoot := pullRequestStateRoot(cfg.Root, rec)
+	os.RemoveAll(stateRoot)
+	excludeInRepo(cfg.Root, ".belmont/"+pullRequestStateDir+"/")
+	syncFeatureStateAfterMerge(stateRoot, wtPath, cfg.Feature)
+
+	// Same cleanup as a merge, minus deleting the branch: the worktree has
+	// nothing left to do, but the code lands on review.
+	os.Remove(filepath.Join(cfg.Root, ".belmont", "reconciliation-report.json"))
+	tracker.teardownEntry(id)
+	releaseUnitWorktree(cfg.Root, wtPath, id)
+	tracker.remove(id)
+
+	fmt.Fprintf(os.Stderr, "  \033[36m⧗ %s awaiting review\033[0m — %s\n", id, prURL)
+	return errAwaitingReview
+}
+
+// checkPullRequest reports pullRequestMerged once rec's code is in the local
+// base branch, fast-forwarding it from <remote>/<base> when the pull request
+// was merged upstream, and pullRequestClosed when the forge says it was
+// closed without merging. Otherwise it reports pullRequestOpen, and why says
+// what the unit is waiting for.
+func checkPullRequest(root string, rec pullRequestRecord, adapter forgeAdapter) (state, why string) {
+	if _, err := gitOutputIn(root, "merge-base", "--is-ancestor", rec.Head, rec.Base); err == nil {
+		return pullRequestMerged, ""
+	}
+	upstream := rec.Remote + "/" + rec.Base
+	gitOutputIn(root, "fetch", "-q", rec.Remote, rec.Base) // best-effort; fall back to the last fetch
+	_, ancErr := gitOutputIn(root, "merge-base", "--is-ancestor", rec.Head, upstream)
+	merged := ancErr == nil
+	if !merged && adapter != nil {
+		// Squash and rebase merges rewrite the commits; ask the forge.
+		state, err := adapter.State(rec)
+		if err == nil && state == pullRequestClosed {
+			return pullRequestClosed, "closed without merging"
+		}
+		merged = err == nil && state == pullRequestMerged
+	}
+	if !merged {
+		return pullRequestOpen, "awaiting review"
+	}
+	if getCurrentBranch(root) == rec.Base {
+		if _, err := gitOutputIn(root, "merge", "--ff-only", "-q", upstream); err == nil {
+			return pullRequestMerged, ""
+		}
+	}
+	return pullRequestOpen, fmt.Sprintf("merged — pull %s into %s to unblock dependents", upstream, rec.Base)
+}
+
+// refreshPullRequests drops the records whose pull requests have landed,
+// syncing their saved feature state into the checkout, and those closed
+// without merging, whose units then run again. Both lose their local
+// branches. Returns the records still pending for feature, or for every
+// feature when feature is "".
+func refreshPullRequests(root, feature string) []pullRequestRecord {
+	recs := loadPullRequests(root)
+	if len(recs) == 0 {
+		return nil
+	}
+	m := pullRequestMode(loadWorktreeHooks(root))
+	var adapter forgeAdapter
+	if m != nil {
+		adapter, _ = newForgeAdapter(root, m) // nil: fall back to git ancestry
+	}
+	var keep, pending, landed []pullRequestRecord
+	for _, rec := range recs {
+		if feature != "" && rec.Feature != feature {
+			keep = append(keep, rec)
+			continue
+		}
+		switch state, why := checkPullRequest(root, rec, adapter); state {
+		case pullRequestMerged:
+			fmt.Fprintf(os.Stderr, "\033[32m✓ %s landed\033[0m — %s\n", rec.unit(), rec.URL)
+			landed = append(landed, rec)
+		case pullRequestClosed:
+			fmt.Fprintf(os.Stderr, "\033[33m✗ %s %s\033[0m — %s; it will run again (the pushed branch stays on %s)\n", rec.unit(), why, rec.URL, rec.Remote)
+			os.RemoveAll(pullRequestStateRoot(root, rec))
+			gitOutputIn(root, "branch", "-D", rec.Branch) // best-effort
+		default:
+			fmt.Fprintf(os.Stderr, "\033[36m⧗ %s %s\033[0m — %s\n", rec.unit(), why, rec.URL)
+			keep = append(keep, rec)
+			pending = append(pending, rec)
+		}
+	}
+	if len(keep) != len(recs) {
+		if err := savePullRequests(root, keep); err != nil {
+			fmt.Fprintf(os.Stderr, "\033[33m⚠ Failed to update %s: %s\033[0m\n", pullRequestsFile, err)
+		}
+	}
+	// Synced only after every fast-forward above, so no merge runs against
+	// a checkout the sync has just modified.
+	for _, rec := range landed {
+		stateRoot := pullRequestStateRoot(root, rec)
+		syncFeatureStateAfterMerge(root, stateRoot, rec.Feature)
+		os.RemoveAll(stateRoot)
+		gitOutputIn(root, "branch", "-D", rec.Branch) // best-effort
+	}
+	return pending
+}
+
+// reviewHolds returns the units that must wait for a pull request: every
+// unit depending, directly or through other units, on one in awaiting. The
+// result maps each held unit to the dependency it waits on. Pure.
+func reviewHolds(units []scheduleUnit, awaiting map[string]bool) map[string]string {
+	held := map[string]string{}
+	for changed := true; changed; {
+		changed = false
+		for _, u := range units {
+			if _, ok := held[u.ID]; ok || awaiting[u.ID] {
+				continue
+			}
+			for _, d := range u.Deps {
+				if _, blocked := held[d]; awaiting[d] || blocked {
+					held[u.ID] = d
+					changed = true
+					break
+				}
+			}
+		}
+	}
+	return held
+}
+
+// withAwaiting returns paused plus every unit in awaiting, for dependency
+// filters that hold both the same way.
+func withAwaiting(paused, awaiting map[string]bool) map[string]bool {
+	if len(awaiting) == 0 {
+		return paused
+	}
+	out := make(map[string]bool, len(paused)+len(awaiting))
+	for k := range paused {
+		out[k] = true
+	}
+	for k := range awaiting {
+		out[k] = true
+	}
+	return out
+}
+
+// printAwaitingReview lists the pull requests opened or still pending for
+// the given units of feature (any feature when ""), and the units waiting
+// on them.
+func printAwaitingReview(root, feature string, awaiting map[string]bool, waiting []string) {
+	var lines []string
+	for _, rec := range loadPullRequests(root) {
+		if (feature == "" || rec.Feature == feature) && awaiting[rec.unit()] {
+			lines = append(lines, fmt.Sprintf("  %s: %s", rec.unit(), rec.URL))
+		}
+	}
+	if len(lines) == 0 && len(waiting) == 0 {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "\n\033[36m⧗ %d pull request(s) awaiting review:\033[0m\n", len(lines))
+	for _, l := range lines {
+		fmt.Fprintln(os.Stderr, l)
+	}
+	for _, w := range waiting {
+		fmt.Fprintf(os.Stderr, "  %s\n", w)
+	}
+	fmt.Fprintf(os.Stderr, "Rerun belmont auto once they land; dependents start from the merged code.\n")
+}
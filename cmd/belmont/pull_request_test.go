@@ -0,0 +1,342 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const prProgress = `# Progress
+
+## Milestones
+
+### M1: API
+- [v] P1-1: Add login endpoint
+- [x] P1-2: Rate limit logins
+- [!] P1-3: SSO
+
+### M2: UI
+- [ ] P2-1: Login form
+
+## Decisions Log
+- Sessions use signed cookies, not JWTs
+`
+
+func TestBuildPullRequestBody(t *testing.T) {
+	commits := []prCommit{
+		{SHA: "abc1234", Subject: "[P1-1]: add login endpoint", Message: "[P1-1]: add login endpoint\n"},
+		{SHA: "def5678", Subject: "tidy", Message: "tidy\n\nmentions P1-10 only\n"},
+	}
+	got := buildPullRequestBody("auth", "M1", prProgress, commits)
+	for _, want := range []string{
+		"milestone M1 of feature `auth`",
+		"- [x] P1-1: Add login endpoint — verified",
+		"- [x] P1-2: Rate limit logins — not verified",
+		"- [ ] P1-3: SSO — blocked",
+		"## Decisions\n\n- Sessions use signed cookies, not JWTs",
+		"- P1-1: `abc1234` [P1-1]: add login endpoint\n",
+		"2 commit(s) on this branch",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("body missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "P2-1") {
+		t.Errorf("milestone body should leave out other milestones:\n%s", got)
+	}
+
+	whole := buildPullRequestBody("auth", "", prProgress, nil)
+	for _, want := range []string{"**M2: UI**", "- [ ] P2-1: Login form", "- P1-1: no commit on this branch names the task"} {
+		if !strings.Contains(whole, want) {
+			t.Errorf("feature body missing %q:\n%s", want, whole)
+		}
+	}
+}
+
+func TestGitHubForge_OpenAndState(t *testing.T) {
+	var got map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/repos/acme/app/pulls":
+			json.NewDecoder(r.Body).Decode(&got)
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"html_url": "https://github.example/acme/app/pull/7", "number": 7}`))
+		case r.Method == "GET" && r.URL.Path == "/repos/acme/app/pulls/7":
+			w.Write([]byte(`{"state": "closed", "merged": true}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	t.Setenv("ACME_TOKEN", "secret")
+
+	forge, err := newForgeAdapter(t.TempDir(), &mergeModeConfig{Mode: mergeModePR, Forge: forgeConfig{Type: forgeGitHub, APIURL: srv.URL, Repo: "acme/app", TokenEnv: "ACME_TOKEN"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, number, err := forge.Open(pullRequestRequest{Title: "belmont: feature auth", Body: "body", Head: "belmont/auto/auth", Base: "main", Draft: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url != "https://github.example/acme/app/pull/7" || number != 7 {
+		t.Errorf("opened %s #%d", url, number)
+	}
+	if got["head"] != "belmont/auto/auth" || got["base"] != "main" || got["draft"] != true || got["body"] != "body" {
+		t.Errorf("request = %v", got)
+	}
+	if state, err := forge.State(pullRequestRecord{Number: 7}); err != nil || state != pullRequestMerged {
+		t.Errorf("state = %q, %v", state, err)
+	}
+
+	t.Setenv("ACME_TOKEN", "")
+	if _, err := newForgeAdapter(t.TempDir(), &mergeModeConfig{Forge: forgeConfig{Type: forgeGitHub, TokenEnv: "ACME_TOKEN"}}); err == nil {
+		t.Error("missing token should be an error")
+	}
+}
+
+func TestGitLabForge_OpenDraft(t *testing.T) {
+	var got map[string]interface{}
+	var path string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.EscapedPath()
+		if r.Header.Get("PRIVATE-TOKEN") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"web_url": "https://gitlab.example/group/app/-/merge_requests/3", "iid": 3}`))
+	}))
+	defer srv.Close()
+	t.Setenv("GITLAB_TOKEN", "secret")
+
+	forge, err := newForgeAdapter(t.TempDir(), &mergeModeConfig{Forge: forgeConfig{Type: forgeGitLab, APIURL: srv.URL + "/", Repo: "group/app"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, number, err := forge.Open(pullRequestRequest{Title: "belmont: feature auth", Head: "belmont/auto/auth", Base: "main", Draft: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if number != 3 || path != "/projects/group%2Fapp/merge_requests" {
+		t.Errorf("opened #%d at %s", number, path)
+	}
+	if got["title"] != "Draft: belmont: feature auth" || got["source_branch"] != "belmont/auto/auth" || got["target_branch"] != "main" {
+		t.Errorf("request = %v", got)
+	}
+}
+
+func TestCommandForge_QuotesFieldsAndReadsURL(t *testing.T) {
+	dir := t.TempDir()
+	m := &mergeModeConfig{Forge: forgeConfig{
+		Type:          forgeCommand,
+		Command:       `printf '%s' {{.Title}} > title.txt && cp {{.BodyFile}} body.md && echo "created: https://forge.example/app/pulls/42"`,
+		StatusCommand: `test {{.Number}} = 42 && echo merged`,
+	}}
+	if err := validateMergeMode(&mergeModeConfig{Mode: mergeModePR, Forge: m.Forge}); err != nil {
+		t.Fatal(err)
+	}
+	forge, err := newForgeAdapter(dir, m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, number, err := forge.Open(pullRequestRequest{Title: "it's $HOME; done", Body: "## Tasks\n", Head: "b", Base: "main"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url != "https://forge.example/app/pulls/42" || number != 42 {
+		t.Errorf("opened %s #%d", url, number)
+	}
+	if got := readString(t, filepath.Join(dir, "title.txt")); got != "it's $HOME; done" {
+		t.Errorf("title = %q", got)
+	}
+	if got := readString(t, filepath.Join(dir, "body.md")); got != "## Tasks\n" {
+		t.Errorf("body = %q", got)
+	}
+	if state, err := forge.State(pullRequestRecord{URL: url, Number: number}); err != nil || state != pullRequestMerged {
+		t.Errorf("state = %q, %v", state, err)
+	}
+}
+
+func TestValidateMergeMode(t *testing.T) {
+	for name, m := range map[string]mergeModeConfig{
+		"bad mode":     {Mode: "squash"},
+		"bad forge":    {Mode: mergeModePR, Forge: forgeConfig{Type: "bitbucket"}},
+		"no command":   {Mode: mergeModePR, Forge: forgeConfig{Type: forgeCommand}},
+		"bad template": {Mode: mergeModePR, Forge: forgeConfig{Type: forgeCommand, Command: "gh pr create {{.Title"}},
+	} {
+		if err := validateMergeMode(&m); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if err := validateMergeMode(&mergeModeConfig{Mode: mergeModeLocal}); err != nil {
+		t.Errorf("local mode: %v", err)
+	}
+}
+
+func TestRepoFromRemoteURL(t *testing.T) {
+	for remote, want := range map[string]string{
+		"git@github.com:acme/app.git":             "acme/app",
+		"https://github.com/acme/app.git":         "acme/app",
+		"https://gitlab.com/group/sub/app":        "group/sub/app",
+		"ssh://git@gitlab.example:2222/group/app": "group/app",
+		"/srv/git/app.git":                        "",
+	} {
+		if got := repoFromRemoteURL(remote); got != want {
+			t.Errorf("%s: got %q, want %q", remote, got, want)
+		}
+	}
+}
+
+func TestReviewHolds(t *testing.T) {
+	units := []scheduleUnit{
+		{ID: "auth"},
+		{ID: "billing", Deps: []string{"auth"}},
+		{ID: "reports", Deps: []string{"billing"}},
+		{ID: "search"},
+	}
+	held := reviewHolds(units, map[string]bool{"auth": true})
+	if len(held) != 2 || held["billing"] != "auth" || held["reports"] != "billing" {
+		t.Errorf("held = %v", held)
+	}
+	if held := reviewHolds(units, nil); len(held) != 0 {
+		t.Errorf("nothing awaiting: held = %v", held)
+	}
+}
+
+func TestMergeFeatureBranch_PullRequestMode(t *testing.T) {
+	root := setupPoolRepo(t, `{"merge": {"mode": "pr", "base": "main", "forge": {"type": "command", "command": "echo https://forge.example/app/pulls/9"}}}`)
+	remote := filepath.Join(t.TempDir(), "remote.git")
+	runGit(t, root, "init", "-q", "--bare", remote)
+	runGit(t, root, "remote", "add", "origin", remote)
+	runGit(t, root, "push", "-q", "origin", "main")
+	excludeInRepo(root, ".belmont/features/") // real worktrees mark the state copy assume-unchanged
+
+	branch := "belmont/auto/auth"
+	wtPath := filepath.Join(worktreeBasePath(root), "auth")
+	if err := createUnitWorktree(root, branch, wtPath); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, wtPath, "login.go", "package app\n")
+	runGit(t, wtPath, "add", "-A")
+	runGit(t, wtPath, "commit", "-q", "-m", "[P1-1]: add login endpoint")
+	writeFile(t, wtPath, ".belmont/features/auth/PROGRESS.md", prProgress)
+	tracker := &worktreeTracker{entries: map[string]worktreeEntry{}}
+	tracker.add("auth", wtPath, branch)
+
+	err := mergeFeatureBranch(loopConfig{Root: root}, "auth", branch, wtPath, tracker)
+	if !errors.Is(err, errAwaitingReview) {
+		t.Fatalf("want errAwaitingReview, got %v", err)
+	}
+	if runGit(t, root, "rev-parse", "main") == runGit(t, root, "rev-parse", branch) {
+		t.Error("pull-request mode must not merge locally")
+	}
+	if runGit(t, remote, "rev-parse", branch) != runGit(t, root, "rev-parse", branch) {
+		t.Error("branch was not pushed")
+	}
+	if dirExists(wtPath) {
+		t.Error("worktree should be released like after a merge")
+	}
+	if fileExists(filepath.Join(root, ".belmont", "features", "auth", "PROGRESS.md")) {
+		t.Error("feature state must not be synced before the pull request lands")
+	}
+	recs := loadPullRequests(root)
+	if len(recs) != 1 || recs[0].unit() != "auth" || recs[0].Number != 9 || recs[0].Base != "main" || recs[0].Remote != "origin" {
+		t.Fatalf("records = %+v", recs)
+	}
+
+	// Still in review: the record stays and dependents are held.
+	if pending := refreshPullRequests(root, ""); len(pending) != 1 {
+		t.Fatalf("pending = %+v", pending)
+	}
+
+	// Merged upstream: the next run fast-forwards main and drops the record.
+	runGit(t, root, "push", "-q", "origin", branch+":main")
+	if pending := refreshPullRequests(root, ""); len(pending) != 0 {
+		t.Fatalf("landed PR still pending: %+v", pending)
+	}
+	if !fileExists(filepath.Join(root, "login.go")) {
+		t.Error("main should have been fast-forwarded to the merged code")
+	}
+	if got := readString(t, filepath.Join(root, ".belmont", "features", "auth", "PROGRESS.md")); got != prProgress {
+		t.Errorf("feature state should be synced once landed, got %q", got)
+	}
+	if dirExists(pullRequestStateRoot(root, recs[0])) {
+		t.Error("saved state should be removed once synced")
+	}
+	if _, err := os.Stat(pullRequestsPath(root)); !os.IsNotExist(err) {
+		t.Error("records file should be gone once nothing is pending")
+	}
+	if out, _ := gitOutputIn(root, "branch", "--list", branch); out != "" {
+		t.Errorf("landed branch should be deleted, got %q", out)
+	}
+}
+
+func TestDoctorCheckWorktreeJSON_MergeMode(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, ".belmont/worktree.json", `{"merge": {"mode": "pr", "forge": {"type": "bitbucket"}}}`)
+	if got := doctorStatuses(doctorCheckWorktreeJSON(dir)); got != "worktree.json=error" {
+		t.Errorf("unknown forge: %s", got)
+	}
+	t.Setenv("GITHUB_TOKEN", "")
+	writeFile(t, dir, ".belmont/worktree.json", `{"merge": {"mode": "pr", "forge": {"type": "github", "repo": "acme/app"}}}`)
+	if got := doctorStatuses(doctorCheckWorktreeJSON(dir)); got != "worktree.json=warn" {
+		t.Errorf("missing token: %s", got)
+	}
+	writeFile(t, dir, ".belmont/worktree.json", `{"merge": {"mode": "pr", "forge": {"type": "command", "command": "gh pr create --fill"}}}`)
+	if got := doctorStatuses(doctorCheckWorktreeJSON(dir)); got != "worktree.json=ok" {
+		t.Errorf("command forge: %s", got)
+	}
+}
+
+func TestRefreshPullRequests_ClosedWithoutMergingIsDropped(t *testing.T) {
+	root := setupPoolRepo(t, `{"merge": {"mode": "pr", "base": "main", "forge": {"type": "command", "command": "echo https://forge.example/app/pulls/9", "status_command": "echo closed"}}}`)
+	remote := filepath.Join(t.TempDir(), "remote.git")
+	runGit(t, root, "init", "-q", "--bare", remote)
+	runGit(t, root, "remote", "add", "origin", remote)
+	runGit(t, root, "push", "-q", "origin", "main")
+	excludeInRepo(root, ".belmont/features/")
+
+	branch := "belmont/auto/auth/m1"
+	wtPath := filepath.Join(worktreeBasePath(root), "auth-m1")
+	if err := createUnitWorktree(root, branch, wtPath); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, wtPath, "login.go", "package app\n")
+	runGit(t, wtPath, "add", "-A")
+	runGit(t, wtPath, "commit", "-q", "-m", "[P1-1]: add login endpoint")
+	writeFile(t, wtPath, ".belmont/features/auth/PROGRESS.md", prProgress)
+	tracker := &worktreeTracker{entries: map[string]worktreeEntry{}}
+	tracker.add("M1", wtPath, branch)
+
+	m := pullRequestMode(loadWorktreeHooks(root))
+	if err := openPullRequest(loopConfig{Root: root, Feature: "auth"}, m, "M1", branch, wtPath, tracker); !errors.Is(err, errAwaitingReview) {
+		t.Fatalf("want errAwaitingReview, got %v", err)
+	}
+	rec := loadPullRequests(root)[0]
+	if !dirExists(pullRequestStateRoot(root, rec)) {
+		t.Fatal("state should be saved while the pull request is open")
+	}
+
+	if pending := refreshPullRequests(root, "auth"); len(pending) != 0 {
+		t.Fatalf("closed PR still pending: %+v", pending)
+	}
+	if len(loadPullRequests(root)) != 0 {
+		t.Error("closed record should be dropped so the milestone runs again")
+	}
+	if fileExists(filepath.Join(root, ".belmont", "features", "auth", "PROGRESS.md")) || dirExists(pullRequestStateRoot(root, rec)) {
+		t.Error("state of a closed pull request must be discarded, not synced")
+	}
+	if out, _ := gitOutputIn(root, "branch", "--list", branch); out != "" {
+		t.Errorf("closed branch should be deleted locally, got %q", out)
+	}
+}
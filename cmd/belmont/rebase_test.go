@@ -235,3 +235,54 @@ func TestRebaseWorktreeOnMain_ReportsZeroWhenWorktreeAhead(t *testing.T) {
 		t.Errorf("worktree HEAD changed when it was already ahead of main: pre=%s post=%s", preSHA, postSHA)
 	}
 }
+
+func TestRebaseOnMainAtBoundary_CheckpointsAndRebases(t *testing.T) {
+	mainRoot, wtPath, _ := setupMainAndWorktree(t)
+
+	mustWrite(t, filepath.Join(mainRoot, "sibling.txt"), "merged\n")
+	runGit(t, mainRoot, "add", "-A")
+	runGit(t, mainRoot, "commit", "-q", "-m", "sibling merge")
+	// Uncommitted work from the previous action.
+	mustWrite(t, filepath.Join(wtPath, "feature.txt"), "wip\n")
+
+	cfg := loopConfig{Root: wtPath, MainRoot: mainRoot, Feature: "feature-a"}
+	if tip := rebaseOnMainAtBoundary(cfg, ""); tip != "" {
+		t.Errorf("clean rebase should not record a steered tip, got %q", tip)
+	}
+	if _, err := os.Stat(filepath.Join(wtPath, "sibling.txt")); err != nil {
+		t.Errorf("sibling merge not picked up: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(wtPath, "feature.txt")); err != nil {
+		t.Errorf("checkpointed work lost: %v", err)
+	}
+}
+
+func TestRebaseOnMainAtBoundary_ConflictWritesSteeringOnce(t *testing.T) {
+	mainRoot, wtPath, _ := setupMainAndWorktree(t)
+
+	mustWrite(t, filepath.Join(mainRoot, "app.txt"), "main\n")
+	runGit(t, mainRoot, "commit", "-q", "-am", "sibling edit")
+	mustWrite(t, filepath.Join(wtPath, "app.txt"), "worktree\n")
+	runGit(t, wtPath, "commit", "-q", "-am", "feature edit")
+
+	cfg := loopConfig{Root: wtPath, MainRoot: mainRoot, Feature: "feature-a"}
+	tip := rebaseOnMainAtBoundary(cfg, "")
+	if tip != runGit(t, mainRoot, "rev-parse", "HEAD") {
+		t.Fatalf("expected steered tip to be main HEAD, got %q", tip)
+	}
+	steering := filepath.Join(wtPath, ".belmont", "features", "feature-a", "STEERING.md")
+	data, err := os.ReadFile(steering)
+	if err != nil {
+		t.Fatalf("no steering written: %v", err)
+	}
+	if !strings.Contains(string(data), "app.txt") || !strings.Contains(string(data), "git merge") {
+		t.Errorf("steering missing conflict details:\n%s", data)
+	}
+
+	// Same main tip on the next boundary: no duplicate entry.
+	rebaseOnMainAtBoundary(cfg, tip)
+	again, _ := os.ReadFile(steering)
+	if strings.Count(string(again), "(pending)") != 1 {
+		t.Errorf("steering duplicated:\n%s", again)
+	}
+}
@@ -0,0 +1,370 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ============================================================================
+// Reconciliation history
+//
+// Every merge that needs the reconciliation agent leaves an audit record in
+// .belmont/reconciliation/history/: which files conflicted, the agent's
+// confidence and strategy for each, how each resolution was applied (and so
+// whether an operator signed off on the low-confidence ones), the merge gate
+// result and whether the merge landed. `belmont recover --history` lists the
+// records; `--hotspots` aggregates them into files that keep conflicting
+// across milestones, which the tech-plan skill uses to redraw milestone
+// boundaries. The directory shares the review's repo-local git exclude.
+// ============================================================================
+
+// How a resolution reached the merge.
+const (
+	resolutionAuto         = "auto"         // high confidence, applied without review
+	resolutionUnattended   = "unattended"   // low confidence, applied with no terminal to ask
+	resolutionAccepted     = "accepted"     // low confidence, operator accepted
+	resolutionAcceptedAll  = "accepted-all" // low confidence, covered by "auto-resolve all remaining"
+	resolutionEdited       = "edited"       // operator edited the proposal
+	resolutionReviewed     = "reviewed"     // applied from a `recover --merge --dry-run` review
+	resolutionRegenerated  = "regenerated"  // deleted and rebuilt by a post-resolve command
+	resolutionLegacy       = "legacy"       // resolved in place by the legacy single-pass agent
+	resolutionUnresolvable = "unresolvable" // agent refused; merge aborted
+)
+
+// Merge gate results recorded in history.
+const (
+	gateResultNone     = "none" // no merge_gate configured, or nothing to check
+	gateResultPassed   = "passed"
+	gateResultRepaired = "repaired" // passed after one repair pass
+	gateResultFailed   = "failed"
+)
+
+// reconciliationAuditFile is one conflicted file in a history record.
+type reconciliationAuditFile struct {
+	File       string `json:"file"`
+	Confidence string `json:"confidence,omitempty"`
+	Strategy   string `json:"strategy,omitempty"`
+	Reason     string `json:"reason,omitempty"`
+	Resolution string `json:"resolution"`
+}
+
+// reconciliationAudit is the history record for one reconciled merge. It
+// travels on loopConfig.Audit so the agent, the apply step and the gate can
+// each fill in their part; all methods are no-ops on a nil receiver.
+type reconciliationAudit struct {
+	ID        string                    `json:"id"`
+	Feature   string                    `json:"feature,omitempty"`
+	Branch    string                    `json:"branch"`
+	StartedAt string                    `json:"started_at"`
+	Files     []reconciliationAuditFile `json:"files"`
+	Gate      string                    `json:"gate"`
+	Merged    bool                      `json:"merged"`
+	Error     string                    `json:"error,omitempty"`
+}
+
+// newReconciliationAudit starts a record for the files handed to the agent.
+// Files the agent never reports on keep an empty resolution.
+func newReconciliationAudit(cfg loopConfig, id, branch string, files []string) *reconciliationAudit {
+	a := &reconciliationAudit{
+		ID:        id,
+		Feature:   cfg.Feature,
+		Branch:    branch,
+		StartedAt: time.Now().UTC().Format(time.RFC3339),
+		Gate:      gateResultNone,
+	}
+	for _, f := range files {
+		a.Files = append(a.Files, reconciliationAuditFile{File: f})
+	}
+	return a
+}
+
+// record sets how f was resolved, replacing any earlier entry for the file.
+func (a *reconciliationAudit) record(f reconciliationFile, resolution string) {
+	if a == nil {
+		return
+	}
+	entry := reconciliationAuditFile{File: f.File, Confidence: f.Confidence, Strategy: f.Strategy, Reason: f.Reason, Resolution: resolution}
+	for i := range a.Files {
+		if a.Files[i].File == f.File {
+			a.Files[i] = entry
+			return
+		}
+	}
+	a.Files = append(a.Files, entry)
+}
+
+// setGate records the merge gate result.
+func (a *reconciliationAudit) setGate(result string) {
+	if a != nil {
+		a.Gate = result
+	}
+}
+
+// finish records the outcome and archives the record. Best-effort: a merge
+// never fails because its history could not be written.
+func (a *reconciliationAudit) finish(root string, err error) {
+	if a == nil {
+		return
+	}
+	a.Merged = err == nil
+	if err != nil {
+		a.Error = err.Error()
+	}
+	if werr := saveReconciliationAudit(root, a); werr != nil {
+		fmt.Fprintf(os.Stderr, "  \033[33m⚠ Could not archive reconciliation record: %v\033[0m\n", werr)
+	}
+}
+
+// lowConfidence counts the record's low-confidence files and how many of
+// those an operator explicitly signed off on.
+func (a *reconciliationAudit) lowConfidence() (total, approved int) {
+	for _, f := range a.Files {
+		if f.Confidence != "low" {
+			continue
+		}
+		total++
+		switch f.Resolution {
+		case resolutionAccepted, resolutionAcceptedAll, resolutionEdited, resolutionReviewed:
+			approved++
+		}
+	}
+	return total, approved
+}
+
+// reviewed returns the reviewed proposal behind a reused resolution, with
+// the agent's original confidence rather than the "high" it is applied as.
+func (s *reconciliationDecisionSet) reviewed(f reconciliationFile) reconciliationFile {
+	if s != nil {
+		for _, d := range s.Files {
+			if d.File == f.File {
+				orig := d.reconciliationFile
+				if d.Decision == decisionEdit {
+					orig.Strategy = "operator-edit"
+				}
+				return orig
+			}
+		}
+	}
+	return f
+}
+
+// runAuditedLegacyReconciliation runs the legacy agent and, when it
+// succeeds, records each file it resolved. The legacy pass writes no report,
+// so there is no confidence or strategy to keep.
+func runAuditedLegacyReconciliation(cfg loopConfig, milestoneID, branch, conflictedFiles string) error {
+	if err := runLegacyReconciliation(cfg, milestoneID, branch, conflictedFiles); err != nil {
+		return err
+	}
+	for _, f := range strings.Split(conflictedFiles, "\n") {
+		if f = strings.TrimSpace(f); f != "" {
+			cfg.Audit.record(reconciliationFile{File: f}, resolutionLegacy)
+		}
+	}
+	return nil
+}
+
+// reconciliationHistoryDir holds one JSON record per reconciled merge.
+func reconciliationHistoryDir(root string) string {
+	return filepath.Join(root, ".belmont", "reconciliation", "history")
+}
+
+var unsafeHistoryChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// saveReconciliationAudit writes a to history/<timestamp>-<id>.json.
+func saveReconciliationAudit(root string, a *reconciliationAudit) error {
+	dir := reconciliationHistoryDir(root)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	stamp := time.Now().UTC().Format("20060102T150405Z")
+	if t, err := time.Parse(time.RFC3339, a.StartedAt); err == nil {
+		stamp = t.UTC().Format("20060102T150405Z")
+	}
+	name := stamp + "-" + unsafeHistoryChars.ReplaceAllString(a.ID, "_") + ".json"
+	data, err := json.MarshalIndent(a, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	excludeInRepo(root, ".belmont/reconciliation/")
+	return nil
+}
+
+// loadReconciliationHistory reads every record, oldest first. Unreadable
+// records are skipped.
+func loadReconciliationHistory(root string) []reconciliationAudit {
+	entries, err := os.ReadDir(reconciliationHistoryDir(root))
+	if err != nil {
+		return nil
+	}
+	var records []reconciliationAudit
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(reconciliationHistoryDir(root), e.Name()))
+		if err != nil {
+			continue
+		}
+		var a reconciliationAudit
+		if json.Unmarshal(data, &a) != nil {
+			continue
+		}
+		records = append(records, a)
+	}
+	sort.SliceStable(records, func(i, j int) bool { return records[i].StartedAt < records[j].StartedAt })
+	return records
+}
+
+// conflictHotspot is a file that needed reconciliation in more than one
+// milestone.
+type conflictHotspot struct {
+	File       string   `json:"file"`
+	Milestones []string `json:"milestones"`
+	Merges     int      `json:"merges"`
+	Strategies []string `json:"strategies,omitempty"`
+	LastSeen   string   `json:"last_seen"`
+}
+
+// conflictHotspots finds files that conflicted in at least two distinct
+// milestones, most frequent first. History is project-wide, so a milestone
+// is its feature and ID: M1 of two features counts twice.
+// milestoneKey names the record's milestone as "<feature>/<ID>", or just
+// the ID when no feature was recorded.
+func (a reconciliationAudit) milestoneKey() string {
+	if a.Feature == "" {
+		return a.ID
+	}
+	return a.Feature + "/" + a.ID
+}
+
+func conflictHotspots(records []reconciliationAudit) []conflictHotspot {
+	byFile := map[string]*conflictHotspot{}
+	for _, r := range records {
+		for _, f := range r.Files {
+			if strings.HasPrefix(f.File, ".belmont/") {
+				continue
+			}
+			h := byFile[f.File]
+			if h == nil {
+				h = &conflictHotspot{File: f.File}
+				byFile[f.File] = h
+			}
+			h.Merges++
+			h.Milestones = uniqueStrings(append(h.Milestones, r.milestoneKey()))
+			if f.Strategy != "" {
+				h.Strategies = uniqueStrings(append(h.Strategies, f.Strategy))
+			}
+			if r.StartedAt > h.LastSeen {
+				h.LastSeen = r.StartedAt
+			}
+		}
+	}
+	var out []conflictHotspot
+	for _, h := range byFile {
+		if len(h.Milestones) >= 2 {
+			out = append(out, *h)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if len(out[i].Milestones) != len(out[j].Milestones) {
+			return len(out[i].Milestones) > len(out[j].Milestones)
+		}
+		return out[i].File < out[j].File
+	})
+	return out
+}
+
+// recoverHistory prints the reconciliation history (or only its hotspots).
+func recoverHistory(root, format string, hotspotsOnly bool) error {
+	records := loadReconciliationHistory(root)
+	hotspots := conflictHotspots(records)
+
+	if format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if hotspots == nil {
+			hotspots = []conflictHotspot{}
+		}
+		if hotspotsOnly {
+			return enc.Encode(hotspots)
+		}
+		if records == nil {
+			records = []reconciliationAudit{}
+		}
+		return enc.Encode(struct {
+			Records  []reconciliationAudit `json:"records"`
+			Hotspots []conflictHotspot     `json:"hotspots"`
+		}{records, hotspots})
+	}
+
+	if !hotspotsOnly {
+		if len(records) == 0 {
+			fmt.Println("No reconciliation history.")
+			return nil
+		}
+		fmt.Printf("Reconciliation history (%d):\n\n", len(records))
+		for i := len(records) - 1; i >= 0; i-- {
+			printReconciliationAudit(records[i])
+		}
+	}
+
+	if len(hotspots) == 0 {
+		if hotspotsOnly {
+			fmt.Println("No conflict hotspots (no file has conflicted in more than one milestone).")
+		}
+		return nil
+	}
+	fmt.Printf("Conflict hotspots (%d):\n\n", len(hotspots))
+	for _, h := range hotspots {
+		fmt.Printf("  %s\n", h.File)
+		fmt.Printf("    Milestones: %s (%d merge(s))\n", strings.Join(h.Milestones, ", "), h.Merges)
+		if len(h.Strategies) > 0 {
+			fmt.Printf("    Strategies: %s\n", strings.Join(h.Strategies, ", "))
+		}
+	}
+	fmt.Println()
+	return nil
+}
+
+func printReconciliationAudit(a reconciliationAudit) {
+	outcome := "\033[32mmerged\033[0m"
+	if !a.Merged {
+		outcome = "\033[31maborted\033[0m"
+	}
+	fmt.Printf("  %s  %s  %s\n", a.StartedAt, a.ID, outcome)
+	fmt.Printf("    Branch: %s\n", a.Branch)
+	gate := a.Gate
+	if gate == "" {
+		gate = gateResultNone
+	}
+	fmt.Printf("    Gate:   %s\n", gate)
+	if total, approved := a.lowConfidence(); total > 0 {
+		fmt.Printf("    Low-confidence: %d, operator-approved: %d\n", total, approved)
+	}
+	for _, f := range a.Files {
+		detail := f.Resolution
+		if detail == "" {
+			detail = "not applied"
+		}
+		if f.Confidence != "" {
+			detail = f.Confidence + ", " + detail
+		}
+		if f.Strategy != "" {
+			detail += ", " + f.Strategy
+		}
+		fmt.Printf("    %s \033[2m(%s)\033[0m\n", f.File, detail)
+	}
+	if a.Error != "" {
+		fmt.Printf("    Error:  %s\n", a.Error)
+	}
+	fmt.Println()
+}
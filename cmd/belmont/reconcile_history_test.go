@@ -0,0 +1,97 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestConflictHotspots_NeedTwoMilestones(t *testing.T) {
+	records := []reconciliationAudit{
+		{ID: "M1", StartedAt: "2026-01-01T00:00:00Z", Files: []reconciliationAuditFile{
+			{File: "src/routes.ts", Strategy: "additive-functions"},
+			{File: "package.json", Strategy: "package-manifest-union"},
+		}},
+		{ID: "M2", StartedAt: "2026-01-02T00:00:00Z", Files: []reconciliationAuditFile{
+			{File: "src/routes.ts", Strategy: "import-union"},
+			{File: ".belmont/features/a/PROGRESS.md"},
+		}},
+		{ID: "M2", StartedAt: "2026-01-03T00:00:00Z", Files: []reconciliationAuditFile{
+			{File: "package.json"},
+			{File: ".belmont/features/a/PROGRESS.md"},
+		}},
+		{ID: "M3", StartedAt: "2026-01-04T00:00:00Z", Files: []reconciliationAuditFile{
+			{File: "src/routes.ts", Strategy: "import-union"},
+		}},
+	}
+	got := conflictHotspots(records)
+	if len(got) != 2 {
+		t.Fatalf("hotspots = %+v", got)
+	}
+	if got[0].File != "src/routes.ts" || !equalStringSlices(got[0].Milestones, []string{"M1", "M2", "M3"}) ||
+		got[0].Merges != 3 || !equalStringSlices(got[0].Strategies, []string{"additive-functions", "import-union"}) ||
+		got[0].LastSeen != "2026-01-04T00:00:00Z" {
+		t.Errorf("first hotspot = %+v", got[0])
+	}
+	if got[1].File != "package.json" || got[1].Merges != 2 {
+		t.Errorf("second hotspot = %+v", got[1])
+	}
+}
+
+func TestConflictHotspots_MilestonesKeyedByFeature(t *testing.T) {
+	records := []reconciliationAudit{
+		{Feature: "auth", ID: "M1", Files: []reconciliationAuditFile{{File: "src/app.ts"}}},
+		{Feature: "billing", ID: "M1", Files: []reconciliationAuditFile{{File: "src/app.ts"}}},
+	}
+	got := conflictHotspots(records)
+	if len(got) != 1 || !equalStringSlices(got[0].Milestones, []string{"auth/M1", "billing/M1"}) {
+		t.Errorf("hotspots = %+v", got)
+	}
+}
+
+func TestSaveReconciliationAudit_RoundTripAndExcluded(t *testing.T) {
+	root := t.TempDir()
+	runGit(t, root, "init", "-q", "-b", "main")
+	first := newReconciliationAudit(loopConfig{Feature: "auth"}, "feat/M2", "belmont/auto/auth/m2", []string{"a.go"})
+	first.StartedAt = "2026-03-01T10:00:00Z"
+	first.record(reconciliationFile{File: "a.go", Confidence: "low", Strategy: "additive-functions"}, resolutionAccepted)
+	first.setGate(gateResultRepaired)
+	first.finish(root, nil)
+
+	second := newReconciliationAudit(loopConfig{}, "M1", "b", []string{"b.go"})
+	second.StartedAt = "2026-02-01T10:00:00Z"
+	second.finish(root, errWorktreeDirty)
+
+	records := loadReconciliationHistory(root)
+	if len(records) != 2 || records[0].ID != "M1" || records[1].ID != "feat/M2" {
+		t.Fatalf("records = %+v", records)
+	}
+	if r := records[0]; r.Merged || r.Error == "" || r.Gate != gateResultNone || r.Files[0].Resolution != "" {
+		t.Errorf("aborted record = %+v", r)
+	}
+	r := records[1]
+	if !r.Merged || r.Feature != "auth" || r.Gate != gateResultRepaired {
+		t.Errorf("merged record = %+v", r)
+	}
+	if total, approved := r.lowConfidence(); total != 1 || approved != 1 {
+		t.Errorf("lowConfidence = %d, %d", total, approved)
+	}
+	if out := runGit(t, root, "status", "--porcelain", "--untracked-files=all"); out != "" {
+		t.Errorf("history shows up in git status: %s", out)
+	}
+}
+
+func TestRunReconciliationAgent_AuditsReviewedResolution(t *testing.T) {
+	root := setupConflictedMerge(t)
+	ours, theirs := stageBlobs(root, "notes.txt")
+	review := &reconciliationDecisionSet{Files: []reconciliationDecision{{
+		reconciliationFile: reconciliationFile{File: "notes.txt", Confidence: "low", Strategy: "line-union", ResolvedContent: "beta\ngamma\n"},
+		OursBlob:           ours, TheirsBlob: theirs, Decision: decisionAccept,
+	}}}
+	audit := newReconciliationAudit(loopConfig{}, "side", "side", []string{"notes.txt"})
+	if err := runReconciliationAgent(loopConfig{Root: root, Review: review, Audit: audit}, "side", "side"); err != nil {
+		t.Fatal(err)
+	}
+	want := reconciliationAuditFile{File: "notes.txt", Confidence: "low", Strategy: "line-union", Resolution: resolutionReviewed}
+	if len(audit.Files) != 1 || audit.Files[0] != want {
+		t.Errorf("audit files = %+v", audit.Files)
+	}
+}
@@ -0,0 +1,499 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ============================================================================
+// Reconciliation review
+//
+// `belmont recover --merge <slug> --dry-run` runs the merge and the
+// reconciliation analysis in a throwaway detached worktree, renders every
+// proposed resolution as a diff against both sides, and lets the operator
+// accept, reject or edit each file. Nothing in the project tree changes; the
+// decisions are saved to .belmont/reconciliation/<slug>.json and picked up by
+// the next `belmont recover --merge <slug>`, which applies accepted and
+// edited resolutions without another AI pass.
+// ============================================================================
+
+// Review decisions for one file.
+const (
+	decisionPending = "pending" // proposed, not yet reviewed
+	decisionAccept  = "accept"
+	decisionReject  = "reject"
+	decisionEdit    = "edit" // operator-edited content replaces the proposal
+)
+
+// reconciliationDecision is one reviewed file. The stage blob IDs pin the
+// decision to the exact conflict it was made for: if either side changes,
+// the decision is stale and the file is analysed again.
+type reconciliationDecision struct {
+	reconciliationFile
+	OursBlob      string `json:"ours_blob"`
+	TheirsBlob    string `json:"theirs_blob"`
+	Decision      string `json:"decision"`
+	EditedContent string `json:"edited_content,omitempty"`
+}
+
+// reconciliationDecisionSet is the saved review for one preserved worktree.
+type reconciliationDecisionSet struct {
+	Slug      string                   `json:"slug"`
+	Branch    string                   `json:"branch"`
+	UpdatedAt string                   `json:"updated_at"`
+	Files     []reconciliationDecision `json:"files"`
+}
+
+// reviewDecisionsPath is where the review for slug is saved.
+func reviewDecisionsPath(root, slug string) string {
+	return filepath.Join(root, ".belmont", "reconciliation", slug+".json")
+}
+
+// loadReviewDecisions reads a saved review. A missing file is (nil, nil).
+func loadReviewDecisions(root, slug string) (*reconciliationDecisionSet, error) {
+	data, err := os.ReadFile(reviewDecisionsPath(root, slug))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var set reconciliationDecisionSet
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", reviewDecisionsPath(root, slug), err)
+	}
+	return &set, nil
+}
+
+// saveReviewDecisions writes the review and keeps .belmont/reconciliation/
+// out of git via the repo-local exclude, so a saved review never trips the
+// clean-tree preflight or lands in a commit.
+func saveReviewDecisions(root string, set *reconciliationDecisionSet) error {
+	path := reviewDecisionsPath(root, set.Slug)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	set.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	data, err := json.MarshalIndent(set, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	excludeInRepo(root, ".belmont/reconciliation/")
+	return nil
+}
+
+// excludeInRepo appends a pattern to the repository's info/exclude if it is
+// not already listed. Best-effort.
+func excludeInRepo(root, pattern string) {
+	cmd := exec.Command("git", "rev-parse", "--git-path", "info/exclude")
+	cmd.Dir = root
+	out, err := cmd.Output()
+	if err != nil {
+		return
+	}
+	path := strings.TrimSpace(string(out))
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	existing, _ := os.ReadFile(path)
+	for _, l := range strings.Split(string(existing), "\n") {
+		if strings.TrimSpace(l) == pattern {
+			return
+		}
+	}
+	os.MkdirAll(filepath.Dir(path), 0755)
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	if len(existing) > 0 && existing[len(existing)-1] != '\n' {
+		f.WriteString("\n")
+	}
+	f.WriteString(pattern + "\n")
+}
+
+// lookup returns the decision recorded for file against the same two sides,
+// or nil.
+func (s *reconciliationDecisionSet) lookup(file, oursBlob, theirsBlob string) *reconciliationDecision {
+	if s == nil {
+		return nil
+	}
+	for i := range s.Files {
+		d := &s.Files[i]
+		if d.File == file && d.OursBlob == oursBlob && d.TheirsBlob == theirsBlob {
+			return d
+		}
+	}
+	return nil
+}
+
+// rejected lists files whose resolution was rejected in review.
+func (s *reconciliationDecisionSet) rejected() []string {
+	if s == nil {
+		return nil
+	}
+	var out []string
+	for _, d := range s.Files {
+		if d.Decision == decisionReject {
+			out = append(out, d.File)
+		}
+	}
+	return out
+}
+
+// resolution turns a decision back into a report entry for
+// applyReconciliationReport. Reviewed files apply without another prompt;
+// pending ones keep the analysis' confidence.
+func (d reconciliationDecision) resolution() reconciliationFile {
+	f := d.reconciliationFile
+	switch d.Decision {
+	case decisionAccept:
+		f.Confidence = "high"
+	case decisionEdit:
+		f.Confidence = "high"
+		f.ResolvedContent = d.EditedContent
+		f.PostResolveCmd = ""
+		f.Strategy = "operator-edit"
+	}
+	return f
+}
+
+// stageBlobs returns the ours/theirs blob IDs of a conflicted path.
+func stageBlobs(root, file string) (ours, theirs string) {
+	return resolveBranchSHA(root, ":2:"+file), resolveBranchSHA(root, ":3:"+file)
+}
+
+// partitionReviewedConflicts splits conflicted files into resolutions taken
+// from a saved review and files that still need analysis. A file rejected
+// in review is returned in rejected and must not be merged.
+func partitionReviewedConflicts(root string, set *reconciliationDecisionSet, files []string) (reused []reconciliationFile, rejected, rest []string) {
+	for _, f := range files {
+		ours, theirs := stageBlobs(root, f)
+		d := set.lookup(f, ours, theirs)
+		switch {
+		case d == nil:
+			rest = append(rest, f)
+		case d.Decision == decisionReject:
+			rejected = append(rejected, f)
+		default:
+			reused = append(reused, d.resolution())
+		}
+	}
+	return reused, rejected, rest
+}
+
+// conflictedPaths lists unmerged paths in root.
+func conflictedPaths(root string) []string {
+	cmd := exec.Command("git", "diff", "--name-only", "--diff-filter=U")
+	cmd.Dir = root
+	out, err := cmd.Output()
+	if err != nil {
+		return nil
+	}
+	var files []string
+	for _, l := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if l = strings.TrimSpace(l); l != "" {
+			files = append(files, l)
+		}
+	}
+	return files
+}
+
+// unifiedDiff renders a -U3 diff of two contents under the given labels
+// using `git diff --no-index`. Returns "" when they are identical.
+func unifiedDiff(a, b []byte, labelA, labelB string) (string, error) {
+	dir, err := os.MkdirTemp("", "belmont-diff-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(dir)
+	pa, pb := filepath.Join(dir, "a"), filepath.Join(dir, "b")
+	if err := os.WriteFile(pa, a, 0644); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(pb, b, 0644); err != nil {
+		return "", err
+	}
+	cmd := exec.Command("git", "diff", "--no-index", "--no-color", "-U3", "--", pa, pb)
+	out, err := cmd.Output()
+	var exitErr *exec.ExitError
+	if err != nil && !(errors.As(err, &exitErr) && exitErr.ExitCode() == 1) {
+		return "", err
+	}
+	text := string(out)
+	if text == "" {
+		return "", nil
+	}
+	// Replace git's temp-file headers with the caller's labels.
+	if i := strings.Index(text, "\n@@"); i >= 0 {
+		text = text[i+1:]
+	}
+	return "--- " + labelA + "\n+++ " + labelB + "\n" + text, nil
+}
+
+// renderReviewDiffs prints a file's proposal as two diffs: what the
+// resolution changes relative to ours, and relative to theirs.
+func renderReviewDiffs(w io.Writer, root string, d reconciliationDecision) {
+	fmt.Fprintf(w, "\n  \033[1m%s\033[0m  \033[2m[%s · %s · %s]\033[0m\n", d.File, d.Confidence, d.Strategy, d.Decision)
+	if d.ConflictSummary != "" {
+		fmt.Fprintf(w, "    %s\n", d.ConflictSummary)
+	}
+	if d.Reason != "" {
+		fmt.Fprintf(w, "    \033[2m%s\033[0m\n", d.Reason)
+	}
+	res := d.resolution()
+	switch {
+	case d.Confidence == "unresolvable" && d.Decision != decisionEdit:
+		fmt.Fprintf(w, "    \033[31m✗ No resolution proposed\033[0m — edit one by hand or fix the branch\n")
+		return
+	case res.ResolvedContent == "" && res.PostResolveCmd != "":
+		fmt.Fprintf(w, "    \033[2mRegenerated via: %s\033[0m\n", res.PostResolveCmd)
+		return
+	}
+	for _, side := range []struct {
+		stage int
+		name  string
+	}{{2, "ours"}, {3, "theirs"}} {
+		content, _ := gitShowStage(root, side.stage, d.File)
+		diff, err := unifiedDiff(content, []byte(res.ResolvedContent), side.name+"/"+d.File, "resolved/"+d.File)
+		if err != nil {
+			fmt.Fprintf(w, "    \033[33m⚠ diff vs %s failed: %s\033[0m\n", side.name, err)
+			continue
+		}
+		if diff == "" {
+			fmt.Fprintf(w, "    \033[2m(resolution is identical to %s)\033[0m\n", side.name)
+			continue
+		}
+		writeColoredDiff(w, diff)
+	}
+}
+
+// writeColoredDiff indents a unified diff and colours its lines.
+func writeColoredDiff(w io.Writer, diff string) {
+	for _, l := range strings.Split(strings.TrimRight(diff, "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(l, "---"), strings.HasPrefix(l, "+++"):
+			fmt.Fprintf(w, "    \033[1m%s\033[0m\n", l)
+		case strings.HasPrefix(l, "@@"):
+			fmt.Fprintf(w, "    \033[36m%s\033[0m\n", l)
+		case strings.HasPrefix(l, "+"):
+			fmt.Fprintf(w, "    \033[32m%s\033[0m\n", l)
+		case strings.HasPrefix(l, "-"):
+			fmt.Fprintf(w, "    \033[31m%s\033[0m\n", l)
+		default:
+			fmt.Fprintf(w, "    %s\n", l)
+		}
+	}
+}
+
+// promptReviewDecision asks for one file's decision. quit=true saves the
+// review as it stands; the current file stays as it was.
+func promptReviewDecision(scanner *bufio.Scanner, root string, d *reconciliationDecision) (quit bool, err error) {
+	for {
+		fmt.Fprintf(os.Stderr, "\n    [a] Accept  [r] Reject  [e] Edit in $EDITOR  [v] View full resolution\n")
+		fmt.Fprintf(os.Stderr, "    [s] Skip (decide later)  [q] Save and quit\n\n")
+		fmt.Fprintf(os.Stderr, "    Choice [s]: ")
+		if !scanner.Scan() {
+			return true, nil
+		}
+		switch strings.TrimSpace(strings.ToLower(scanner.Text())) {
+		case "a":
+			if d.Confidence == "unresolvable" {
+				fmt.Fprintf(os.Stderr, "    Nothing to accept — edit a resolution or reject.\n")
+				continue
+			}
+			d.Decision = decisionAccept
+			return false, nil
+		case "r":
+			d.Decision = decisionReject
+			return false, nil
+		case "e":
+			content, err := editResolution(root, *d)
+			if err != nil {
+				return false, err
+			}
+			if containsConflictMarkers(content) {
+				fmt.Fprintf(os.Stderr, "    \033[33m⚠ Edited content still has conflict markers — not saved.\033[0m\n")
+				continue
+			}
+			d.Decision = decisionEdit
+			d.EditedContent = content
+			return false, nil
+		case "v":
+			fmt.Fprintf(os.Stderr, "\n    \033[2m--- Proposed resolution for %s ---\033[0m\n", d.File)
+			for i, line := range strings.Split(d.resolution().ResolvedContent, "\n") {
+				fmt.Fprintf(os.Stderr, "    \033[2m%4d\033[0m  %s\n", i+1, line)
+			}
+			fmt.Fprintf(os.Stderr, "    \033[2m--- End ---\033[0m\n")
+		case "", "s":
+			return false, nil
+		case "q":
+			return true, nil
+		default:
+			fmt.Fprintf(os.Stderr, "    Invalid choice. Try again.\n")
+		}
+	}
+}
+
+// editResolution opens the proposal (or, with none, the conflicted file) in
+// $EDITOR on a temp copy and returns the edited content.
+func editResolution(root string, d reconciliationDecision) (string, error) {
+	start := d.resolution().ResolvedContent
+	if start == "" {
+		data, _ := os.ReadFile(filepath.Join(root, d.File))
+		start = string(data)
+	}
+	tmp, err := os.CreateTemp("", "belmont-resolve-*"+filepath.Ext(d.File))
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.WriteString(start); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	tmp.Close()
+
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = "vi"
+	}
+	editorCmd := exec.Command(editor, tmp.Name())
+	editorCmd.Stdin = os.Stdin
+	editorCmd.Stdout = os.Stdout
+	editorCmd.Stderr = os.Stderr
+	if err := editorCmd.Run(); err != nil {
+		return "", fmt.Errorf("editor for %s: %w", d.File, err)
+	}
+	data, err := os.ReadFile(tmp.Name())
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// containsConflictMarkers reports whether text still holds a marker line.
+func containsConflictMarkers(text string) bool {
+	for _, l := range strings.Split(text, "\n") {
+		for _, m := range []string{markerOurs, markerBase, markerSplit, markerTheirs} {
+			if strings.HasPrefix(l, m) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// recoverMergeDryRun proposes resolutions for a preserved worktree's merge
+// without touching the project tree, reviews them and saves the decisions.
+func recoverMergeDryRun(root, slug, tool string, wt *worktreeEntry) error {
+	tmp, err := os.MkdirTemp("", "belmont-review-")
+	if err != nil {
+		return fmt.Errorf("recover: %w", err)
+	}
+	addCmd := exec.Command("git", "worktree", "add", "--detach", tmp, "HEAD")
+	addCmd.Dir = root
+	if out, err := addCmd.CombinedOutput(); err != nil {
+		os.RemoveAll(tmp)
+		return fmt.Errorf("recover: create review worktree: %s", strings.TrimSpace(string(out)))
+	}
+	defer func() {
+		rm := exec.Command("git", "worktree", "remove", "--force", tmp)
+		rm.Dir = root
+		rm.Run()
+		os.RemoveAll(tmp)
+	}()
+
+	fmt.Fprintf(os.Stderr, "  \033[2mDry run: merging %s in a scratch worktree — the project tree is not touched\033[0m\n", wt.Branch)
+	mergeCmd := exec.Command("git", "merge", "--no-ff", "--no-commit", wt.Branch)
+	mergeCmd.Dir = tmp
+	if err := mergeCmd.Run(); err == nil {
+		fmt.Fprintf(os.Stderr, "  \033[32m✓ %s merges cleanly — nothing to review\033[0m\n", slug)
+		return nil
+	}
+	autoResolveBelmontConflicts(tmp)
+	autoResolveStructuredConflicts(tmp)
+	autoResolveSourceHunks(tmp)
+	files := conflictedPaths(tmp)
+	if len(files) == 0 {
+		fmt.Fprintf(os.Stderr, "  \033[32m✓ All conflicts for %s resolve deterministically — nothing to review\033[0m\n", slug)
+		return nil
+	}
+
+	prev, err := loadReviewDecisions(root, slug)
+	if err != nil {
+		return fmt.Errorf("recover: %w", err)
+	}
+	set := &reconciliationDecisionSet{Slug: slug, Branch: wt.Branch}
+	var fresh []string
+	for _, f := range files {
+		ours, theirs := stageBlobs(tmp, f)
+		if d := prev.lookup(f, ours, theirs); d != nil && d.Decision != decisionReject {
+			set.Files = append(set.Files, *d)
+			continue
+		}
+		fresh = append(fresh, f)
+	}
+	if len(fresh) > 0 {
+		fmt.Fprintf(os.Stderr, "  \033[2mAnalyzing %d conflicted file(s)...\033[0m\n", len(fresh))
+		reportPath := filepath.Join(tmp, ".belmont", "reconciliation-report.json")
+		os.MkdirAll(filepath.Dir(reportPath), 0755)
+		cfg := loopConfig{Root: tmp, Tool: tool}
+		if err := runReconciliationAnalysis(cfg, slug, wt.Branch, strings.Join(fresh, "\n"), reportPath); err != nil {
+			return fmt.Errorf("recover: analysis failed: %w", err)
+		}
+		report, err := parseReconciliationReport(reportPath)
+		if err != nil {
+			return fmt.Errorf("recover: %w", err)
+		}
+		for _, rf := range report.Files {
+			ours, theirs := stageBlobs(tmp, rf.File)
+			set.Files = append(set.Files, reconciliationDecision{reconciliationFile: rf, OursBlob: ours, TheirsBlob: theirs, Decision: decisionPending})
+		}
+	}
+
+	interactive := isTerminal(os.Stdin)
+	scanner := bufio.NewScanner(os.Stdin)
+	for i := range set.Files {
+		d := &set.Files[i]
+		renderReviewDiffs(os.Stderr, tmp, *d)
+		if !interactive || d.Decision != decisionPending {
+			continue
+		}
+		quit, err := promptReviewDecision(scanner, tmp, d)
+		if err != nil {
+			return fmt.Errorf("recover: %w", err)
+		}
+		if quit {
+			break
+		}
+	}
+
+	if err := saveReviewDecisions(root, set); err != nil {
+		return fmt.Errorf("recover: save review: %w", err)
+	}
+	counts := map[string]int{}
+	for _, d := range set.Files {
+		counts[d.Decision]++
+	}
+	fmt.Fprintf(os.Stderr, "\n  Review saved to %s\n", reviewDecisionsPath(root, slug))
+	fmt.Fprintf(os.Stderr, "    %d accepted, %d edited, %d rejected, %d pending\n", counts[decisionAccept], counts[decisionEdit], counts[decisionReject], counts[decisionPending])
+	if counts[decisionReject] > 0 {
+		fmt.Fprintf(os.Stderr, "    Rejected files get a fresh proposal on the next: belmont recover --merge %s --dry-run\n", slug)
+	} else {
+		fmt.Fprintf(os.Stderr, "    Apply: belmont recover --merge %s\n", slug)
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+)
+
+// ============================================================================
+// REVIEW action
+//
+// VERIFY checks a milestone against its acceptance criteria; REVIEW runs the
+// code-review agent over the milestone's diff — the commits between the SHA
+// captured before its first implement phase and the one after its latest
+// phase, both already recorded in historyEntry. Findings are written as
+// FWLUP tasks in the same milestone, so TRIAGE and FIX_ALL pick them up
+// exactly as they do verification follow-ups. The model tier is the
+// code-review agent's (tiers.code-review in models.yaml).
+//
+// The AI decider may schedule REVIEW at any time. With "review": {"required":
+// true} in .belmont/actions.json the loop also requires it: a milestone only
+// counts as verified once a review has passed since its last implement or
+// fix phase, optionally only for some work types. The smart rules review a
+// milestone right after it passes verification; requireReviewBeforeComplete
+// catches the rest (fixes that skipped re-verification, resumed runs) by
+// holding COMPLETE, whichever decider chose it.
+// ============================================================================
+
+// loopReviewConfig is the "review" block of .belmont/actions.json.
+type loopReviewConfig struct {
+	Required  bool     `json:"required,omitempty"`   // milestones need a passing REVIEW to count as verified
+	WorkTypes []string `json:"work_types,omitempty"` // limit the requirement to these work types; empty = all
+}
+
+// reviewRequired reports whether milestone msID must pass REVIEW under cfg.
+func reviewRequired(cfg loopReviewConfig, msID string, msStates map[string]*milestoneLoopState) bool {
+	if !cfg.Required || msID == "" {
+		return false
+	}
+	if len(cfg.WorkTypes) == 0 {
+		return true
+	}
+	s, ok := msStates[msID]
+	return ok && containsString(cfg.WorkTypes, string(s.WorkType))
+}
+
+// reviewDue reports whether milestone msID has changed since it last passed
+// REVIEW (or never passed one). Changes are successful implement and fix
+// phases attributed to the milestone.
+func reviewDue(history []historyEntry, msID string) bool {
+	for i := len(history) - 1; i >= 0; i-- {
+		h := history[i]
+		if h.Action.MilestoneID != msID || h.Result == nil || !h.Result.Success {
+			continue
+		}
+		switch h.Action.Type {
+		case actionReview:
+			return false
+		case actionImplementMilestone, actionImplementNext, actionFixAll, actionDebug:
+			return true
+		}
+	}
+	return true
+}
+
+// requireReviewBeforeComplete turns a COMPLETE into a REVIEW of the first
+// in-range milestone that still needs one, or returns nil.
+func requireReviewBeforeComplete(action *loopAction, report statusReport, history []historyEntry, cfg loopConfig, msStates map[string]*milestoneLoopState) *loopAction {
+	if action == nil || action.Type != actionComplete || !cfg.CodeReview.Required {
+		return nil
+	}
+	for _, m := range milestonesInRange(report.Milestones, cfg.From, cfg.To) {
+		if reviewRequired(cfg.CodeReview, m.ID, msStates) && reviewDue(history, m.ID) {
+			return &loopAction{Type: actionReview, Reason: fmt.Sprintf("Review required before completing — %s changed since its last review", m.ID), MilestoneID: m.ID}
+		}
+	}
+	return nil
+}
+
+// milestoneReviewRange returns the commit range of msID's work: the SHA
+// captured before its first implement phase and the one after its latest
+// phase. Either is "" when the history doesn't have it (e.g. a resumed run).
+func milestoneReviewRange(history []historyEntry, msID string) (base, head string) {
+	for _, h := range history {
+		if h.Action.MilestoneID != msID {
+			continue
+		}
+		if base == "" && h.GitSHA != "" && (h.Action.Type == actionImplementMilestone || h.Action.Type == actionImplementNext) {
+			base = h.GitSHA
+		}
+		if h.PostGitSHA != "" {
+			head = h.PostGitSHA
+		}
+	}
+	if base == head {
+		base = ""
+	}
+	return base, head
+}
+
+// buildReviewPrompt renders the code-review prompt for a REVIEW action.
+func buildReviewPrompt(action loopAction, feature string) string {
+	featureBase := filepath.ToSlash(filepath.Join(".belmont", "features", feature))
+	rangeDesc := "the commits for this milestone's tasks (find them with `git log --grep <task ID>`)"
+	if action.ReviewBase != "" {
+		head := action.ReviewHead
+		if head == "" {
+			head = "HEAD"
+		}
+		rangeDesc = fmt.Sprintf("`git diff %s..%s` (commits: `git log %s..%s`)", action.ReviewBase, head, action.ReviewBase, head)
+	}
+	data := map[string]string{
+		"Feature":     feature,
+		"FeatureBase": featureBase,
+		"MilestoneID": action.MilestoneID,
+		"Range":       rangeDesc,
+	}
+	if tmpl, err := loadPromptTemplate("code-review"); err == nil {
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err == nil {
+			return buf.String()
+		}
+	}
+	return fmt.Sprintf(`You are the Code Review Agent. Read .agents/belmont/code-review-agent.md and follow it.
+
+Feature: %s (%s)
+Milestone: %s
+Review: %s
+
+Review only this diff, against %s/PRD.md and %s/TECH_PLAN.md. For every Critical or Warning finding add a follow-up task under the %s heading in %s/PROGRESS.md:
+  - [ ] P<n>-%s-FWLUP-R<k>: <issue> — <file:line>
+Append Polish items to %s/NOTES.md under "## Polish". Do NOT fix anything yourself, and do NOT touch any other milestone.
+Commit with message: belmont: review %s — N findings`,
+		feature, featureBase, action.MilestoneID, rangeDesc, featureBase, featureBase,
+		action.MilestoneID, featureBase, action.MilestoneID, featureBase, action.MilestoneID)
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDecideLoopActionSmart_RequiredReview(t *testing.T) {
+	done := []task{{ID: "P1-1", Status: taskVerified}}
+	report := statusReport{Milestones: []milestone{
+		{ID: "M1", Name: "API", Tasks: done},
+		{ID: "M2", Name: "UI", Tasks: []task{{ID: "P1-2", Status: taskTodo}}},
+	}}
+	ok := &executionResult{Success: true}
+	history := []historyEntry{
+		{Action: loopAction{Type: actionImplementMilestone, MilestoneID: "M1"}, Result: ok, WorkType: workBackend, FilesChanged: 4, GitSHA: "aaa", PostGitSHA: "bbb"},
+		{Action: loopAction{Type: actionVerify, MilestoneID: "M1"}, Result: ok, GitSHA: "bbb", PostGitSHA: "ccc"},
+	}
+	decide := func(cfg loopConfig, history []historyEntry, hasMsFwlup bool) *loopAction {
+		states := buildMilestoneLoopStates(history, report.Milestones)
+		return decideLoopActionSmart(report, history, cfg, hasMsFwlup, hasMsFwlup, true, hasMsFwlup, states)
+	}
+
+	// Without the requirement, verification moves straight on.
+	if got := decide(loopConfig{}, history, false); got == nil || got.Type != actionImplementMilestone || got.MilestoneID != "M2" {
+		t.Fatalf("no requirement: got %+v", got)
+	}
+
+	cfg := loopConfig{CodeReview: loopReviewConfig{Required: true}}
+	got := decide(cfg, history, false)
+	if got == nil || got.Type != actionReview || got.MilestoneID != "M1" {
+		t.Fatalf("want REVIEW M1, got %+v", got)
+	}
+
+	// The requirement can be limited to some work types.
+	frontendOnly := loopConfig{CodeReview: loopReviewConfig{Required: true, WorkTypes: []string{"frontend"}}}
+	if got := decide(frontendOnly, history, false); got == nil || got.Type != actionImplementMilestone {
+		t.Fatalf("backend milestone with frontend-only review: got %+v", got)
+	}
+
+	// Findings go to triage; a clean review moves on.
+	reviewed := append(history[:2:2], historyEntry{Action: loopAction{Type: actionReview, MilestoneID: "M1"}, Result: ok})
+	if got := decide(cfg, reviewed, true); got == nil || got.Type != actionTriage || got.MilestoneID != "M1" {
+		t.Fatalf("review findings: got %+v", got)
+	}
+	if got := decide(cfg, reviewed, false); got == nil || got.Type != actionImplementMilestone || got.MilestoneID != "M2" {
+		t.Fatalf("clean review: got %+v", got)
+	}
+
+	// A failed review run is retried.
+	failed := append(history[:2:2], historyEntry{Action: loopAction{Type: actionReview, MilestoneID: "M1"}, Result: &executionResult{}})
+	if got := decide(cfg, failed, false); got == nil || got.Type != actionReview {
+		t.Fatalf("failed review: got %+v", got)
+	}
+}
+
+func TestDecideLoopActionSmart_RequiredReviewBeforeComplete(t *testing.T) {
+	report := statusReport{Milestones: []milestone{{ID: "M1", Tasks: []task{{ID: "P1-1", Status: taskVerified}}}}}
+	ok := &executionResult{Success: true}
+	history := []historyEntry{
+		{Action: loopAction{Type: actionImplementMilestone, MilestoneID: "M1"}, Result: ok, FilesChanged: 3},
+		{Action: loopAction{Type: actionVerify, MilestoneID: "M1"}, Result: ok},
+		{Action: loopAction{Type: actionReview, MilestoneID: "M1"}, Result: ok},
+		{Action: loopAction{Type: actionTriage, MilestoneID: "M1", TriageDecision: "fix_and_proceed"}, Result: ok},
+		{Action: loopAction{Type: actionFixAll, MilestoneID: "M1", TriageDecision: "fix_and_proceed"}, Result: ok},
+		{Action: loopAction{Type: actionImplementNext, MilestoneID: "M1"}, Result: ok},
+	}
+	cfg := loopConfig{CodeReview: loopReviewConfig{Required: true}}
+	states := buildMilestoneLoopStates(history, report.Milestones)
+	if states["M1"].ReviewSucceeded != 1 {
+		t.Fatalf("ReviewSucceeded = %d", states["M1"].ReviewSucceeded)
+	}
+
+	// The fixes changed M1 after its review, so it doesn't count as verified yet.
+	decided := decideLoopActionSmart(report, history, cfg, false, false, false, false, states)
+	if decided == nil || decided.Type != actionComplete {
+		t.Fatalf("smart rules: got %+v", decided)
+	}
+	got := requireReviewBeforeComplete(decided, report, history, cfg, states)
+	if got == nil || got.Type != actionReview || got.MilestoneID != "M1" {
+		t.Fatalf("want REVIEW before COMPLETE, got %+v", got)
+	}
+	if got := requireReviewBeforeComplete(decided, report, history, loopConfig{}, states); got != nil {
+		t.Fatalf("no requirement: got %+v", got)
+	}
+
+	history = append(history, historyEntry{Action: *got, Result: ok})
+	states = buildMilestoneLoopStates(history, report.Milestones)
+	if got := requireReviewBeforeComplete(decided, report, history, cfg, states); got != nil {
+		t.Fatalf("want COMPLETE after review, got %+v", got)
+	}
+}
+
+func TestMilestoneReviewRange(t *testing.T) {
+	history := []historyEntry{
+		{Action: loopAction{Type: actionImplementMilestone, MilestoneID: "M1"}, GitSHA: "a1", PostGitSHA: "a2"},
+		{Action: loopAction{Type: actionImplementMilestone, MilestoneID: "M2"}, GitSHA: "a2", PostGitSHA: "b1"},
+		{Action: loopAction{Type: actionVerify, MilestoneID: "M1"}, GitSHA: "b1", PostGitSHA: "b2"},
+		{Action: loopAction{Type: actionFixAll, MilestoneID: "M1"}, GitSHA: "b2", PostGitSHA: "b3"},
+	}
+	if base, head := milestoneReviewRange(history, "M1"); base != "a1" || head != "b3" {
+		t.Errorf("M1 range = %s..%s", base, head)
+	}
+	if base, head := milestoneReviewRange(history, "M3"); base != "" || head != "" {
+		t.Errorf("unknown milestone range = %s..%s", base, head)
+	}
+	// A phase that made no commits leaves no range to diff.
+	noop := []historyEntry{{Action: loopAction{Type: actionImplementMilestone, MilestoneID: "M1"}, GitSHA: "a1", PostGitSHA: "a1"}}
+	if base, _ := milestoneReviewRange(noop, "M1"); base != "" {
+		t.Errorf("no-op range base = %q", base)
+	}
+}
+
+func TestBuildReviewPrompt(t *testing.T) {
+	got := buildLoopPrompt(loopAction{Type: actionReview, MilestoneID: "M2", ReviewBase: "abc123", ReviewHead: "def456"}, "auth")
+	for _, want := range []string{"git diff abc123..def456", "M2", "FWLUP", ".belmont/features/auth/PROGRESS.md"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("prompt missing %q:\n%s", want, got)
+		}
+	}
+	got = buildLoopPrompt(loopAction{Type: actionReview, MilestoneID: "M2"}, "auth")
+	if !strings.Contains(got, "git log --grep") {
+		t.Errorf("prompt without a range should fall back to task commits:\n%s", got)
+	}
+	if tierForAction(actionReview, modelTierConfig{Tiers: map[string]string{"code-review": "high"}}) != "high" {
+		t.Error("REVIEW should use the code-review tier")
+	}
+}
+
+func TestLoadLoopActionsFile_Review(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, ".belmont/actions.json", `{"review": {"required": true, "work_types": ["backend", "mixed"]}}`)
+	got, err := loadLoopActionsFile(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Review.Required || len(got.Review.WorkTypes) != 2 || len(got.Actions) != 0 {
+		t.Fatalf("got %+v", got)
+	}
+	writeFile(t, dir, ".belmont/actions.json", `{"review": {"required": true, "work_types": ["server"]}}`)
+	if _, err := loadLoopActionsFile(dir); err == nil {
+		t.Error("unknown work type should be rejected")
+	}
+}
@@ -0,0 +1,409 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Scheduler names accepted by `belmont auto --scheduler`.
+const (
+	schedulerCriticalPath = "critical-path"
+	schedulerWaves        = "waves"
+)
+
+// scheduleUnit is one node handed to runSchedule: a milestone in
+// single-feature-parallel mode or a feature in multi-feature mode. Deps that
+// name units outside the run (already done, out of range) are treated as
+// satisfied, matching computeWaves / computeFeatureWaves.
+type scheduleUnit struct {
+	ID       string
+	Deps     []string
+	Priority string // master-table priority ("P0" highest); empty sorts last
+}
+
+// scheduleHooks are the caller-supplied steps runSchedule drives.
+//
+// Start, Settle and Skip always run on the scheduler goroutine, one at a
+// time, so they may prompt on stdin or touch the main repo (merges) without
+// locking. Only the work func returned by Start runs concurrently, up to
+// maxParallel at once.
+type scheduleHooks struct {
+	// Start runs just before a unit launches (stale-worktree resolution) and
+	// returns the work to run in the background. An error stops new
+	// launches; in-flight units still settle.
+	Start func(id string) (run func() error, err error)
+	// Settle reports the result of Run and merges on success. proceed=false
+	// cascade-skips the unit's dependents; a non-nil fatal error stops new
+	// launches the same way a Start error does.
+	Settle func(id string, runErr error) (proceed bool, fatal error)
+	// Skip is called for every dependent that can no longer run. blocker is
+	// the direct dependency that failed, paused or was itself skipped.
+	Skip func(id, blocker string)
+	// Order, when set, is the merge queue: it orders finished units (first,
+	// in completion order) together with those still running. Finished units
+	// settle in that order up to the first running one; the rest wait for
+	// it. Nil settles in completion order.
+	Order func(ids []string) []string
+}
+
+// criticalPathRanks returns, for each unit, the length of the longest chain
+// of dependents hanging off it (itself included). A unit nothing depends on
+// ranks 1. Cycles must be rejected by the caller first.
+func criticalPathRanks(units []scheduleUnit) map[string]int {
+	known := make(map[string]bool, len(units))
+	for _, u := range units {
+		known[u.ID] = true
+	}
+	dependents := make(map[string][]string)
+	for _, u := range units {
+		for _, d := range u.Deps {
+			if known[d] {
+				dependents[d] = append(dependents[d], u.ID)
+			}
+		}
+	}
+	ranks := make(map[string]int, len(units))
+	var visit func(id string) int
+	visit = func(id string) int {
+		if r, ok := ranks[id]; ok {
+			return r
+		}
+		ranks[id] = 1 // guards against cycles looping forever
+		best := 0
+		for _, c := range dependents[id] {
+			if r := visit(c); r > best {
+				best = r
+			}
+		}
+		ranks[id] = best + 1
+		return ranks[id]
+	}
+	for _, u := range units {
+		visit(u.ID)
+	}
+	return ranks
+}
+
+// priorityRank maps a master-table priority ("P0", "p2") to a sortable int.
+// Missing or unparseable priorities sort after every explicit one.
+func priorityRank(p string) int {
+	p = strings.ToUpper(strings.TrimSpace(p))
+	if n, err := strconv.Atoi(strings.TrimPrefix(p, "P")); err == nil && strings.HasPrefix(p, "P") {
+		return n
+	}
+	return 1 << 30
+}
+
+// sortReadyUnits orders ready units for launch: longest remaining dependent
+// chain first, then master-table priority, then input order. Pure.
+func sortReadyUnits(ready []scheduleUnit, ranks map[string]int, order map[string]int) {
+	sort.SliceStable(ready, func(i, j int) bool {
+		a, b := ready[i], ready[j]
+		if ranks[a.ID] != ranks[b.ID] {
+			return ranks[a.ID] > ranks[b.ID]
+		}
+		if pa, pb := priorityRank(a.Priority), priorityRank(b.Priority); pa != pb {
+			return pa < pb
+		}
+		return order[a.ID] < order[b.ID]
+	})
+}
+
+// runSchedule runs units as soon as their own dependencies have settled
+// successfully, instead of waiting for a whole Kahn layer. Up to maxParallel
+// units run at once; completions are settled (merged) one at a time in
+// completion order unless h.Order holds one back, and a unit only launches
+// after every dependency merged,
+// so a worktree always forks from a main that includes its deps. See
+// knowledge/auto-mode/multi-feature-scheduling.md.
+func runSchedule(units []scheduleUnit, maxParallel int, h scheduleHooks) error {
+	if maxParallel < 1 {
+		maxParallel = 1
+	}
+	ranks := criticalPathRanks(units)
+	order := make(map[string]int, len(units))
+	byID := make(map[string]scheduleUnit, len(units))
+	for i, u := range units {
+		order[u.ID] = i
+		byID[u.ID] = u
+	}
+
+	pending := make(map[string]int, len(units))
+	dependents := make(map[string][]string)
+	var ready []scheduleUnit
+	for _, u := range units {
+		n := 0
+		for _, d := range u.Deps {
+			if _, ok := byID[d]; ok {
+				n++
+				dependents[d] = append(dependents[d], u.ID)
+			}
+		}
+		pending[u.ID] = n
+		if n == 0 {
+			ready = append(ready, u)
+		}
+	}
+
+	type done struct {
+		id  string
+		err error
+	}
+	doneCh := make(chan done)
+	skipped := make(map[string]bool)
+	inFlight := make(map[string]bool)
+	var queued []done
+	running := 0
+	var abortErr error
+
+	// nextToSettle takes the queued completions h.Order lets merge now.
+	nextToSettle := func() []done {
+		if h.Order == nil {
+			batch := queued
+			queued = nil
+			return batch
+		}
+		byDone := make(map[string]done, len(queued))
+		ids := make([]string, 0, len(queued)+len(inFlight))
+		for _, q := range queued {
+			byDone[q.id] = q
+			ids = append(ids, q.id)
+		}
+		var flying []string
+		for id := range inFlight {
+			flying = append(flying, id)
+		}
+		sort.Slice(flying, func(i, j int) bool { return order[flying[i]] < order[flying[j]] })
+		var batch []done
+		for _, id := range h.Order(append(ids, flying...)) {
+			q, ok := byDone[id]
+			if !ok {
+				break // a running unit merges first
+			}
+			batch = append(batch, q)
+			delete(byDone, id)
+		}
+		queued = queued[:0]
+		for _, id := range ids {
+			if q, ok := byDone[id]; ok {
+				queued = append(queued, q)
+			}
+		}
+		return batch
+	}
+
+	// skipDependents walks the dependents of a unit that will never merge,
+	// skipping each one once and naming the unit it was directly blocked by.
+	var skipDependents func(id string)
+	skipDependents = func(id string) {
+		for _, c := range dependents[id] {
+			if skipped[c] {
+				continue
+			}
+			skipped[c] = true
+			if h.Skip != nil {
+				h.Skip(c, id)
+			}
+			skipDependents(c)
+		}
+	}
+
+	for {
+		for abortErr == nil && running < maxParallel && len(ready) > 0 {
+			sortReadyUnits(ready, ranks, order)
+			u := ready[0]
+			ready = ready[1:]
+			run, err := h.Start(u.ID)
+			if err != nil {
+				abortErr = err
+				break
+			}
+			running++
+			inFlight[u.ID] = true
+			go func(id string) {
+				doneCh <- done{id: id, err: run()}
+			}(u.ID)
+		}
+		if running == 0 {
+			break
+		}
+
+		d := <-doneCh
+		running--
+		delete(inFlight, d.id)
+		queued = append(queued, d)
+		for _, d := range nextToSettle() {
+			proceed, fatal := h.Settle(d.id, d.err)
+			if fatal != nil && abortErr == nil {
+				abortErr = fatal
+			}
+			if !proceed {
+				skipDependents(d.id)
+				continue
+			}
+			for _, c := range dependents[d.id] {
+				pending[c]--
+				if pending[c] == 0 && !skipped[c] {
+					ready = append(ready, byID[c])
+				}
+			}
+		}
+	}
+	return abortErr
+}
+
+// validateSchedulerName rejects unknown --scheduler values.
+func validateSchedulerName(name string) error {
+	switch name {
+	case schedulerCriticalPath, schedulerWaves:
+		return nil
+	}
+	return fmt.Errorf("invalid --scheduler %q (use %s or %s)", name, schedulerCriticalPath, schedulerWaves)
+}
+
+// runMilestoneSchedule is the critical-path counterpart of the wave loop in
+// runAutoParallel: each milestone launches in its own worktree as soon as
+// the milestones it depends on have merged back to the feature branch.
+// Milestones that go up for review (merge mode "pr") are added to awaiting,
+// and their dependents to waiting rather than to the failures.
+func runMilestoneSchedule(cfg loopConfig, waves []wave, tracker *worktreeTracker, awaiting map[string]bool, waiting *[]string) error {
+	type failure struct {
+		MilestoneID  string
+		WorktreePath string
+		Reason       string
+	}
+
+	byID := make(map[string]milestone)
+	var units []scheduleUnit
+	var milestones []milestone
+	for _, w := range waves {
+		for _, m := range w.Milestones {
+			byID[m.ID] = m
+			units = append(units, scheduleUnit{ID: m.ID, Deps: m.Deps})
+			milestones = append(milestones, m)
+		}
+	}
+
+	branchFor := func(id string) string {
+		return fmt.Sprintf("belmont/auto/%s/%s", cfg.Feature, strings.ToLower(id))
+	}
+	wtPathFor := func(id string) string {
+		return filepath.Join(worktreeBasePath(cfg.Root), fmt.Sprintf("%s-%s", cfg.Feature, strings.ToLower(id)))
+	}
+
+	// mergeLog records merge order; startedAt is the mergeLog length when a
+	// milestone forked, so overlap is only reported against siblings merged
+	// after the fork (earlier merges are already in its base).
+	var mergeLog []string
+	startedAt := make(map[string]int)
+	touched := make(map[string][]string)
+	running := make(map[string]bool)
+	var failures []failure
+
+	err := runSchedule(units, cfg.MaxParallel, scheduleHooks{
+		Start: func(id string) (func() error, error) {
+			branch, wtPath := branchFor(id), wtPathFor(id)
+			resumed, err := handleStaleWorktree(cfg.Root, id, branch, wtPath)
+			if err != nil {
+				return nil, err
+			}
+			startedAt[id] = len(mergeLog)
+			tracker.add(id, wtPath, branch)
+			fmt.Fprintf(os.Stderr, "  \033[36m▶ %s: %s\033[0m (worktree)\n", id, byID[id].Name)
+			ms := byID[id]
+			side := []milestone{ms}
+			for _, m := range milestones {
+				if running[m.ID] {
+					side = append(side, m)
+				}
+			}
+			warnWaveWorkspaceDependencies(cfg.Root, cfg.Feature, side, id)
+			running[id] = true
+			return func() error {
+				return runMilestoneInWorktree(cfg, ms, branch, wtPath, tracker, resumed)
+			}, nil
+		},
+		Settle: func(id string, runErr error) (bool, error) {
+			delete(running, id)
+			if runErr != nil {
+				fmt.Fprintf(os.Stderr, "  \033[31m✗ %s failed: %s\033[0m\n", id, runErr)
+				failures = append(failures, failure{MilestoneID: id, WorktreePath: wtPathFor(id), Reason: runErr.Error()})
+				return false, nil
+			}
+			fmt.Fprintf(os.Stderr, "  \033[32m✓ %s complete\033[0m\n", id)
+			if err := ensureCleanMergeState(cfg.Root); err != nil {
+				fmt.Fprintf(os.Stderr, "  \033[33m⚠ %s — skipping merge for %s\033[0m\n", err, id)
+				failures = append(failures, failure{MilestoneID: id, WorktreePath: wtPathFor(id), Reason: "skipped: unclean merge state"})
+				return false, nil
+			}
+			mergedFiles := map[string][]string{}
+			for _, sib := range mergeLog[startedAt[id]:] {
+				for _, f := range touched[sib] {
+					mergedFiles[f] = append(mergedFiles[f], sib)
+				}
+			}
+			branch := branchFor(id)
+			reportMergeOverlap(cfg.Root, branch, id, mergedFiles)
+			files := branchTouchedFiles(cfg.Root, branch)
+			if err := mergeWorktreeBranch(cfg, id, branch, wtPathFor(id), tracker); errors.Is(err, errAwaitingReview) {
+				awaiting[id] = true
+				return false, nil
+			} else if err != nil {
+				return false, fmt.Errorf("auto: merge failed for %s: %w", id, err)
+			}
+			touched[id] = files
+			mergeLog = append(mergeLog, id)
+			return true, nil
+		},
+		Skip: func(id, blocker string) {
+			if awaiting[blocker] {
+				fmt.Fprintf(os.Stderr, "  \033[36m⊘ %s skipped\033[0m — dependency %s awaiting review\n", id, blocker)
+				awaiting[id] = true
+				*waiting = append(*waiting, fmt.Sprintf("%s — waiting on %s", id, blocker))
+				return
+			}
+			fmt.Fprintf(os.Stderr, "  \033[31m⊘ %s skipped\033[0m — dependency %s did not merge\n", id, blocker)
+			failures = append(failures, failure{MilestoneID: id, Reason: fmt.Sprintf("dependency %s did not merge", blocker)})
+		},
+		// A finished milestone waits for a running one that touches an
+		// upstream workspace, as in a wave's merge order.
+		Order: func(ids []string) []string {
+			branches := make(map[string]string, len(ids))
+			for _, id := range ids {
+				branches[id] = branchFor(id)
+			}
+			return workspaceMergeOrder(cfg.Root, cfg.Feature, milestones, ids, branches)
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(failures) > 0 {
+		fmt.Fprintf(os.Stderr, "\n\033[33m⚠ %d milestone(s) did not complete:\033[0m\n", len(failures))
+		for _, f := range failures {
+			if f.WorktreePath == "" {
+				fmt.Fprintf(os.Stderr, "  %s: %s\n", f.MilestoneID, f.Reason)
+				continue
+			}
+			fmt.Fprintf(os.Stderr, "  %s: worktree preserved at %s\n", f.MilestoneID, f.WorktreePath)
+			fmt.Fprintf(os.Stderr, "    Resume: cd %s && belmont auto --feature %s --from %s --to %s\n", f.WorktreePath, cfg.Feature, f.MilestoneID, f.MilestoneID)
+		}
+		return fmt.Errorf("auto: %d milestone(s) failed", len(failures))
+	}
+	return nil
+}
+
+// schedulerLabel names the scheduler a run will actually use; a single slot
+// always runs the serial wave path regardless of --scheduler.
+func schedulerLabel(cfg loopConfig) string {
+	if cfg.MaxParallel > 1 && cfg.Scheduler == schedulerCriticalPath {
+		return schedulerCriticalPath
+	}
+	return schedulerWaves
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCriticalPathRanks(t *testing.T) {
+	units := []scheduleUnit{
+		{ID: "M2"},
+		{ID: "M3"},
+		{ID: "M4", Deps: []string{"M3"}},
+		{ID: "M5", Deps: []string{"M4", "M1"}}, // M1 is outside the run
+	}
+	ranks := criticalPathRanks(units)
+	want := map[string]int{"M2": 1, "M3": 3, "M4": 2, "M5": 1}
+	for id, r := range want {
+		if ranks[id] != r {
+			t.Errorf("rank[%s] = %d, want %d", id, ranks[id], r)
+		}
+	}
+}
+
+func TestSortReadyUnits_ChainThenPriorityThenOrder(t *testing.T) {
+	ready := []scheduleUnit{
+		{ID: "a", Priority: "P2"},
+		{ID: "b", Priority: "P0"},
+		{ID: "c"},
+		{ID: "d", Priority: "P2"},
+		{ID: "e", Priority: "P3"},
+	}
+	ranks := map[string]int{"a": 1, "b": 1, "c": 1, "d": 1, "e": 2}
+	order := map[string]int{"a": 0, "b": 1, "c": 2, "d": 3, "e": 4}
+	sortReadyUnits(ready, ranks, order)
+	var got []string
+	for _, u := range ready {
+		got = append(got, u.ID)
+	}
+	if !equalStringSlices(got, []string{"e", "b", "a", "d", "c"}) {
+		t.Errorf("order = %v", got)
+	}
+}
+
+// TestRunSchedule_StartsDependentBeforeSiblingFinishes pins the point of the
+// scheduler: M4 depends only on M3, so it must launch while M2 (a wave-1
+// sibling of M3) is still running.
+func TestRunSchedule_StartsDependentBeforeSiblingFinishes(t *testing.T) {
+	units := []scheduleUnit{
+		{ID: "M2"},
+		{ID: "M3"},
+		{ID: "M4", Deps: []string{"M3"}},
+	}
+	releaseM2 := make(chan struct{})
+	var events []string
+	err := runSchedule(units, 2, scheduleHooks{
+		Start: func(id string) (func() error, error) {
+			events = append(events, "start "+id)
+			return func() error {
+				if id == "M2" {
+					<-releaseM2
+				}
+				return nil
+			}, nil
+		},
+		Settle: func(id string, runErr error) (bool, error) {
+			events = append(events, "merge "+id)
+			if id == "M4" {
+				close(releaseM2)
+			}
+			return true, nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"start M3", "start M2", "merge M3", "start M4", "merge M4", "merge M2"}
+	if !equalStringSlices(events, want) {
+		t.Errorf("events = %v, want %v", events, want)
+	}
+}
+
+func TestRunSchedule_FailureCascadesToDependentsOnly(t *testing.T) {
+	units := []scheduleUnit{
+		{ID: "auth"},
+		{ID: "billing", Deps: []string{"auth"}},
+		{ID: "invoices", Deps: []string{"billing"}},
+		{ID: "docs"},
+	}
+	var merged []string
+	skips := map[string]string{}
+	err := runSchedule(units, 1, scheduleHooks{
+		Start: func(id string) (func() error, error) {
+			return func() error {
+				if id == "auth" {
+					return errors.New("boom")
+				}
+				return nil
+			}, nil
+		},
+		Settle: func(id string, runErr error) (bool, error) {
+			if runErr != nil {
+				return false, nil
+			}
+			merged = append(merged, id)
+			return true, nil
+		},
+		Skip: func(id, blocker string) { skips[id] = blocker },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equalStringSlices(merged, []string{"docs"}) {
+		t.Errorf("merged = %v", merged)
+	}
+	if skips["billing"] != "auth" || skips["invoices"] != "billing" || len(skips) != 2 {
+		t.Errorf("skips = %v", skips)
+	}
+}
+
+func TestRunSchedule_StartErrorDrainsInFlight(t *testing.T) {
+	units := []scheduleUnit{{ID: "a"}, {ID: "b"}}
+	settled := 0
+	err := runSchedule(units, 2, scheduleHooks{
+		Start: func(id string) (func() error, error) {
+			if id == "b" {
+				return nil, errors.New("user chose to quit")
+			}
+			return func() error { return nil }, nil
+		},
+		Settle: func(id string, runErr error) (bool, error) {
+			settled++
+			return true, nil
+		},
+	})
+	if err == nil || settled != 1 {
+		t.Errorf("err = %v, settled = %d", err, settled)
+	}
+}
+
+// TestRunSchedule_OrderHoldsDownstreamMerge covers the critical-path merge
+// queue: M2 (web) finishes while M4 (types, which web depends on) is still
+// running, so M2's merge waits and M4 merges first, as in a wave.
+func TestRunSchedule_OrderHoldsDownstreamMerge(t *testing.T) {
+	ws := []workspaceInfo{
+		{ID: "web", Path: "apps/web", Deps: []string{"types"}},
+		{ID: "types", Path: "packages/types"},
+	}
+	scopes := map[string][]string{"M2": {"web"}, "M4": {"types"}}
+	units := []scheduleUnit{{ID: "M2"}, {ID: "M4"}}
+
+	releaseM4 := make(chan struct{})
+	var events []string
+	err := runSchedule(units, 2, scheduleHooks{
+		Start: func(id string) (func() error, error) {
+			return func() error {
+				if id == "M4" {
+					<-releaseM4
+				}
+				return nil
+			}, nil
+		},
+		Settle: func(id string, runErr error) (bool, error) {
+			events = append(events, "merge "+id)
+			return true, nil
+		},
+		Order: func(ids []string) []string {
+			events = append(events, "queue "+strings.Join(ids, ","))
+			if len(events) == 1 {
+				close(releaseM4)
+			}
+			return orderByWorkspaceGraph(ws, ids, scopes)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"queue M2,M4", "queue M2,M4", "merge M4", "merge M2"}
+	if !equalStringSlices(events, want) {
+		t.Errorf("events = %v, want %v", events, want)
+	}
+}
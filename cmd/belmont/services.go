@@ -0,0 +1,376 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ============================================================================
+// Per-worktree services
+//
+// Every worktree copies the same .env, so parallel milestones share one
+// DATABASE_URL and one worktree's migrations break another's. The services
+// block of worktree.json gives each worktree its own instance: a Postgres
+// database or schema, a Redis DB index, a temp directory, or anything a
+// pair of shell commands can create and drop. Services are provisioned
+// before the setup hooks (so migrations in setup hit the worktree's own
+// database) and their connection strings join the worktree env next to
+// BELMONT_PORT. What was provisioned is recorded in .belmont/services/ in
+// the main repo so teardown — after a merge, on cleanup, from
+// `recover --clean` — can drop exactly that, and a resumed worktree reuses
+// it. Interrupting a run keeps services along with the preserved worktree.
+// ============================================================================
+
+// Service types.
+const (
+	serviceTypePostgres = "postgres"
+	serviceTypeRedis    = "redis"
+	serviceTypeTempdir  = "tempdir"
+	serviceTypeCommand  = "command"
+)
+
+// worktreeServiceConfig is one entry of the services block of worktree.json.
+type worktreeServiceConfig struct {
+	Type     string `json:"type"`
+	URL      string `json:"url,omitempty"`      // postgres / redis: the shared server (its database is the template for names)
+	Env      string `json:"env,omitempty"`      // variable that receives the connection string or path
+	Mode     string `json:"mode,omitempty"`     // postgres: "database" (default) or "schema"
+	Template bool   `json:"template,omitempty"` // postgres database mode: clone the URL's database instead of starting empty
+	Setup    string `json:"setup,omitempty"`    // command: provisions the instance
+	Teardown string `json:"teardown,omitempty"` // command: removes it
+	Value    string `json:"value,omitempty"`    // command: the exported value; $BELMONT_SERVICE_INSTANCE expands
+
+	// Workspaces binds the service to monorepo workspaces: it is provisioned
+	// only when the worktree's milestone affects one of them (see
+	// workspace_scope.go). Empty means always.
+	Workspaces []string `json:"workspaces,omitempty"`
+}
+
+// provisionedService is what teardown needs to undo one service.
+type provisionedService struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Instance string `json:"instance"` // database/schema name, Redis DB index or directory
+	Mode     string `json:"mode,omitempty"`
+	Admin    string `json:"admin,omitempty"`
+	Teardown string `json:"teardown,omitempty"`
+	Value    string `json:"value"`
+}
+
+// worktreeServiceRecord is .belmont/services/<worktree>.json.
+type worktreeServiceRecord struct {
+	Worktree string               `json:"worktree"`
+	Services []provisionedService `json:"services"`
+	Env      map[string]string    `json:"env"`
+}
+
+// serviceCommand runs a service client (psql, redis-cli, sh) and returns
+// its trimmed output. Tests replace it.
+var serviceCommand = func(env []string, name string, args ...string) (string, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Env = append(os.Environ(), env...)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("%s: %w: %s", name, err, tailOutput(string(out), 500))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func worktreeServicesDir(root string) string {
+	return filepath.Join(root, ".belmont", "services")
+}
+
+func worktreeServiceRecordPath(root, wtPath string) string {
+	return filepath.Join(worktreeServicesDir(root), filepath.Base(wtPath)+".json")
+}
+
+// worktreeServicesMu serialises provisioning so parallel units don't pick
+// the same Redis index.
+var worktreeServicesMu sync.Mutex
+
+var unsafeServiceChars = regexp.MustCompile(`[^a-z0-9_]+`)
+
+// serviceInstanceName derives a database/schema name for a worktree from a
+// base name: lower-case, [a-z0-9_] only, within Postgres' 63-byte limit.
+func serviceInstanceName(base, wtPath string) string {
+	name := unsafeServiceChars.ReplaceAllString(strings.ToLower(base+"_"+filepath.Base(wtPath)), "_")
+	name = strings.Trim(name, "_")
+	if len(name) > 63 {
+		name = name[len(name)-63:]
+	}
+	return name
+}
+
+// serviceEnvName is the variable a service exports to: its env field, or
+// BELMONT_SERVICE_<NAME>.
+func serviceEnvName(name string, svc worktreeServiceConfig) string {
+	if svc.Env != "" {
+		return svc.Env
+	}
+	return "BELMONT_SERVICE_" + strings.ToUpper(unsafeServiceChars.ReplaceAllString(strings.ToLower(name), "_"))
+}
+
+// loadWorktreeServiceRecord reads the record for wtPath, or nil.
+func loadWorktreeServiceRecord(root, wtPath string) *worktreeServiceRecord {
+	data, err := os.ReadFile(worktreeServiceRecordPath(root, wtPath))
+	if err != nil {
+		return nil
+	}
+	var rec worktreeServiceRecord
+	if json.Unmarshal(data, &rec) != nil {
+		return nil
+	}
+	return &rec
+}
+
+func saveWorktreeServiceRecord(root, wtPath string, rec *worktreeServiceRecord) error {
+	if err := os.MkdirAll(worktreeServicesDir(root), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return err
+	}
+	excludeInRepo(root, ".belmont/services/")
+	return os.WriteFile(worktreeServiceRecordPath(root, wtPath), append(data, '\n'), 0644)
+}
+
+// provisionWorktreeServices provisions every service declared in
+// worktree.json for wtPath and returns env with the services' variables
+// added (a service overrides the same key from env). A worktree that
+// already has a record reuses its instances. On failure, anything
+// provisioned so far is torn down again.
+func provisionWorktreeServices(root, wtPath string, hooks *worktreeHooks, env map[string]string) (map[string]string, error) {
+	if hooks == nil || len(hooks.Services) == 0 {
+		return env, nil
+	}
+	worktreeServicesMu.Lock()
+	defer worktreeServicesMu.Unlock()
+	out := map[string]string{}
+	for k, v := range env {
+		out[k] = v
+	}
+	if rec := loadWorktreeServiceRecord(root, wtPath); rec != nil {
+		for k, v := range rec.Env {
+			out[k] = v
+		}
+		return out, nil
+	}
+
+	names := make([]string, 0, len(hooks.Services))
+	for name := range hooks.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	rec := &worktreeServiceRecord{Worktree: wtPath, Env: map[string]string{}}
+	for _, name := range names {
+		svc := hooks.Services[name]
+		p, err := provisionService(root, wtPath, name, svc)
+		if err != nil {
+			teardownProvisionedServices(wtPath, rec.Services)
+			return env, fmt.Errorf("service %s: %w", name, err)
+		}
+		rec.Services = append(rec.Services, p)
+		rec.Env[serviceEnvName(name, svc)] = p.Value
+		fmt.Fprintf(os.Stderr, "    \033[2mProvisioned %s service %s (%s)\033[0m\n", svc.Type, name, p.Instance)
+	}
+	if err := saveWorktreeServiceRecord(root, wtPath, rec); err != nil {
+		teardownProvisionedServices(wtPath, rec.Services)
+		return env, fmt.Errorf("record services: %w", err)
+	}
+	for k, v := range rec.Env {
+		out[k] = v
+	}
+	return out, nil
+}
+
+func provisionService(root, wtPath, name string, svc worktreeServiceConfig) (provisionedService, error) {
+	p := provisionedService{Name: name, Type: svc.Type}
+	switch svc.Type {
+	case serviceTypePostgres:
+		u, err := url.Parse(svc.URL)
+		if err != nil || svc.URL == "" {
+			return p, fmt.Errorf("postgres needs a url")
+		}
+		base := strings.TrimPrefix(u.Path, "/")
+		if base == "" {
+			base = "postgres"
+		}
+		p.Instance = serviceInstanceName(base, wtPath)
+		if svc.Mode == "schema" {
+			p.Mode = "schema"
+			p.Admin = svc.URL
+			if _, err := serviceCommand(nil, "psql", svc.URL, "-v", "ON_ERROR_STOP=1", "-qc", fmt.Sprintf(`CREATE SCHEMA IF NOT EXISTS "%s"`, p.Instance)); err != nil {
+				return p, err
+			}
+			q := u.Query()
+			q.Set("options", "-c search_path="+p.Instance)
+			// libpq only decodes %XX in a URI, so the space can't be
+			// Encode's "+"; a literal "+" is already %2B.
+			u.RawQuery = strings.ReplaceAll(q.Encode(), "+", "%20")
+			p.Value = u.String()
+			return p, nil
+		}
+		admin := *u
+		admin.Path = "/postgres"
+		p.Admin = admin.String()
+		exists, err := serviceCommand(nil, "psql", p.Admin, "-tAc", fmt.Sprintf(`SELECT 1 FROM pg_database WHERE datname = '%s'`, p.Instance))
+		if err != nil {
+			return p, err
+		}
+		if exists != "1" {
+			create := fmt.Sprintf(`CREATE DATABASE "%s"`, p.Instance)
+			if svc.Template {
+				create += fmt.Sprintf(` TEMPLATE "%s"`, base)
+			}
+			if _, err := serviceCommand(nil, "psql", p.Admin, "-v", "ON_ERROR_STOP=1", "-qc", create); err != nil {
+				return p, err
+			}
+		}
+		u.Path = "/" + p.Instance
+		p.Value = u.String()
+		return p, nil
+
+	case serviceTypeRedis:
+		u, err := url.Parse(svc.URL)
+		if err != nil || svc.URL == "" {
+			return p, fmt.Errorf("redis needs a url")
+		}
+		index, err := freeRedisIndex(root, svc.URL)
+		if err != nil {
+			return p, err
+		}
+		u.Path = "/" + strconv.Itoa(index)
+		p.Instance = strconv.Itoa(index)
+		p.Value = u.String()
+		p.Admin = p.Value
+		if _, err := serviceCommand(nil, "redis-cli", "-u", p.Value, "FLUSHDB"); err != nil {
+			return p, err
+		}
+		return p, nil
+
+	case serviceTypeTempdir:
+		// Inside the worktree (and ignored) rather than under $TMPDIR, so it
+		// is visible to an agent running in the worktree's container.
+		dir := filepath.Join(wtPath, ".belmont", "tmp", name)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return p, err
+		}
+		excludeInRepo(root, ".belmont/tmp/")
+		p.Instance = dir
+		p.Value = dir
+		return p, nil
+
+	case serviceTypeCommand:
+		p.Instance = serviceInstanceName(name, wtPath)
+		if svc.Setup != "" {
+			if _, err := serviceCommand(serviceCommandEnv(p.Instance, wtPath), "sh", "-c", svc.Setup); err != nil {
+				return p, err
+			}
+		}
+		p.Teardown = svc.Teardown
+		p.Value = os.Expand(svc.Value, func(k string) string {
+			switch k {
+			case "BELMONT_SERVICE_INSTANCE":
+				return p.Instance
+			case "BELMONT_WORKTREE_PATH":
+				return wtPath
+			}
+			return os.Getenv(k)
+		})
+		return p, nil
+	}
+	return p, fmt.Errorf("unknown service type %q (want postgres, redis, tempdir or command)", svc.Type)
+}
+
+// serviceCommandEnv is the env a command service's setup and teardown run with.
+func serviceCommandEnv(instance, wtPath string) []string {
+	return []string{"BELMONT_SERVICE_INSTANCE=" + instance, "BELMONT_WORKTREE_PATH=" + wtPath}
+}
+
+// freeRedisIndex picks the lowest DB index from 1 to 15 that no other
+// worktree's record holds on the same server. Index 0 is left to the main
+// checkout.
+func freeRedisIndex(root, server string) (int, error) {
+	used := map[string]bool{}
+	entries, _ := os.ReadDir(worktreeServicesDir(root))
+	for _, e := range entries {
+		data, err := os.ReadFile(filepath.Join(worktreeServicesDir(root), e.Name()))
+		if err != nil {
+			continue
+		}
+		var rec worktreeServiceRecord
+		if json.Unmarshal(data, &rec) != nil {
+			continue
+		}
+		for _, s := range rec.Services {
+			if s.Type == serviceTypeRedis && redisServer(s.Value) == redisServer(server) {
+				used[s.Instance] = true
+			}
+		}
+	}
+	for i := 1; i < 16; i++ {
+		if !used[strconv.Itoa(i)] {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("no free redis DB index on %s", redisServer(server))
+}
+
+// redisServer strips the DB index from a Redis URL.
+func redisServer(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	u.Path = ""
+	return u.String()
+}
+
+// teardownWorktreeServices drops the services recorded for wtPath and
+// deletes the record. Best-effort; a no-op without a record.
+func teardownWorktreeServices(root, wtPath string) {
+	rec := loadWorktreeServiceRecord(root, wtPath)
+	if rec == nil {
+		return
+	}
+	teardownProvisionedServices(wtPath, rec.Services)
+	os.Remove(worktreeServiceRecordPath(root, wtPath))
+}
+
+// teardownProvisionedServices undoes services in reverse order.
+func teardownProvisionedServices(wtPath string, services []provisionedService) {
+	for i := len(services) - 1; i >= 0; i-- {
+		s := services[i]
+		var err error
+		switch s.Type {
+		case serviceTypePostgres:
+			if s.Mode == "schema" {
+				_, err = serviceCommand(nil, "psql", s.Admin, "-qc", fmt.Sprintf(`DROP SCHEMA IF EXISTS "%s" CASCADE`, s.Instance))
+			} else {
+				_, err = serviceCommand(nil, "psql", s.Admin, "-qc", fmt.Sprintf(`DROP DATABASE IF EXISTS "%s"`, s.Instance))
+			}
+		case serviceTypeRedis:
+			_, err = serviceCommand(nil, "redis-cli", "-u", s.Admin, "FLUSHDB")
+		case serviceTypeTempdir:
+			err = os.RemoveAll(s.Instance)
+		case serviceTypeCommand:
+			if s.Teardown != "" {
+				_, err = serviceCommand(serviceCommandEnv(s.Instance, wtPath), "sh", "-c", s.Teardown)
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "    \033[33m⚠ Could not tear down service %s: %v\033[0m\n", s.Name, err)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// stubServiceCommands records service client calls instead of running them.
+func stubServiceCommands(t *testing.T, reply func(args []string) string) *[]string {
+	t.Helper()
+	var calls []string
+	orig := serviceCommand
+	serviceCommand = func(env []string, name string, args ...string) (string, error) {
+		calls = append(calls, name+" "+strings.Join(args, " "))
+		if reply != nil {
+			return reply(args), nil
+		}
+		return "", nil
+	}
+	t.Cleanup(func() { serviceCommand = orig })
+	return &calls
+}
+
+func TestWorktreeServices_PostgresDatabasePerWorktree(t *testing.T) {
+	root := t.TempDir()
+	calls := stubServiceCommands(t, nil)
+	hooks := &worktreeHooks{Services: map[string]worktreeServiceConfig{
+		"db": {Type: "postgres", URL: "postgresql://app:pw@localhost:5432/myapp?sslmode=disable", Env: "DATABASE_URL", Template: true},
+	}}
+	wtPath := filepath.Join(t.TempDir(), "feat-m2")
+
+	env, err := provisionWorktreeServices(root, wtPath, hooks, map[string]string{"DATABASE_URL": "shared", "KEEP": "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := env["DATABASE_URL"], "postgresql://app:pw@localhost:5432/myapp_feat_m2?sslmode=disable"; got != want {
+		t.Errorf("DATABASE_URL = %q, want %q", got, want)
+	}
+	if env["KEEP"] != "1" {
+		t.Error("other env entries should be kept")
+	}
+	joined := strings.Join(*calls, "\n")
+	if !strings.Contains(joined, `CREATE DATABASE "myapp_feat_m2" TEMPLATE "myapp"`) || !strings.Contains(joined, "/postgres?sslmode=disable") {
+		t.Errorf("unexpected psql calls:\n%s", joined)
+	}
+
+	// A resumed worktree reuses the record without provisioning again.
+	*calls = nil
+	again, err := provisionWorktreeServices(root, wtPath, hooks, nil)
+	if err != nil || again["DATABASE_URL"] != env["DATABASE_URL"] || len(*calls) != 0 {
+		t.Errorf("reprovision = %v, %v, calls %v", again, err, *calls)
+	}
+
+	teardownWorktreeServices(root, wtPath)
+	if len(*calls) != 1 || !strings.Contains((*calls)[0], `DROP DATABASE IF EXISTS "myapp_feat_m2"`) {
+		t.Errorf("teardown calls = %v", *calls)
+	}
+	if loadWorktreeServiceRecord(root, wtPath) != nil {
+		t.Error("record should be removed after teardown")
+	}
+}
+
+func TestWorktreeServices_PostgresSchemaMode(t *testing.T) {
+	calls := stubServiceCommands(t, nil)
+	p, err := provisionService(t.TempDir(), "/wt/feat-m1", "db", worktreeServiceConfig{Type: "postgres", URL: "postgres://localhost/app?sslmode=disable", Mode: "schema"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "postgres://localhost/app?options=-c%20search_path%3Dapp_feat_m1&sslmode=disable"; p.Value != want {
+		t.Errorf("schema URL = %s, want %s", p.Value, want)
+	}
+	teardownProvisionedServices("/wt/feat-m1", []provisionedService{p})
+	if last := (*calls)[len(*calls)-1]; !strings.Contains(last, `DROP SCHEMA IF EXISTS "app_feat_m1" CASCADE`) {
+		t.Errorf("teardown = %s", last)
+	}
+}
+
+func TestWorktreeServices_RedisIndexesDoNotCollide(t *testing.T) {
+	root := t.TempDir()
+	stubServiceCommands(t, nil)
+	hooks := &worktreeHooks{Services: map[string]worktreeServiceConfig{
+		"cache": {Type: "redis", URL: "redis://localhost:6379/0"},
+	}}
+	first, err := provisionWorktreeServices(root, "/wt/a", hooks, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := provisionWorktreeServices(root, "/wt/b", hooks, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first["BELMONT_SERVICE_CACHE"] != "redis://localhost:6379/1" || second["BELMONT_SERVICE_CACHE"] != "redis://localhost:6379/2" {
+		t.Errorf("indexes = %q, %q", first["BELMONT_SERVICE_CACHE"], second["BELMONT_SERVICE_CACHE"])
+	}
+	teardownWorktreeServices(root, "/wt/a")
+	third, _ := provisionWorktreeServices(root, "/wt/c", hooks, nil)
+	if third["BELMONT_SERVICE_CACHE"] != "redis://localhost:6379/1" {
+		t.Errorf("freed index should be reused, got %q", third["BELMONT_SERVICE_CACHE"])
+	}
+}
+
+func TestWorktreeServices_TempdirAndCommand(t *testing.T) {
+	root := t.TempDir()
+	calls := stubServiceCommands(t, nil)
+	hooks := &worktreeHooks{Services: map[string]worktreeServiceConfig{
+		"scratch": {Type: "tempdir", Env: "SCRATCH_DIR"},
+		"queue":   {Type: "command", Setup: "mkqueue $BELMONT_SERVICE_INSTANCE", Teardown: "rmqueue $BELMONT_SERVICE_INSTANCE", Value: "amqp://localhost/${BELMONT_SERVICE_INSTANCE}", Env: "AMQP_URL"},
+	}}
+	wtPath := filepath.Join(t.TempDir(), "feat-m3")
+	env, err := provisionWorktreeServices(root, wtPath, hooks, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(wtPath, ".belmont", "tmp", "scratch"); env["SCRATCH_DIR"] != want || !dirExists(want) {
+		t.Errorf("tempdir %q not created inside the worktree", env["SCRATCH_DIR"])
+	}
+	if env["AMQP_URL"] != "amqp://localhost/queue_feat_m3" {
+		t.Errorf("AMQP_URL = %q", env["AMQP_URL"])
+	}
+	teardownWorktreeServices(root, wtPath)
+	if _, err := os.Stat(env["SCRATCH_DIR"]); !os.IsNotExist(err) {
+		t.Error("tempdir should be removed on teardown")
+	}
+	if got := (*calls)[len(*calls)-1]; got != "sh -c rmqueue $BELMONT_SERVICE_INSTANCE" {
+		t.Errorf("last call = %q", got)
+	}
+}
+
+func TestWorktreeServices_FailureRollsBack(t *testing.T) {
+	root := t.TempDir()
+	stubServiceCommands(t, nil)
+	hooks := &worktreeHooks{Services: map[string]worktreeServiceConfig{
+		"a":  {Type: "tempdir"},
+		"zz": {Type: "mongo"},
+	}}
+	wtPath := filepath.Join(t.TempDir(), "x")
+	if _, err := provisionWorktreeServices(root, wtPath, hooks, nil); err == nil || !strings.Contains(err.Error(), "unknown service type") {
+		t.Fatalf("err = %v", err)
+	}
+	if dirExists(filepath.Join(wtPath, ".belmont", "tmp", "a")) {
+		t.Error("tempdir from the failed provisioning should be removed")
+	}
+	if loadWorktreeServiceRecord(root, wtPath) != nil {
+		t.Error("no record should be written on failure")
+	}
+}
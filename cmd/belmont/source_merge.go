@@ -0,0 +1,577 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ============================================================================
+// Source hunk union
+//
+// The reconciliation agent's most common strategy is "import-union": two
+// parallel milestones each added imports to the same file, or each appended
+// a route/export/function at its end. autoResolveSourceHunks settles those
+// hunks deterministically for Go, TS/JS, Python and Rust and leaves every
+// other hunk in place (in diff3 form, so the agent also sees the base) for
+// the agent. A file with no hunks left is staged.
+// ============================================================================
+
+// Conflict marker prefixes (git's default marker size of 7).
+const (
+	markerOurs   = "<<<<<<<"
+	markerBase   = "|||||||"
+	markerSplit  = "======="
+	markerTheirs = ">>>>>>>"
+)
+
+// conflictHunk is one conflict region of a file. Base is nil when the file
+// was written without diff3 markers.
+type conflictHunk struct {
+	Header, BaseHeader, Footer string // the marker lines themselves
+	Ours, Base, Theirs         []string
+}
+
+// conflictSegment is either plain text (Hunk == nil) or a conflict hunk.
+type conflictSegment struct {
+	Lines []string
+	Hunk  *conflictHunk
+}
+
+// parseConflictFile splits a conflicted file into plain and hunk segments.
+// ok is false for malformed or nested markers.
+func parseConflictFile(text string) (segs []conflictSegment, ok bool) {
+	lines := strings.Split(text, "\n")
+	var plain []string
+	for i := 0; i < len(lines); i++ {
+		if !strings.HasPrefix(lines[i], markerOurs) {
+			plain = append(plain, lines[i])
+			continue
+		}
+		if len(plain) > 0 {
+			segs = append(segs, conflictSegment{Lines: plain})
+			plain = nil
+		}
+		h := &conflictHunk{Header: lines[i]}
+		section := &h.Ours
+		closed := false
+		for i++; i < len(lines); i++ {
+			l := lines[i]
+			switch {
+			case strings.HasPrefix(l, markerOurs):
+				return nil, false
+			case strings.HasPrefix(l, markerBase) && section == &h.Ours:
+				h.BaseHeader = l
+				h.Base = []string{}
+				section = &h.Base
+			case l == markerSplit && section != &h.Theirs:
+				section = &h.Theirs
+			case strings.HasPrefix(l, markerTheirs) && section == &h.Theirs:
+				h.Footer = l
+				closed = true
+			default:
+				*section = append(*section, l)
+			}
+			if closed {
+				break
+			}
+		}
+		if !closed {
+			return nil, false
+		}
+		segs = append(segs, conflictSegment{Hunk: h})
+	}
+	if len(plain) > 0 {
+		segs = append(segs, conflictSegment{Lines: plain})
+	}
+	return segs, true
+}
+
+// renderConflictFile joins segments back into file text; unresolved hunks
+// keep their markers.
+func renderConflictFile(segs []conflictSegment) string {
+	var out []string
+	for _, s := range segs {
+		if s.Hunk == nil {
+			out = append(out, s.Lines...)
+			continue
+		}
+		h := s.Hunk
+		out = append(out, h.Header)
+		out = append(out, h.Ours...)
+		if h.Base != nil {
+			out = append(out, h.BaseHeader)
+			out = append(out, h.Base...)
+		}
+		out = append(out, markerSplit)
+		out = append(out, h.Theirs...)
+		out = append(out, h.Footer)
+	}
+	return strings.Join(out, "\n")
+}
+
+// importLinePatterns recognise single-line import statements per language.
+// Multi-line forms (`import {\n a,\n} from`, `use a::{\n`) are deliberately
+// not matched: the hunk is left to the agent. Go specs without the keyword
+// are matched by goImportSpecRe, only inside an `import ( … )` block.
+var importLinePatterns = map[string][]*regexp.Regexp{
+	"go": {
+		regexp.MustCompile(`^\s*import\s+([A-Za-z_.][\w.]*\s+)?"[^"]+"\s*$`),
+	},
+	"js": {
+		regexp.MustCompile(`^\s*import\s+[^'"]+\s+from\s+['"][^'"]+['"]\s*;?\s*$`),
+		regexp.MustCompile(`^\s*import\s+['"][^'"]+['"]\s*;?\s*$`),
+		regexp.MustCompile(`^\s*export\s+(type\s+)?(\*|\*\s+as\s+\w+|\{[^}]*\})\s+from\s+['"][^'"]+['"]\s*;?\s*$`),
+		regexp.MustCompile(`^\s*(const|let|var)\s+[\w$]+\s*=\s*require\(\s*['"][^'"]+['"]\s*\)\s*;?\s*$`),
+		regexp.MustCompile(`^\s*(const|let|var)\s+\{[^}]*\}\s*=\s*require\(\s*['"][^'"]+['"]\s*\)\s*;?\s*$`),
+	},
+	"python": {
+		regexp.MustCompile(`^\s*import\s+[\w.]+(\s+as\s+\w+)?(\s*,\s*[\w.]+(\s+as\s+\w+)?)*\s*$`),
+		regexp.MustCompile(`^\s*from\s+[.\w]+\s+import\s+[^()\\#]+$`),
+	},
+	"rust": {
+		regexp.MustCompile(`^\s*(pub(\([^)]*\))?\s+)?use\s+[^;{}]*(\{[^{}]*\})?\s*;\s*$`),
+		regexp.MustCompile(`^\s*(pub(\([^)]*\))?\s+)?mod\s+\w+\s*;\s*$`),
+		regexp.MustCompile(`^\s*extern\s+crate\s+\w+(\s+as\s+\w+)?\s*;\s*$`),
+	},
+}
+
+// goImportSpecRe matches one spec of a Go import block. Outside a block the
+// same shape is an ordinary statement (`return "a"`), so it never counts
+// there.
+var (
+	goImportSpecRe      = regexp.MustCompile(`^\s*([A-Za-z_.][\w.]*\s+)?"[^"]+"\s*$`)
+	goImportBlockOpenRe = regexp.MustCompile(`^\s*import\s*\(\s*$`)
+)
+
+// sourceLanguageFor maps a path to a key of importLinePatterns, or "".
+func sourceLanguageFor(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".go":
+		return "go"
+	case ".ts", ".tsx", ".js", ".jsx", ".mjs", ".cjs", ".mts", ".cts":
+		return "js"
+	case ".py", ".pyi":
+		return "python"
+	case ".rs":
+		return "rust"
+	}
+	return ""
+}
+
+// isImportLine reports whether l is an import statement. inBlock says the
+// line sits inside a Go `import ( … )` block.
+func isImportLine(lang, l string, inBlock bool) bool {
+	for _, re := range importLinePatterns[lang] {
+		if re.MatchString(l) {
+			return true
+		}
+	}
+	return lang == "go" && inBlock && goImportSpecRe.MatchString(l)
+}
+
+// isImportSection reports whether every non-blank line is an import
+// statement (an empty section qualifies).
+func isImportSection(lang string, lines []string, inBlock bool) bool {
+	for _, l := range lines {
+		if strings.TrimSpace(l) != "" && !isImportLine(lang, l, inBlock) {
+			return false
+		}
+	}
+	return true
+}
+
+// importKey normalises an import line for de-duplication: surrounding and
+// repeated whitespace, a trailing semicolon, quote style and Go's optional
+// `import` keyword don't make two imports different.
+func importKey(line string) string {
+	t := strings.TrimSuffix(strings.TrimSpace(line), ";")
+	t = strings.Join(strings.Fields(t), " ")
+	if strings.HasPrefix(t, "import \"") {
+		t = strings.TrimPrefix(t, "import ")
+	}
+	return strings.ReplaceAll(t, "'", "\"")
+}
+
+// unionImports keeps ours' lines in order and appends theirs' new imports
+// after ours' last import. With a base, an import removed by either side
+// stays removed. Imports in context (outside the hunk, see
+// fileImports) and repeats within the union are dropped, so an import
+// both sides added appears once.
+func unionImports(h *conflictHunk, context map[string]bool) []string {
+	have := map[string]bool{}
+	for _, l := range h.Ours {
+		have[importKey(l)] = true
+	}
+	removed := map[string]bool{}
+	if h.Base != nil {
+		inTheirs := map[string]bool{}
+		for _, l := range h.Theirs {
+			inTheirs[importKey(l)] = true
+		}
+		for _, l := range h.Base {
+			if k := importKey(l); k != "" && (!have[k] || !inTheirs[k]) {
+				removed[k] = true
+			}
+		}
+	}
+
+	seen := map[string]bool{}
+	for k := range context {
+		seen[k] = true
+	}
+	var out, added []string
+	for _, l := range h.Ours {
+		k := importKey(l)
+		if k == "" {
+			out = append(out, l)
+			continue
+		}
+		if removed[k] || seen[k] {
+			continue
+		}
+		seen[k] = true
+		out = append(out, l)
+	}
+	for _, l := range h.Theirs {
+		k := importKey(l)
+		if k == "" || removed[k] || seen[k] {
+			continue
+		}
+		seen[k] = true
+		added = append(added, l)
+	}
+	last := len(out)
+	for last > 0 && strings.TrimSpace(out[last-1]) == "" {
+		last--
+	}
+	return append(append(append([]string{}, out[:last]...), added...), out[last:]...)
+}
+
+// unionAppend concatenates two end-of-file additions, ours first, with a
+// blank line between them when neither side supplies one.
+func unionAppend(h *conflictHunk) []string {
+	out := append([]string{}, h.Ours...)
+	if len(out) > 0 && len(h.Theirs) > 0 &&
+		strings.TrimSpace(out[len(out)-1]) != "" && strings.TrimSpace(h.Theirs[0]) != "" {
+		out = append(out, "")
+	}
+	return append(out, h.Theirs...)
+}
+
+// resolveSourceHunks resolves import-only and pure-append hunks in place and
+// returns how many hunks there were and the strategy used for each resolved
+// one. Pure appends need the diff3 base to prove neither side changed
+// existing lines.
+func resolveSourceHunks(lang string, segs []conflictSegment) (total int, strategies []string) {
+	context, inBlock := fileImports(lang, segs)
+	for i := range segs {
+		h := segs[i].Hunk
+		if h == nil {
+			continue
+		}
+		total++
+		var resolved []string
+		switch {
+		case isImportSection(lang, h.Ours, inBlock[i]) && isImportSection(lang, h.Theirs, inBlock[i]) && isImportSection(lang, h.Base, inBlock[i]):
+			resolved = unionImports(h, context)
+			if bindingClash(lang, resolved, context) {
+				continue
+			}
+			strategies = append(strategies, "import-union")
+		case h.Base != nil && isBlankLines(h.Base) && atEndOfFile(segs[i+1:]):
+			resolved = unionAppend(h)
+			strategies = append(strategies, "append-union")
+		default:
+			continue
+		}
+		segs[i] = conflictSegment{Lines: resolved}
+	}
+	return total, strategies
+}
+
+// fileImports returns the importKey of every import line in the plain
+// (non-conflicted) text of a file, and for each segment whether it starts
+// inside a Go `import ( … )` block.
+func fileImports(lang string, segs []conflictSegment) (keys map[string]bool, inBlock []bool) {
+	keys = map[string]bool{}
+	inBlock = make([]bool, len(segs))
+	open := false
+	for i, s := range segs {
+		inBlock[i] = open
+		if s.Hunk != nil {
+			continue
+		}
+		for _, l := range s.Lines {
+			switch {
+			case lang == "go" && goImportBlockOpenRe.MatchString(l):
+				open = true
+			case open && strings.TrimSpace(l) == ")":
+				open = false
+			case strings.TrimSpace(l) != "" && isImportLine(lang, l, open):
+				keys[importKey(l)] = true
+			}
+		}
+	}
+	return keys, inBlock
+}
+
+// importBinding is a name an import brings into scope and what it refers
+// to.
+type importBinding struct{ name, source string }
+
+var (
+	goBindingRe      = regexp.MustCompile(`^\s*(?:import\s+)?(?:([A-Za-z_.][\w.]*)\s+)?"([^"]+)"`)
+	goMajorVersionRe = regexp.MustCompile(`^v[0-9]+$`)
+	jsImportFromRe   = regexp.MustCompile(`^\s*import\s+(?:type\s+)?(.+?)\s+from\s+["']([^"']+)["']`)
+	jsRequireRe      = regexp.MustCompile(`^\s*(?:const|let|var)\s+(.+?)\s*=\s*require\(\s*["']([^"']+)["']`)
+	pyImportRe       = regexp.MustCompile(`^\s*import\s+(.+)$`)
+	pyFromImportRe   = regexp.MustCompile(`^\s*from\s+([.\w]+)\s+import\s+(.+)$`)
+	rustUseRe        = regexp.MustCompile(`^\s*(?:pub(?:\([^)]*\))?\s+)?use\s+([^;{}]*?)(?:\{([^{}]*)\})?\s*;?\s*$`)
+	rustCrateRe      = regexp.MustCompile(`^\s*extern\s+crate\s+(\w+)(?:\s+as\s+(\w+))?`)
+)
+
+// splitAs splits an import item such as `a as b` into the imported name
+// and the name it binds.
+func splitAs(item string) (orig, name string) {
+	f := strings.Fields(item)
+	if len(f) == 3 && f[1] == "as" {
+		return f[0], f[2]
+	}
+	if len(f) == 1 {
+		return f[0], f[0]
+	}
+	return "", ""
+}
+
+// importBindings returns the names one import line binds. Forms it can't
+// read bind nothing, so they never count as a clash.
+func importBindings(lang, line string) []importBinding {
+	var out []importBinding
+	add := func(name, source string) {
+		if name != "" && name != "_" && name != "." && name != "*" && name != "self" {
+			out = append(out, importBinding{name, source})
+		}
+	}
+	switch lang {
+	case "go":
+		m := goBindingRe.FindStringSubmatch(line)
+		if m == nil {
+			return nil
+		}
+		name := m[1]
+		if name == "" {
+			// The package name is only known from its source; the last
+			// path element (minus a major version) is the usual one.
+			parts := strings.Split(m[2], "/")
+			name = parts[len(parts)-1]
+			if goMajorVersionRe.MatchString(name) && len(parts) > 1 {
+				name = parts[len(parts)-2]
+			}
+			if i := strings.Index(name, ".v"); i > 0 {
+				name = name[:i]
+			}
+			if strings.ContainsAny(name, "-.") {
+				return nil
+			}
+		}
+		add(name, m[2])
+	case "js":
+		m := jsImportFromRe.FindStringSubmatch(line)
+		if m == nil {
+			m = jsRequireRe.FindStringSubmatch(line)
+		}
+		if m == nil {
+			return nil
+		}
+		clause, module := m[1], m[2]
+		if i := strings.Index(clause, "{"); i >= 0 {
+			if j := strings.LastIndex(clause, "}"); j > i {
+				for _, item := range strings.Split(clause[i+1:j], ",") {
+					orig, name := splitAs(strings.TrimPrefix(strings.TrimSpace(item), "type "))
+					add(name, module+":"+orig)
+				}
+			}
+			clause = clause[:i]
+		}
+		for _, part := range strings.Split(clause, ",") {
+			part = strings.TrimSpace(part)
+			if strings.HasPrefix(part, "*") {
+				_, name := splitAs(part)
+				add(name, module+":*")
+			} else if part != "" {
+				add(part, module+":default")
+			}
+		}
+	case "python":
+		if m := pyFromImportRe.FindStringSubmatch(line); m != nil {
+			for _, item := range strings.Split(m[2], ",") {
+				orig, name := splitAs(item)
+				add(name, m[1]+"."+orig)
+			}
+		} else if m := pyImportRe.FindStringSubmatch(line); m != nil {
+			for _, item := range strings.Split(m[1], ",") {
+				orig, name := splitAs(item)
+				if orig == name {
+					// `import a.b` binds a, whichever submodule follows.
+					name = strings.Split(orig, ".")[0]
+					orig = name
+				}
+				add(name, orig)
+			}
+		}
+	case "rust":
+		if m := rustCrateRe.FindStringSubmatch(line); m != nil {
+			name := m[2]
+			if name == "" {
+				name = m[1]
+			}
+			add(name, "crate "+m[1])
+		} else if m := rustUseRe.FindStringSubmatch(line); m != nil {
+			prefix := strings.TrimSpace(m[1])
+			items := []string{prefix}
+			if m[2] != "" {
+				items = strings.Split(m[2], ",")
+			} else {
+				prefix = ""
+			}
+			for _, item := range items {
+				orig, name := splitAs(item)
+				if i := strings.LastIndex(name, "::"); i >= 0 && orig == name {
+					name = name[i+2:]
+				}
+				add(name, prefix+orig)
+			}
+		}
+	}
+	return out
+}
+
+// bindingClash reports whether the resolved lines and the file's other
+// imports bind one name to two different sources, e.g. `const x =
+// require("a")` on one side and `const x = require("b")` on the other.
+// Keeping both would redeclare the name, so the hunk goes to the agent.
+func bindingClash(lang string, lines []string, context map[string]bool) bool {
+	bound := map[string]string{}
+	clash := func(l string) bool {
+		for _, b := range importBindings(lang, l) {
+			if src, ok := bound[b.name]; ok && src != b.source {
+				return true
+			}
+			bound[b.name] = b.source
+		}
+		return false
+	}
+	for k := range context {
+		if clash(k) {
+			return true
+		}
+	}
+	for _, l := range lines {
+		if clash(l) {
+			return true
+		}
+	}
+	return false
+}
+
+func isBlankLines(lines []string) bool {
+	for _, l := range lines {
+		if strings.TrimSpace(l) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// atEndOfFile reports whether only blank plain text follows a hunk.
+func atEndOfFile(rest []conflictSegment) bool {
+	for _, s := range rest {
+		if s.Hunk != nil || !isBlankLines(s.Lines) {
+			return false
+		}
+	}
+	return true
+}
+
+// autoResolveSourceHunks runs the hunk union over every conflicted Go,
+// TS/JS, Python and Rust file. Files whose hunks are all resolved are
+// staged; partially resolved files are rewritten with only the remaining
+// hunks conflicted. Files where no hunk resolves are restored byte for byte,
+// so the agent sees git's own markers. Returns the files it staged.
+func autoResolveSourceHunks(root string) []string {
+	cmd := exec.Command("git", "diff", "--name-only", "--diff-filter=U")
+	cmd.Dir = root
+	out, err := cmd.Output()
+	if err != nil {
+		return nil
+	}
+
+	var staged []string
+	for _, file := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		file = strings.TrimSpace(file)
+		lang := sourceLanguageFor(file)
+		if file == "" || lang == "" || strings.HasPrefix(file, ".belmont/") {
+			continue
+		}
+		path := filepath.Join(root, file)
+		original, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		// Re-checkout the conflict with base sections so appends can be
+		// told apart from edits. Fails for delete/modify conflicts — skip.
+		co := exec.Command("git", "checkout", "--conflict=diff3", "--", file)
+		co.Dir = root
+		if err := co.Run(); err != nil {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			_ = os.WriteFile(path, original, 0644)
+			continue
+		}
+		segs, ok := parseConflictFile(string(data))
+		var total int
+		var strategies []string
+		if ok {
+			total, strategies = resolveSourceHunks(lang, segs)
+		}
+		if len(strategies) == 0 {
+			_ = os.WriteFile(path, original, 0644)
+			continue
+		}
+		if err := os.WriteFile(path, []byte(renderConflictFile(segs)), 0644); err != nil {
+			continue
+		}
+		label := strings.Join(uniqueStrings(strategies), ", ")
+		if len(strategies) < total {
+			fmt.Fprintf(os.Stderr, "  \033[2mAuto-resolved %d/%d hunk(s) in %s (%s) — rest left for reconciliation\033[0m\n", len(strategies), total, file, label)
+			continue
+		}
+		addCmd := exec.Command("git", "add", "--", file)
+		addCmd.Dir = root
+		if err := addCmd.Run(); err != nil {
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "  \033[2mAuto-resolved %s (%s, %d hunk(s))\033[0m\n", file, label, total)
+		staged = append(staged, file)
+	}
+	return staged
+}
+
+// uniqueStrings returns ss without repeats, keeping first-seen order.
+func uniqueStrings(ss []string) []string {
+	seen := make(map[string]bool, len(ss))
+	var out []string
+	for _, s := range ss {
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	return out
+}
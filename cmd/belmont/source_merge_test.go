@@ -0,0 +1,294 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func resolveText(t *testing.T, lang, text string) (string, int, []string) {
+	t.Helper()
+	segs, ok := parseConflictFile(text)
+	if !ok {
+		t.Fatalf("parse failed:\n%s", text)
+	}
+	total, strategies := resolveSourceHunks(lang, segs)
+	return renderConflictFile(segs), total, strategies
+}
+
+func TestResolveSourceHunks_TSImportUnion(t *testing.T) {
+	text := `import React from "react";
+<<<<<<< HEAD
+import { Hero } from "./hero";
+import { Team } from "./team";
+||||||| base
+import { Hero } from "./hero";
+=======
+import { Hero } from "./hero";
+import { Pricing } from "./pricing";
+>>>>>>> belmont/auto/feat/m3
+
+export default function Page() {}
+`
+	got, total, strategies := resolveText(t, "js", text)
+	want := `import React from "react";
+import { Hero } from "./hero";
+import { Team } from "./team";
+import { Pricing } from "./pricing";
+
+export default function Page() {}
+`
+	if got != want || total != 1 || !equalStringSlices(strategies, []string{"import-union"}) {
+		t.Errorf("got (%d, %v):\n%s", total, strategies, got)
+	}
+}
+
+func TestResolveSourceHunks_ImportUnionDeduplicates(t *testing.T) {
+	text := `import React from "react";
+<<<<<<< HEAD
+import { Team } from "./team";
+import { Auth } from "./auth";
+import React from 'react';
+||||||| base
+=======
+import { Pricing } from "./pricing"
+import { Auth } from './auth';
+>>>>>>> belmont/auto/feat/m3
+`
+	got, _, strategies := resolveText(t, "js", text)
+	want := `import React from "react";
+import { Team } from "./team";
+import { Auth } from "./auth";
+import { Pricing } from "./pricing"
+`
+	if got != want || !equalStringSlices(strategies, []string{"import-union"}) {
+		t.Errorf("got %v:\n%s", strategies, got)
+	}
+}
+
+func TestResolveSourceHunks_RemovalOnOneSideSticks(t *testing.T) {
+	text := `<<<<<<< ours
+import os
+import json
+||||||| base
+import os
+import sys
+=======
+import os
+import sys
+import re
+>>>>>>> theirs
+`
+	got, _, _ := resolveText(t, "python", text)
+	want := "import os\nimport json\nimport re\n"
+	if got != want {
+		t.Errorf("got:\n%s", got)
+	}
+}
+
+func TestResolveSourceHunks_GoImportBlockAndAppend(t *testing.T) {
+	text := `package routes
+
+import (
+	"net/http"
+<<<<<<< ours
+	"example.com/app/billing"
+||||||| base
+=======
+	"example.com/app/auth"
+>>>>>>> theirs
+)
+
+func Register(mux *http.ServeMux) {}
+<<<<<<< ours
+
+func Billing() {}
+||||||| base
+=======
+
+func Auth() {}
+>>>>>>> theirs
+`
+	got, total, strategies := resolveText(t, "go", text)
+	if total != 2 || !equalStringSlices(strategies, []string{"import-union", "append-union"}) {
+		t.Fatalf("total=%d strategies=%v", total, strategies)
+	}
+	for _, want := range []string{"\t\"example.com/app/billing\"\n\t\"example.com/app/auth\"\n)", "func Billing() {}\n\nfunc Auth() {}\n"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, markerOurs) {
+		t.Errorf("markers remain:\n%s", got)
+	}
+}
+
+func TestResolveSourceHunks_LeavesEditsConflicted(t *testing.T) {
+	text := `use std::fmt;
+<<<<<<< ours
+use crate::a;
+||||||| base
+=======
+use crate::b;
+>>>>>>> theirs
+
+fn main() {
+<<<<<<< ours
+    run(1);
+||||||| base
+    run(0);
+=======
+    run(2);
+>>>>>>> theirs
+}
+`
+	got, total, strategies := resolveText(t, "rust", text)
+	if total != 2 || len(strategies) != 1 {
+		t.Fatalf("total=%d strategies=%v", total, strategies)
+	}
+	if !strings.Contains(got, "use crate::a;\nuse crate::b;\n") || !strings.Contains(got, "<<<<<<< ours\n    run(1);\n||||||| base\n    run(0);\n=======\n    run(2);\n>>>>>>> theirs\n}") {
+		t.Errorf("got:\n%s", got)
+	}
+}
+
+func TestResolveSourceHunks_MidFileInsertNotAppend(t *testing.T) {
+	text := `const routes = [
+<<<<<<< ours
+  "/billing",
+||||||| base
+=======
+  "/auth",
+>>>>>>> theirs
+];
+`
+	_, _, strategies := resolveText(t, "js", text)
+	if len(strategies) != 0 {
+		t.Errorf("mid-file insert should be left for the agent, got %v", strategies)
+	}
+}
+
+func TestResolveSourceHunks_GoStringStatementsAreNotImports(t *testing.T) {
+	text := `package app
+
+func Name() string {
+<<<<<<< ours
+	return "a"
+||||||| base
+	return ""
+=======
+	return "b"
+>>>>>>> theirs
+}
+`
+	got, total, strategies := resolveText(t, "go", text)
+	if total != 1 || len(strategies) != 0 || got != text {
+		t.Errorf("conflicting returns must be left for the agent, got %v:\n%s", strategies, got)
+	}
+}
+
+func TestResolveSourceHunks_BindingClashLeftForAgent(t *testing.T) {
+	cases := []struct{ lang, ours, theirs string }{
+		{"js", "const x = require('a');", "const x = require('b');"},
+		{"js", `import { Button } from "./ui";`, `import { Button } from "./forms";`},
+		{"go", `import foo "example.com/a"`, `import foo "example.com/b"`},
+		{"python", "from a import x", "from b import x"},
+		{"rust", "use crate::a::Error;", "use crate::b::Error;"},
+	}
+	for _, c := range cases {
+		text := "<<<<<<< ours\n" + c.ours + "\n||||||| base\n=======\n" + c.theirs + "\n>>>>>>> theirs\n"
+		if _, _, strategies := resolveText(t, c.lang, text); len(strategies) != 0 {
+			t.Errorf("%s: %q vs %q should clash, got %v", c.lang, c.ours, c.theirs, strategies)
+		}
+	}
+
+	// The same binding from the same source is not a clash.
+	text := "<<<<<<< ours\nimport foo \"example.com/a\"\n||||||| base\n=======\nimport foo \"example.com/a\"\nimport \"example.com/b\"\n>>>>>>> theirs\n"
+	if _, _, strategies := resolveText(t, "go", text); !equalStringSlices(strategies, []string{"import-union"}) {
+		t.Errorf("matching bindings should union, got %v", strategies)
+	}
+}
+
+func TestParseConflictFile_RejectsUnclosedHunk(t *testing.T) {
+	if _, ok := parseConflictFile("<<<<<<< ours\na\n=======\nb\n"); ok {
+		t.Error("unclosed hunk should not parse")
+	}
+}
+
+func TestAutoResolveSourceHunks_StagesFullyResolvedFile(t *testing.T) {
+	root := t.TempDir()
+	runGit(t, root, "init", "-q", "-b", "main")
+	runGit(t, root, "config", "user.email", "t@example.com")
+	runGit(t, root, "config", "user.name", "t")
+	writeFile(t, root, "app.py", "import os\n\n\ndef main():\n    pass\n")
+	runGit(t, root, "add", "-A")
+	runGit(t, root, "commit", "-q", "-m", "base")
+
+	runGit(t, root, "checkout", "-q", "-b", "side")
+	writeFile(t, root, "app.py", "import os\nimport re\n\n\ndef main():\n    pass\n\n\ndef parse():\n    pass\n")
+	runGit(t, root, "commit", "-q", "-am", "parse")
+	runGit(t, root, "checkout", "-q", "main")
+	writeFile(t, root, "app.py", "import os\nimport json\n\n\ndef main():\n    pass\n\n\ndef dump():\n    pass\n")
+	runGit(t, root, "commit", "-q", "-am", "dump")
+
+	merge := exec.Command("git", "merge", "--no-ff", "side", "-m", "merge")
+	merge.Dir = root
+	if err := merge.Run(); err == nil {
+		t.Fatal("expected a textual conflict")
+	}
+
+	staged := autoResolveSourceHunks(root)
+	if !equalStringSlices(staged, []string{"app.py"}) {
+		t.Fatalf("staged = %v", staged)
+	}
+	data, err := os.ReadFile(filepath.Join(root, "app.py"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "import os\nimport json\nimport re\n\n\ndef main():\n    pass\n\n\ndef dump():\n    pass\n\n\ndef parse():\n    pass\n"
+	if string(data) != want {
+		t.Errorf("app.py:\n%s", data)
+	}
+	if out := runGit(t, root, "diff", "--name-only", "--diff-filter=U"); out != "" {
+		t.Errorf("still conflicted: %s", out)
+	}
+}
+
+func TestAutoResolveSourceHunks_LeavesUnresolvedFileUntouched(t *testing.T) {
+	root := t.TempDir()
+	runGit(t, root, "init", "-q", "-b", "main")
+	runGit(t, root, "config", "user.email", "t@example.com")
+	runGit(t, root, "config", "user.name", "t")
+	writeFile(t, root, "app.py", "import os\n\n\ndef main():\n    return 1\n\n\ndef tail():\n    pass\n")
+	runGit(t, root, "add", "-A")
+	runGit(t, root, "commit", "-q", "-m", "base")
+
+	runGit(t, root, "checkout", "-q", "-b", "side")
+	writeFile(t, root, "app.py", "import os\n\n\ndef main():\n    return 2\n\n\ndef tail():\n    pass\n")
+	runGit(t, root, "commit", "-q", "-am", "two")
+	runGit(t, root, "checkout", "-q", "main")
+	writeFile(t, root, "app.py", "import os\n\n\ndef main():\n    return 3\n\n\ndef tail():\n    pass\n")
+	runGit(t, root, "commit", "-q", "-am", "three")
+
+	merge := exec.Command("git", "merge", "--no-ff", "side", "-m", "merge")
+	merge.Dir = root
+	if err := merge.Run(); err == nil {
+		t.Fatal("expected a textual conflict")
+	}
+	before, err := os.ReadFile(filepath.Join(root, "app.py"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if staged := autoResolveSourceHunks(root); len(staged) != 0 {
+		t.Fatalf("staged = %v", staged)
+	}
+	after, err := os.ReadFile(filepath.Join(root, "app.py"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(after) != string(before) {
+		t.Errorf("app.py rewritten:\n%s", after)
+	}
+}
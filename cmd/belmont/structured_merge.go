@@ -0,0 +1,990 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ============================================================================
+// Structured merge resolvers
+//
+// Most manifest conflicts between parallel units are additive: two
+// milestones each add a dependency to package.json, a require to go.mod or
+// a key to a YAML config. Git sees overlapping hunks; semantically both
+// sides simply want their key present. autoResolveStructuredConflicts
+// three-way merges such files key by key (base/ours/theirs from the index
+// stages) before the reconciliation agent is invoked. A key changed to two
+// different values is a true clash: the file is left conflicted for the
+// agent and the clash is logged. Shapes a resolver does not understand are
+// skipped, never guessed at.
+// ============================================================================
+
+// kvEntry is one key of a parsed structured file. Leaves compare by Text;
+// nested mappings carry Children. Head is the format's header line for a
+// nested mapping (YAML `key:`, TOML `[table]`); Lead holds blank/comment
+// lines that precede the entry and render with it.
+type kvEntry struct {
+	Key      string
+	Text     string
+	Head     string
+	Lead     string
+	Children *kvMap
+}
+
+// kvMap is an insertion-ordered mapping of entries.
+type kvMap struct {
+	Entries []*kvEntry
+	Tail    string // trailing blank/comment lines after the last entry
+	index   map[string]int
+}
+
+func newKVMap() *kvMap { return &kvMap{index: map[string]int{}} }
+
+// add appends an entry; duplicate keys make the file unsupported.
+func (m *kvMap) add(e *kvEntry) bool {
+	if _, dup := m.index[e.Key]; dup {
+		return false
+	}
+	m.index[e.Key] = len(m.Entries)
+	m.Entries = append(m.Entries, e)
+	return true
+}
+
+func (m *kvMap) get(key string) *kvEntry {
+	if m == nil {
+		return nil
+	}
+	if i, ok := m.index[key]; ok {
+		return m.Entries[i]
+	}
+	return nil
+}
+
+// kvEqual reports whether two entries hold the same value. Nested mappings
+// are equal when they have the same keys with equal values, in any order.
+func kvEqual(a, b *kvEntry) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if (a.Children == nil) != (b.Children == nil) {
+		return false
+	}
+	if a.Children == nil {
+		return a.Text == b.Text
+	}
+	if a.Head != b.Head || len(a.Children.Entries) != len(b.Children.Entries) {
+		return false
+	}
+	for _, e := range a.Children.Entries {
+		if !kvEqual(e, b.Children.get(e.Key)) {
+			return false
+		}
+	}
+	return true
+}
+
+// kvMergeResult collects what a three-way merge decided.
+type kvMergeResult struct {
+	Notes   []string // one line per non-trivial decision, for the log
+	Clashes []string // true value clashes; any clash leaves the file conflicted
+	// resolveClash, when set, may settle a clash deterministically (go.mod
+	// takes the higher version, as MVS would).
+	resolveClash func(path string, ours, theirs *kvEntry) (*kvEntry, string, bool)
+}
+
+// kvLabel is the short value shown in logs for a leaf.
+func kvLabel(e *kvEntry) string {
+	if e == nil {
+		return "(deleted)"
+	}
+	if e.Children != nil {
+		return "{…}"
+	}
+	s := strings.TrimSpace(e.Text)
+	if i := strings.LastIndex(s, "\n"); i >= 0 || len(s) > 60 {
+		return "…"
+	}
+	return s
+}
+
+// merge3KV three-way merges ours and theirs against base. Ours' key order
+// wins; keys only theirs has are inserted after their nearest preceding
+// theirs key that survived.
+func merge3KV(base, ours, theirs *kvMap, path string, r *kvMergeResult) *kvMap {
+	out := newKVMap()
+	join := func(k string) string {
+		if path == "" {
+			return k
+		}
+		return path + "." + k
+	}
+
+	pick := func(k string) *kvEntry {
+		b, o, t := base.get(k), ours.get(k), theirs.get(k)
+		p := join(k)
+		switch {
+		case o != nil && t != nil:
+			if kvEqual(o, t) {
+				return o
+			}
+			if o.Children != nil && t.Children != nil && o.Head == t.Head {
+				var bc *kvMap
+				if b != nil {
+					bc = b.Children
+				}
+				merged := *o
+				merged.Children = merge3KV(bc, o.Children, t.Children, p, r)
+				return &merged
+			}
+			if b != nil && kvEqual(b, o) {
+				r.Notes = append(r.Notes, "~"+p+" (theirs)")
+				return t
+			}
+			if b != nil && kvEqual(b, t) {
+				return o
+			}
+			if r.resolveClash != nil {
+				if e, note, ok := r.resolveClash(p, o, t); ok {
+					r.Notes = append(r.Notes, note)
+					return e
+				}
+			}
+			r.Clashes = append(r.Clashes, fmt.Sprintf("%s: ours %s vs theirs %s", p, kvLabel(o), kvLabel(t)))
+			return o
+		case o != nil:
+			if b == nil {
+				r.Notes = append(r.Notes, "+"+p+" (ours)")
+				return o
+			}
+			if kvEqual(b, o) {
+				r.Notes = append(r.Notes, "-"+p+" (theirs removed)")
+				return nil
+			}
+			r.Clashes = append(r.Clashes, fmt.Sprintf("%s: changed by ours, removed by theirs", p))
+			return o
+		case t != nil:
+			if b == nil {
+				r.Notes = append(r.Notes, "+"+p+" (theirs)")
+				return t
+			}
+			if kvEqual(b, t) {
+				r.Notes = append(r.Notes, "-"+p+" (ours removed)")
+				return nil
+			}
+			r.Clashes = append(r.Clashes, fmt.Sprintf("%s: removed by ours, changed by theirs", p))
+			return t
+		}
+		return nil
+	}
+
+	for _, e := range ours.Entries {
+		if m := pick(e.Key); m != nil {
+			out.add(m)
+		}
+	}
+	// Keys only theirs has, positioned after their predecessor in theirs.
+	for i, e := range theirs.Entries {
+		if ours.get(e.Key) != nil {
+			continue
+		}
+		m := pick(e.Key)
+		if m == nil {
+			continue
+		}
+		pos := 0
+		for j := i - 1; j >= 0; j-- {
+			if at, ok := out.index[theirs.Entries[j].Key]; ok {
+				pos = at + 1
+				break
+			}
+		}
+		out.insert(pos, m)
+	}
+	// Keys only base had were deleted on both sides — nothing to emit.
+	out.Tail = ours.Tail
+	return out
+}
+
+// insert places an entry at pos, shifting later entries.
+func (m *kvMap) insert(pos int, e *kvEntry) {
+	if pos >= len(m.Entries) {
+		m.add(e)
+		return
+	}
+	m.Entries = append(m.Entries, nil)
+	copy(m.Entries[pos+1:], m.Entries[pos:])
+	m.Entries[pos] = e
+	for i, x := range m.Entries {
+		m.index[x.Key] = i
+	}
+}
+
+// structuredFormat parses and renders one file type. render receives ours'
+// raw bytes so it can keep formatting details such as indentation.
+type structuredFormat struct {
+	Strategy string
+	Parse    func(data []byte) (*kvMap, bool)
+	Render   func(m *kvMap, ours []byte) []byte
+	Clash    func(path string, ours, theirs *kvEntry) (*kvEntry, string, bool)
+}
+
+// structuredFormatFor picks the resolver for a conflicted path, or nil when
+// the file is not a supported manifest/config. Lock files (see isLockFile)
+// are left to autoResolveLockFiles, .belmont/ state to
+// autoResolveBelmontConflicts.
+func structuredFormatFor(hooks *worktreeHooks, path string) *structuredFormat {
+	base := filepath.Base(path)
+	if strings.HasPrefix(path, ".belmont/") || isLockFile(hooks, path) {
+		return nil
+	}
+	switch {
+	case base == "go.mod":
+		return &structuredFormat{Strategy: "go-mod-union", Parse: parseGoModKV, Render: renderGoModKV, Clash: goModClash}
+	case strings.HasSuffix(base, ".json"):
+		return &structuredFormat{Strategy: "json-key-union", Parse: parseJSONKV, Render: renderJSONKV}
+	case strings.HasSuffix(base, ".toml"):
+		return &structuredFormat{Strategy: "toml-key-union", Parse: parseTOMLKV, Render: renderTOMLKV}
+	case strings.HasSuffix(base, ".yaml"), strings.HasSuffix(base, ".yml"):
+		return &structuredFormat{Strategy: "yaml-key-union", Parse: parseYAMLKV, Render: renderYAMLKV}
+	}
+	return nil
+}
+
+// structuredMerge three-way merges one file's contents. ok is false when
+// the format is unsupported or any side fails to parse.
+func structuredMerge(f *structuredFormat, base, ours, theirs []byte) (merged []byte, r kvMergeResult, ok bool) {
+	om, ok1 := f.Parse(ours)
+	tm, ok2 := f.Parse(theirs)
+	if !ok1 || !ok2 {
+		return nil, r, false
+	}
+	var bm *kvMap
+	if base != nil {
+		var ok3 bool
+		if bm, ok3 = f.Parse(base); !ok3 {
+			return nil, r, false
+		}
+	}
+	r.resolveClash = f.Clash
+	m := merge3KV(bm, om, tm, "", &r)
+	if len(r.Clashes) > 0 {
+		return nil, r, true
+	}
+	return f.Render(m, ours), r, true
+}
+
+// autoResolveStructuredConflicts resolves conflicted manifests and config
+// files by key-level union. Every resolution and clash is logged. Returns
+// the files it resolved (and staged).
+func autoResolveStructuredConflicts(root string) []string {
+	cmd := exec.Command("git", "diff", "--name-only", "--diff-filter=U")
+	cmd.Dir = root
+	out, err := cmd.Output()
+	if err != nil {
+		return nil
+	}
+
+	hooks := loadWorktreeHooks(root)
+	var resolved []string
+	for _, file := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		file = strings.TrimSpace(file)
+		f := structuredFormatFor(hooks, file)
+		if file == "" || f == nil {
+			continue
+		}
+		ours, okO := gitShowStage(root, 2, file)
+		theirs, okT := gitShowStage(root, 3, file)
+		if !okO || !okT {
+			continue // delete/modify conflict — not a key-level question
+		}
+		base, okB := gitShowStage(root, 1, file)
+		if !okB {
+			base = nil // add/add: no common ancestor
+		}
+
+		merged, r, ok := structuredMerge(f, base, ours, theirs)
+		if !ok {
+			continue
+		}
+		if len(r.Clashes) > 0 {
+			fmt.Fprintf(os.Stderr, "  \033[33m⚠ %s: %d value clash(es) — leaving for reconciliation\033[0m\n", file, len(r.Clashes))
+			for _, c := range r.Clashes {
+				fmt.Fprintf(os.Stderr, "    \033[2m%s\033[0m\n", c)
+			}
+			continue
+		}
+		if err := os.WriteFile(filepath.Join(root, file), merged, 0644); err != nil {
+			continue
+		}
+		addCmd := exec.Command("git", "add", "--", file)
+		addCmd.Dir = root
+		if err := addCmd.Run(); err != nil {
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "  \033[2mAuto-resolved %s (%s): %s\033[0m\n", file, f.Strategy, summarizeNotes(r.Notes, 6))
+		resolved = append(resolved, file)
+	}
+	return resolved
+}
+
+// summarizeNotes joins up to max notes, collapsing the rest into a count.
+func summarizeNotes(notes []string, max int) string {
+	if len(notes) == 0 {
+		return "identical changes on both sides"
+	}
+	if len(notes) <= max {
+		return strings.Join(notes, ", ")
+	}
+	return strings.Join(notes[:max], ", ") + fmt.Sprintf(" (+%d more)", len(notes)-max)
+}
+
+// gitShowStage reads one index stage (1 base, 2 ours, 3 theirs) of a path.
+func gitShowStage(root string, stage int, path string) ([]byte, bool) {
+	cmd := exec.Command("git", "show", fmt.Sprintf(":%d:%s", stage, path))
+	cmd.Dir = root
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, false
+	}
+	return out, true
+}
+
+// ---------------------------------------------------------------------------
+// JSON
+// ---------------------------------------------------------------------------
+
+// parseJSONKV parses a JSON object preserving key order. Arrays and scalars
+// are leaves compared by their compact form.
+func parseJSONKV(data []byte) (*kvMap, bool) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	m, ok := decodeJSONObject(dec)
+	if !ok {
+		return nil, false
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, false
+	}
+	return m, true
+}
+
+func decodeJSONObject(dec *json.Decoder) (*kvMap, bool) {
+	tok, err := dec.Token()
+	if err != nil || tok != json.Delim('{') {
+		return nil, false
+	}
+	m := newKVMap()
+	for dec.More() {
+		kt, err := dec.Token()
+		if err != nil {
+			return nil, false
+		}
+		key, ok := kt.(string)
+		if !ok {
+			return nil, false
+		}
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, false
+		}
+		e := &kvEntry{Key: key}
+		raw = bytes.TrimSpace(raw)
+		if len(raw) > 0 && raw[0] == '{' {
+			child, ok := decodeJSONObject(json.NewDecoder(bytes.NewReader(raw)))
+			if !ok {
+				return nil, false
+			}
+			e.Children = child
+		} else {
+			var buf bytes.Buffer
+			if err := json.Compact(&buf, raw); err != nil {
+				return nil, false
+			}
+			e.Text = buf.String()
+		}
+		if !m.add(e) {
+			return nil, false
+		}
+	}
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('}') {
+		return nil, false
+	}
+	return m, true
+}
+
+// renderJSONKV renders with ours' indentation unit and trailing newline.
+func renderJSONKV(m *kvMap, ours []byte) []byte {
+	indent := "  "
+	for _, line := range strings.Split(string(ours), "\n")[1:] {
+		if trimmed := strings.TrimLeft(line, " \t"); trimmed != "" && len(trimmed) < len(line) {
+			indent = line[:len(line)-len(trimmed)]
+			break
+		}
+	}
+	var b strings.Builder
+	writeJSONObject(&b, m, indent, 0)
+	if bytes.HasSuffix(ours, []byte("\n")) {
+		b.WriteString("\n")
+	}
+	return []byte(b.String())
+}
+
+func writeJSONObject(b *strings.Builder, m *kvMap, indent string, depth int) {
+	if len(m.Entries) == 0 {
+		b.WriteString("{}")
+		return
+	}
+	b.WriteString("{\n")
+	pad := strings.Repeat(indent, depth+1)
+	for i, e := range m.Entries {
+		b.WriteString(pad)
+		b.WriteString(jsonString(e.Key))
+		b.WriteString(": ")
+		if e.Children != nil {
+			writeJSONObject(b, e.Children, indent, depth+1)
+		} else {
+			var buf bytes.Buffer
+			if err := json.Indent(&buf, []byte(e.Text), pad, indent); err != nil {
+				b.WriteString(e.Text)
+			} else {
+				b.Write(buf.Bytes())
+			}
+		}
+		if i < len(m.Entries)-1 {
+			b.WriteString(",")
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString(strings.Repeat(indent, depth))
+	b.WriteString("}")
+}
+
+// jsonString encodes s as a JSON string without HTML escaping.
+func jsonString(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	return strings.TrimRight(buf.String(), "\n")
+}
+
+// ---------------------------------------------------------------------------
+// YAML (block mappings only)
+// ---------------------------------------------------------------------------
+
+var yamlKeyLineRe = regexp.MustCompile(`^( *)("[^"]*"|'[^']*'|[^\s#'"\-?:,\[\]{}&*!|>%@` + "`" + `][^#:]*?|-[^\s#:][^#:]*?)\s*:(\s|$)`)
+
+// parseYAMLKV parses the block-mapping subset of YAML used by config files.
+// Flow-style roots, multiple documents and tabs are unsupported.
+func parseYAMLKV(data []byte) (*kvMap, bool) {
+	text := string(data)
+	if strings.Contains(text, "\t") {
+		return nil, false
+	}
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	for _, l := range lines {
+		if strings.HasPrefix(l, "---") || strings.HasPrefix(l, "...") {
+			return nil, false
+		}
+	}
+	return parseYAMLBlock(lines, 0)
+}
+
+func yamlIsFiller(line string) bool {
+	t := strings.TrimSpace(line)
+	return t == "" || strings.HasPrefix(t, "#")
+}
+
+func parseYAMLBlock(lines []string, indent int) (*kvMap, bool) {
+	m := newKVMap()
+	var lead []string
+	var cur *kvEntry
+	var body []string
+
+	flush := func() bool {
+		if cur == nil {
+			return true
+		}
+		// Trailing filler belongs to whatever follows.
+		n := len(body)
+		for n > 0 && yamlIsFiller(body[n-1]) {
+			n--
+		}
+		tail := body[n:]
+		body = body[:n]
+		if !finishYAMLEntry(cur, body, indent) || !m.add(cur) {
+			return false
+		}
+		lead = append([]string{}, tail...)
+		cur, body = nil, nil
+		return true
+	}
+
+	for _, line := range lines {
+		if cur == nil && yamlIsFiller(line) {
+			lead = append(lead, line)
+			continue
+		}
+		lineIndent := len(line) - len(strings.TrimLeft(line, " "))
+		if !yamlIsFiller(line) && lineIndent == indent {
+			trimmed := line[indent:]
+			if strings.HasPrefix(trimmed, "- ") || trimmed == "-" {
+				// Sequence items at the key's own indent belong to the key.
+				if cur == nil {
+					return nil, false
+				}
+				body = append(body, line)
+				continue
+			}
+			km := yamlKeyLineRe.FindStringSubmatch(line)
+			if km == nil || len(km[1]) != indent {
+				return nil, false
+			}
+			if !flush() {
+				return nil, false
+			}
+			cur = &kvEntry{Key: strings.Trim(strings.TrimSpace(km[2]), `"'`), Head: line, Lead: joinLines(lead)}
+			lead = nil
+			continue
+		}
+		if !yamlIsFiller(line) && lineIndent < indent {
+			return nil, false
+		}
+		if cur == nil {
+			return nil, false
+		}
+		body = append(body, line)
+	}
+	if !flush() {
+		return nil, false
+	}
+	m.Tail = joinLines(lead)
+	return m, true
+}
+
+// finishYAMLEntry decides whether an entry is a nested mapping (value on
+// following, deeper-indented key lines) or a leaf compared as raw text.
+func finishYAMLEntry(e *kvEntry, body []string, indent int) bool {
+	head := e.Head
+	if i := strings.Index(head, " #"); i >= 0 {
+		head = head[:i]
+	}
+	inline := strings.TrimSpace(head[strings.Index(head, ":")+1:])
+	if inline == "" {
+		for _, l := range body {
+			if yamlIsFiller(l) {
+				continue
+			}
+			childIndent := len(l) - len(strings.TrimLeft(l, " "))
+			if childIndent > indent && yamlKeyLineRe.MatchString(l) && !strings.HasPrefix(strings.TrimSpace(l), "- ") {
+				child, ok := parseYAMLBlock(body, childIndent)
+				if !ok {
+					return false
+				}
+				e.Children = child
+				return true
+			}
+			break
+		}
+	}
+	e.Text = joinLines(append([]string{e.Head}, body...))
+	e.Head = ""
+	return true
+}
+
+func renderYAMLKV(m *kvMap, ours []byte) []byte {
+	var b strings.Builder
+	writeYAMLMap(&b, m)
+	return finishRender(&b, ours)
+}
+
+func writeYAMLMap(b *strings.Builder, m *kvMap) {
+	for _, e := range m.Entries {
+		b.WriteString(e.Lead)
+		if e.Children != nil {
+			b.WriteString(e.Head + "\n")
+			writeYAMLMap(b, e.Children)
+		} else {
+			b.WriteString(e.Text)
+		}
+	}
+	b.WriteString(m.Tail)
+}
+
+// joinLines joins lines into a newline-terminated block, so a lone blank
+// line survives as "\n" rather than vanishing.
+func joinLines(lines []string) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// finishRender applies ours' trailing-newline convention to a rendered file.
+func finishRender(b *strings.Builder, ours []byte) []byte {
+	out := b.String()
+	if !bytes.HasSuffix(ours, []byte("\n")) {
+		out = strings.TrimSuffix(out, "\n")
+	}
+	return []byte(out)
+}
+
+// ---------------------------------------------------------------------------
+// TOML (tables and key/value lines)
+// ---------------------------------------------------------------------------
+
+var (
+	tomlTableRe = regexp.MustCompile(`^\s*(\[\[?)\s*([^\]]+?)\s*\]\]?\s*(#.*)?$`)
+	tomlKeyRe   = regexp.MustCompile(`^\s*("[^"]*"|'[^']*'|[A-Za-z0-9_\-]+(?:\s*\.\s*(?:"[^"]*"|'[^']*'|[A-Za-z0-9_\-]+))*)\s*=`)
+)
+
+// parseTOMLKV parses TOML into a root map whose entries are tables (keyed
+// by their header; the implicit root table has key "") holding key/value
+// leaves. Array-of-tables sections are opaque leaves keyed by occurrence.
+func parseTOMLKV(data []byte) (*kvMap, bool) {
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	root := newKVMap()
+	table := &kvEntry{Key: "", Children: newKVMap()}
+	var lead []string
+	var arrayTable *kvEntry
+	var arrayBody []string
+	aotCount := map[string]int{}
+
+	closeTable := func() bool {
+		if arrayTable != nil {
+			arrayTable.Text = joinLines(arrayBody)
+			arrayTable = nil
+			arrayBody = nil
+		}
+		return true
+	}
+	if !root.add(table) {
+		return nil, false
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		t := strings.TrimSpace(line)
+		if tm := tomlTableRe.FindStringSubmatch(line); tm != nil && !tomlKeyRe.MatchString(line) {
+			closeTable()
+			name := tm[2]
+			if tm[1] == "[[" {
+				key := fmt.Sprintf("[[%s]]#%d", name, aotCount[name])
+				aotCount[name]++
+				arrayTable = &kvEntry{Key: key, Lead: joinLines(lead)}
+				arrayBody = []string{line}
+				lead = nil
+				if !root.add(arrayTable) {
+					return nil, false
+				}
+				continue
+			}
+			table = &kvEntry{Key: "[" + name + "]", Head: line, Lead: joinLines(lead), Children: newKVMap()}
+			lead = nil
+			if !root.add(table) {
+				return nil, false
+			}
+			continue
+		}
+		if arrayTable != nil {
+			arrayBody = append(arrayBody, line)
+			continue
+		}
+		if t == "" || strings.HasPrefix(t, "#") {
+			lead = append(lead, line)
+			continue
+		}
+		km := tomlKeyRe.FindStringSubmatch(line)
+		if km == nil {
+			return nil, false
+		}
+		// Multi-line values (arrays, inline tables, triple-quoted strings).
+		stmt := []string{line}
+		for !tomlValueComplete(strings.Join(stmt, "\n")) {
+			i++
+			if i >= len(lines) {
+				return nil, false
+			}
+			stmt = append(stmt, lines[i])
+		}
+		key := strings.Join(strings.Fields(km[1]), "")
+		if !table.Children.add(&kvEntry{Key: key, Text: joinLines(stmt), Lead: joinLines(lead)}) {
+			return nil, false
+		}
+		lead = nil
+	}
+	closeTable()
+	root.Tail = joinLines(lead)
+	return root, true
+}
+
+// tomlValueComplete reports whether brackets and strings in a key/value
+// statement are balanced, i.e. the statement does not continue on the next
+// line. Comments outside strings end the line.
+func tomlValueComplete(s string) bool {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case strings.HasPrefix(s[i:], `"""`) || strings.HasPrefix(s[i:], `'''`):
+			q := s[i : i+3]
+			end := strings.Index(s[i+3:], q)
+			if end < 0 {
+				return false
+			}
+			i += 3 + end + 2
+		case c == '"':
+			for i++; i < len(s) && s[i] != '"' && s[i] != '\n'; i++ {
+				if s[i] == '\\' {
+					i++
+				}
+			}
+		case c == '\'':
+			for i++; i < len(s) && s[i] != '\'' && s[i] != '\n'; i++ {
+			}
+		case c == '#':
+			for i < len(s) && s[i] != '\n' {
+				i++
+			}
+		case c == '[' || c == '{':
+			depth++
+		case c == ']' || c == '}':
+			depth--
+		}
+	}
+	return depth <= 0
+}
+
+func renderTOMLKV(m *kvMap, ours []byte) []byte {
+	var b strings.Builder
+	for _, t := range m.Entries {
+		b.WriteString(t.Lead)
+		if t.Children == nil {
+			b.WriteString(t.Text) // array-of-tables block
+			continue
+		}
+		if t.Head != "" {
+			b.WriteString(t.Head + "\n")
+		}
+		for _, kv := range t.Children.Entries {
+			b.WriteString(kv.Lead)
+			b.WriteString(kv.Text)
+		}
+	}
+	b.WriteString(m.Tail)
+	return finishRender(&b, ours)
+}
+
+// ---------------------------------------------------------------------------
+// go.mod
+// ---------------------------------------------------------------------------
+
+// parseGoModKV keys go.mod statements by directive and module path. Files
+// with comments (other than `// indirect`) are unsupported because the
+// canonical re-render would drop them.
+func parseGoModKV(data []byte) (*kvMap, bool) {
+	m := newKVMap()
+	block := ""
+	for _, raw := range strings.Split(string(data), "\n") {
+		line := strings.TrimSpace(raw)
+		indirect := false
+		if i := strings.Index(line, "//"); i >= 0 {
+			if strings.TrimSpace(line[i+2:]) != "indirect" {
+				return nil, false
+			}
+			indirect = true
+			line = strings.TrimSpace(line[:i])
+		}
+		if line == "" {
+			continue
+		}
+		if block != "" {
+			if line == ")" {
+				block = ""
+				continue
+			}
+			if !addGoModStmt(m, block, line, indirect) {
+				return nil, false
+			}
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[1] == "(" {
+			block = fields[0]
+			continue
+		}
+		if !addGoModStmt(m, fields[0], strings.TrimSpace(strings.TrimPrefix(line, fields[0])), indirect) {
+			return nil, false
+		}
+	}
+	return m, block == ""
+}
+
+func addGoModStmt(m *kvMap, directive, rest string, indirect bool) bool {
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return false
+	}
+	text := rest
+	if indirect {
+		text += " // indirect"
+	}
+	var key string
+	switch directive {
+	case "module", "go", "toolchain":
+		key = directive
+	case "require":
+		key = "require " + fields[0]
+	case "replace":
+		arrow := strings.Index(rest, "=>")
+		if arrow < 0 {
+			return false
+		}
+		key = "replace " + strings.Join(strings.Fields(rest[:arrow]), " ")
+	case "exclude", "retract":
+		key = directive + " " + strings.Join(fields, " ")
+	default:
+		return false
+	}
+	return m.add(&kvEntry{Key: key, Text: text})
+}
+
+// goModClash settles version clashes the way the go command would: a
+// require (or the go directive) takes the higher version, and a module
+// direct on either side stays direct.
+func goModClash(path string, ours, theirs *kvEntry) (*kvEntry, string, bool) {
+	if !strings.HasPrefix(path, "require ") && path != "go" && path != "toolchain" {
+		return nil, "", false
+	}
+	ov, oi := goModVersion(ours.Text)
+	tv, ti := goModVersion(theirs.Text)
+	winner := ours
+	if compareSemver(tv, ov) > 0 {
+		winner = theirs
+	}
+	text := strings.TrimSuffix(winner.Text, " // indirect")
+	if oi && ti {
+		text += " // indirect"
+	}
+	note := fmt.Sprintf("~%s → %s (max of %s, %s)", path, kvLabel(&kvEntry{Text: text}), ov, tv)
+	return &kvEntry{Key: ours.Key, Text: text}, note, true
+}
+
+// goModVersion returns the version field of a require/go text and whether
+// it is marked indirect.
+func goModVersion(text string) (string, bool) {
+	indirect := strings.HasSuffix(text, " // indirect")
+	fields := strings.Fields(strings.TrimSuffix(text, " // indirect"))
+	if len(fields) == 0 {
+		return "", indirect
+	}
+	return fields[len(fields)-1], indirect
+}
+
+// compareSemver compares Go-style versions (v1.2.3, 1.21, pre-releases and
+// pseudo-versions). Numeric parts compare numerically; a pre-release sorts
+// before its release.
+func compareSemver(a, b string) int {
+	split := func(v string) ([]string, string) {
+		v = strings.TrimPrefix(strings.TrimPrefix(v, "go"), "v")
+		if i := strings.Index(v, "+"); i >= 0 {
+			v = v[:i]
+		}
+		pre := ""
+		if i := strings.Index(v, "-"); i >= 0 {
+			v, pre = v[:i], v[i+1:]
+		}
+		return strings.Split(v, "."), pre
+	}
+	an, ap := split(a)
+	bn, bp := split(b)
+	for i := 0; i < len(an) || i < len(bn); i++ {
+		var x, y int
+		if i < len(an) {
+			x, _ = strconv.Atoi(an[i])
+		}
+		if i < len(bn) {
+			y, _ = strconv.Atoi(bn[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case ap == bp:
+		return 0
+	case ap == "":
+		return 1
+	case bp == "":
+		return -1
+	case ap < bp:
+		return -1
+	}
+	return 1
+}
+
+// renderGoModKV re-renders go.mod in `go mod tidy` layout: module, go,
+// toolchain, a direct require block, an indirect require block, then
+// replace/exclude/retract blocks, each sorted.
+func renderGoModKV(m *kvMap, _ []byte) []byte {
+	var b strings.Builder
+	var direct, indirect, replace, exclude, retract []string
+	for _, e := range m.Entries {
+		switch {
+		case e.Key == "module", e.Key == "go", e.Key == "toolchain":
+		case strings.HasPrefix(e.Key, "require "):
+			if strings.HasSuffix(e.Text, "// indirect") {
+				indirect = append(indirect, e.Text)
+			} else {
+				direct = append(direct, e.Text)
+			}
+		case strings.HasPrefix(e.Key, "replace "):
+			replace = append(replace, e.Text)
+		case strings.HasPrefix(e.Key, "exclude "):
+			exclude = append(exclude, e.Text)
+		case strings.HasPrefix(e.Key, "retract "):
+			retract = append(retract, e.Text)
+		}
+	}
+	if e := m.get("module"); e != nil {
+		b.WriteString("module " + e.Text + "\n")
+	}
+	header := false
+	for _, d := range []string{"go", "toolchain"} {
+		if e := m.get(d); e != nil {
+			if !header {
+				b.WriteString("\n")
+				header = true
+			}
+			b.WriteString(d + " " + e.Text + "\n")
+		}
+	}
+	writeBlock := func(directive string, items []string) {
+		if len(items) == 0 {
+			return
+		}
+		sort.Strings(items)
+		b.WriteString("\n" + directive + " (\n")
+		for _, it := range items {
+			b.WriteString("\t" + it + "\n")
+		}
+		b.WriteString(")\n")
+	}
+	writeBlock("require", direct)
+	writeBlock("require", indirect)
+	writeBlock("replace", replace)
+	writeBlock("exclude", exclude)
+	writeBlock("retract", retract)
+	return []byte(b.String())
+}
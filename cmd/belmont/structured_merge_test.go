@@ -0,0 +1,274 @@
+package main
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func mergeFor(t *testing.T, path, base, ours, theirs string) (string, kvMergeResult) {
+	t.Helper()
+	f := structuredFormatFor(nil, path)
+	if f == nil {
+		t.Fatalf("no structured format for %s", path)
+	}
+	var b []byte
+	if base != "" {
+		b = []byte(base)
+	}
+	out, r, ok := structuredMerge(f, b, []byte(ours), []byte(theirs))
+	if !ok {
+		t.Fatalf("%s: unsupported shape", path)
+	}
+	return string(out), r
+}
+
+func TestStructuredMerge_PackageJSONDependencyUnion(t *testing.T) {
+	base := `{
+  "name": "app",
+  "dependencies": {
+    "react": "^18.2.0"
+  }
+}
+`
+	ours := `{
+  "name": "app",
+  "dependencies": {
+    "react": "^18.2.0",
+    "zod": "^3.22.0"
+  }
+}
+`
+	theirs := `{
+  "name": "app",
+  "scripts": {
+    "lint": "eslint ."
+  },
+  "dependencies": {
+    "axios": "^1.6.0",
+    "react": "^18.2.0"
+  }
+}
+`
+	got, r := mergeFor(t, "web/package.json", base, ours, theirs)
+	want := `{
+  "name": "app",
+  "scripts": {
+    "lint": "eslint ."
+  },
+  "dependencies": {
+    "axios": "^1.6.0",
+    "react": "^18.2.0",
+    "zod": "^3.22.0"
+  }
+}
+`
+	if got != want {
+		t.Errorf("merged:\n%s\nwant:\n%s", got, want)
+	}
+	if len(r.Notes) != 3 {
+		t.Errorf("notes = %v", r.Notes)
+	}
+}
+
+func TestStructuredMerge_JSONValueClash(t *testing.T) {
+	base := `{"dependencies": {"react": "^17.0.0"}}`
+	ours := `{"dependencies": {"react": "^18.0.0"}}`
+	theirs := `{"dependencies": {"react": "^19.0.0"}}`
+	_, r := mergeFor(t, "package.json", base, ours, theirs)
+	if len(r.Clashes) != 1 || !strings.Contains(r.Clashes[0], "dependencies.react") {
+		t.Errorf("clashes = %v", r.Clashes)
+	}
+}
+
+func TestStructuredMerge_JSONOneSidedChangeAndDelete(t *testing.T) {
+	base := `{"a": 1, "b": 2, "c": 3}`
+	ours := `{"a": 1, "b": 20, "c": 3}`
+	theirs := `{"a": 1, "b": 2}`
+	got, r := mergeFor(t, "x.json", base, ours, theirs)
+	if len(r.Clashes) != 0 {
+		t.Fatalf("clashes = %v", r.Clashes)
+	}
+	if strings.Contains(got, `"c"`) || !strings.Contains(got, `"b": 20`) {
+		t.Errorf("merged = %s", got)
+	}
+}
+
+func TestStructuredMerge_GoModRequireUnionTakesMaxVersion(t *testing.T) {
+	base := "module example.com/app\n\ngo 1.21\n\nrequire (\n\tgithub.com/a/a v1.0.0\n)\n"
+	ours := "module example.com/app\n\ngo 1.21\n\nrequire (\n\tgithub.com/a/a v1.2.0\n\tgithub.com/b/b v0.3.0\n)\n"
+	theirs := "module example.com/app\n\ngo 1.22\n\nrequire (\n\tgithub.com/a/a v1.10.0\n\tgithub.com/c/c v2.0.0+incompatible // indirect\n)\n"
+	got, r := mergeFor(t, "go.mod", base, ours, theirs)
+	want := "module example.com/app\n\ngo 1.22\n\nrequire (\n\tgithub.com/a/a v1.10.0\n\tgithub.com/b/b v0.3.0\n)\n\nrequire (\n\tgithub.com/c/c v2.0.0+incompatible // indirect\n)\n"
+	if got != want {
+		t.Errorf("merged:\n%s\nwant:\n%s", got, want)
+	}
+	if len(r.Clashes) != 0 {
+		t.Errorf("clashes = %v", r.Clashes)
+	}
+}
+
+func TestStructuredMerge_GoModWithCommentsUnsupported(t *testing.T) {
+	f := structuredFormatFor(nil, "go.mod")
+	if _, ok := f.Parse([]byte("module x\n\n// pinned for CVE\nrequire a v1.0.0\n")); ok {
+		t.Error("go.mod with free-form comments should not parse")
+	}
+}
+
+func TestCompareSemver(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want int
+	}{
+		{"v1.10.0", "v1.9.0", 1},
+		{"v1.2.0", "v1.2.0", 0},
+		{"v1.2.0-rc.1", "v1.2.0", -1},
+		{"1.21", "1.21.5", -1},
+		{"v2.0.0+incompatible", "v1.9.9", 1},
+	}
+	for _, c := range cases {
+		if got := compareSemver(c.a, c.b); got != c.want {
+			t.Errorf("compareSemver(%s, %s) = %d, want %d", c.a, c.b, got, c.want)
+		}
+	}
+}
+
+func TestStructuredMerge_CargoTOMLTablesUnion(t *testing.T) {
+	base := `[package]
+name = "app"
+version = "0.1.0"
+
+[dependencies]
+serde = "1"
+`
+	ours := `[package]
+name = "app"
+version = "0.1.0"
+
+[dependencies]
+serde = "1"
+tokio = { version = "1", features = [
+    "full",
+] }
+`
+	theirs := `[package]
+name = "app"
+version = "0.1.0"
+
+[dependencies]
+serde = "1"
+anyhow = "1"
+
+[dev-dependencies]
+insta = "1"
+`
+	got, r := mergeFor(t, "Cargo.toml", base, ours, theirs)
+	want := `[package]
+name = "app"
+version = "0.1.0"
+
+[dependencies]
+serde = "1"
+anyhow = "1"
+tokio = { version = "1", features = [
+    "full",
+] }
+
+[dev-dependencies]
+insta = "1"
+`
+	if got != want {
+		t.Errorf("merged:\n%s\nwant:\n%s", got, want)
+	}
+	if len(r.Clashes) != 0 {
+		t.Errorf("clashes = %v", r.Clashes)
+	}
+}
+
+func TestStructuredMerge_YAMLNestedUnionAndClash(t *testing.T) {
+	base := `services:
+  api:
+    image: api:1
+`
+	ours := `services:
+  api:
+    image: api:1
+  worker:
+    image: worker:1
+    command:
+      - run
+`
+	theirs := `# shared config
+services:
+  api:
+    image: api:1
+    ports:
+      - "8080:8080"
+`
+	got, r := mergeFor(t, "docker-compose.yml", base, ours, theirs)
+	want := `services:
+  api:
+    image: api:1
+    ports:
+      - "8080:8080"
+  worker:
+    image: worker:1
+    command:
+      - run
+`
+	if got != want {
+		t.Errorf("merged:\n%s\nwant:\n%s", got, want)
+	}
+	if len(r.Clashes) != 0 {
+		t.Errorf("clashes = %v", r.Clashes)
+	}
+
+	_, r = mergeFor(t, "config.yaml", "port: 1\n", "port: 2\n", "port: 3\n")
+	if len(r.Clashes) != 1 {
+		t.Errorf("clashes = %v", r.Clashes)
+	}
+}
+
+func TestStructuredFormatFor_SkipsLockAndBelmontFiles(t *testing.T) {
+	for _, p := range []string{"package-lock.json", "web/pnpm-lock.yaml", ".belmont/features/a/state.json", "main.go"} {
+		if structuredFormatFor(nil, p) != nil {
+			t.Errorf("%s should not get a structured resolver", p)
+		}
+	}
+}
+
+func TestAutoResolveStructuredConflicts_StagesResolvedManifest(t *testing.T) {
+	root := t.TempDir()
+	runGit(t, root, "init", "-q", "-b", "main")
+	runGit(t, root, "config", "user.email", "t@example.com")
+	runGit(t, root, "config", "user.name", "t")
+	writeFile(t, root, "package.json", "{\n  \"dependencies\": {\n    \"react\": \"^18.2.0\"\n  }\n}\n")
+	runGit(t, root, "add", "-A")
+	runGit(t, root, "commit", "-q", "-m", "base")
+
+	runGit(t, root, "checkout", "-q", "-b", "side")
+	writeFile(t, root, "package.json", "{\n  \"dependencies\": {\n    \"react\": \"^18.2.0\",\n    \"zod\": \"^3.22.0\"\n  }\n}\n")
+	runGit(t, root, "commit", "-q", "-am", "zod")
+	runGit(t, root, "checkout", "-q", "main")
+	writeFile(t, root, "package.json", "{\n  \"dependencies\": {\n    \"react\": \"^18.2.0\",\n    \"axios\": \"^1.6.0\"\n  }\n}\n")
+	runGit(t, root, "commit", "-q", "-am", "axios")
+
+	merge := exec.Command("git", "merge", "--no-ff", "side", "-m", "merge")
+	merge.Dir = root
+	if err := merge.Run(); err == nil {
+		t.Fatal("expected a textual conflict")
+	}
+
+	resolved := autoResolveStructuredConflicts(root)
+	if !equalStringSlices(resolved, []string{"package.json"}) {
+		t.Fatalf("resolved = %v", resolved)
+	}
+	if out := runGit(t, root, "diff", "--name-only", "--diff-filter=U"); out != "" {
+		t.Errorf("still conflicted: %s", out)
+	}
+	got := runGit(t, root, "show", ":"+filepath.ToSlash("package.json"))
+	if !strings.Contains(got, `"axios"`) || !strings.Contains(got, `"zod"`) || strings.Contains(got, "<<<<<<<") {
+		t.Errorf("staged package.json = %s", got)
+	}
+}
@@ -0,0 +1,367 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// ============================================================================
+// Release sources for `belmont update`
+//
+// Releases come from a GitHub-API-compatible endpoint: `<source>/releases/
+// latest` for the newest tag and `<source>/releases/tags/<tag>` for a pinned
+// one (--version, which may also downgrade). The source defaults to the
+// public repo and can point at an internal mirror or GitHub Enterprise via
+// --source or BELMONT_RELEASE_URL. Every release publishes checksums.txt
+// (`sha256sum belmont-*`); downloads are verified against it before the
+// binary is replaced. --from-file installs a binary, .tar.gz or .zip that was
+// downloaded by hand, verified against a checksums.txt next to it (or
+// --checksums) so air-gapped machines never touch the network.
+// ============================================================================
+
+const (
+	defaultReleaseSource = "https://api.github.com/repos/blake-simpson/belmont"
+	checksumsAssetName   = "checksums.txt"
+)
+
+// releaseSource returns the release API base URL: the flag, then
+// BELMONT_RELEASE_URL, then the public repo.
+func releaseSource(flagValue string) string {
+	for _, v := range []string{flagValue, os.Getenv("BELMONT_RELEASE_URL")} {
+		if v = strings.TrimSpace(v); v != "" {
+			return strings.TrimRight(v, "/")
+		}
+	}
+	return defaultReleaseSource
+}
+
+// githubReleaseHosts are the hosts GITHUB_TOKEN may be sent to: the API and
+// the hosts its release asset downloads are served from.
+var githubReleaseHosts = map[string]bool{
+	"api.github.com":                true,
+	"github.com":                    true,
+	"objects.githubusercontent.com": true,
+}
+
+// releaseTokenEnv names the variable whose token authenticates a request to
+// rawURL: GITHUB_TOKEN for GitHub itself, BELMONT_RELEASE_TOKEN for a mirror
+// or GitHub Enterprise, so a GitHub token never leaks to another host.
+func releaseTokenEnv(rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil && githubReleaseHosts[strings.ToLower(u.Hostname())] {
+		return "GITHUB_TOKEN"
+	}
+	return "BELMONT_RELEASE_TOKEN"
+}
+
+// setReleaseAuth adds the Authorization header for req's host, if its
+// token variable is set.
+func setReleaseAuth(req *http.Request) {
+	if token := os.Getenv(releaseTokenEnv(req.URL.String())); token != "" {
+		req.Header.Set("Authorization", "token "+token)
+	}
+}
+
+// releaseAssetName is the binary this platform downloads.
+func releaseAssetName() string {
+	name := fmt.Sprintf("belmont-%s-%s", runtime.GOOS, runtime.GOARCH)
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// normalizeReleaseTag turns "1.2.3" into "v1.2.3".
+func normalizeReleaseTag(tag string) string {
+	tag = strings.TrimSpace(tag)
+	if tag != "" && !strings.HasPrefix(tag, "v") {
+		tag = "v" + tag
+	}
+	return tag
+}
+
+// fetchRelease looks up a release at source: the latest when tag is empty.
+func fetchRelease(source, tag string) (*githubRelease, error) {
+	url := source + "/releases/latest"
+	if tag != "" {
+		url = source + "/releases/tags/" + tag
+	}
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	setReleaseAuth(req)
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach %s (are you offline? use --from-file): %w", source, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == 404 && tag != "":
+		return nil, fmt.Errorf("release %s not found at %s", tag, source)
+	case resp.StatusCode == 403 || resp.StatusCode == 429:
+		return nil, fmt.Errorf("release API rate limited — set %s env var to authenticate", releaseTokenEnv(url))
+	case resp.StatusCode != 200:
+		return nil, fmt.Errorf("release API returned %d", resp.StatusCode)
+	}
+
+	var release githubRelease
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return nil, err
+	}
+	return &release, nil
+}
+
+func (r *githubRelease) assetURL(name string) string {
+	for _, asset := range r.Assets {
+		if asset.Name == name {
+			return asset.BrowserDownloadURL
+		}
+	}
+	return ""
+}
+
+// downloadReleaseBinary downloads assetName from release to dest and
+// verifies it against the release's checksums.txt. A release without one
+// is refused unless skipVerify.
+func downloadReleaseBinary(release *githubRelease, assetName, dest string, skipVerify bool) error {
+	url := release.assetURL(assetName)
+	if url == "" {
+		return fmt.Errorf("no binary found for %s/%s in release %s", runtime.GOOS, runtime.GOARCH, release.TagName)
+	}
+	var sums map[string]string
+	if !skipVerify {
+		sumsURL := release.assetURL(checksumsAssetName)
+		if sumsURL == "" {
+			return fmt.Errorf("release %s has no %s to verify against (use --no-verify to install anyway)", release.TagName, checksumsAssetName)
+		}
+		tmp := dest + ".sums"
+		defer os.Remove(tmp)
+		if err := downloadFile(sumsURL, tmp); err != nil {
+			return fmt.Errorf("download %s: %w", checksumsAssetName, err)
+		}
+		var err error
+		if sums, err = readChecksumsFile(tmp); err != nil {
+			return err
+		}
+	}
+	if err := downloadFile(url, dest); err != nil {
+		return fmt.Errorf("download failed: %w", err)
+	}
+	if skipVerify {
+		return nil
+	}
+	want, ok := sums[assetName]
+	if !ok {
+		return fmt.Errorf("%s does not list %s", checksumsAssetName, assetName)
+	}
+	got, err := sha256File(dest)
+	if err != nil {
+		return err
+	}
+	if got != want {
+		return fmt.Errorf("checksum mismatch for %s: got %s, %s says %s", assetName, got, checksumsAssetName, want)
+	}
+	return nil
+}
+
+// readChecksumsFile parses `sha256sum` output ("<hex>  <name>", with an
+// optional "*" binary marker) into name → lowercase hex.
+func readChecksumsFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	sums := map[string]string{}
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) != 2 || len(fields[0]) != sha256.Size*2 {
+			continue
+		}
+		sums[strings.TrimPrefix(fields[1], "*")] = strings.ToLower(fields[0])
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if len(sums) == 0 {
+		return nil, fmt.Errorf("%s has no sha256 entries", path)
+	}
+	return sums, nil
+}
+
+func sha256File(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// verifyLocalFile checks a hand-downloaded file against a checksums file.
+// The file may have been renamed, so any entry with a matching hash counts;
+// the entry's name is returned.
+func verifyLocalFile(path, checksumsPath string) (string, error) {
+	sums, err := readChecksumsFile(checksumsPath)
+	if err != nil {
+		return "", err
+	}
+	got, err := sha256File(path)
+	if err != nil {
+		return "", err
+	}
+	if want, ok := sums[filepath.Base(path)]; ok && want != got {
+		return "", fmt.Errorf("checksum mismatch for %s: got %s, %s says %s", filepath.Base(path), got, filepath.Base(checksumsPath), want)
+	}
+	for name, want := range sums {
+		if want == got {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("%s (sha256 %s) is not listed in %s", filepath.Base(path), got, checksumsPath)
+}
+
+// isUpdateArchive reports whether a --from-file path is an archive that
+// extractUpdateBinary unpacks, rather than the binary itself.
+func isUpdateArchive(path string) bool {
+	lower := strings.ToLower(path)
+	return strings.HasSuffix(lower, ".tar.gz") || strings.HasSuffix(lower, ".tgz") || strings.HasSuffix(lower, ".zip")
+}
+
+// verifyUpdateFile checks a --from-file update against a checksums file.
+// An archive the checksums list is verified as a whole. Releases only list
+// the belmont-* binaries, so for any other archive the binary extracted from
+// it must match the entry for assetName. Returns the matching entry's name.
+func verifyUpdateFile(src, extracted, assetName, checksumsPath string) (string, error) {
+	name, err := verifyLocalFile(src, checksumsPath)
+	if err == nil || !isUpdateArchive(src) || strings.HasPrefix(err.Error(), "checksum mismatch") {
+		return name, err
+	}
+	sums, serr := readChecksumsFile(checksumsPath)
+	if serr != nil {
+		return "", serr
+	}
+	want, ok := sums[assetName]
+	if !ok {
+		return "", fmt.Errorf("neither %s nor %s is listed in %s", filepath.Base(src), assetName, checksumsPath)
+	}
+	got, err := sha256File(extracted)
+	if err != nil {
+		return "", err
+	}
+	if got != want {
+		return "", fmt.Errorf("checksum mismatch for %s in %s: got %s, %s says %s", assetName, filepath.Base(src), got, filepath.Base(checksumsPath), want)
+	}
+	return assetName, nil
+}
+
+// extractUpdateBinary writes the belmont binary contained in src to dest. src
+// is the binary itself, or a .tar.gz/.tgz/.zip holding assetName or a plain
+// "belmont" executable.
+func extractUpdateBinary(src, assetName, dest string) error {
+	lower := strings.ToLower(src)
+	wanted := func(name string) bool {
+		base := filepath.Base(name)
+		return base == assetName || base == "belmont" || base == "belmont.exe"
+	}
+	switch {
+	case strings.HasSuffix(lower, ".tar.gz") || strings.HasSuffix(lower, ".tgz"):
+		f, err := os.Open(src)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return fmt.Errorf("%s: %w", src, err)
+		}
+		tr := tar.NewReader(gz)
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return fmt.Errorf("%s: %w", src, err)
+			}
+			if hdr.Typeflag == tar.TypeReg && wanted(hdr.Name) {
+				return writeFileFrom(dest, tr)
+			}
+		}
+	case strings.HasSuffix(lower, ".zip"):
+		zr, err := zip.OpenReader(src)
+		if err != nil {
+			return fmt.Errorf("%s: %w", src, err)
+		}
+		defer zr.Close()
+		for _, zf := range zr.File {
+			if zf.FileInfo().IsDir() || !wanted(zf.Name) {
+				continue
+			}
+			rc, err := zf.Open()
+			if err != nil {
+				return err
+			}
+			defer rc.Close()
+			return writeFileFrom(dest, rc)
+		}
+	default:
+		f, err := os.Open(src)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return writeFileFrom(dest, f)
+	}
+	return fmt.Errorf("%s contains no %s or belmont binary", src, assetName)
+}
+
+func writeFileFrom(dest string, r io.Reader) error {
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, r); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// binaryVersionTag runs `<bin> version` and returns its tag ("v1.2.3"), or
+// "" when the binary can't report one.
+func binaryVersionTag(bin string) string {
+	out, err := exec.Command(bin, "version").Output()
+	if err != nil {
+		return ""
+	}
+	fields := strings.Fields(string(out))
+	if len(fields) < 2 || fields[0] != "belmont" {
+		return ""
+	}
+	return normalizeReleaseTag(fields[1])
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func sha256Hex(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+// releaseServer stands in for a release mirror. Each tag serves the
+// platform binary and a checksums.txt; sums overrides the checksums body.
+func releaseServer(t *testing.T, latest string, binaries map[string]string, sums string) *httptest.Server {
+	t.Helper()
+	asset := releaseAssetName()
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.Path
+		switch {
+		case path == "/releases/latest":
+			path = "/releases/tags/" + latest
+			fallthrough
+		case strings.HasPrefix(path, "/releases/tags/"):
+			tag := strings.TrimPrefix(path, "/releases/tags/")
+			if _, ok := binaries[tag]; !ok {
+				http.NotFound(w, r)
+				return
+			}
+			json.NewEncoder(w).Encode(githubRelease{TagName: tag, Assets: []githubAsset{
+				{Name: asset, BrowserDownloadURL: srv.URL + "/download/" + tag + "/" + asset},
+				{Name: checksumsAssetName, BrowserDownloadURL: srv.URL + "/download/" + tag + "/" + checksumsAssetName},
+			}})
+		case strings.HasPrefix(path, "/download/"):
+			parts := strings.Split(strings.TrimPrefix(path, "/download/"), "/")
+			bin := binaries[parts[0]]
+			if parts[1] == checksumsAssetName {
+				if sums != "" {
+					fmt.Fprint(w, sums)
+					return
+				}
+				fmt.Fprintf(w, "%s  %s\n%s  belmont-plan9-mips\n", sha256Hex(bin), asset, sha256Hex("other"))
+				return
+			}
+			fmt.Fprint(w, bin)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestReleaseSource(t *testing.T) {
+	t.Setenv("BELMONT_RELEASE_URL", "")
+	if got := releaseSource(""); got != defaultReleaseSource {
+		t.Errorf("default = %s", got)
+	}
+	t.Setenv("BELMONT_RELEASE_URL", "https://mirror.internal/api/belmont/")
+	if got := releaseSource(""); got != "https://mirror.internal/api/belmont" {
+		t.Errorf("env = %s", got)
+	}
+	if got := releaseSource("http://127.0.0.1:9"); got != "http://127.0.0.1:9" {
+		t.Errorf("flag should win: %s", got)
+	}
+}
+
+func TestReleaseTokenOnlyForItsHost(t *testing.T) {
+	for rawURL, want := range map[string]string{
+		defaultReleaseSource + "/releases/latest":                            "GITHUB_TOKEN",
+		"https://github.com/blake-simpson/belmont/releases/download/v1/x":    "GITHUB_TOKEN",
+		"https://objects.githubusercontent.com/github-production-release/1":  "GITHUB_TOKEN",
+		"https://ghe.example.com/api/v3/repos/tools/belmont/releases/latest": "BELMONT_RELEASE_TOKEN",
+		"https://api.github.com.evil.example/releases/latest":                "BELMONT_RELEASE_TOKEN",
+	} {
+		if got := releaseTokenEnv(rawURL); got != want {
+			t.Errorf("releaseTokenEnv(%s) = %s, want %s", rawURL, got, want)
+		}
+	}
+
+	var auth []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = append(auth, r.Header.Get("Authorization"))
+		json.NewEncoder(w).Encode(githubRelease{TagName: "v1.0.0"})
+	}))
+	t.Cleanup(srv.Close)
+	t.Setenv("GITHUB_TOKEN", "gh-secret")
+	t.Setenv("BELMONT_RELEASE_TOKEN", "")
+	if _, err := fetchRelease(srv.URL, ""); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("BELMONT_RELEASE_TOKEN", "mirror-secret")
+	if _, err := fetchRelease(srv.URL, ""); err != nil {
+		t.Fatal(err)
+	}
+	if !equalStringSlices(auth, []string{"", "token mirror-secret"}) {
+		t.Errorf("Authorization headers = %q", auth)
+	}
+}
+
+func TestFetchRelease_LatestAndPinned(t *testing.T) {
+	srv := releaseServer(t, "v1.3.0", map[string]string{"v1.3.0": "new", "v1.1.0": "old"}, "")
+
+	latest, err := fetchRelease(srv.URL, "")
+	if err != nil || latest.TagName != "v1.3.0" {
+		t.Fatalf("latest = %+v, %v", latest, err)
+	}
+	pinned, err := fetchRelease(srv.URL, normalizeReleaseTag("1.1.0"))
+	if err != nil || pinned.TagName != "v1.1.0" {
+		t.Fatalf("pinned = %+v, %v", pinned, err)
+	}
+	if _, err := fetchRelease(srv.URL, "v9.9.9"); err == nil || !strings.Contains(err.Error(), "v9.9.9 not found") {
+		t.Errorf("missing tag error = %v", err)
+	}
+}
+
+func TestDownloadReleaseBinary_VerifiesChecksum(t *testing.T) {
+	srv := releaseServer(t, "v1.3.0", map[string]string{"v1.3.0": "binary-v1.3.0"}, "")
+	release, err := fetchRelease(srv.URL, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dest := filepath.Join(t.TempDir(), "belmont.tmp")
+	if err := downloadReleaseBinary(release, releaseAssetName(), dest, false); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(dest); string(data) != "binary-v1.3.0" {
+		t.Errorf("downloaded %q", data)
+	}
+
+	bad := releaseServer(t, "v1.3.0", map[string]string{"v1.3.0": "tampered"}, sha256Hex("binary-v1.3.0")+"  "+releaseAssetName()+"\n")
+	release, _ = fetchRelease(bad.URL, "")
+	err = downloadReleaseBinary(release, releaseAssetName(), dest, false)
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("tampered download error = %v", err)
+	}
+	if err := downloadReleaseBinary(release, releaseAssetName(), dest, true); err != nil {
+		t.Errorf("--no-verify should skip the check: %v", err)
+	}
+
+	release.Assets = release.Assets[:1]
+	if err := downloadReleaseBinary(release, releaseAssetName(), dest, false); err == nil || !strings.Contains(err.Error(), "no checksums.txt") {
+		t.Errorf("missing checksums error = %v", err)
+	}
+}
+
+func TestVerifyLocalFileAndExtract_TarGz(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "belmont-offline.tar.gz")
+	f, err := os.Create(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for name, body := range map[string]string{"README.md": "docs", "dist/" + releaseAssetName(): "the-binary"} {
+		tw.WriteHeader(&tar.Header{Name: name, Mode: 0755, Size: int64(len(body)), Typeflag: tar.TypeReg})
+		tw.Write([]byte(body))
+	}
+	tw.Close()
+	gz.Close()
+	f.Close()
+
+	sums := filepath.Join(dir, checksumsAssetName)
+	data, _ := os.ReadFile(archive)
+	os.WriteFile(sums, []byte(fmt.Sprintf("%s *belmont-v1.2.0.tar.gz\n", sha256Hex(string(data)))), 0644)
+	if name, err := verifyLocalFile(archive, sums); err != nil || name != "belmont-v1.2.0.tar.gz" {
+		t.Errorf("renamed archive: %q, %v", name, err)
+	}
+
+	os.WriteFile(sums, []byte(sha256Hex("something else")+"  belmont-offline.tar.gz\n"), 0644)
+	if _, err := verifyLocalFile(archive, sums); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("mismatch error = %v", err)
+	}
+
+	dest := filepath.Join(dir, "out")
+	if err := extractUpdateBinary(archive, releaseAssetName(), dest); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(dest); string(got) != "the-binary" {
+		t.Errorf("extracted %q", got)
+	}
+
+	// A release's checksums list only the binaries: the archive is checked
+	// through the binary inside it.
+	os.WriteFile(sums, []byte(sha256Hex("the-binary")+"  "+releaseAssetName()+"\n"), 0644)
+	if name, err := verifyUpdateFile(archive, dest, releaseAssetName(), sums); err != nil || name != releaseAssetName() {
+		t.Errorf("archive via binary: %q, %v", name, err)
+	}
+	os.WriteFile(sums, []byte(sha256Hex("other-binary")+"  "+releaseAssetName()+"\n"), 0644)
+	if _, err := verifyUpdateFile(archive, dest, releaseAssetName(), sums); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("tampered binary in archive error = %v", err)
+	}
+}
@@ -0,0 +1,383 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ============================================================================
+// Workspace dependency graph
+//
+// linkWorkspaceDeps records, for every workspace, the other workspaces it
+// depends on. It reads package.json dependency maps (workspace:* or plain
+// version ranges naming a sibling), Cargo path and named deps, go.mod
+// requires and replaces of sibling modules, pyproject dependencies and
+// uv/Poetry path sources, Gradle project(...) references, Maven
+// <dependency> artifactIds, .NET ProjectReferences, Mix in_umbrella deps and
+// Bazel //labels. A parallel wave warns when two of its milestones touch
+// workspaces that depend on each other, the merges at the end of a wave put
+// upstream workspaces first, and the graph is shown under the monorepo
+// report in `belmont status --format json`.
+// ============================================================================
+
+// linkWorkspaceDeps fills in Deps for each workspace, in place.
+func linkWorkspaceDeps(root string, workspaces []workspaceInfo) {
+	aliases := map[string]string{} // dependency name → workspace ID
+	for _, w := range workspaces {
+		aliases[normalizeDepName(w.ID)] = w.ID
+		if filepath.Base(w.Manifest) == "go.mod" {
+			if mod := goModulePath(w.Manifest); mod != "" {
+				aliases[mod] = w.ID
+			}
+		}
+	}
+	for i := range workspaces {
+		w := &workspaces[i]
+		if w.Manifest == "" {
+			continue
+		}
+		names, paths := manifestDependencyRefs(root, *w)
+		var deps []string
+		for _, n := range names {
+			if id, ok := aliases[normalizeDepName(n)]; ok && id != w.ID {
+				deps = append(deps, id)
+			}
+		}
+		for _, p := range paths {
+			if dep := owningWorkspace(workspaces, filepath.ToSlash(p)); dep != nil && dep.ID != w.ID {
+				deps = append(deps, dep.ID)
+			}
+		}
+		deps = uniqueStrings(deps)
+		sort.Strings(deps)
+		w.Deps = deps
+	}
+}
+
+// normalizeDepName folds case and Python's -/_/. equivalence so a
+// requirement "My_Lib" matches a workspace "my-lib".
+func normalizeDepName(s string) string {
+	return strings.NewReplacer("_", "-", ".", "-").Replace(strings.ToLower(s))
+}
+
+var (
+	goModuleRe     = regexp.MustCompile(`(?m)^module\s+(\S+)`)
+	goRequireRe    = regexp.MustCompile(`(?m)^\s*(?:require\s+)?([\w.-]+\.[\w./-]+|[\w-]+/[\w./-]+)\s+v\d`)
+	goReplaceRe    = regexp.MustCompile(`(?m)=>\s*(\.{1,2}/\S*)`)
+	tomlPathRe     = regexp.MustCompile(`path\s*=\s*"([^"]+)"`)
+	pyRequireRe    = regexp.MustCompile(`["']\s*([A-Za-z0-9][A-Za-z0-9_.-]*)`)
+	gradleProjRe   = regexp.MustCompile(`project\(\s*(?:path\s*[:=]\s*)?["']:?([^"']+)["']`)
+	mavenDepRe     = regexp.MustCompile(`(?s)<dependency>.*?</dependency>`)
+	dotnetRefRe    = regexp.MustCompile(`<ProjectReference\s+Include="([^"]+)"`)
+	mixUmbrellaRe  = regexp.MustCompile(`\{:(\w+),[^}]*in_umbrella:\s*true`)
+	bazelLabelRe   = regexp.MustCompile(`"@?//([^:"]+)`)
+	tomlDepSection = regexp.MustCompile(`(?i)(^|\.)(dependencies|dev-dependencies|build-dependencies|sources|group\.[^.]+\.dependencies)($|\.)`)
+)
+
+func goModulePath(manifest string) string {
+	data, err := os.ReadFile(manifest)
+	if err != nil {
+		return ""
+	}
+	if m := goModuleRe.FindSubmatch(data); m != nil {
+		return string(m[1])
+	}
+	return ""
+}
+
+// manifestDependencyRefs returns the dependency names a workspace's manifest
+// mentions and the repo-relative paths it points at.
+func manifestDependencyRefs(root string, w workspaceInfo) (names, paths []string) {
+	data, err := os.ReadFile(w.Manifest)
+	if err != nil {
+		return nil, nil
+	}
+	text := string(data)
+	dir := filepath.Dir(w.Manifest)
+	rel := func(p string) {
+		abs := filepath.Clean(filepath.Join(dir, filepath.FromSlash(strings.ReplaceAll(p, `\`, "/"))))
+		if r, err := filepath.Rel(root, abs); err == nil && !strings.HasPrefix(r, "..") {
+			paths = append(paths, r)
+		}
+	}
+
+	switch base := filepath.Base(w.Manifest); {
+	case base == "package.json":
+		var pkg struct {
+			Dependencies         map[string]string `json:"dependencies"`
+			DevDependencies      map[string]string `json:"devDependencies"`
+			PeerDependencies     map[string]string `json:"peerDependencies"`
+			OptionalDependencies map[string]string `json:"optionalDependencies"`
+		}
+		if json.Unmarshal(data, &pkg) != nil {
+			return nil, nil
+		}
+		for _, deps := range []map[string]string{pkg.Dependencies, pkg.DevDependencies, pkg.PeerDependencies, pkg.OptionalDependencies} {
+			for name, spec := range deps {
+				names = append(names, name)
+				if p, ok := strings.CutPrefix(spec, "file:"); ok {
+					rel(p)
+				} else if p, ok := strings.CutPrefix(spec, "link:"); ok {
+					rel(p)
+				}
+			}
+		}
+	case base == "Cargo.toml" || base == "pyproject.toml":
+		section := ""
+		inDepsArray := false
+		for _, line := range strings.Split(text, "\n") {
+			trimmed := strings.TrimSpace(line)
+			if m := tomlTableRe.FindStringSubmatch(trimmed); m != nil {
+				section = m[2]
+				inDepsArray = false
+				// [dependencies.foo] names the dependency in the header.
+				if i := strings.Index(section, "dependencies."); i >= 0 {
+					names = append(names, section[i+len("dependencies."):])
+				}
+				continue
+			}
+			if tomlDepSection.MatchString(section) {
+				if m := tomlKeyRe.FindStringSubmatch(line); m != nil {
+					names = append(names, strings.Trim(m[1], `"'`))
+				}
+			}
+			if m := tomlPathRe.FindStringSubmatch(line); m != nil {
+				rel(m[1])
+			}
+			// PEP 621 / Poetry group arrays: dependencies = ["pkg>=1", ...]
+			if strings.HasPrefix(trimmed, "dependencies") && strings.Contains(trimmed, "[") {
+				inDepsArray = true
+			}
+			if inDepsArray || (section != "" && strings.Contains(section, "optional-dependencies")) {
+				for _, m := range pyRequireRe.FindAllStringSubmatch(line, -1) {
+					names = append(names, m[1])
+				}
+				if strings.Contains(trimmed, "]") {
+					inDepsArray = false
+				}
+			}
+		}
+	case base == "go.mod":
+		for _, m := range goRequireRe.FindAllStringSubmatch(text, -1) {
+			names = append(names, m[1])
+		}
+		for _, m := range goReplaceRe.FindAllStringSubmatch(text, -1) {
+			rel(m[1])
+		}
+	case base == "build.gradle" || base == "build.gradle.kts":
+		for _, m := range gradleProjRe.FindAllStringSubmatch(stripLineComments(text, "//"), -1) {
+			names = append(names, m[1])
+		}
+	case base == "pom.xml":
+		for _, dep := range mavenDepRe.FindAllString(stripXMLComments(text), -1) {
+			if m := mavenArtifactRe.FindStringSubmatch(dep); m != nil {
+				names = append(names, m[1])
+			}
+		}
+	case base == "mix.exs":
+		for _, m := range mixUmbrellaRe.FindAllStringSubmatch(text, -1) {
+			names = append(names, m[1])
+		}
+	case base == "BUILD" || base == "BUILD.bazel":
+		for _, m := range bazelLabelRe.FindAllStringSubmatch(text, -1) {
+			paths = append(paths, filepath.FromSlash(m[1]))
+		}
+	case isDotnetProjectFile(base):
+		for _, m := range dotnetRefRe.FindAllStringSubmatch(text, -1) {
+			rel(filepath.Dir(strings.ReplaceAll(m[1], `\`, "/")))
+		}
+	}
+	return names, paths
+}
+
+// workspaceDependsOn reports whether a depends on b, directly or through
+// other workspaces.
+func workspaceDependsOn(workspaces []workspaceInfo, a, b string) bool {
+	deps := make(map[string][]string, len(workspaces))
+	for _, w := range workspaces {
+		deps[w.ID] = w.Deps
+	}
+	seen := map[string]bool{}
+	var walk func(id string) bool
+	walk = func(id string) bool {
+		if seen[id] {
+			return false
+		}
+		seen[id] = true
+		for _, d := range deps[id] {
+			if d == b || walk(d) {
+				return true
+			}
+		}
+		return false
+	}
+	return a != b && walk(a)
+}
+
+// scopeDependency describes why two milestones are coupled: a workspace in
+// the first's scope depends on one in the second's.
+type scopeDependency struct {
+	Downstream, Upstream string // milestone IDs
+	From, To             string // workspace IDs (From depends on To)
+}
+
+// scopeDependencies lists the coupled pairs among milestones with the given
+// scopes. Milestones that share a workspace are left to the overlap
+// checks; only distinct workspaces linked by the graph are reported.
+func scopeDependencies(workspaces []workspaceInfo, ids []string, scopes map[string][]string) []scopeDependency {
+	var out []scopeDependency
+	for _, a := range ids {
+		for _, b := range ids {
+			if a == b {
+				continue
+			}
+		pair:
+			for _, wa := range scopes[a] {
+				for _, wb := range scopes[b] {
+					if wa != wb && workspaceDependsOn(workspaces, wa, wb) {
+						out = append(out, scopeDependency{Downstream: a, Upstream: b, From: wa, To: wb})
+						break pair
+					}
+				}
+			}
+		}
+	}
+	return out
+}
+
+// warnWaveWorkspaceDependencies warns before a parallel wave when milestones
+// that run side by side touch workspaces that depend on each other, since
+// a change to the upstream workspace can break the downstream one only once
+// both are merged. With launching set (the critical-path scheduler starting
+// one milestone next to those already running), only pairs that include it
+// are reported.
+func warnWaveWorkspaceDependencies(root, feature string, milestones []milestone, launching string) {
+	workspaces, _, _ := resolveWorkspaces(root, loadWorktreeHooks(root))
+	if len(workspaces) == 0 || len(milestones) < 2 {
+		return
+	}
+	targets := featureWorkspaceTargets(root, feature, workspaces)
+	ids := make([]string, 0, len(milestones))
+	scopes := map[string][]string{}
+	for _, m := range milestones {
+		ids = append(ids, m.ID)
+		scopes[m.ID] = milestoneScope(m, targets, workspaces, nil)
+	}
+	for _, d := range scopeDependencies(workspaces, ids, scopes) {
+		if launching != "" && d.Downstream != launching && d.Upstream != launching {
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "  \033[33m⚠ %s (%s) and %s (%s) run in parallel, but %s depends on %s — re-verify %s after both merge\033[0m\n",
+			d.Downstream, d.From, d.Upstream, d.To, d.From, d.To, d.From)
+	}
+}
+
+// orderByWorkspaceGraph orders milestone IDs so that a milestone touching an
+// upstream workspace merges before one touching a workspace that depends on
+// it. Otherwise the given order is kept. A dependency cycle is broken by
+// placing its first milestone in the given order; the constraints still
+// hold for everything else.
+func orderByWorkspaceGraph(workspaces []workspaceInfo, ids []string, scopes map[string][]string) []string {
+	deps := scopeDependencies(workspaces, ids, scopes)
+	if len(deps) == 0 {
+		return ids
+	}
+	before := map[string]map[string]bool{} // milestone → milestones that must merge first
+	for _, d := range deps {
+		if workspaceDependsOn(workspaces, d.To, d.From) {
+			continue // mutual dependency: no preferred order
+		}
+		if before[d.Downstream] == nil {
+			before[d.Downstream] = map[string]bool{}
+		}
+		before[d.Downstream][d.Upstream] = true
+	}
+	var out []string
+	placed := map[string]bool{}
+	for len(out) < len(ids) {
+		progressed := false
+		for _, id := range ids {
+			if placed[id] {
+				continue
+			}
+			ready := true
+			for up := range before[id] {
+				if !placed[up] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				out = append(out, id)
+				placed[id] = true
+				progressed = true
+				break
+			}
+		}
+		if !progressed {
+			id := firstInCycle(ids, before, placed)
+			out = append(out, id)
+			placed[id] = true
+		}
+	}
+	return out
+}
+
+// firstInCycle returns the first unplaced milestone that waits on itself
+// through unplaced milestones. Nothing is ready only when such a cycle
+// exists; the first unplaced milestone is a fallback that keeps the loop
+// finite.
+func firstInCycle(ids []string, before map[string]map[string]bool, placed map[string]bool) string {
+	fallback := ""
+	for _, id := range ids {
+		if placed[id] {
+			continue
+		}
+		if fallback == "" {
+			fallback = id
+		}
+		seen := map[string]bool{}
+		stack := []string{id}
+		for len(stack) > 0 {
+			cur := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			for up := range before[cur] {
+				if up == id {
+					return id
+				}
+				if !placed[up] && !seen[up] {
+					seen[up] = true
+					stack = append(stack, up)
+				}
+			}
+		}
+	}
+	return fallback
+}
+
+// workspaceMergeOrder reorders the milestone IDs about to merge with
+// orderByWorkspaceGraph, scoping each milestone by its task tags and the
+// files its branch touched. Logs when the order changes.
+func workspaceMergeOrder(root, feature string, milestones []milestone, ids []string, branches map[string]string) []string {
+	workspaces, _, _ := resolveWorkspaces(root, loadWorktreeHooks(root))
+	if len(workspaces) == 0 {
+		return ids
+	}
+	targets := featureWorkspaceTargets(root, feature, workspaces)
+	scopes := map[string][]string{}
+	for _, m := range milestones {
+		if branch, ok := branches[m.ID]; ok {
+			scopes[m.ID] = milestoneScope(m, targets, workspaces, branchTouchedFiles(root, branch))
+		}
+	}
+	ordered := orderByWorkspaceGraph(workspaces, ids, scopes)
+	if strings.Join(ordered, ",") != strings.Join(ids, ",") {
+		fmt.Fprintf(os.Stderr, "  \033[2mMerging upstream workspaces first: %s\033[0m\n", strings.Join(ordered, " → "))
+	}
+	return ordered
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// depsOf returns a workspace's Deps joined by commas.
+func depsOf(t *testing.T, ws []workspaceInfo, id string) string {
+	t.Helper()
+	return strings.Join(findWorkspace(t, ws, id).Deps, ",")
+}
+
+func TestLinkWorkspaceDeps_PnpmWorkspaceProtocol(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "pnpm-workspace.yaml", "packages:\n  - 'packages/*'\n")
+	writeFile(t, dir, "packages/web/package.json", `{"name":"@acme/web","dependencies":{"@acme/types":"workspace:*","react":"^18"},"devDependencies":{"@acme/ui":"workspace:^"}}`)
+	writeFile(t, dir, "packages/ui/package.json", `{"name":"@acme/ui","peerDependencies":{"@acme/types":"workspace:*"}}`)
+	writeFile(t, dir, "packages/types/package.json", `{"name":"@acme/types"}`)
+
+	ws, _, _ := resolveWorkspaces(dir, nil)
+	if got := depsOf(t, ws, "@acme/web"); got != "@acme/types,@acme/ui" {
+		t.Errorf("web deps = %s", got)
+	}
+	if got := depsOf(t, ws, "@acme/ui"); got != "@acme/types" {
+		t.Errorf("ui deps = %s", got)
+	}
+	if got := depsOf(t, ws, "@acme/types"); got != "" {
+		t.Errorf("types deps = %s", got)
+	}
+	if !workspaceDependsOn(ws, "@acme/web", "@acme/types") || workspaceDependsOn(ws, "@acme/types", "@acme/web") {
+		t.Error("dependency direction is wrong")
+	}
+}
+
+func TestLinkWorkspaceDeps_CargoPathAndGoReplace(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "Cargo.toml", "[workspace]\nmembers = [\"crates/*\"]\n")
+	writeFile(t, dir, "crates/cli/Cargo.toml", "[package]\nname = \"cli\"\n\n[dependencies]\ncore = { path = \"../core\" }\nserde = \"1\"\n\n[dev-dependencies.fixtures]\nversion = \"0.1\"\n")
+	writeFile(t, dir, "crates/core/Cargo.toml", "[package]\nname = \"core\"\n")
+	writeFile(t, dir, "crates/fixtures/Cargo.toml", "[package]\nname = \"fixtures\"\n")
+
+	ws, _, _ := resolveWorkspaces(dir, nil)
+	if got := depsOf(t, ws, "cli"); got != "core,fixtures" {
+		t.Errorf("cli deps = %s", got)
+	}
+
+	goDir := t.TempDir()
+	writeFile(t, goDir, "go.work", "go 1.21\n\nuse (\n\t./api\n\t./types\n)\n")
+	writeFile(t, goDir, "api/go.mod", "module example.com/api\n\ngo 1.21\n\nrequire example.com/types v0.0.0\n\nreplace example.com/types => ../types\n")
+	writeFile(t, goDir, "types/go.mod", "module example.com/types\n\ngo 1.21\n")
+	gws, _, _ := resolveWorkspaces(goDir, nil)
+	if got := depsOf(t, gws, "api"); got != "types" {
+		t.Errorf("go api deps = %s", got)
+	}
+}
+
+func TestLinkWorkspaceDeps_BuildSystems(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "Shop.sln", "Project(\"{FAE04EC0-301F-11D3-BF4B-00C04F79EFBC}\") = \"Api\", \"src\\Api\\Api.csproj\", \"{1}\"\nEndProject\n"+
+		"Project(\"{FAE04EC0-301F-11D3-BF4B-00C04F79EFBC}\") = \"Core\", \"src\\Core\\Core.csproj\", \"{2}\"\nEndProject\n")
+	writeFile(t, dir, "src/Api/Api.csproj", `<Project Sdk="Microsoft.NET.Sdk.Web"><ItemGroup><ProjectReference Include="..\Core\Core.csproj" /></ItemGroup></Project>`)
+	writeFile(t, dir, "src/Core/Core.csproj", `<Project Sdk="Microsoft.NET.Sdk"></Project>`)
+	ws, _, _ := resolveWorkspaces(dir, nil)
+	if got := depsOf(t, ws, "Api"); got != "Core" {
+		t.Errorf(".NET Api deps = %s", got)
+	}
+
+	gradle := t.TempDir()
+	writeFile(t, gradle, "settings.gradle", "include ':app', ':libs:core'\n")
+	writeFile(t, gradle, "app/build.gradle", "dependencies {\n  implementation project(':libs:core')\n  // implementation project(':legacy')\n}\n")
+	writeFile(t, gradle, "libs/core/build.gradle", "")
+	gws, _, _ := resolveWorkspaces(gradle, nil)
+	if got := depsOf(t, gws, "app"); got != "libs:core" {
+		t.Errorf("gradle app deps = %s", got)
+	}
+}
+
+func TestOrderByWorkspaceGraph_UpstreamMergesFirst(t *testing.T) {
+	ws := []workspaceInfo{
+		{ID: "web", Path: "apps/web", Deps: []string{"types"}},
+		{ID: "api", Path: "apps/api", Deps: []string{"types"}},
+		{ID: "types", Path: "packages/types"},
+	}
+	scopes := map[string][]string{"M2": {"web"}, "M3": {"api"}, "M4": {"types"}}
+	if got := strings.Join(orderByWorkspaceGraph(ws, []string{"M2", "M3", "M4"}, scopes), ","); got != "M4,M2,M3" {
+		t.Errorf("order = %s", got)
+	}
+	// Unrelated milestones keep milestone order.
+	if got := strings.Join(orderByWorkspaceGraph(ws, []string{"M2", "M3"}, scopes), ","); got != "M2,M3" {
+		t.Errorf("order = %s", got)
+	}
+	deps := scopeDependencies(ws, []string{"M2", "M3", "M4"}, scopes)
+	if len(deps) != 2 || deps[0] != (scopeDependency{Downstream: "M2", Upstream: "M4", From: "web", To: "types"}) {
+		t.Errorf("deps = %+v", deps)
+	}
+}
+
+func TestOrderByWorkspaceGraph_CycleKeepsOrder(t *testing.T) {
+	ws := []workspaceInfo{
+		{ID: "a", Path: "a", Deps: []string{"b"}},
+		{ID: "b", Path: "b", Deps: []string{"a"}},
+	}
+	scopes := map[string][]string{"M1": {"a"}, "M2": {"b"}}
+	if got := strings.Join(orderByWorkspaceGraph(ws, []string{"M1", "M2"}, scopes), ","); got != "M1,M2" {
+		t.Errorf("order = %s", got)
+	}
+}
+
+func TestOrderByWorkspaceGraph_CycleKeepsOtherConstraints(t *testing.T) {
+	ws := []workspaceInfo{
+		{ID: "web", Path: "apps/web", Deps: []string{"types"}},
+		{ID: "api", Path: "apps/api", Deps: []string{"lib"}},
+		{ID: "app", Path: "apps/app", Deps: []string{"lib"}},
+		{ID: "types", Path: "packages/types"},
+		{ID: "lib", Path: "packages/lib"},
+	}
+	// M1 and M2 each wait on the other; M3 only waits on M1.
+	scopes := map[string][]string{"M1": {"web", "lib"}, "M2": {"types", "api"}, "M3": {"app"}}
+	if got := strings.Join(orderByWorkspaceGraph(ws, []string{"M3", "M1", "M2"}, scopes), ","); got != "M1,M3,M2" {
+		t.Errorf("order = %s", got)
+	}
+}
+
+func TestBuildStatus_MonorepoReportIncludesDeps(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "package.json", `{"name":"root","workspaces":["packages/*"]}`)
+	writeFile(t, dir, "packages/web/package.json", `{"name":"web","dependencies":{"types":"*"}}`)
+	writeFile(t, dir, "packages/types/package.json", `{"name":"types"}`)
+	writeFile(t, dir, ".belmont/PRD.md", "# PRD: Demo\n")
+	writeFile(t, dir, ".belmont/PROGRESS.md", "## Milestones\n### M1: Start\n- [ ] P0-1: Task\n")
+
+	report, err := buildStatus(dir, 40, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := json.Marshal(report.Monorepo)
+	if !strings.Contains(string(data), `{"id":"web","path":"packages/web","deps":["types"]}`) {
+		t.Errorf("monorepo report = %s", data)
+	}
+}
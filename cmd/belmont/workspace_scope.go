@@ -0,0 +1,262 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ============================================================================
+// Workspace-scoped milestones
+//
+// A PRD names the workspaces a feature touches in "## Target Workspace(s)",
+// and tasks carry [WEB] / [API] prefixes. milestoneScope maps those
+// annotations, plus the files a milestone's commits touched, onto workspace
+// IDs. The scope decides which workspaces' setup hooks run and which
+// workspace-bound services are provisioned when a worktree is created. It is
+// exported as BELMONT_AFFECTED_WORKSPACES so verification runs only the
+// affected workspaces' build and test commands, and it is listed next to each
+// milestone in `belmont status`. An empty scope means "unknown", and
+// everything runs as before.
+// ============================================================================
+
+var (
+	taskTagRe          = regexp.MustCompile(`^\s*\[([^\]]+)\]`)
+	backtickTokenRe    = regexp.MustCompile("`([^`]+)`")
+	targetWorkspacesRe = regexp.MustCompile(`(?i)^##\s+target\s+workspace`)
+)
+
+// taskWorkspaceTags returns the leading bracket tags of a task name:
+// "[WEB][API] Share the session type" gives ["WEB", "API"]. A tag may also
+// list several workspaces separated by commas or slashes ("[WEB/API]").
+func taskWorkspaceTags(name string) []string {
+	var tags []string
+	rest := name
+	for {
+		m := taskTagRe.FindStringSubmatchIndex(rest)
+		if m == nil {
+			return tags
+		}
+		for _, t := range strings.FieldsFunc(rest[m[2]:m[3]], func(r rune) bool { return r == ',' || r == '/' }) {
+			if t = strings.TrimSpace(t); t != "" {
+				tags = append(tags, t)
+			}
+		}
+		rest = rest[m[1]:]
+	}
+}
+
+// matchWorkspace resolves a tag or PRD token to a workspace ID. It matches,
+// case-insensitively, the ID, the ID's last segment ("@org/web" for WEB), the
+// path, or the path's base name. Returns "" when nothing matches.
+func matchWorkspace(token string, workspaces []workspaceInfo) string {
+	token = strings.Trim(strings.TrimSpace(token), "/")
+	if token == "" {
+		return ""
+	}
+	norm := filepath.ToSlash(filepath.Clean(token))
+	for _, w := range workspaces {
+		if strings.EqualFold(w.ID, token) || strings.EqualFold(filepath.ToSlash(w.Path), norm) {
+			return w.ID
+		}
+	}
+	for _, w := range workspaces {
+		if strings.EqualFold(w.ID[strings.LastIndexAny(w.ID, "/:")+1:], token) || strings.EqualFold(filepath.Base(w.Path), token) {
+			return w.ID
+		}
+	}
+	return ""
+}
+
+// prdTargetWorkspaces reads the "## Target Workspace(s)" section of a PRD.
+// Each bullet names a workspace in backticks by ID or path:
+// "- `web` (`packages/web`) — primary".
+func prdTargetWorkspaces(prd string, workspaces []workspaceInfo) []string {
+	var ids []string
+	in := false
+	for _, line := range strings.Split(prd, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "## ") {
+			in = targetWorkspacesRe.MatchString(trimmed)
+			continue
+		}
+		if !in || !(strings.HasPrefix(trimmed, "- ") || strings.HasPrefix(trimmed, "* ")) {
+			continue
+		}
+		for _, m := range backtickTokenRe.FindAllStringSubmatch(trimmed, -1) {
+			if id := matchWorkspace(m[1], workspaces); id != "" {
+				ids = append(ids, id)
+				break
+			}
+		}
+	}
+	return uniqueStrings(ids)
+}
+
+// workspacesForFiles maps repo-relative paths to the workspaces that own them.
+func workspacesForFiles(workspaces []workspaceInfo, files []string) []string {
+	var ids []string
+	for _, f := range files {
+		if strings.HasPrefix(f, ".belmont/") {
+			continue
+		}
+		if ws := owningWorkspace(workspaces, f); ws != nil {
+			ids = append(ids, ws.ID)
+		}
+	}
+	return uniqueStrings(ids)
+}
+
+// milestoneScope returns the sorted workspace IDs a milestone affects: its
+// task tags plus the owners of the files it changed. When neither says
+// anything, the feature's PRD targets apply. Nil for single-package projects
+// or when nothing is known.
+func milestoneScope(ms milestone, prdTargets []string, workspaces []workspaceInfo, changed []string) []string {
+	if len(workspaces) == 0 {
+		return nil
+	}
+	var ids []string
+	for _, t := range ms.Tasks {
+		for _, tag := range taskWorkspaceTags(t.Name) {
+			if id := matchWorkspace(tag, workspaces); id != "" {
+				ids = append(ids, id)
+			}
+		}
+	}
+	ids = append(ids, workspacesForFiles(workspaces, changed)...)
+	if len(ids) == 0 {
+		ids = append(ids, prdTargets...)
+	}
+	ids = uniqueStrings(ids)
+	sort.Strings(ids)
+	return ids
+}
+
+// featureWorkspaceTargets reads the PRD targets of a feature, from the
+// feature directory under root.
+func featureWorkspaceTargets(root, feature string, workspaces []workspaceInfo) []string {
+	if len(workspaces) == 0 {
+		return nil
+	}
+	data, err := os.ReadFile(filepath.Join(root, ".belmont", "features", feature, "PRD.md"))
+	if err != nil {
+		return nil
+	}
+	return prdTargetWorkspaces(string(data), workspaces)
+}
+
+// worktreeChangedFiles lists the files a worktree changed since it branched
+// from the main checkout's HEAD, committed or not.
+func worktreeChangedFiles(root, wtPath string) []string {
+	head, err := gitOutputIn(root, "rev-parse", "HEAD")
+	if err != nil {
+		return nil
+	}
+	base, err := gitOutputIn(wtPath, "merge-base", "HEAD", strings.TrimSpace(head))
+	if err != nil {
+		return nil
+	}
+	out, err := gitOutputIn(wtPath, "diff", "--name-only", strings.TrimSpace(base))
+	if err != nil {
+		return nil
+	}
+	return strings.Fields(out)
+}
+
+// worktreeScope computes the scope for a unit's worktree: one milestone, or
+// every milestone of the feature when msID is empty. A resumed worktree also
+// counts the files it has already changed.
+func worktreeScope(root, feature, msID, wtPath string, workspaces []workspaceInfo, resumed bool) []string {
+	if len(workspaces) == 0 {
+		return nil
+	}
+	var ms milestone
+	if data, err := os.ReadFile(filepath.Join(root, ".belmont", "features", feature, "PROGRESS.md")); err == nil {
+		for _, m := range parseMilestones(string(data)) {
+			if msID == "" || m.ID == msID {
+				ms.Tasks = append(ms.Tasks, m.Tasks...)
+			}
+		}
+	}
+	var changed []string
+	if resumed {
+		changed = worktreeChangedFiles(root, wtPath)
+	}
+	return milestoneScope(ms, featureWorkspaceTargets(root, feature, workspaces), workspaces, changed)
+}
+
+// inScope reports whether any of ids is in scope. An empty scope, or an
+// empty ids list (not bound to a workspace), is always in scope.
+func inScope(scope, ids []string) bool {
+	if len(scope) == 0 || len(ids) == 0 {
+		return true
+	}
+	for _, id := range ids {
+		if containsString(scope, id) {
+			return true
+		}
+	}
+	return false
+}
+
+// scopedWorktreeHooks returns hooks with the services bound to out-of-scope
+// workspaces removed. hooks itself is not modified.
+func scopedWorktreeHooks(hooks *worktreeHooks, scope []string) *worktreeHooks {
+	if hooks == nil || len(scope) == 0 || len(hooks.Services) == 0 {
+		return hooks
+	}
+	scoped := *hooks
+	scoped.Services = map[string]worktreeServiceConfig{}
+	for name, svc := range hooks.Services {
+		if inScope(scope, svc.Workspaces) {
+			scoped.Services[name] = svc
+		} else {
+			fmt.Fprintf(os.Stderr, "    \033[2mSkipping service %s (workspaces %s not affected)\033[0m\n", name, strings.Join(svc.Workspaces, ", "))
+		}
+	}
+	return &scoped
+}
+
+// withAffectedWorkspaces returns a copy of env with BELMONT_AFFECTED_WORKSPACES
+// set to the scope as a JSON array. Unchanged when the scope is empty.
+func withAffectedWorkspaces(env map[string]string, scope []string) map[string]string {
+	if len(scope) == 0 {
+		return env
+	}
+	out := make(map[string]string, len(env)+1)
+	for k, v := range env {
+		out[k] = v
+	}
+	data, _ := json.Marshal(scope)
+	out["BELMONT_AFFECTED_WORKSPACES"] = string(data)
+	return out
+}
+
+// runWorkspaceSetupHooks runs each in-scope workspace's own setup commands
+// inside that workspace's directory, in workspace ID order.
+func runWorkspaceSetupHooks(hooks *worktreeHooks, scope []string, wtPath string, port int, env map[string]string, workspaces []workspaceInfo, primary string, mType monorepoType, ctr *worktreeContainer) error {
+	if hooks == nil {
+		return nil
+	}
+	for _, ws := range workspaces {
+		cmds := hooks.Workspaces[ws.ID].Setup
+		if len(cmds) == 0 || !inScope(scope, []string{ws.ID}) {
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "    Running setup hooks for workspace %s...\n", ws.ID)
+		if err := runWorktreeHookCommands(cmds, filepath.Join(wtPath, ws.Path), port, env, workspaces, primary, mType, ctr); err != nil {
+			return fmt.Errorf("workspace %s: %w", ws.ID, err)
+		}
+	}
+	return nil
+}
+
+// liveWorktreeRoot maps a milestone's LiveFrom (the feature directory inside
+// the worktree) back to the worktree root.
+func liveWorktreeRoot(liveFrom string) string {
+	return filepath.Dir(filepath.Dir(filepath.Dir(liveFrom)))
+}
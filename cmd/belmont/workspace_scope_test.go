@@ -0,0 +1,124 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var scopeTestWorkspaces = []workspaceInfo{
+	{ID: "@acme/web", Path: "packages/web"},
+	{ID: "api", Path: "apps/api"},
+	{ID: "types", Path: "packages/types"},
+}
+
+func TestTaskWorkspaceTags(t *testing.T) {
+	cases := map[string]string{
+		"[WEB] Render the new shell":       "WEB",
+		"[WEB][API] Share the session":     "WEB,API",
+		"[web/api] Contract change":        "web,api",
+		"Plain task without a tag":         "",
+		"Fix [WEB] mention mid-sentence":   "",
+		"  [API, types] Add typed handler": "API,types",
+	}
+	for name, want := range cases {
+		if got := strings.Join(taskWorkspaceTags(name), ","); got != want {
+			t.Errorf("taskWorkspaceTags(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestMatchWorkspace(t *testing.T) {
+	for token, want := range map[string]string{
+		"WEB":          "@acme/web",
+		"@acme/web":    "@acme/web",
+		"apps/api":     "api",
+		"packages/web": "@acme/web",
+		"Types":        "types",
+		"docs":         "",
+	} {
+		if got := matchWorkspace(token, scopeTestWorkspaces); got != want {
+			t.Errorf("matchWorkspace(%q) = %q, want %q", token, got, want)
+		}
+	}
+}
+
+func TestPrdTargetWorkspaces(t *testing.T) {
+	prd := "# PRD\n\n## Target Workspace(s) — optional, monorepos only\n" +
+		"- `web` (`packages/web`) — primary\n" +
+		"- `api` (`apps/api`)\n" +
+		"- `unknown` (`libs/nope`)\n\n" +
+		"## Tasks\n- `types` is not a target\n"
+	if got := strings.Join(prdTargetWorkspaces(prd, scopeTestWorkspaces), ","); got != "@acme/web,api" {
+		t.Errorf("targets = %s", got)
+	}
+}
+
+func TestMilestoneScope(t *testing.T) {
+	ms := milestone{ID: "M2", Tasks: []task{{Name: "[WEB] Docs page"}, {Name: "Copy tweaks"}}}
+	if got := strings.Join(milestoneScope(ms, []string{"api"}, scopeTestWorkspaces, nil), ","); got != "@acme/web" {
+		t.Errorf("tagged scope = %s", got)
+	}
+	changed := []string{"packages/types/index.ts", ".belmont/features/x/PROGRESS.md", "README.md"}
+	if got := strings.Join(milestoneScope(ms, nil, scopeTestWorkspaces, changed), ","); got != "@acme/web,types" {
+		t.Errorf("tags + changes = %s", got)
+	}
+	untagged := milestone{Tasks: []task{{Name: "Polish"}}}
+	if got := strings.Join(milestoneScope(untagged, []string{"api"}, scopeTestWorkspaces, nil), ","); got != "api" {
+		t.Errorf("PRD fallback = %s", got)
+	}
+	if got := milestoneScope(untagged, nil, nil, nil); got != nil {
+		t.Errorf("single-package scope = %v", got)
+	}
+}
+
+func TestScopedWorktreeHooks_DropsServicesOfUnaffectedWorkspaces(t *testing.T) {
+	hooks := &worktreeHooks{Services: map[string]worktreeServiceConfig{
+		"db":      {Type: "postgres", Workspaces: []string{"api"}},
+		"scratch": {Type: "tempdir"},
+	}}
+	scoped := scopedWorktreeHooks(hooks, []string{"@acme/web"})
+	if _, ok := scoped.Services["db"]; ok {
+		t.Error("api's database should not be provisioned for a web-only milestone")
+	}
+	if _, ok := scoped.Services["scratch"]; !ok {
+		t.Error("unbound services always run")
+	}
+	if len(hooks.Services) != 2 {
+		t.Error("original hooks must not be modified")
+	}
+	if scopedWorktreeHooks(hooks, nil) != hooks {
+		t.Error("an unknown scope keeps every service")
+	}
+}
+
+func TestRunWorkspaceSetupHooks_OnlyAffectedWorkspaces(t *testing.T) {
+	wt := t.TempDir()
+	for _, ws := range scopeTestWorkspaces {
+		os.MkdirAll(filepath.Join(wt, ws.Path), 0755)
+	}
+	hooks := &worktreeHooks{Workspaces: map[string]workspaceOverride{
+		"@acme/web": {Path: "packages/web", Setup: []string{"touch setup-ran"}},
+		"api":       {Path: "apps/api", Setup: []string{"touch setup-ran"}},
+	}}
+	if err := runWorkspaceSetupHooks(hooks, []string{"@acme/web"}, wt, 0, nil, scopeTestWorkspaces, "@acme/web", monorepoPnpm, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !fileExists(filepath.Join(wt, "packages/web/setup-ran")) {
+		t.Error("web setup should run inside packages/web")
+	}
+	if fileExists(filepath.Join(wt, "apps/api/setup-ran")) {
+		t.Error("api setup should be skipped for a web-only milestone")
+	}
+}
+
+func TestWithAffectedWorkspaces(t *testing.T) {
+	env := withAffectedWorkspaces(map[string]string{"A": "1"}, []string{"api", "web"})
+	if env["BELMONT_AFFECTED_WORKSPACES"] != `["api","web"]` || env["A"] != "1" {
+		t.Errorf("env = %v", env)
+	}
+	if _, ok := withAffectedWorkspaces(map[string]string{}, nil)["BELMONT_AFFECTED_WORKSPACES"]; ok {
+		t.Error("empty scope should not export the variable")
+	}
+}